@@ -0,0 +1,162 @@
+// Package anchor assigns stable, collision-free anchor IDs to headlines
+// so that exporters and internal link resolution agree on them.
+//
+// A headline's anchor is its CUSTOM_ID if set, otherwise "ID-" followed
+// by its ID property, as in Org's HTML export, otherwise a slug of its
+// title. Slugs that are already taken get a numeric suffix. Anchors only
+// depend on the document, so the same input always yields the same IDs.
+package anchor
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/justyntemme/organelle/ast"
+)
+
+// Set holds the anchors of every headline of a document
+type Set struct {
+	byHeadline map[*ast.Headline]string
+	byAnchor   map[string]*ast.Headline
+	byTitle    map[string]*ast.Headline
+	byID       map[string]*ast.Headline
+	order      []*ast.Headline
+}
+
+// New computes the anchors for doc. Explicit CUSTOM_ID and ID anchors are
+// reserved first, so a slug never takes an anchor a later headline
+// declares explicitly.
+func New(doc *ast.Document) *Set {
+	s := &Set{
+		byHeadline: map[*ast.Headline]string{},
+		byAnchor:   map[string]*ast.Headline{},
+		byTitle:    map[string]*ast.Headline{},
+		byID:       map[string]*ast.Headline{},
+	}
+	walk(doc.Children, func(h *ast.Headline) {
+		s.order = append(s.order, h)
+	})
+
+	for _, h := range s.order {
+		if _, ok := s.byTitle[h.Title]; !ok {
+			s.byTitle[h.Title] = h
+		}
+		if id, ok := h.Property("ID"); ok && id != "" {
+			if _, taken := s.byID[id]; !taken {
+				s.byID[id] = h
+			}
+		}
+		if a := explicit(h); a != "" {
+			if _, taken := s.byAnchor[a]; !taken {
+				s.assign(h, a)
+			}
+		}
+	}
+
+	used := map[string]int{}
+	for _, h := range s.order {
+		if _, ok := s.byHeadline[h]; ok {
+			continue
+		}
+		base := explicit(h)
+		if base == "" {
+			base = Slug(h.Title)
+		}
+		if base == "" {
+			base = "section"
+		}
+		a := base
+		for n := used[base]; ; n++ {
+			if n > 0 {
+				a = fmt.Sprintf("%s-%d", base, n)
+			}
+			if _, taken := s.byAnchor[a]; !taken {
+				used[base] = n + 1
+				break
+			}
+		}
+		s.assign(h, a)
+	}
+	return s
+}
+
+func (s *Set) assign(h *ast.Headline, a string) {
+	s.byHeadline[h] = a
+	s.byAnchor[a] = h
+}
+
+// explicit returns the anchor requested by the headline's properties
+func explicit(h *ast.Headline) string {
+	if id, ok := h.Property("CUSTOM_ID"); ok && id != "" {
+		return id
+	}
+	if id, ok := h.Property("ID"); ok && id != "" {
+		return "ID-" + id
+	}
+	return ""
+}
+
+func walk(nodes []ast.Node, fn func(*ast.Headline)) {
+	for _, n := range nodes {
+		if h, ok := n.(*ast.Headline); ok {
+			fn(h)
+			walk(h.Children, fn)
+		}
+	}
+}
+
+// For returns the anchor of h, or "" if h is not part of the document
+func (s *Set) For(h *ast.Headline) string {
+	return s.byHeadline[h]
+}
+
+// Headline returns the headline with the given anchor
+func (s *Set) Headline(anchor string) (*ast.Headline, bool) {
+	h, ok := s.byAnchor[anchor]
+	return h, ok
+}
+
+// Resolve finds the headline an internal link points to. It understands
+// "#custom-id", "*Title", "id:UUID" and a bare anchor. The returned
+// anchor is the fragment exporters should link to.
+func (s *Set) Resolve(link string) (*ast.Headline, string, bool) {
+	var h *ast.Headline
+	switch {
+	case strings.HasPrefix(link, "#"):
+		for _, c := range s.order {
+			if id, ok := c.Property("CUSTOM_ID"); ok && id == link[1:] {
+				h = c
+				break
+			}
+		}
+	case strings.HasPrefix(link, "*"):
+		h = s.byTitle[strings.TrimSpace(link[1:])]
+	case strings.HasPrefix(link, "id:"):
+		h = s.byID[link[3:]]
+	default:
+		h = s.byAnchor[link]
+	}
+	if h == nil {
+		return nil, "", false
+	}
+	return h, s.byHeadline[h], true
+}
+
+// Slug lowercases s and joins its runs of letters and digits with '-'
+func Slug(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return b.String()
+}
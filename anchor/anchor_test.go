@@ -0,0 +1,95 @@
+package anchor
+
+import (
+	"testing"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+const input = `* Introduction
+* Setup & Install
+** Introduction
+* Custom
+:PROPERTIES:
+:CUSTOM_ID: introduction-1
+:END:
+* Tracked
+:PROPERTIES:
+:ID: 1f0c-42
+:END:
+`
+
+func headlines(doc *ast.Document) []*ast.Headline {
+	var out []*ast.Headline
+	walk(doc.Children, func(h *ast.Headline) { out = append(out, h) })
+	return out
+}
+
+func TestAnchors(t *testing.T) {
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	s := New(doc)
+	hs := headlines(doc)
+	if len(hs) != 5 {
+		t.Fatalf("expected 5 headlines, got=%d", len(hs))
+	}
+
+	// introduction-1 is reserved by the CUSTOM_ID, so the duplicate slug
+	// skips to introduction-2
+	expected := []string{"introduction", "setup-install", "introduction-2", "introduction-1", "ID-1f0c-42"}
+	for i, h := range hs {
+		if got := s.For(h); got != expected[i] {
+			t.Errorf("headline %q: expected anchor %q, got=%q", h.Title, expected[i], got)
+		}
+	}
+
+	doc = parser.New(lexer.New(input)).ParseDocument()
+	again := New(doc)
+	for i, h := range headlines(doc) {
+		if again.For(h) != expected[i] {
+			t.Errorf("anchors are not deterministic for %q", h.Title)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	s := New(parser.New(lexer.New(input)).ParseDocument())
+	tests := []struct {
+		link   string
+		title  string
+		anchor string
+	}{
+		{"#introduction-1", "Custom", "introduction-1"},
+		{"*Setup & Install", "Setup & Install", "setup-install"},
+		{"id:1f0c-42", "Tracked", "ID-1f0c-42"},
+		{"introduction-2", "Introduction", "introduction-2"},
+	}
+	for _, tt := range tests {
+		h, a, ok := s.Resolve(tt.link)
+		if !ok {
+			t.Errorf("%s: not resolved", tt.link)
+			continue
+		}
+		if h.Title != tt.title || a != tt.anchor {
+			t.Errorf("%s: expected %q#%s, got=%q#%s", tt.link, tt.title, tt.anchor, h.Title, a)
+		}
+	}
+	if _, _, ok := s.Resolve("*Missing"); ok {
+		t.Error("expected missing headline not to resolve")
+	}
+}
+
+func TestSlug(t *testing.T) {
+	tests := map[string]string{
+		"Hello, World!":   "hello-world",
+		"  Über  Straße ": "über-straße",
+		"v1.2 Notes":      "v1-2-notes",
+		"???":             "",
+	}
+	for in, expected := range tests {
+		if got := Slug(in); got != expected {
+			t.Errorf("Slug(%q): expected %q, got=%q", in, expected, got)
+		}
+	}
+}
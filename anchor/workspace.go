@@ -0,0 +1,168 @@
+package anchor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+	"github.com/justyntemme/organelle/workspace"
+)
+
+// Assignment records a CUSTOM_ID given to a headline
+type Assignment struct {
+	File  string // Name of the file, relative to the workspace for AssignWorkspace
+	Line  int    // Line of the headline before any IDs were inserted
+	Title string
+	ID    string
+}
+
+// AssignIDs gives every exported headline without a CUSTOM_ID one slugged
+// from its title, so that links into published files do not change when
+// headlines move. files maps names to Org source. IDs are unique across
+// all files: a slug already used as a CUSTOM_ID anywhere gets a numeric
+// suffix, as in New. Subtrees tagged :noexport: or listed in #+EXCLUDE_TAGS
+// and COMMENT subtrees are skipped.
+//
+// The :CUSTOM_ID: lines are inserted into the source text, leaving the
+// rest of each file as it was. AssignIDs returns the new source of every
+// file it changed and the assignments in file and document order.
+func AssignIDs(files map[string]string) (map[string]string, []Assignment) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	docs := make(map[string]*ast.Document, len(files))
+	taken := map[string]bool{}
+	for _, name := range names {
+		docs[name] = parser.New(lexer.New(files[name])).ParseDocument()
+		walk(docs[name].Children, func(h *ast.Headline) {
+			if id, ok := h.Property("CUSTOM_ID"); ok && id != "" {
+				taken[id] = true
+			}
+		})
+	}
+
+	changed := map[string]string{}
+	var out []Assignment
+	used := map[string]int{}
+	for _, name := range names {
+		doc := docs[name]
+		exclude := append([]string{"noexport"}, strings.Fields(doc.Keyword("EXCLUDE_TAGS"))...)
+		var inserts []insertion
+		walkExported(doc.Children, exclude, func(h *ast.Headline) {
+			if id, ok := h.Property("CUSTOM_ID"); ok && id != "" {
+				return
+			}
+			base := Slug(h.Title)
+			if base == "" {
+				base = "section"
+			}
+			id := base
+			for n := used[base]; ; n++ {
+				if n > 0 {
+					id = fmt.Sprintf("%s-%d", base, n)
+				}
+				if !taken[id] {
+					used[base] = n + 1
+					break
+				}
+			}
+			taken[id] = true
+			inserts = append(inserts, customIDInsertion(h, id))
+			out = append(out, Assignment{File: name, Line: h.Token.Line, Title: h.Title, ID: id})
+		})
+		if len(inserts) > 0 {
+			changed[name] = insertLines(files[name], inserts)
+		}
+	}
+	return changed, out
+}
+
+// AssignWorkspace runs AssignIDs on the .org files below dir and
+// writes the changed files back, so the IDs persist
+func AssignWorkspace(dir string) ([]Assignment, error) {
+	files, err := workspace.ReadFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	changed, out := AssignIDs(files)
+	for name, src := range changed {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, []byte(src), info.Mode().Perm()); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// walkExported is walk without the subtrees an exporter leaves out
+func walkExported(nodes []ast.Node, exclude []string, fn func(*ast.Headline)) {
+	for _, n := range nodes {
+		h, ok := n.(*ast.Headline)
+		if !ok || h.Keyword == "COMMENT" || h.Title == "COMMENT" || strings.HasPrefix(h.Title, "COMMENT ") {
+			continue
+		}
+		skip := false
+		for _, tag := range exclude {
+			skip = skip || h.HasTag(tag)
+		}
+		if skip {
+			continue
+		}
+		fn(h)
+		walkExported(h.Children, exclude, fn)
+	}
+}
+
+// insertion is a line to insert after line after (1-based)
+type insertion struct {
+	after int
+	text  string
+}
+
+// customIDInsertion adds the property to h's PROPERTIES drawer, or adds
+// a drawer below the headline and its planning line
+func customIDInsertion(h *ast.Headline, id string) insertion {
+	for _, n := range h.BodyNodes() {
+		if d, ok := n.(*ast.Drawer); ok && d.Name == "PROPERTIES" {
+			return insertion{after: d.Token.Line, text: ":CUSTOM_ID: " + id}
+		}
+	}
+	after := h.Token.Line
+	if h.Planning() != "" {
+		after++
+	}
+	return insertion{after: after, text: ":PROPERTIES:\n:CUSTOM_ID: " + id + "\n:END:"}
+}
+
+// insertLines applies the insertions to src
+func insertLines(src string, inserts []insertion) string {
+	lines := strings.SplitAfter(src, "\n")
+	at := map[int][]string{}
+	for _, in := range inserts {
+		at[in.after] = append(at[in.after], in.text+"\n")
+	}
+	var b strings.Builder
+	for i, line := range lines {
+		b.WriteString(line)
+		if add, ok := at[i+1]; ok {
+			if !strings.HasSuffix(line, "\n") {
+				b.WriteString("\n")
+			}
+			b.WriteString(strings.Join(add, ""))
+		}
+	}
+	return b.String()
+}
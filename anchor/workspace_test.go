@@ -0,0 +1,126 @@
+package anchor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAssignIDs(t *testing.T) {
+	files := map[string]string{
+		"a.org": `* Setup
+SCHEDULED: <2024-01-01 Mon>
+
+Text
+* Usage
+:PROPERTIES:
+:OWNER: ada
+:END:
+** Private :noexport:
+*** Hidden
+`,
+		"b.org": `* Setup
+* Usage
+:PROPERTIES:
+:CUSTOM_ID: usage
+:END:
+* COMMENT Draft`,
+	}
+	changed, got := AssignIDs(files)
+
+	want := []Assignment{
+		{File: "a.org", Line: 1, Title: "Setup", ID: "setup"},
+		{File: "a.org", Line: 5, Title: "Usage", ID: "usage-1"},
+		{File: "b.org", Line: 1, Title: "Setup", ID: "setup-1"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d assignments, got=%v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("assignment %d: expected %+v, got=%+v", i, want[i], got[i])
+		}
+	}
+
+	wantA := `* Setup
+SCHEDULED: <2024-01-01 Mon>
+:PROPERTIES:
+:CUSTOM_ID: setup
+:END:
+
+Text
+* Usage
+:PROPERTIES:
+:CUSTOM_ID: usage-1
+:OWNER: ada
+:END:
+** Private :noexport:
+*** Hidden
+`
+	if changed["a.org"] != wantA {
+		t.Errorf("unexpected a.org:\n%s", changed["a.org"])
+	}
+	if changed["b.org"] != "* Setup\n:PROPERTIES:\n:CUSTOM_ID: setup-1\n:END:\n* Usage\n:PROPERTIES:\n:CUSTOM_ID: usage\n:END:\n* COMMENT Draft" {
+		t.Errorf("unexpected b.org:\n%s", changed["b.org"])
+	}
+
+	// A second run finds nothing left to do
+	if again, got := AssignIDs(map[string]string{"a.org": changed["a.org"], "b.org": changed["b.org"]}); len(again) != 0 || len(got) != 0 {
+		t.Errorf("expected assignments to be stable, got %v", got)
+	}
+}
+
+func TestAssignIDsOrder(t *testing.T) {
+	files := map[string]string{
+		"z.org":     "* Intro\n",
+		"a.org":     "* Intro\n* Setup\n",
+		"m/b.org":   "* Intro\n",
+		"m/a.org":   "* Setup\n",
+		"index.org": "* Intro\n",
+	}
+	want := []Assignment{
+		{File: "a.org", Line: 1, Title: "Intro", ID: "intro"},
+		{File: "a.org", Line: 2, Title: "Setup", ID: "setup"},
+		{File: "index.org", Line: 1, Title: "Intro", ID: "intro-1"},
+		{File: "m/a.org", Line: 1, Title: "Setup", ID: "setup-1"},
+		{File: "m/b.org", Line: 1, Title: "Intro", ID: "intro-2"},
+		{File: "z.org", Line: 1, Title: "Intro", ID: "intro-3"},
+	}
+	// Map iteration order varies between runs; the result must not
+	for range 10 {
+		_, got := AssignIDs(files)
+		if len(got) != len(want) {
+			t.Fatalf("expected %d assignments, got=%v", len(want), got)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("assignment %d: expected %+v, got=%+v", i, want[i], got[i])
+			}
+		}
+	}
+}
+
+func TestAssignWorkspace(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "notes"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "notes", "todo.org")
+	if err := os.WriteFile(path, []byte("* Plan\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := AssignWorkspace(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].File != "notes/todo.org" || got[0].ID != "plan" {
+		t.Errorf("unexpected assignments %v", got)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "* Plan\n:PROPERTIES:\n:CUSTOM_ID: plan\n:END:\n" {
+		t.Errorf("unexpected file:\n%s", data)
+	}
+}
@@ -3,8 +3,11 @@ package ast
 import (
 	"bytes"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/justyntemme/organelle/entity"
 	"github.com/justyntemme/organelle/token"
 )
 
@@ -40,16 +43,380 @@ func (d *Document) String() string {
 	return out.String()
 }
 
+// Keyword returns the value of the first top-level #+KEY: line matching
+// key case-insensitively, or "" if there is none. Keywords in the zeroth
+// section count as top-level.
+func (d *Document) Keyword(key string) string {
+	for _, kw := range d.Keywords() {
+		if strings.EqualFold(kw.Key, key) {
+			return kw.Value
+		}
+	}
+	return ""
+}
+
+// Keywords returns the top-level #+KEY: lines in document order,
+// including those in the zeroth section
+func (d *Document) Keywords() []*Keyword {
+	var out []*Keyword
+	for _, c := range d.Children {
+		switch n := c.(type) {
+		case *Section:
+			out = append(out, n.Keywords()...)
+		case *Keyword:
+			if n != nil { // parseKeyword may yield a typed nil
+				out = append(out, n)
+			}
+		}
+	}
+	return out
+}
+
+// FileTags returns the tags of all #+FILETAGS lines, which every headline
+// of the document inherits
+func (d *Document) FileTags() []string {
+	var out []string
+	for _, kw := range d.Keywords() {
+		if strings.EqualFold(kw.Key, "FILETAGS") {
+			out = append(out, SplitTags(kw.Value)...)
+		}
+	}
+	return out
+}
+
+// AllTags returns the tags of h including those it inherits from its
+// ancestors and #+FILETAGS, outermost first and without duplicates. With
+// exclusiveGroups, an inherited tag is dropped when a nearer headline has
+// another tag of the same mutually exclusive #+TAGS group, such as
+// { @work @home }. h must be in d for its ancestors to be found.
+func (d *Document) AllTags(h *Headline, exclusiveGroups bool) []string {
+	levels := [][]string{d.FileTags()}
+	for _, a := range headlinePath(d.Children, h) {
+		levels = append(levels, a.Tags)
+	}
+	if len(levels) == 1 {
+		levels = append(levels, h.Tags)
+	}
+
+	keep := make([][]string, len(levels))
+	claimed := map[int]bool{} // Exclusive groups with a tag on a nearer level
+	groups := d.exclusiveTagGroups()
+	for i := len(levels) - 1; i >= 0; i-- {
+		var seen []int
+		for _, tag := range levels[i] {
+			g, grouped := groups[tag]
+			if exclusiveGroups && grouped && claimed[g] {
+				continue
+			}
+			keep[i] = append(keep[i], tag)
+			if grouped {
+				seen = append(seen, g)
+			}
+		}
+		for _, g := range seen {
+			claimed[g] = true
+		}
+	}
+
+	var out []string
+	dup := map[string]bool{}
+	for _, tags := range keep {
+		for _, tag := range tags {
+			if !dup[tag] {
+				dup[tag] = true
+				out = append(out, tag)
+			}
+		}
+	}
+	return out
+}
+
+// headlinePath returns the headlines from the top level down to h, or nil
+// if h is not among nodes or their subheadlines
+func headlinePath(nodes []Node, h *Headline) []*Headline {
+	for _, n := range nodes {
+		hl, ok := n.(*Headline)
+		if !ok {
+			continue
+		}
+		if hl == h {
+			return []*Headline{h}
+		}
+		var subs []Node
+		for _, sub := range hl.Subheadlines() {
+			subs = append(subs, sub)
+		}
+		if path := headlinePath(subs, h); path != nil {
+			return append([]*Headline{hl}, path...)
+		}
+	}
+	return nil
+}
+
+// SplitTags splits a tag string such as ":work:project:" into its tags
+func SplitTags(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == ':' || r == ' ' || r == '\t' })
+}
+
+// exclusiveTagGroups maps each tag in a { ... } group of a #+TAGS line to
+// the index of its group
+func (d *Document) exclusiveTagGroups() map[string]int {
+	groups := map[string]int{}
+	n := 0
+	for _, kw := range d.Keywords() {
+		if !strings.EqualFold(kw.Key, "TAGS") {
+			continue
+		}
+		in := false
+		value := strings.NewReplacer("{", " { ", "}", " } ").Replace(kw.Value)
+		for _, word := range strings.Fields(value) {
+			switch {
+			case word == "{":
+				in = true
+			case word == "}":
+				in = false
+				n++
+			case in:
+				name, _, _ := strings.Cut(word, "(") // Drop the fast-access key
+				groups[name] = n
+			}
+		}
+	}
+	return groups
+}
+
+// Footnotes returns the footnote definitions of the document in document
+// order, wherever they are
+func (d *Document) Footnotes() []*FootnoteDefinition {
+	var out []*FootnoteDefinition
+	var walk func([]Node)
+	walk = func(nodes []Node) {
+		for _, c := range nodes {
+			switch n := c.(type) {
+			case *FootnoteDefinition:
+				out = append(out, n)
+			case *Headline:
+				walk(n.Children)
+			case *Section:
+				walk(n.Children)
+			}
+		}
+	}
+	walk(d.Children)
+	return out
+}
+
+// Footnote returns the definition of the footnote with the given label,
+// resolving an InlineFootnoteRef. If a label is defined twice, the first
+// definition wins.
+func (d *Document) Footnote(label string) (*FootnoteDefinition, bool) {
+	for _, def := range d.Footnotes() {
+		if def.Label == label {
+			return def, true
+		}
+	}
+	return nil, false
+}
+
+// ZerothSection returns the content before the first headline. When the
+// document was parsed without sections, a Section is built around the
+// loose nodes; it is nil if the document starts with a headline.
+func (d *Document) ZerothSection() *Section {
+	if len(d.Children) == 0 {
+		return nil
+	}
+	if s, ok := d.Children[0].(*Section); ok {
+		return s
+	}
+	var nodes []Node
+	for _, c := range d.Children {
+		if _, ok := c.(*Headline); ok {
+			break
+		}
+		nodes = append(nodes, c)
+	}
+	if len(nodes) == 0 {
+		return nil
+	}
+	return NewSection(nodes)
+}
+
+// Section groups body content apart from the outline: the content of a
+// headline before its first subheadline, or the content of the document
+// before its first headline (the zeroth section). The parser only creates
+// sections when asked to; a section is then the first child of its owner.
+type Section struct {
+	Token    token.Token // The first token of the section
+	Children []Node
+}
+
+// NewSection creates a section holding nodes
+func NewSection(nodes []Node) *Section {
+	s := &Section{Children: nodes}
+	for _, n := range nodes {
+		if tok, ok := nodeToken(n); ok {
+			s.Token = tok
+			break
+		}
+	}
+	return s
+}
+
+func (s *Section) statementNode()       {}
+func (s *Section) TokenLiteral() string { return s.Token.Literal }
+func (s *Section) String() string {
+	var out bytes.Buffer
+	for _, c := range s.Children {
+		out.WriteString(c.String())
+	}
+	return out.String()
+}
+
+// Keywords returns the #+KEY: lines of the section
+func (s *Section) Keywords() []*Keyword {
+	var out []*Keyword
+	for _, c := range s.Children {
+		if kw, ok := c.(*Keyword); ok && kw != nil {
+			out = append(out, kw)
+		}
+	}
+	return out
+}
+
+// Keyword returns the value of the first #+KEY: line matching key
+// case-insensitively, or "" if there is none
+func (s *Section) Keyword(key string) string {
+	for _, kw := range s.Keywords() {
+		if strings.EqualFold(kw.Key, key) {
+			return kw.Value
+		}
+	}
+	return ""
+}
+
+// Body returns the nodes of the section other than keywords
+func (s *Section) Body() []Node {
+	var out []Node
+	for _, c := range s.Children {
+		if _, ok := c.(*Keyword); !ok {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// nodeToken returns the token a node starts at
+func nodeToken(n Node) (token.Token, bool) {
+	switch n := n.(type) {
+	case *Paragraph:
+		return n.Token, true
+	case *Keyword:
+		if n != nil { // parseKeyword may yield a typed nil
+			return n.Token, true
+		}
+	case *Call:
+		return n.Token, true
+	case *Block:
+		return n.Token, true
+	case *Drawer:
+		return n.Token, true
+	case *List:
+		return n.Token, true
+	case *Table:
+		return n.Token, true
+	case *Comment:
+		return n.Token, true
+	case *HorizontalRule:
+		return n.Token, true
+	case *FootnoteDefinition:
+		return n.Token, true
+	}
+	return token.Token{}, false
+}
+
+// NamedElement pairs a #+NAME: label with the element it labels
+type NamedElement struct {
+	Name string
+	Node Node
+}
+
+// NamedElement returns the element labelled with #+NAME: name, searching
+// the whole outline. It returns nil when no element carries the name; if
+// several do, the first one in document order wins.
+func (d *Document) NamedElement(name string) Node {
+	for _, ne := range d.NamedElements() {
+		if ne.Name == name {
+			return ne.Node
+		}
+	}
+	return nil
+}
+
+// NamedElements returns every #+NAME: labelled element in document order.
+// Other affiliated keywords (#+CAPTION, #+ATTR_...) between the name and
+// the element are skipped.
+func (d *Document) NamedElements() []NamedElement {
+	var out []NamedElement
+	collectNamed(d.Children, &out)
+	return out
+}
+
+func collectNamed(nodes []Node, out *[]NamedElement) {
+	for i, n := range nodes {
+		switch n := n.(type) {
+		case *Headline:
+			collectNamed(n.Children, out)
+		case *Section:
+			collectNamed(n.Children, out)
+		case *Keyword:
+			if n == nil || !strings.EqualFold(n.Key, "NAME") || n.Value == "" {
+				continue
+			}
+			for _, next := range nodes[i+1:] {
+				kw, isKeyword := next.(*Keyword)
+				if isKeyword && kw == nil {
+					break // A malformed keyword line names nothing
+				}
+				if isKeyword && isAffiliatedKey(kw.Key) {
+					continue
+				}
+				if _, ok := next.(*Headline); !ok {
+					*out = append(*out, NamedElement{Name: n.Value, Node: next})
+				}
+				break
+			}
+		}
+	}
+}
+
+// isAffiliatedKey reports whether key is an affiliated keyword that may
+// sit between #+NAME and the element it names
+func isAffiliatedKey(key string) bool {
+	upper := strings.ToUpper(key)
+	switch upper {
+	case "NAME", "CAPTION", "HEADER", "PLOT", "RESULTS":
+		return true
+	}
+	return strings.HasPrefix(upper, "ATTR_")
+}
+
 // Headline represents a generic Org headline (* Title)
 // It is recursive; it can contain other Nodes (nested headlines or paragraphs)
 type Headline struct {
 	Token    token.Token // The '*' token
 	Level    int
-	Keyword  string   // TODO, DONE, or empty
-	Priority string   // A, B, C or empty
+	Keyword  string // TODO, DONE, or empty
+	Done     bool   // Keyword is one of the done keywords, such as DONE
+	Priority string // A, B, C or empty
 	Title    string
 	Tags     []string // :tag1:tag2: parsed as ["tag1", "tag2"]
+	Lang     string   // Language code of the title, set by export.DetectLang
 	Children []Node
+
+	// Timestamps from the planning line directly below the headline
+	Scheduled *Timestamp
+	Deadline  *Timestamp
+	Closed    *Timestamp
 }
 
 func (h *Headline) statementNode()       {}
@@ -74,17 +441,152 @@ func (h *Headline) String() string {
 		out.WriteString(":")
 	}
 	out.WriteString("\n")
+	if planning := h.Planning(); planning != "" {
+		out.WriteString(planning)
+		out.WriteString("\n")
+	}
 	for _, c := range h.Children {
 		out.WriteString(c.String())
 	}
 	return out.String()
 }
 
+// Planning returns the headline's planning line, with CLOSED, DEADLINE
+// and SCHEDULED in that order, or "" if it has no planning timestamps
+func (h *Headline) Planning() string {
+	var parts []string
+	for _, p := range []struct {
+		key string
+		ts  *Timestamp
+	}{{"CLOSED", h.Closed}, {"DEADLINE", h.Deadline}, {"SCHEDULED", h.Scheduled}} {
+		if p.ts != nil {
+			parts = append(parts, p.key+": "+p.ts.String())
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Property returns the value of a property from the headline's own
+// PROPERTIES drawer. Keys are matched case-insensitively, as in Org; if
+// several keys match, the first in drawer order wins.
+func (h *Headline) Property(key string) (string, bool) {
+	for _, c := range h.BodyNodes() {
+		d, ok := c.(*Drawer)
+		if !ok || d.Name != "PROPERTIES" {
+			continue
+		}
+		for _, k := range d.PropertyKeys() {
+			if strings.EqualFold(k, key) {
+				return d.Properties[k], true
+			}
+		}
+	}
+	return "", false
+}
+
+// Clocks returns the CLOCK entries of the headline's own LOGBOOK drawers,
+// in source order
+func (h *Headline) Clocks() []*Clock {
+	var clocks []*Clock
+	for _, c := range h.BodyNodes() {
+		if d, ok := c.(*Drawer); ok && d.Name == "LOGBOOK" {
+			clocks = append(clocks, d.Clocks...)
+		}
+	}
+	return clocks
+}
+
+// ClockedTime returns the time clocked on the headline, including its
+// subheadlines if recursive is set. Running clocks count as zero.
+func (h *Headline) ClockedTime(recursive bool) time.Duration {
+	var total time.Duration
+	for _, c := range h.Clocks() {
+		total += c.Duration
+	}
+	if recursive {
+		for _, sub := range h.Subheadlines() {
+			total += sub.ClockedTime(true)
+		}
+	}
+	return total
+}
+
+// IsFootnoteSection reports whether h is the top-level "Footnotes"
+// headline holding the document's footnote definitions
+func (h *Headline) IsFootnoteSection() bool {
+	return h.Level == 1 && h.Title == FootnoteSection
+}
+
+// ArchiveTag marks archived subtrees, including the "Archive" sibling
+// created by org-archive-to-archive-sibling
+const ArchiveTag = "ARCHIVE"
+
+// IsArchived reports whether the headline itself carries the ARCHIVE tag.
+// Descendants of an archived headline are archived as well; callers that
+// walk the tree should skip the whole subtree.
+func (h *Headline) IsArchived() bool {
+	return h.HasTag(ArchiveTag)
+}
+
+// BodyNodes returns the headline's own content: the children of its
+// section, or the children before its first subheadline when it was
+// parsed without sections
+func (h *Headline) BodyNodes() []Node {
+	if len(h.Children) > 0 {
+		if s, ok := h.Children[0].(*Section); ok {
+			return s.Children
+		}
+	}
+	for i, c := range h.Children {
+		if _, ok := c.(*Headline); ok {
+			return h.Children[:i:i]
+		}
+	}
+	return h.Children
+}
+
+// Section returns the headline's section. When the headline was parsed
+// without sections, a Section is built around its body nodes; it is nil
+// if the headline has no body.
+func (h *Headline) Section() *Section {
+	if len(h.Children) > 0 {
+		if s, ok := h.Children[0].(*Section); ok {
+			return s
+		}
+	}
+	if body := h.BodyNodes(); len(body) > 0 {
+		return NewSection(body)
+	}
+	return nil
+}
+
+// Subheadlines returns the headline's direct child headlines
+func (h *Headline) Subheadlines() []*Headline {
+	var out []*Headline
+	for _, c := range h.Children {
+		if sub, ok := c.(*Headline); ok {
+			out = append(out, sub)
+		}
+	}
+	return out
+}
+
+// HasTag reports whether the headline carries the given tag directly
+func (h *Headline) HasTag(tag string) bool {
+	for _, t := range h.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
 // Paragraph represents a block of text (may contain inline elements)
 type Paragraph struct {
 	Token   token.Token
 	Content string
 	Inline  []InlineElement // Parsed inline elements (bold, italic, links, etc.)
+	Lang    string          // Language code such as "ja", set by export.DetectLang
 }
 
 func (p *Paragraph) statementNode()       {}
@@ -93,13 +595,23 @@ func (p *Paragraph) String() string {
 	return p.Content + "\n"
 }
 
+// Span returns the absolute byte range of an inline element of this
+// paragraph in the parsed input. Nested elements are positioned relative
+// to the paragraph too, so this works at any depth.
+func (p *Paragraph) Span(e InlineElement) (start, end int) {
+	return p.Token.Offset + e.Start, p.Token.Offset + e.End
+}
+
 // InlineElement represents inline formatting within text
 // It supports nesting via the Children field
 type InlineElement struct {
-	Type     InlineType
-	Content  string          // Raw content (for text, code, verbatim - non-nestable types)
-	URL      string          // For links
-	Children []InlineElement // Nested inline elements (for bold, italic, etc.)
+	Type      InlineType
+	Content   string          // Raw content (for text, code, verbatim - non-nestable types)
+	URL       string          // For links
+	Timestamp *Timestamp      // For timestamps; Content holds the source text
+	Children  []InlineElement // Nested inline elements (for bold, italic, etc.)
+	Start     int             // Byte offset of the element (including markers) in Paragraph.Content
+	End       int             // Byte offset just past the element in Paragraph.Content
 }
 
 type InlineType int
@@ -113,6 +625,9 @@ const (
 	InlineStrikethrough
 	InlineUnderline
 	InlineLink
+	InlineEntity      // \alpha or \alpha{}; Content holds the entity name
+	InlineFootnoteRef // [fn:label]; Content holds the label
+	InlineTimestamp   // <2024-01-01 Mon> or a range; Content holds the source text
 )
 
 // String returns the string representation of an InlineType
@@ -134,14 +649,30 @@ func (t InlineType) String() string {
 		return "underline"
 	case InlineLink:
 		return "link"
+	case InlineEntity:
+		return "entity"
+	case InlineFootnoteRef:
+		return "footnote-ref"
+	case InlineTimestamp:
+		return "timestamp"
 	default:
 		return "unknown"
 	}
 }
 
-// PlainText extracts plain text content from an InlineElement, recursively
+// PlainText extracts plain text content from an InlineElement, recursively.
+// Footnote references have no text.
 func (e *InlineElement) PlainText() string {
-	if e.Type == InlineText || e.Type == InlineCode || e.Type == InlineVerbatim {
+	if e.Type == InlineFootnoteRef {
+		return ""
+	}
+	if e.Type == InlineEntity {
+		if ent, ok := entity.Lookup(e.Content); ok {
+			return ent.UTF8
+		}
+		return e.Content
+	}
+	if e.Type == InlineText || e.Type == InlineCode || e.Type == InlineVerbatim || e.Type == InlineTimestamp {
 		return e.Content
 	}
 	var result strings.Builder
@@ -164,6 +695,52 @@ func (k *Keyword) String() string {
 	return fmt.Sprintf("#+%s: %s\n", k.Key, k.Value)
 }
 
+// Call represents a #+CALL: name[inside header](args) end header line
+// that evaluates the named source block
+type Call struct {
+	Token        token.Token
+	Name         string    // Name of the source block to call
+	InsideHeader string    // Header arguments in [...] between name and arguments
+	Arguments    string    // Raw text between the parentheses
+	Args         []CallArg // Arguments split into name=value pairs
+	EndHeader    string    // Header arguments after the closing parenthesis
+}
+
+// CallArg is a single name=value argument of a #+CALL: line
+type CallArg struct {
+	Name  string
+	Value string
+}
+
+func (c *Call) statementNode()       {}
+func (c *Call) TokenLiteral() string { return c.Token.Literal }
+func (c *Call) String() string {
+	var out bytes.Buffer
+	out.WriteString("#+CALL: ")
+	out.WriteString(c.Name)
+	if c.InsideHeader != "" {
+		out.WriteString("[")
+		out.WriteString(c.InsideHeader)
+		out.WriteString("]")
+	}
+	out.WriteString("(")
+	out.WriteString(c.Arguments)
+	out.WriteString(")")
+	if c.EndHeader != "" {
+		out.WriteString(" ")
+		out.WriteString(c.EndHeader)
+	}
+	out.WriteString("\n")
+	return out.String()
+}
+
+// Block resolves the source block the call refers to, or nil if the
+// document has no block with that #+NAME
+func (c *Call) Block(doc *Document) *Block {
+	b, _ := doc.NamedElement(c.Name).(*Block)
+	return b
+}
+
 // Block represents #+BEGIN_X ... #+END_X blocks
 type Block struct {
 	Token    token.Token
@@ -203,7 +780,9 @@ type Drawer struct {
 	Token      token.Token
 	Name       string
 	Properties map[string]string // For PROPERTIES drawer
+	Keys       []string          // Property keys in source order
 	Content    string            // Raw content for other drawers
+	Clocks     []*Clock          // CLOCK lines of a LOGBOOK drawer, also kept in Content
 }
 
 func (d *Drawer) statementNode()       {}
@@ -214,20 +793,44 @@ func (d *Drawer) String() string {
 	out.WriteString(d.Name)
 	out.WriteString(":\n")
 	if d.Name == "PROPERTIES" {
-		for k, v := range d.Properties {
+		for _, k := range d.PropertyKeys() {
 			out.WriteString(":")
 			out.WriteString(k)
 			out.WriteString(": ")
-			out.WriteString(v)
+			out.WriteString(d.Properties[k])
 			out.WriteString("\n")
 		}
 	} else {
 		out.WriteString(d.Content)
+		if d.Content != "" && !strings.HasSuffix(d.Content, "\n") {
+			out.WriteString("\n")
+		}
 	}
 	out.WriteString(":END:\n")
 	return out.String()
 }
 
+// PropertyKeys returns the keys of Properties in source order. Keys
+// added without updating Keys follow in sorted order.
+func (d *Drawer) PropertyKeys() []string {
+	keys := make([]string, 0, len(d.Properties))
+	seen := make(map[string]bool, len(d.Properties))
+	for _, k := range d.Keys {
+		if _, ok := d.Properties[k]; ok && !seen[k] {
+			keys = append(keys, k)
+			seen[k] = true
+		}
+	}
+	var rest []string
+	for k := range d.Properties {
+		if !seen[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
+
 // List represents ordered or unordered lists
 type List struct {
 	Token   token.Token
@@ -252,20 +855,20 @@ func (l *List) String() string {
 
 // ListItem represents a single item in a list
 type ListItem struct {
-	Token       token.Token
-	Indent      int           // Indentation level (number of spaces/tabs)
-	Checkbox    CheckboxState
-	Content     string
-	Children    []Node // Nested content (paragraphs, sub-lists)
+	Token    token.Token
+	Indent   int // Indentation level (number of spaces/tabs)
+	Checkbox CheckboxState
+	Content  string
+	Children []Node // Nested content (paragraphs, sub-lists)
 }
 
 type CheckboxState int
 
 const (
-	CheckboxNone CheckboxState = iota
-	CheckboxUnchecked // [ ]
-	CheckboxChecked   // [X]
-	CheckboxPartial   // [-]
+	CheckboxNone      CheckboxState = iota
+	CheckboxUnchecked               // [ ]
+	CheckboxChecked                 // [X]
+	CheckboxPartial                 // [-]
 )
 
 func (li *ListItem) statementNode()       {}
@@ -321,16 +924,44 @@ func (tr *TableRow) String() string {
 	return "| " + strings.Join(tr.Cells, " | ") + " |\n"
 }
 
+// FootnoteSection is the title of the headline Org collects footnote
+// definitions under
+const FootnoteSection = "Footnotes"
+
+// FootnoteDefinition represents a [fn:label] definition at the start of a
+// line. The definition runs to the next blank line.
+type FootnoteDefinition struct {
+	Token    token.Token
+	Label    string
+	Children []Node // One Paragraph per line of the definition's text
+}
+
+func (f *FootnoteDefinition) statementNode()       {}
+func (f *FootnoteDefinition) TokenLiteral() string { return f.Token.Literal }
+func (f *FootnoteDefinition) String() string {
+	var out bytes.Buffer
+	out.WriteString("[fn:" + f.Label + "] ")
+	for _, c := range f.Children {
+		out.WriteString(c.String())
+	}
+	if len(f.Children) == 0 {
+		out.WriteString("\n")
+	}
+	return out.String()
+}
+
 // Timestamp represents org-mode timestamps
 type Timestamp struct {
-	Token    token.Token
-	Active   bool   // <...> is active, [...] is inactive
-	Date     string // 2024-01-01
-	Time     string // 10:00 (optional)
-	Repeat   string // +1w, .+1d, ++1m (optional)
-	Warning  string // -3d (optional)
-	EndDate  string // For ranges: <2024-01-01>--<2024-01-02>
-	EndTime  string
+	Token   token.Token
+	Active  bool   // <...> is active, [...] is inactive
+	Date    string // 2024-01-01
+	Day     string // Mon (optional)
+	Time    string // 10:00 (optional)
+	Repeat  string // +1w, .+1d, ++1m (optional)
+	Warning string // -3d (optional)
+	EndDate string // For ranges: <2024-01-01>--<2024-01-02>
+	EndDay  string
+	EndTime string // End of the range, or of <2024-01-01 Mon 10:00-11:30>
 }
 
 func (ts *Timestamp) statementNode()       {}
@@ -343,9 +974,17 @@ func (ts *Timestamp) String() string {
 		out.WriteString("[")
 	}
 	out.WriteString(ts.Date)
+	if ts.Day != "" {
+		out.WriteString(" ")
+		out.WriteString(ts.Day)
+	}
 	if ts.Time != "" {
 		out.WriteString(" ")
 		out.WriteString(ts.Time)
+		if ts.EndDate == "" && ts.EndTime != "" {
+			out.WriteString("-")
+			out.WriteString(ts.EndTime)
+		}
 	}
 	if ts.Repeat != "" {
 		out.WriteString(" ")
@@ -368,6 +1007,10 @@ func (ts *Timestamp) String() string {
 			out.WriteString("[")
 		}
 		out.WriteString(ts.EndDate)
+		if ts.EndDay != "" {
+			out.WriteString(" ")
+			out.WriteString(ts.EndDay)
+		}
 		if ts.EndTime != "" {
 			out.WriteString(" ")
 			out.WriteString(ts.EndTime)
@@ -381,6 +1024,64 @@ func (ts *Timestamp) String() string {
 	return out.String()
 }
 
+// ToTime returns the start of the timestamp as a time in UTC
+func (ts *Timestamp) ToTime() (time.Time, error) {
+	if ts.Time == "" {
+		return time.Parse("2006-01-02", ts.Date)
+	}
+	return time.Parse("2006-01-02 15:04", ts.Date+" "+ts.Time)
+}
+
+// Start returns the start of the timestamp in UTC, or the zero time if it
+// does not parse
+func (ts *Timestamp) Start() time.Time {
+	t, _ := ts.ToTime()
+	return t
+}
+
+// End returns the end of the timestamp in UTC: the end date and time of a
+// range, the end time of <2024-01-01 Mon 10:00-11:30>, or Start otherwise.
+// It is the zero time if the timestamp does not parse.
+func (ts *Timestamp) End() time.Time {
+	date, clock := ts.EndDate, ts.EndTime
+	if date == "" {
+		if clock == "" {
+			return ts.Start()
+		}
+		date = ts.Date
+	}
+	layout, value := "2006-01-02", date
+	if clock != "" {
+		layout, value = "2006-01-02 15:04", date+" "+clock
+	}
+	t, _ := time.Parse(layout, value)
+	return t
+}
+
+// Clock represents a CLOCK line recording time spent on a headline:
+// CLOCK: [2024-01-15 Mon 09:00]--[2024-01-15 Mon 10:30] =>  1:30
+type Clock struct {
+	Token    token.Token
+	Start    *Timestamp
+	End      *Timestamp    // nil while the clock is running
+	Duration time.Duration // From "=> H:MM", or End minus Start if absent
+}
+
+func (c *Clock) statementNode()       {}
+func (c *Clock) TokenLiteral() string { return c.Token.Literal }
+func (c *Clock) String() string {
+	if c.End == nil {
+		return "CLOCK: " + c.Start.String()
+	}
+	minutes := int(c.Duration / time.Minute)
+	return fmt.Sprintf("CLOCK: %s--%s => %2d:%02d", c.Start, c.End, minutes/60, minutes%60)
+}
+
+// Running reports whether the clock has been started but not stopped
+func (c *Clock) Running() bool {
+	return c.End == nil
+}
+
 // Link represents [[url][description]] or [[url]] links
 type Link struct {
 	Token       token.Token
@@ -0,0 +1,125 @@
+package ast
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// effortUnits are the duration units Org accepts in efforts, longest first
+// so that "min" is tried before "m"
+var effortUnits = []struct {
+	name string
+	size time.Duration
+}{
+	{"min", time.Minute},
+	{"h", time.Hour},
+	{"d", 24 * time.Hour},
+	{"w", 7 * 24 * time.Hour},
+	{"m", 30 * 24 * time.Hour},
+	{"y", 365 * 24 * time.Hour},
+}
+
+// ParseEffort parses an Org duration such as "1:30", "2h", "90min" or
+// "1d 2h". A plain number counts minutes. It reports false if s is not a
+// duration or does not fit in a time.Duration.
+func ParseEffort(s string) (time.Duration, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, false
+	}
+	if hours, minutes, ok := strings.Cut(s, ":"); ok {
+		h, ok1 := number(hours)
+		m, ok2 := number(minutes)
+		if !ok1 || !ok2 || strings.Contains(s, ".") || m > 59 || len(minutes) != 2 {
+			return 0, false
+		}
+		return sum(h*float64(time.Hour), m*float64(time.Minute))
+	}
+	if n, ok := number(s); ok {
+		return sum(n * float64(time.Minute))
+	}
+
+	// One or more number and unit pairs, optionally separated by spaces
+	var parts []float64
+	for s != "" {
+		i := 0
+		for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+			i++
+		}
+		n, ok := number(s[:i])
+		if !ok {
+			return 0, false
+		}
+		s = s[i:]
+		var size time.Duration
+		for _, u := range effortUnits {
+			if rest, ok := strings.CutPrefix(s, u.name); ok {
+				size, s = u.size, rest
+				break
+			}
+		}
+		if size == 0 {
+			return 0, false
+		}
+		parts = append(parts, n*float64(size))
+		s = strings.TrimLeft(s, " ")
+	}
+	return sum(parts...)
+}
+
+// number parses a non-negative decimal made of digits and at most one
+// dot, such as "2" or "0.5"
+func number(s string) (float64, bool) {
+	digits := strings.Count(s, ".") <= 1 && strings.Trim(s, ".") != ""
+	for i := 0; digits && i < len(s); i++ {
+		digits = s[i] >= '0' && s[i] <= '9' || s[i] == '.'
+	}
+	if !digits {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	return n, err == nil
+}
+
+// sum adds durations given in nanoseconds, reporting false if the total
+// does not fit in a time.Duration
+func sum(parts ...float64) (time.Duration, bool) {
+	var total float64
+	for _, p := range parts {
+		total += p
+	}
+	if total >= math.MaxInt64 {
+		return 0, false
+	}
+	return time.Duration(total), true
+}
+
+// Effort returns the duration of the headline's :Effort: property and
+// whether it has one that parses
+func (h *Headline) Effort() (time.Duration, bool) {
+	v, ok := h.Property("Effort")
+	if !ok {
+		return 0, false
+	}
+	return ParseEffort(v)
+}
+
+// TotalEffort sums efforts the way Org's column view does: a headline
+// whose subheadlines carry efforts counts the sum of theirs in place of
+// its own. It reports false if no headline in the subtree has an effort.
+func (h *Headline) TotalEffort() (time.Duration, bool) {
+	var total time.Duration
+	found := false
+	for _, sub := range h.Subheadlines() {
+		if d, ok := sub.TotalEffort(); ok {
+			total += d
+			found = true
+		}
+	}
+	if found {
+		return total, true
+	}
+	return h.Effort()
+}
@@ -0,0 +1,29 @@
+package ast
+
+import "strings"
+
+// Habit is the view of a headline tracked as an Org habit: one with a
+// :STYLE: habit property and a repeating SCHEDULED timestamp
+type Habit struct {
+	Headline  *Headline
+	Scheduled *Timestamp
+	// Repeater is the interval between repetitions. For ".+2d/3d" it also
+	// carries the longest interval the habit may go without being done.
+	Repeater Repeater
+}
+
+// Habit returns the headline's habit view. It reports false if the
+// headline is not styled as a habit or is not scheduled with a repeater.
+func (h *Headline) Habit() (*Habit, bool) {
+	if style, _ := h.Property("STYLE"); !strings.EqualFold(strings.TrimSpace(style), "habit") {
+		return nil, false
+	}
+	if h.Scheduled == nil {
+		return nil, false
+	}
+	r, ok := h.Scheduled.Repeater()
+	if !ok {
+		return nil, false
+	}
+	return &Habit{Headline: h, Scheduled: h.Scheduled, Repeater: r}, true
+}
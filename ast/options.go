@@ -0,0 +1,21 @@
+package ast
+
+import "strings"
+
+// ExportOption returns the value of an item on the #+OPTIONS lines, such
+// as "3" for toc:3. Names are case-sensitive and later items override
+// earlier ones, as in Org.
+func (d *Document) ExportOption(name string) (string, bool) {
+	value, found := "", false
+	for _, kw := range d.Keywords() {
+		if !strings.EqualFold(kw.Key, "OPTIONS") {
+			continue
+		}
+		for _, item := range strings.Fields(kw.Value) {
+			if k, v, ok := strings.Cut(item, ":"); ok && k == name {
+				value, found = v, true
+			}
+		}
+	}
+	return value, found
+}
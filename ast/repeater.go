@@ -0,0 +1,170 @@
+package ast
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RepeaterKind is how a repeating timestamp moves when it is marked done
+type RepeaterKind int
+
+const (
+	RepeatCumulative RepeaterKind = iota // +1w: shift by one interval
+	RepeatCatchUp                        // ++1w: shift by whole intervals until in the future
+	RepeatRestart                        // .+1w: shift by one interval from now
+)
+
+// String returns the org marker of the kind
+func (k RepeaterKind) String() string {
+	switch k {
+	case RepeatCatchUp:
+		return "++"
+	case RepeatRestart:
+		return ".+"
+	default:
+		return "+"
+	}
+}
+
+// Repeater is the parsed form of a timestamp repeater such as ".+2d"
+type Repeater struct {
+	Kind  RepeaterKind
+	Value int  // Number of units per interval
+	Unit  byte // h, d, w, m or y
+
+	// The longest interval a habit may go without being done, from
+	// ".+2d/3d"; MaxValue is 0 if the repeater has none
+	MaxValue int
+	MaxUnit  byte
+}
+
+func (r Repeater) String() string {
+	s := r.Kind.String() + strconv.Itoa(r.Value) + string(r.Unit)
+	if r.MaxValue > 0 {
+		s += "/" + strconv.Itoa(r.MaxValue) + string(r.MaxUnit)
+	}
+	return s
+}
+
+// ParseRepeater parses a repeater such as "+1w", "++2d", ".+1m" or the
+// habit form ".+2d/3d". It reports false for anything else, including an
+// empty string.
+func ParseRepeater(s string) (Repeater, bool) {
+	var r Repeater
+	switch {
+	case strings.HasPrefix(s, "++"):
+		r.Kind, s = RepeatCatchUp, s[2:]
+	case strings.HasPrefix(s, ".+"):
+		r.Kind, s = RepeatRestart, s[2:]
+	case strings.HasPrefix(s, "+"):
+		r.Kind, s = RepeatCumulative, s[1:]
+	default:
+		return Repeater{}, false
+	}
+	s, limit, hasMax := strings.Cut(s, "/")
+	var ok bool
+	if r.Value, r.Unit, ok = parseInterval(s); !ok {
+		return Repeater{}, false
+	}
+	if hasMax {
+		if r.MaxValue, r.MaxUnit, ok = parseInterval(limit); !ok {
+			return Repeater{}, false
+		}
+	}
+	return r, true
+}
+
+// parseInterval parses a count and unit such as "2d"
+func parseInterval(s string) (int, byte, bool) {
+	if len(s) < 2 || !strings.ContainsRune("hdwmy", rune(s[len(s)-1])) {
+		return 0, 0, false
+	}
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n < 1 {
+		return 0, 0, false
+	}
+	return n, s[len(s)-1], true
+}
+
+// Repeater returns the parsed repeater of the timestamp and whether it has
+// a valid one
+func (ts *Timestamp) Repeater() (Repeater, bool) {
+	return ParseRepeater(ts.Repeat)
+}
+
+// Next returns the date a timestamp at start moves to when it is marked
+// done at now, as Org does:
+//
+//   - cumulative (+) adds one interval to start, even if that is still in
+//     the past
+//   - catch-up (++) adds whole intervals to start until it is after now,
+//     keeping the weekday and time of day
+//   - restart (.+) adds one interval to now; for units other than hours
+//     the time of day of start is kept
+func (r Repeater) Next(start, now time.Time) time.Time {
+	switch r.Kind {
+	case RepeatCatchUp:
+		next := r.add(start, 1)
+		if !next.After(now) {
+			// Skip most of the way without stepping through every interval
+			if k := r.intervalsBetween(start, now); k > 1 {
+				next = r.add(start, k-1)
+			}
+			for !next.After(now) {
+				next = r.add(next, 1)
+			}
+		}
+		return next
+	case RepeatRestart:
+		if r.Unit == 'h' {
+			return r.add(now, 1)
+		}
+		base := time.Date(now.Year(), now.Month(), now.Day(), start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+		return r.add(base, 1)
+	default:
+		return r.add(start, 1)
+	}
+}
+
+// add moves t by n intervals
+func (r Repeater) add(t time.Time, n int) time.Time {
+	n *= r.Value
+	switch r.Unit {
+	case 'h':
+		return t.Add(time.Duration(n) * time.Hour)
+	case 'w':
+		return t.AddDate(0, 0, 7*n)
+	case 'm':
+		return t.AddDate(0, n, 0)
+	case 'y':
+		return t.AddDate(n, 0, 0)
+	}
+	return t.AddDate(0, 0, n)
+}
+
+// intervalsBetween estimates how many intervals fit between start and
+// now, never overestimating by more than one
+func (r Repeater) intervalsBetween(start, now time.Time) int {
+	var per time.Duration
+	switch r.Unit {
+	case 'h':
+		per = time.Hour
+	case 'd':
+		per = 24 * time.Hour
+	case 'w':
+		per = 7 * 24 * time.Hour
+	case 'm':
+		per = 28 * 24 * time.Hour
+	default:
+		per = 365 * 24 * time.Hour
+	}
+	// Months and years are longer than per, so this may overestimate them
+	k := int(now.Sub(start) / (per * time.Duration(r.Value)))
+	if r.Unit == 'm' || r.Unit == 'y' {
+		for k > 0 && r.add(start, k).After(now) {
+			k--
+		}
+	}
+	return k
+}
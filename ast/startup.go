@@ -0,0 +1,126 @@
+package ast
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Visibility is the initial folding state requested for a buffer or subtree
+type Visibility int
+
+const (
+	VisibilityDefault        Visibility = iota // nothing requested
+	VisibilityFolded                           // overview / folded: only top-level headlines
+	VisibilityChildren                         // direct children visible
+	VisibilityContent                          // all headlines, no bodies
+	VisibilityShowLevels                       // showNlevels: headlines down to Startup.Levels
+	VisibilityShowAll                          // showall / all: everything except drawers
+	VisibilityShowEverything                   // showeverything: including drawers and blocks
+)
+
+// String returns the org spelling of the visibility state
+func (v Visibility) String() string {
+	switch v {
+	case VisibilityFolded:
+		return "folded"
+	case VisibilityChildren:
+		return "children"
+	case VisibilityContent:
+		return "content"
+	case VisibilityShowLevels:
+		return "showlevels"
+	case VisibilityShowAll:
+		return "all"
+	case VisibilityShowEverything:
+		return "showeverything"
+	default:
+		return "default"
+	}
+}
+
+// Startup holds the settings collected from #+STARTUP lines
+type Startup struct {
+	Visibility  Visibility
+	Levels      int      // For showNlevels
+	HideBlocks  bool     // hideblocks / nohideblocks
+	HideDrawers bool     // hidedrawers / nohidedrawers
+	HideStars   bool     // hidestars / showstars
+	Indent      bool     // indent / noindent
+	Options     []string // Every option in order, including ones not modelled above
+}
+
+// Startup collects all #+STARTUP keywords at the top level of the document.
+// Options from later lines override earlier ones, as in Org.
+func (d *Document) Startup() Startup {
+	var s Startup
+	for _, kw := range d.Keywords() {
+		if !strings.EqualFold(kw.Key, "STARTUP") {
+			continue
+		}
+		for _, opt := range strings.Fields(kw.Value) {
+			s.Options = append(s.Options, opt)
+			s.apply(strings.ToLower(opt))
+		}
+	}
+	return s
+}
+
+func (s *Startup) apply(opt string) {
+	switch opt {
+	case "overview", "fold", "folded":
+		s.Visibility = VisibilityFolded
+	case "content":
+		s.Visibility = VisibilityContent
+	case "showall", "nofold":
+		s.Visibility = VisibilityShowAll
+	case "showeverything":
+		s.Visibility = VisibilityShowEverything
+	case "hideblocks":
+		s.HideBlocks = true
+	case "nohideblocks":
+		s.HideBlocks = false
+	case "hidedrawers":
+		s.HideDrawers = true
+	case "nohidedrawers":
+		s.HideDrawers = false
+	case "hidestars":
+		s.HideStars = true
+	case "showstars":
+		s.HideStars = false
+	case "indent":
+		s.Indent = true
+	case "noindent":
+		s.Indent = false
+	default:
+		// show2levels ... show5levels
+		if n, ok := strings.CutPrefix(opt, "show"); ok {
+			if n, ok := strings.CutSuffix(n, "levels"); ok {
+				if levels, err := strconv.Atoi(n); err == nil && levels > 0 {
+					s.Visibility = VisibilityShowLevels
+					s.Levels = levels
+				}
+			}
+		}
+	}
+}
+
+// Visibility returns the folding state requested by the headline's
+// :VISIBILITY: property, or VisibilityDefault when unset or unrecognized
+func (h *Headline) Visibility() Visibility {
+	v, ok := h.Property("VISIBILITY")
+	if !ok {
+		return VisibilityDefault
+	}
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "folded":
+		return VisibilityFolded
+	case "children":
+		return VisibilityChildren
+	case "content":
+		return VisibilityContent
+	case "all":
+		return VisibilityShowAll
+	default:
+		return VisibilityDefault
+	}
+}
@@ -0,0 +1,94 @@
+package ast
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Align is the alignment of a table column
+type Align int
+
+const (
+	AlignDefault Align = iota
+	AlignLeft
+	AlignCenter
+	AlignRight
+)
+
+// cookieRegex matches a column cookie such as <l>, <c>, <r10> or <10>
+var cookieRegex = regexp.MustCompile(`^<([lcr]?)(\d*)>$`)
+
+// IsCookieRow reports whether the row holds nothing but column cookies,
+// such as "| <l> | <r10> |". Org uses them to align and size columns and
+// leaves them out of exports.
+func (tr *TableRow) IsCookieRow() bool {
+	if tr.Separator {
+		return false
+	}
+	found := false
+	for _, cell := range tr.Cells {
+		cell = strings.TrimSpace(cell)
+		if cell == "" {
+			continue
+		}
+		if cell == "<>" || !cookieRegex.MatchString(cell) {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// Alignments returns the alignment of each column, as set by the cookies
+// of the cookie rows; a later cookie overrides an earlier one. Columns
+// without an alignment cookie are AlignDefault.
+func (t *Table) Alignments() []Align {
+	width := 0
+	for _, row := range t.Rows {
+		width = max(width, len(row.Cells))
+	}
+	out := make([]Align, width)
+	for _, row := range t.Rows {
+		if !row.IsCookieRow() {
+			continue
+		}
+		for i, cell := range row.Cells {
+			m := cookieRegex.FindStringSubmatch(strings.TrimSpace(cell))
+			if m == nil {
+				continue
+			}
+			switch m[1] {
+			case "l":
+				out[i] = AlignLeft
+			case "c":
+				out[i] = AlignCenter
+			case "r":
+				out[i] = AlignRight
+			}
+		}
+	}
+	return out
+}
+
+// HeaderEnd returns the index in Rows of the separator that ends the
+// header: the first separator, when data rows come both above and below
+// it, as Org requires for a header. It returns -1 if the table has none.
+// Cookie rows are not data rows.
+func (t *Table) HeaderEnd() int {
+	above := false
+	end := -1
+	for i, row := range t.Rows {
+		switch {
+		case row.Separator:
+			if end < 0 && above {
+				end = i
+			}
+		case row.IsCookieRow():
+		case end >= 0:
+			return end
+		default:
+			above = true
+		}
+	}
+	return -1
+}
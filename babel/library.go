@@ -0,0 +1,142 @@
+// Package babel provides source block resolution for literate programming
+// workflows built on organelle.
+package babel
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+// Library is a set of named source blocks loaded from other Org files,
+// the equivalent of Org's Library of Babel. It lets noweb references and
+// #+CALL lines resolve blocks that are not defined in the calling file.
+//
+// A Library is not safe for concurrent modification; load all files
+// before sharing it between goroutines.
+type Library struct {
+	blocks    map[string]libraryEntry
+	conflicts []Conflict
+}
+
+type libraryEntry struct {
+	block  *ast.Block
+	source string
+}
+
+// Conflict reports a block name defined by more than one loaded source.
+// The block from the first source stays in effect.
+type Conflict struct {
+	Name    string
+	Sources []string // Sources defining the name, in load order
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("block %q defined in %v", c.Name, c.Sources)
+}
+
+// NewLibrary creates an empty Library
+func NewLibrary() *Library {
+	return &Library{blocks: make(map[string]libraryEntry)}
+}
+
+// Load registers every named SRC block of doc under the given source
+// label (usually the file path) and returns the conflicts it introduced
+func (l *Library) Load(source string, doc *ast.Document) []Conflict {
+	var added []Conflict
+	for _, ne := range doc.NamedElements() {
+		block, ok := ne.Node.(*ast.Block)
+		if !ok || block.Type != "SRC" {
+			continue
+		}
+		existing, ok := l.blocks[ne.Name]
+		if !ok {
+			l.blocks[ne.Name] = libraryEntry{block: block, source: source}
+			continue
+		}
+		added = append(added, l.recordConflict(ne.Name, existing.source, source).copy())
+	}
+	return added
+}
+
+// recordConflict adds source to the conflict entry for name, creating the
+// entry on first collision. A source that defines the name more than once
+// is listed once.
+func (l *Library) recordConflict(name, first, source string) Conflict {
+	for i := range l.conflicts {
+		if l.conflicts[i].Name == name {
+			for _, s := range l.conflicts[i].Sources {
+				if s == source {
+					return l.conflicts[i]
+				}
+			}
+			l.conflicts[i].Sources = append(l.conflicts[i].Sources, source)
+			return l.conflicts[i]
+		}
+	}
+	c := Conflict{Name: name, Sources: []string{first}}
+	if source != first {
+		c.Sources = append(c.Sources, source)
+	}
+	l.conflicts = append(l.conflicts, c)
+	return c
+}
+
+// copy returns c with its own Sources slice
+func (c Conflict) copy() Conflict {
+	c.Sources = append([]string(nil), c.Sources...)
+	return c
+}
+
+// LoadFile parses the Org file at path and loads its named blocks.
+// Parse errors are returned together with any conflicts found.
+func (l *Library) LoadFile(path string, opts ...lexer.Option) ([]Conflict, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p := parser.New(lexer.New(string(data), opts...))
+	doc := p.ParseDocument()
+	if errs := p.Errors(); len(errs) > 0 {
+		return nil, fmt.Errorf("babel: %s: %s", path, errs[0])
+	}
+	return l.Load(path, doc), nil
+}
+
+// Conflicts returns a copy of all conflicts recorded so far, in the order
+// they were first seen
+func (l *Library) Conflicts() []Conflict {
+	out := make([]Conflict, len(l.conflicts))
+	for i, c := range l.conflicts {
+		out[i] = c.copy()
+	}
+	return out
+}
+
+// Lookup returns the library block registered under name and the source
+// it was loaded from
+func (l *Library) Lookup(name string) (*ast.Block, string, bool) {
+	e, ok := l.blocks[name]
+	if !ok {
+		return nil, "", false
+	}
+	return e.block, e.source, true
+}
+
+// Resolve finds the SRC block called name as seen from doc: blocks in doc
+// itself take precedence over library blocks. The returned source is
+// empty for local blocks.
+func (l *Library) Resolve(doc *ast.Document, name string) (*ast.Block, string, bool) {
+	if b, ok := doc.NamedElement(name).(*ast.Block); ok && b.Type == "SRC" {
+		return b, "", true
+	}
+	return l.Lookup(name)
+}
+
+// ResolveCall resolves the block a #+CALL line refers to
+func (l *Library) ResolveCall(doc *ast.Document, call *ast.Call) (*ast.Block, string, bool) {
+	return l.Resolve(doc, call.Name)
+}
@@ -0,0 +1,153 @@
+package babel
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+func parse(input string) *ast.Document {
+	return parser.New(lexer.New(input)).ParseDocument()
+}
+
+func TestLibraryResolve(t *testing.T) {
+	lib := NewLibrary()
+	conflicts := lib.Load("lib.org", parse(`#+NAME: square
+#+BEGIN_SRC python
+return x * x
+#+END_SRC
+#+NAME: greet
+#+BEGIN_SRC sh
+echo hi
+#+END_SRC
+`))
+	if len(conflicts) != 0 {
+		t.Fatalf("unexpected conflicts: %v", conflicts)
+	}
+
+	doc := parse(`#+NAME: greet
+#+BEGIN_SRC sh
+echo local
+#+END_SRC
+#+CALL: square(x=3)
+`)
+
+	call := doc.Children[2].(*ast.Call)
+	block, source, ok := lib.ResolveCall(doc, call)
+	if !ok || source != "lib.org" || block.Language != "python" {
+		t.Errorf("expected square from lib.org, got ok=%v source=%q", ok, source)
+	}
+
+	block, source, ok = lib.Resolve(doc, "greet")
+	if !ok || source != "" || block.Content != "echo local" {
+		t.Errorf("expected local greet to win, got source=%q content=%q", source, block.Content)
+	}
+
+	if _, _, ok := lib.Resolve(doc, "missing"); ok {
+		t.Error("expected missing block to be unresolved")
+	}
+}
+
+func TestLibraryConflicts(t *testing.T) {
+	src := "#+NAME: dup\n#+BEGIN_SRC sh\necho %s\n#+END_SRC\n"
+	lib := NewLibrary()
+	lib.Load("a.org", parse(src))
+	lib.Load("b.org", parse(src))
+	added := lib.Load("c.org", parse(src))
+
+	if len(added) != 1 || len(added[0].Sources) != 3 {
+		t.Fatalf("expected conflict listing 3 sources, got=%v", added)
+	}
+	if len(lib.Conflicts()) != 1 {
+		t.Errorf("expected a single recorded conflict, got=%d", len(lib.Conflicts()))
+	}
+	if _, source, _ := lib.Lookup("dup"); source != "a.org" {
+		t.Errorf("expected first definition to win, got=%q", source)
+	}
+}
+
+func TestLibraryLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lib.org")
+	if err := os.WriteFile(path, []byte("#+NAME: hello\n#+BEGIN_SRC go\nfmt.Println()\n#+END_SRC\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	lib := NewLibrary()
+	if _, err := lib.LoadFile(path); err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if _, source, ok := lib.Lookup("hello"); !ok || source != path {
+		t.Errorf("expected hello from %s, got ok=%v source=%q", path, ok, source)
+	}
+}
+
+func TestLibraryConflictsSameSource(t *testing.T) {
+	src := "#+NAME: dup\n#+BEGIN_SRC sh\necho 1\n#+END_SRC\n#+NAME: dup\n#+BEGIN_SRC sh\necho 2\n#+END_SRC\n"
+	lib := NewLibrary()
+	lib.Load("a.org", parse(src))
+	lib.Load("a.org", parse(src))
+
+	conflicts := lib.Conflicts()
+	if len(conflicts) != 1 || len(conflicts[0].Sources) != 1 || conflicts[0].Sources[0] != "a.org" {
+		t.Fatalf("expected a.org listed once, got=%v", conflicts)
+	}
+	conflicts[0].Sources[0] = "changed"
+	conflicts[0].Name = "changed"
+	if again := lib.Conflicts(); again[0].Name != "dup" || again[0].Sources[0] != "a.org" {
+		t.Errorf("expected Conflicts to return a copy, got=%v", again)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	lib := NewLibrary()
+	lib.Load("lib.org", parse(`#+NAME: imports
+#+BEGIN_SRC python
+import os
+import sys
+#+END_SRC
+`))
+	doc := parse(`#+NAME: body
+#+BEGIN_SRC python
+print(os.getcwd())
+#+END_SRC
+#+NAME: main
+#+BEGIN_SRC python
+<<imports>>
+def main():
+    <<body>>  # inserted
+    call(<<name(x=1)>>)
+#+END_SRC
+#+NAME: loop
+#+BEGIN_SRC sh
+<<loop>>
+#+END_SRC
+#+NAME: broken
+#+BEGIN_SRC sh
+<<missing>>
+#+END_SRC
+`)
+	main, _, _ := lib.Resolve(doc, "main")
+	got, err := lib.Expand(doc, main)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `import os
+import sys
+def main():
+    print(os.getcwd())  # inserted
+    call(<<name(x=1)>>)`
+	if got != want {
+		t.Errorf("unexpected expansion:\n%s\nwant:\n%s", got, want)
+	}
+
+	for _, name := range []string{"loop", "broken"} {
+		block, _, _ := lib.Resolve(doc, name)
+		if _, err := lib.Expand(doc, block); err == nil {
+			t.Errorf("expected expanding %s to fail", name)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package babel
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/justyntemme/organelle/ast"
+)
+
+// nowebRegex matches a noweb reference such as <<name>>. References that
+// call a block, such as <<name(x=1)>>, insert its results in Org and are
+// left as they are.
+var nowebRegex = regexp.MustCompile(`<<([^<>()\s]+)>>`)
+
+// Expand returns the content of block with every noweb reference replaced
+// by the expanded content of the block it names, resolved from doc with
+// Resolve. As in Org, the text before a reference on its line is repeated
+// in front of every inserted line. Org only expands blocks whose :noweb
+// header argument allows it; that check is left to the caller. A
+// reference that does not resolve or that refers back to a block being
+// expanded is an error.
+func (l *Library) Expand(doc *ast.Document, block *ast.Block) (string, error) {
+	return l.expand(doc, block.Content, nil)
+}
+
+// expand expands the references in content; stack holds the names of the
+// blocks being expanded
+func (l *Library) expand(doc *ast.Document, content string, stack []string) (string, error) {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		expanded, err := l.expandLine(doc, line, stack)
+		if err != nil {
+			return "", err
+		}
+		lines[i] = expanded
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func (l *Library) expandLine(doc *ast.Document, line string, stack []string) (string, error) {
+	m := nowebRegex.FindStringSubmatchIndex(line)
+	if m == nil {
+		return line, nil
+	}
+	name := line[m[2]:m[3]]
+	for _, open := range stack {
+		if open == name {
+			return "", fmt.Errorf("babel: noweb reference <<%s>> refers to itself through %s", name, strings.Join(stack, " -> "))
+		}
+	}
+	block, _, ok := l.Resolve(doc, name)
+	if !ok {
+		return "", fmt.Errorf("babel: unresolved noweb reference <<%s>>", name)
+	}
+	body, err := l.expand(doc, block.Content, append(stack, name))
+	if err != nil {
+		return "", err
+	}
+	rest, err := l.expandLine(doc, line[m[1]:], stack)
+	if err != nil {
+		return "", err
+	}
+
+	prefix := line[:m[0]]
+	inserted := strings.Split(strings.TrimSuffix(body, "\n"), "\n")
+	for i := range inserted {
+		inserted[i] = prefix + inserted[i]
+	}
+	return strings.Join(inserted, "\n") + rest, nil
+}
@@ -0,0 +1,307 @@
+// Package clocktable sums the time clocked in LOGBOOK drawers into a
+// report grouped by headline, tag, day or property, like Org's clock table.
+package clocktable
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/redact"
+)
+
+// GroupBy selects how clocked time is grouped into rows
+type GroupBy int
+
+const (
+	ByHeadline GroupBy = iota // One row per headline, in document order
+	ByTag                     // One row per tag, sorted by tag
+	ByDay                     // One row per day a clock started on, sorted by date
+	ByProperty                // One row per value of a property, sorted by value
+)
+
+// Row is one line of the report
+type Row struct {
+	Key      string        // Headline title, tag, date such as 2024-01-15, or property value
+	Level    int           // Headline level for ByHeadline, otherwise 0
+	Headline *ast.Headline // For ByHeadline
+	Tags     []string      // The headline's tags, for ByHeadline
+	Time     time.Duration // For ByHeadline, including the subheadlines
+}
+
+// Report is the clocked time of a document
+type Report struct {
+	Group    GroupBy
+	Property string // The property grouped by, for ByProperty
+	Rows     []Row
+	Total    time.Duration
+
+	ShowTags    bool // Org adds a Tags column
+	ShowPercent bool // Org adds a column with each row's share of the total
+}
+
+// Builder collects clocked time
+type Builder struct {
+	group    GroupBy
+	property string
+	from, to time.Time
+	maxLevel int // 0 means unlimited
+	archived redact.Archived
+	tags     bool
+	percent  bool
+}
+
+// Option is a functional option for configuring the Builder
+type Option func(*Builder)
+
+// WithGroupBy sets how time is grouped (default ByHeadline)
+func WithGroupBy(g GroupBy) Option {
+	return func(b *Builder) {
+		b.group = g
+	}
+}
+
+// WithGroupByProperty groups time by the value of the property name, such
+// as CLIENT. A headline without the property takes it from its nearest
+// ancestor that has it, so a client set on a project covers its tasks;
+// time on headlines without any value is grouped under the empty key.
+func WithGroupByProperty(name string) Option {
+	return func(b *Builder) {
+		b.group = ByProperty
+		b.property = name
+	}
+}
+
+// WithTags adds a Tags column to a ByHeadline report, like :tags t
+func WithTags() Option {
+	return func(b *Builder) {
+		b.tags = true
+	}
+}
+
+// WithPercent adds a column with each row's share of the total time, like
+// :formula %
+func WithPercent() Option {
+	return func(b *Builder) {
+		b.percent = true
+	}
+}
+
+// WithRange counts only clocks that started at or after from and before
+// to. A zero time leaves that end open.
+func WithRange(from, to time.Time) Option {
+	return func(b *Builder) {
+		b.from, b.to = from, to
+	}
+}
+
+// WithMaxLevel leaves headlines deeper than level out of a ByHeadline
+// report; their time still counts towards their ancestors
+func WithMaxLevel(level int) Option {
+	return func(b *Builder) {
+		b.maxLevel = level
+	}
+}
+
+// IncludeArchived counts time clocked in subtrees tagged :ARCHIVE:; see
+// redact.Archived
+func IncludeArchived() Option {
+	return func(b *Builder) {
+		b.archived = true
+	}
+}
+
+// New creates a Builder
+func New(opts ...Option) *Builder {
+	b := &Builder{}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Build returns the report for doc
+func (b *Builder) Build(doc *ast.Document) *Report {
+	r := &Report{Group: b.group, Property: b.property, ShowTags: b.tags && b.group == ByHeadline, ShowPercent: b.percent}
+	totals := map[string]time.Duration{}
+	archived := b.archived.For(doc)
+	var walk func(nodes []ast.Node, value string) time.Duration
+	walk = func(nodes []ast.Node, value string) time.Duration {
+		var sum time.Duration
+		for _, n := range nodes {
+			h, ok := n.(*ast.Headline)
+			if !ok || archived.Skip(h) {
+				continue
+			}
+			row := -1
+			if b.group == ByHeadline && (b.maxLevel == 0 || h.Level <= b.maxLevel) {
+				row = len(r.Rows)
+				r.Rows = append(r.Rows, Row{Key: h.Title, Level: h.Level, Headline: h, Tags: h.Tags})
+			}
+			own := value // Property value for ByProperty, inherited unless set here
+			if b.group == ByProperty {
+				if v, ok := h.Property(b.property); ok {
+					own = strings.TrimSpace(v)
+				}
+			}
+
+			clocked := b.clocked(h, func(c *ast.Clock, start time.Time) {
+				switch b.group {
+				case ByTag:
+					for _, tag := range h.Tags {
+						totals[tag] += c.Duration
+					}
+				case ByDay:
+					totals[start.Format("2006-01-02")] += c.Duration
+				case ByProperty:
+					totals[own] += c.Duration
+				}
+			})
+			subtree := clocked + walk(h.Children, own)
+			sum += subtree
+
+			switch {
+			case row == -1:
+			case subtree == 0:
+				r.Rows = r.Rows[:row] // Nothing clocked in the subtree either
+			default:
+				r.Rows[row].Time = subtree
+			}
+		}
+		return sum
+	}
+	r.Total = walk(doc.Children, "")
+
+	if b.group != ByHeadline {
+		keys := make([]string, 0, len(totals))
+		for k := range totals {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			r.Rows = append(r.Rows, Row{Key: k, Time: totals[k]})
+		}
+	}
+	return r
+}
+
+// Build returns the report for doc with default options
+func Build(doc *ast.Document) *Report {
+	return New().Build(doc)
+}
+
+// clocked sums the finished clocks of h within the range, calling fn for
+// each of them
+func (b *Builder) clocked(h *ast.Headline, fn func(c *ast.Clock, start time.Time)) time.Duration {
+	var sum time.Duration
+	for _, c := range h.Clocks() {
+		if c.Running() {
+			continue
+		}
+		start, err := c.Start.ToTime()
+		if err != nil {
+			continue
+		}
+		if (!b.from.IsZero() && start.Before(b.from)) || (!b.to.IsZero() && !start.Before(b.to)) {
+			continue
+		}
+		sum += c.Duration
+		fn(c, start)
+	}
+	return sum
+}
+
+// Percent returns the share of the total time in row, from 0 to 100
+func (r *Report) Percent(row Row) float64 {
+	if r.Total == 0 {
+		return 0
+	}
+	return float64(row.Time) / float64(r.Total) * 100
+}
+
+// Org renders the report as an Org table with the total on top, as
+// org-clock-report does
+func (r *Report) Org() string {
+	column := "Headline"
+	switch r.Group {
+	case ByTag:
+		column = "Tag"
+	case ByDay:
+		column = "Day"
+	case ByProperty:
+		column = r.Property
+	}
+
+	header := []string{column, "Time"}
+	total := []string{"*Total time*", "*" + FormatDuration(r.Total) + "*"}
+	if r.ShowTags {
+		header = append([]string{"Tags"}, header...)
+		total = append([]string{""}, total...)
+	}
+	if r.ShowPercent {
+		header = append(header, "%")
+		total = append(total, "")
+		if r.Total > 0 {
+			total[len(total)-1] = "100.0"
+		}
+	}
+	rows := [][]string{header, total}
+	for _, row := range r.Rows {
+		key := row.Key
+		if row.Level > 1 {
+			key = `\_` + strings.Repeat("  ", row.Level-1) + key
+		}
+		cells := []string{key, FormatDuration(row.Time)}
+		if r.ShowTags {
+			tags := ""
+			if len(row.Tags) > 0 {
+				tags = ":" + strings.Join(row.Tags, ":") + ":"
+			}
+			cells = append([]string{tags}, cells...)
+		}
+		if r.ShowPercent {
+			cells = append(cells, fmt.Sprintf("%.1f", r.Percent(row)))
+		}
+		rows = append(rows, cells)
+	}
+
+	widths := make([]int, len(header))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len([]rune(cell)))
+		}
+	}
+	var rule strings.Builder
+	for i, w := range widths {
+		sep := "+"
+		if i == 0 {
+			sep = "|"
+		}
+		rule.WriteString(sep + strings.Repeat("-", w+2))
+	}
+	rule.WriteString("|\n")
+
+	var out strings.Builder
+	for i, row := range rows {
+		if i == 1 || i == 2 {
+			out.WriteString(rule.String())
+		}
+		for j, cell := range row {
+			fmt.Fprintf(&out, "| %s ", pad(cell, widths[j]))
+		}
+		out.WriteString("|\n")
+	}
+	return out.String()
+}
+
+func pad(s string, width int) string {
+	return s + strings.Repeat(" ", width-len([]rune(s)))
+}
+
+// FormatDuration renders d as H:MM, the format of CLOCK lines
+func FormatDuration(d time.Duration) string {
+	minutes := int(d / time.Minute)
+	return fmt.Sprintf("%d:%02d", minutes/60, minutes%60)
+}
@@ -0,0 +1,177 @@
+package clocktable
+
+import (
+	"testing"
+	"time"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+const input = `* Project :work:
+:LOGBOOK:
+CLOCK: [2024-01-15 Mon 09:00]--[2024-01-15 Mon 10:30] =>  1:30
+:END:
+** Design :design:
+:LOGBOOK:
+CLOCK: [2024-01-16 Tue 14:00]--[2024-01-16 Tue 14:45] =>  0:45
+CLOCK: [2024-01-17 Wed 09:00]
+:END:
+** Idle
+* Errands
+:LOGBOOK:
+CLOCK: [2024-01-15 Mon 18:00]--[2024-01-15 Mon 18:20] =>  0:20
+:END:
+`
+
+func parse(t *testing.T, opts ...parser.Option) *ast.Document {
+	t.Helper()
+	return parser.New(lexer.New(input), opts...).ParseDocument()
+}
+
+func TestClockedTime(t *testing.T) {
+	for _, opts := range [][]parser.Option{nil, {parser.WithSections()}} {
+		project := parse(t, opts...).Children[0].(*ast.Headline)
+		if got := project.ClockedTime(false); got != 90*time.Minute {
+			t.Errorf("expected own time 1:30, got=%v", got)
+		}
+		if got := project.ClockedTime(true); got != 135*time.Minute {
+			t.Errorf("expected subtree time 2:15, got=%v", got)
+		}
+	}
+}
+
+func TestByHeadline(t *testing.T) {
+	r := Build(parse(t))
+	if r.Total != 155*time.Minute {
+		t.Errorf("expected total 2:35, got=%v", r.Total)
+	}
+	want := []struct {
+		key  string
+		time time.Duration
+	}{{"Project", 135 * time.Minute}, {"Design", 45 * time.Minute}, {"Errands", 20 * time.Minute}}
+	if len(r.Rows) != len(want) {
+		t.Fatalf("expected %d rows without the unclocked headline, got=%+v", len(want), r.Rows)
+	}
+	for i, w := range want {
+		if r.Rows[i].Key != w.key || r.Rows[i].Time != w.time {
+			t.Errorf("row %d: expected %s %v, got=%+v", i, w.key, w.time, r.Rows[i])
+		}
+	}
+
+	expected := `| Headline     | Time   |
+|--------------+--------|
+| *Total time* | *2:35* |
+|--------------+--------|
+| Project      | 2:15   |
+| \_  Design   | 0:45   |
+| Errands      | 0:20   |
+`
+	if got := r.Org(); got != expected {
+		t.Errorf("unexpected table.\nexpected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestByTagAndDay(t *testing.T) {
+	r := New(WithGroupBy(ByTag)).Build(parse(t))
+	if len(r.Rows) != 2 || r.Rows[0].Key != "design" || r.Rows[1].Key != "work" || r.Rows[1].Time != 90*time.Minute {
+		t.Errorf("unexpected tag rows %+v", r.Rows)
+	}
+
+	r = New(WithGroupBy(ByDay)).Build(parse(t))
+	if len(r.Rows) != 2 || r.Rows[0].Key != "2024-01-15" || r.Rows[0].Time != 110*time.Minute {
+		t.Errorf("unexpected day rows %+v", r.Rows)
+	}
+}
+
+func TestRange(t *testing.T) {
+	from := time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC)
+	r := New(WithRange(from, time.Time{}), WithMaxLevel(1)).Build(parse(t))
+	if r.Total != 45*time.Minute || len(r.Rows) != 1 || r.Rows[0].Key != "Project" {
+		t.Errorf("unexpected report %+v", r)
+	}
+}
+
+func TestBuildSkipsArchived(t *testing.T) {
+	doc := parser.New(lexer.New(`* Active
+:LOGBOOK:
+CLOCK: [2024-01-15 Mon 09:00]--[2024-01-15 Mon 10:00] =>  1:00
+:END:
+* Old :ARCHIVE:
+:LOGBOOK:
+CLOCK: [2024-01-10 Wed 09:00]--[2024-01-10 Wed 11:00] =>  2:00
+:END:
+`)).ParseDocument()
+	if r := Build(doc); r.Total != time.Hour || len(r.Rows) != 1 {
+		t.Errorf("got total %v with %d rows, want 1h with 1 row", r.Total, len(r.Rows))
+	}
+	if r := New(IncludeArchived()).Build(doc); r.Total != 3*time.Hour {
+		t.Errorf("IncludeArchived: got total %v, want 3h", r.Total)
+	}
+}
+
+func TestByProperty(t *testing.T) {
+	doc := parser.New(lexer.New(`* Website
+:PROPERTIES:
+:CLIENT: Acme
+:END:
+:LOGBOOK:
+CLOCK: [2024-01-15 Mon 09:00]--[2024-01-15 Mon 10:00] =>  1:00
+:END:
+** Design
+:LOGBOOK:
+CLOCK: [2024-01-16 Tue 09:00]--[2024-01-16 Tue 10:30] =>  1:30
+:END:
+** Hosting
+:PROPERTIES:
+:CLIENT: Globex
+:END:
+:LOGBOOK:
+CLOCK: [2024-01-16 Tue 11:00]--[2024-01-16 Tue 11:30] =>  0:30
+:END:
+* Admin
+:LOGBOOK:
+CLOCK: [2024-01-17 Wed 09:00]--[2024-01-17 Wed 10:00] =>  1:00
+:END:
+`)).ParseDocument()
+	r := New(WithGroupByProperty("client"), WithPercent()).Build(doc)
+	want := []struct {
+		key  string
+		time time.Duration
+	}{{"", time.Hour}, {"Acme", 150 * time.Minute}, {"Globex", 30 * time.Minute}}
+	if len(r.Rows) != len(want) {
+		t.Fatalf("expected %d rows, got=%+v", len(want), r.Rows)
+	}
+	for i, w := range want {
+		if r.Rows[i].Key != w.key || r.Rows[i].Time != w.time {
+			t.Errorf("row %d: expected %q %v, got=%+v", i, w.key, w.time, r.Rows[i])
+		}
+	}
+
+	expected := `| client       | Time   | %     |
+|--------------+--------+-------|
+| *Total time* | *4:00* | 100.0 |
+|--------------+--------+-------|
+|              | 1:00   | 25.0  |
+| Acme         | 2:30   | 62.5  |
+| Globex       | 0:30   | 12.5  |
+`
+	if got := r.Org(); got != expected {
+		t.Errorf("unexpected table.\nexpected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestTagsColumn(t *testing.T) {
+	r := New(WithTags(), WithMaxLevel(1)).Build(parse(t))
+	expected := `| Tags   | Headline     | Time   |
+|--------+--------------+--------|
+|        | *Total time* | *2:35* |
+|--------+--------------+--------|
+| :work: | Project      | 2:15   |
+|        | Errands      | 0:20   |
+`
+	if got := r.Org(); got != expected {
+		t.Errorf("unexpected table.\nexpected:\n%s\ngot:\n%s", expected, got)
+	}
+}
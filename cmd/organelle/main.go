@@ -0,0 +1,115 @@
+// Command organelle runs organelle's tools from the command line.
+//
+// Usage:
+//
+//	organelle compat [-baseline file] [-update] [-exact] [-sections] dir
+//	organelle trace [-sections] file.org
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/justyntemme/organelle/compat"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	var err error
+	switch os.Args[1] {
+	case "compat":
+		err = runCompat(os.Args[2:])
+	case "trace":
+		err = runTrace(os.Args[2:], os.Stdout)
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "organelle:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: organelle compat [-baseline file] [-update] [-exact] [-sections] dir")
+	fmt.Fprintln(os.Stderr, "       organelle trace [-sections] file.org")
+	os.Exit(2)
+}
+
+// runCompat checks a corpus with package compat, exiting with status 1
+// if any file fails
+func runCompat(args []string) error {
+	fs := flag.NewFlagSet("compat", flag.ExitOnError)
+	baselinePath := fs.String("baseline", "", "JSON file of node counts to compare with")
+	update := fs.Bool("update", false, "write the node counts to the -baseline file")
+	exact := fs.Bool("exact", false, "require byte-for-byte round trips")
+	sections := fs.Bool("sections", false, "parse with parser.WithSections")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		usage()
+	}
+
+	var opts []compat.Option
+	if *exact {
+		opts = append(opts, compat.WithExact())
+	}
+	if *sections {
+		opts = append(opts, compat.WithParserOptions(parser.WithSections()))
+	}
+	if *baselinePath != "" && !*update {
+		baseline, err := compat.LoadBaseline(*baselinePath)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, compat.WithBaseline(baseline))
+	}
+
+	report, err := compat.New(opts...).Run(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *update {
+		if *baselinePath == "" {
+			return fmt.Errorf("-update requires -baseline")
+		}
+		if err := report.Baseline().Save(*baselinePath); err != nil {
+			return err
+		}
+	}
+	if err := report.WriteSummary(os.Stdout); err != nil {
+		return err
+	}
+	if !report.OK() {
+		os.Exit(1)
+	}
+	return nil
+}
+
+// runTrace parses a file with parser.WithTrace and writes its source
+// listing annotated with the parse decisions to w
+func runTrace(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("trace", flag.ExitOnError)
+	sections := fs.Bool("sections", false, "parse with parser.WithSections")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		usage()
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	opts := []parser.Option{parser.WithTrace()}
+	if *sections {
+		opts = append(opts, parser.WithSections())
+	}
+	p := parser.New(lexer.New(string(data)), opts...)
+	p.ParseDocument()
+	return parser.WriteTrace(w, string(data), p.Trace())
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunTrace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.org")
+	if err := os.WriteFile(path, []byte("* TODO Task\nSome text\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := runTrace([]string{path}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `   1 | * TODO Task
+     |   headline -> Headline level 1 "Task" (top level)
+   2 | Some text
+     |   paragraph -> Paragraph
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	if err := runTrace([]string{filepath.Join(t.TempDir(), "missing.org")}, &buf); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
@@ -0,0 +1,306 @@
+// Package compat checks how faithfully organelle reads a corpus of Org
+// files, so that users can try it on their own notes before moving tools
+// over. Every file is parsed and written back with package writer; the
+// result is compared with the input, parsed again to check that it is
+// stable, and its node counts are compared with a recorded baseline.
+package compat
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+	"github.com/justyntemme/organelle/workspace"
+	"github.com/justyntemme/organelle/writer"
+)
+
+// Result is the outcome for one file
+type Result struct {
+	File      string         // Path relative to the corpus root
+	Errors    []string       // Parser errors
+	Faithful  bool           // Writing the document back reproduces the input
+	FirstDiff int            // First input line that was not reproduced, 0 if Faithful
+	Stable    bool           // Parsing and writing the output again changes nothing
+	Counts    map[string]int // Nodes by type, such as "Headline"
+	Changes   []string       // Differences from the baseline counts, such as "Headline: 3 -> 4"
+}
+
+// OK reports whether the file round-trips and matches the baseline
+func (r Result) OK() bool {
+	return r.Faithful && r.Stable && len(r.Changes) == 0
+}
+
+// Report is the outcome for a corpus
+type Report struct {
+	Results []Result // Sorted by file
+	Missing []string // Files in the baseline that were not found
+}
+
+// Baseline maps files to their node counts, as recorded by a previous run
+type Baseline map[string]map[string]int
+
+// LoadBaseline reads a JSON baseline file
+func LoadBaseline(path string) (Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	b := Baseline{}
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return b, nil
+}
+
+// Save writes the baseline as JSON
+func (b Baseline) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Checker runs the comparison
+type Checker struct {
+	parserOpts []parser.Option
+	baseline   Baseline
+	exact      bool
+}
+
+// Option is a functional option for configuring the Checker
+type Option func(*Checker)
+
+// WithParserOptions sets the options files are parsed with, such as
+// parser.WithSections, to check a configuration before adopting it
+func WithParserOptions(opts ...parser.Option) Option {
+	return func(c *Checker) {
+		c.parserOpts = opts
+	}
+}
+
+// WithBaseline compares node counts with b
+func WithBaseline(b Baseline) Option {
+	return func(c *Checker) {
+		c.baseline = b
+	}
+}
+
+// WithExact requires the output to match the input byte for byte. By
+// default blank lines and trailing whitespace are ignored, since the
+// writer does not keep them.
+func WithExact() Option {
+	return func(c *Checker) {
+		c.exact = true
+	}
+}
+
+// New creates a Checker
+func New(opts ...Option) *Checker {
+	c := &Checker{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Run checks every .org file below dir
+func (c *Checker) Run(dir string) (*Report, error) {
+	r := &Report{}
+	files, err := workspace.ReadFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	for name, src := range files {
+		r.Results = append(r.Results, c.Check(name, src))
+	}
+	sort.Slice(r.Results, func(i, j int) bool { return r.Results[i].File < r.Results[j].File })
+
+	for file := range c.baseline {
+		if _, ok := files[file]; !ok {
+			r.Missing = append(r.Missing, file)
+		}
+	}
+	sort.Strings(r.Missing)
+	return r, nil
+}
+
+// Check checks a single file; name is used as the Result's File and to
+// find its baseline
+func (c *Checker) Check(name, input string) Result {
+	input = strings.ReplaceAll(input, "\r\n", "\n")
+	p := parser.New(lexer.New(input), c.parserOpts...)
+	doc := p.ParseDocument()
+	out := writer.String(doc)
+
+	res := Result{File: name, Errors: p.Errors(), Counts: Count(doc)}
+	res.FirstDiff = c.firstDiff(input, out)
+	res.Faithful = res.FirstDiff == 0
+	res.Stable = writer.String(parser.New(lexer.New(out), c.parserOpts...).ParseDocument()) == out
+
+	if want, ok := c.baseline[name]; ok {
+		res.Changes = changes(want, res.Counts)
+	}
+	return res
+}
+
+// firstDiff returns the first line of input that out does not reproduce,
+// or 0 if it reproduces all of them
+func (c *Checker) firstDiff(input, out string) int {
+	if c.exact {
+		if input == out {
+			return 0
+		}
+		in, got := strings.Split(input, "\n"), strings.Split(out, "\n")
+		for i := range in {
+			if i >= len(got) || in[i] != got[i] {
+				return i + 1
+			}
+		}
+		return len(in)
+	}
+
+	in, lines := significant(input)
+	got, _ := significant(out)
+	for i := range in {
+		if i >= len(got) || in[i] != got[i] {
+			return lines[i]
+		}
+	}
+	if len(got) > len(in) {
+		return strings.Count(input, "\n") + 1
+	}
+	return 0
+}
+
+// significant returns the non-blank lines of s without trailing
+// whitespace, and their line numbers
+func significant(s string) (out []string, lines []int) {
+	for i, line := range strings.Split(s, "\n") {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			continue
+		}
+		out = append(out, line)
+		lines = append(lines, i+1)
+	}
+	return out, lines
+}
+
+// Count returns the number of nodes of each type in doc, by type name
+// without the package, such as "Headline"
+func Count(doc *ast.Document) map[string]int {
+	counts := map[string]int{}
+	var walk func([]ast.Node)
+	walk = func(nodes []ast.Node) {
+		for _, n := range nodes {
+			if n == nil {
+				continue
+			}
+			if k, ok := n.(*ast.Keyword); ok && k == nil {
+				continue // parseKeyword may yield a typed nil
+			}
+			counts[strings.TrimPrefix(fmt.Sprintf("%T", n), "*ast.")]++
+			switch n := n.(type) {
+			case *ast.Headline:
+				walk(n.Children)
+			case *ast.Section:
+				walk(n.Children)
+			case *ast.FootnoteDefinition:
+				walk(n.Children)
+			case *ast.List:
+				for _, item := range n.Items {
+					walk(item.Children)
+				}
+			}
+		}
+	}
+	walk(doc.Children)
+	return counts
+}
+
+// changes describes the differences between two sets of counts
+func changes(want, got map[string]int) []string {
+	keys := map[string]bool{}
+	for k := range want {
+		keys[k] = true
+	}
+	for k := range got {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var out []string
+	for _, k := range sorted {
+		if want[k] != got[k] {
+			out = append(out, fmt.Sprintf("%s: %d -> %d", k, want[k], got[k]))
+		}
+	}
+	return out
+}
+
+// Baseline returns the node counts of the report, to be saved and
+// compared against in later runs
+func (r *Report) Baseline() Baseline {
+	b := Baseline{}
+	for _, res := range r.Results {
+		b[res.File] = res.Counts
+	}
+	return b
+}
+
+// OK reports whether every file passed and none are missing
+func (r *Report) OK() bool {
+	for _, res := range r.Results {
+		if !res.OK() {
+			return false
+		}
+	}
+	return len(r.Missing) == 0
+}
+
+// WriteSummary writes a line per file that did not pass, then totals
+func (r *Report) WriteSummary(w io.Writer) error {
+	var b strings.Builder
+	faithful, stable, changed := 0, 0, 0
+	for _, res := range r.Results {
+		if res.Faithful {
+			faithful++
+		}
+		if res.Stable {
+			stable++
+		}
+		if len(res.Changes) > 0 {
+			changed++
+		}
+		if res.OK() {
+			continue
+		}
+		var problems []string
+		if !res.Faithful {
+			problems = append(problems, fmt.Sprintf("differs from line %d", res.FirstDiff))
+		}
+		if !res.Stable {
+			problems = append(problems, "unstable")
+		}
+		problems = append(problems, res.Changes...)
+		fmt.Fprintf(&b, "%s: %s\n", res.File, strings.Join(problems, "; "))
+	}
+	for _, file := range r.Missing {
+		fmt.Fprintf(&b, "%s: missing\n", file)
+	}
+	fmt.Fprintf(&b, "%d files: %d faithful, %d stable, %d changed, %d missing\n",
+		len(r.Results), faithful, stable, changed, len(r.Missing))
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,110 @@
+package compat
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/justyntemme/organelle/parser"
+)
+
+func writeCorpus(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestRun(t *testing.T) {
+	dir := writeCorpus(t, map[string]string{
+		"notes.org":      "* TODO Task :work:\nSome text\n\n** Child\n- item\n",
+		"sub/spaced.org": "* Heading   \n\n\nBody\n",
+		"sub/readme.txt": "not org",
+	})
+
+	report, err := New().Run(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Results) != 2 {
+		t.Fatalf("expected 2 results, got=%d", len(report.Results))
+	}
+	notes := report.Results[0]
+	if notes.File != "notes.org" || !notes.Faithful || !notes.Stable {
+		t.Errorf("expected notes.org to round-trip, got %+v", notes)
+	}
+	if notes.Counts["Headline"] != 2 || notes.Counts["List"] != 1 || notes.Counts["Paragraph"] != 1 {
+		t.Errorf("unexpected counts %v", notes.Counts)
+	}
+	if spaced := report.Results[1]; spaced.File != "sub/spaced.org" || !spaced.Faithful {
+		t.Errorf("expected blank lines and trailing spaces to be ignored, got %+v", spaced)
+	}
+
+	exact, err := New(WithExact()).Run(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spaced := exact.Results[1]; spaced.Faithful || spaced.FirstDiff != 1 {
+		t.Errorf("expected exact comparison to differ at line 1, got %+v", spaced)
+	}
+}
+
+func TestBaseline(t *testing.T) {
+	dir := writeCorpus(t, map[string]string{"a.org": "* One\n* Two\n"})
+	report, err := New().Run(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	if err := report.Baseline().Save(path); err != nil {
+		t.Fatal(err)
+	}
+	baseline, err := LoadBaseline(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	baseline["gone.org"] = map[string]int{"Headline": 1}
+
+	if err := os.WriteFile(filepath.Join(dir, "a.org"), []byte("* One\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	report, err = New(WithBaseline(baseline)).Run(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.OK() {
+		t.Fatal("expected report to fail")
+	}
+	if changes := report.Results[0].Changes; len(changes) != 1 || changes[0] != "Headline: 2 -> 1" {
+		t.Errorf("unexpected changes %v", changes)
+	}
+
+	var out strings.Builder
+	if err := report.WriteSummary(&out); err != nil {
+		t.Fatal(err)
+	}
+	want := "a.org: Headline: 2 -> 1\ngone.org: missing\n1 files: 1 faithful, 1 stable, 1 changed, 1 missing\n"
+	if out.String() != want {
+		t.Errorf("unexpected summary:\n%s", out.String())
+	}
+}
+
+func TestCheckSections(t *testing.T) {
+	input := "* One\nText\n"
+	if counts := New().Check("x.org", input).Counts; counts["Section"] != 0 {
+		t.Errorf("expected no sections by default, got %v", counts)
+	}
+	res := New(WithParserOptions(parser.WithSections())).Check("x.org", input)
+	if res.Counts["Section"] != 1 || !res.Faithful || !res.Stable {
+		t.Errorf("expected a faithful section, got %+v", res)
+	}
+}
@@ -0,0 +1,181 @@
+// Package entity contains the table of Org entities (\alpha, \larr,
+// \nbsp, ...) with their renderings for each export target.
+package entity
+
+// Entity describes how a named Org entity is rendered by each backend
+type Entity struct {
+	Name      string
+	LaTeX     string // LaTeX command
+	LaTeXMath bool   // LaTeX command must be wrapped in math mode
+	HTML      string // HTML entity or character reference
+	ASCII     string // Plain ASCII approximation
+	UTF8      string // UTF-8 character(s)
+}
+
+// Lookup returns the entity with the given name. Names are case-sensitive,
+// as in Org (\Delta and \delta are different entities).
+func Lookup(name string) (Entity, bool) {
+	e, ok := byName[name]
+	return e, ok
+}
+
+// All returns every known entity in table order
+func All() []Entity {
+	out := make([]Entity, len(table))
+	copy(out, table)
+	return out
+}
+
+var byName = func() map[string]Entity {
+	m := make(map[string]Entity, len(table))
+	for _, e := range table {
+		m[e.Name] = e
+	}
+	return m
+}()
+
+var table = []Entity{
+	// Greek
+	{"alpha", `\alpha`, true, "&alpha;", "alpha", "α"},
+	{"beta", `\beta`, true, "&beta;", "beta", "β"},
+	{"gamma", `\gamma`, true, "&gamma;", "gamma", "γ"},
+	{"delta", `\delta`, true, "&delta;", "delta", "δ"},
+	{"epsilon", `\epsilon`, true, "&epsilon;", "epsilon", "ε"},
+	{"zeta", `\zeta`, true, "&zeta;", "zeta", "ζ"},
+	{"eta", `\eta`, true, "&eta;", "eta", "η"},
+	{"theta", `\theta`, true, "&theta;", "theta", "θ"},
+	{"iota", `\iota`, true, "&iota;", "iota", "ι"},
+	{"kappa", `\kappa`, true, "&kappa;", "kappa", "κ"},
+	{"lambda", `\lambda`, true, "&lambda;", "lambda", "λ"},
+	{"mu", `\mu`, true, "&mu;", "mu", "μ"},
+	{"nu", `\nu`, true, "&nu;", "nu", "ν"},
+	{"xi", `\xi`, true, "&xi;", "xi", "ξ"},
+	{"omicron", `\textit{o}`, false, "&omicron;", "omicron", "ο"},
+	{"pi", `\pi`, true, "&pi;", "pi", "π"},
+	{"rho", `\rho`, true, "&rho;", "rho", "ρ"},
+	{"sigma", `\sigma`, true, "&sigma;", "sigma", "σ"},
+	{"tau", `\tau`, true, "&tau;", "tau", "τ"},
+	{"upsilon", `\upsilon`, true, "&upsilon;", "upsilon", "υ"},
+	{"phi", `\phi`, true, "&phi;", "phi", "φ"},
+	{"chi", `\chi`, true, "&chi;", "chi", "χ"},
+	{"psi", `\psi`, true, "&psi;", "psi", "ψ"},
+	{"omega", `\omega`, true, "&omega;", "omega", "ω"},
+	{"Gamma", `\Gamma`, true, "&Gamma;", "Gamma", "Γ"},
+	{"Delta", `\Delta`, true, "&Delta;", "Delta", "Δ"},
+	{"Theta", `\Theta`, true, "&Theta;", "Theta", "Θ"},
+	{"Lambda", `\Lambda`, true, "&Lambda;", "Lambda", "Λ"},
+	{"Xi", `\Xi`, true, "&Xi;", "Xi", "Ξ"},
+	{"Pi", `\Pi`, true, "&Pi;", "Pi", "Π"},
+	{"Sigma", `\Sigma`, true, "&Sigma;", "Sigma", "Σ"},
+	{"Upsilon", `\Upsilon`, true, "&Upsilon;", "Upsilon", "Υ"},
+	{"Phi", `\Phi`, true, "&Phi;", "Phi", "Φ"},
+	{"Psi", `\Psi`, true, "&Psi;", "Psi", "Ψ"},
+	{"Omega", `\Omega`, true, "&Omega;", "Omega", "Ω"},
+
+	// Arrows
+	{"larr", `\leftarrow`, true, "&larr;", "<-", "←"},
+	{"gets", `\gets`, true, "&larr;", "<-", "←"},
+	{"rarr", `\rightarrow`, true, "&rarr;", "->", "→"},
+	{"to", `\to`, true, "&rarr;", "->", "→"},
+	{"uarr", `\uparrow`, true, "&uarr;", "^", "↑"},
+	{"darr", `\downarrow`, true, "&darr;", "v", "↓"},
+	{"harr", `\leftrightarrow`, true, "&harr;", "<->", "↔"},
+	{"lArr", `\Leftarrow`, true, "&lArr;", "<=", "⇐"},
+	{"rArr", `\Rightarrow`, true, "&rArr;", "=>", "⇒"},
+	{"hArr", `\Leftrightarrow`, true, "&hArr;", "<=>", "⇔"},
+
+	// Spaces and dashes
+	{"nbsp", `~`, false, "&nbsp;", " ", "\u00a0"},
+	{"ensp", `\hspace*{.5em}`, false, "&ensp;", " ", "\u2002"},
+	{"emsp", `\hspace*{1em}`, false, "&emsp;", " ", "\u2003"},
+	{"thinsp", `\hspace*{.2em}`, false, "&thinsp;", " ", "\u2009"},
+	{"shy", `\-`, false, "&shy;", "", "\u00ad"},
+	{"ndash", `--`, false, "&ndash;", "-", "–"},
+	{"mdash", `---`, false, "&mdash;", "--", "—"},
+	{"hellip", `\dots{}`, false, "&hellip;", "...", "…"},
+	{"dots", `\dots{}`, false, "&hellip;", "...", "…"},
+
+	// Quotes and punctuation
+	{"laquo", `\guillemotleft{}`, false, "&laquo;", "<<", "«"},
+	{"raquo", `\guillemotright{}`, false, "&raquo;", ">>", "»"},
+	{"lsquo", "`", false, "&lsquo;", "`", "‘"},
+	{"rsquo", "'", false, "&rsquo;", "'", "’"},
+	{"ldquo", "``", false, "&ldquo;", `"`, "“"},
+	{"rdquo", "''", false, "&rdquo;", `"`, "”"},
+	{"bdquo", `\quotedblbase{}`, false, "&bdquo;", `"`, "„"},
+	{"sbquo", `\quotesinglbase{}`, false, "&sbquo;", ",", "‚"},
+	{"iexcl", "!`", false, "&iexcl;", "!", "¡"},
+	{"iquest", "?`", false, "&iquest;", "?", "¿"},
+	{"bull", `\textbullet{}`, false, "&bull;", "*", "•"},
+	{"middot", `\textperiodcentered{}`, false, "&middot;", ".", "·"},
+	{"dagger", `\dagger{}`, false, "&dagger;", "[dagger]", "†"},
+	{"Dagger", `\ddagger{}`, false, "&Dagger;", "[doubledagger]", "‡"},
+	{"para", `\P{}`, false, "&para;", "[pilcrow]", "¶"},
+	{"sect", `\S`, false, "&sect;", "paragraph", "§"},
+
+	// Characters that are markup in Org
+	{"ast", `\ast`, true, "&lowast;", "*", "∗"},
+	{"vert", `\vert{}`, true, "&vert;", "|", "|"},
+	{"brvbar", `\textbrokenbar{}`, false, "&brvbar;", "|", "¦"},
+	{"backslash", `\textbackslash{}`, false, "\\", "\\", "\\"},
+	{"under", `\_`, false, "_", "_", "_"},
+
+	// Symbols
+	{"copy", `\textcopyright{}`, false, "&copy;", "(c)", "©"},
+	{"reg", `\textregistered{}`, false, "&reg;", "(r)", "®"},
+	{"trade", `\texttrademark{}`, false, "&trade;", "TM", "™"},
+	{"deg", `\textdegree{}`, false, "&deg;", "degree", "°"},
+	{"check", `\checkmark`, true, "&#10003;", "[checkmark]", "✓"},
+	{"euro", `\texteuro{}`, false, "&euro;", "EUR", "€"},
+	{"cent", `\textcent{}`, false, "&cent;", "cent", "¢"},
+	{"pound", `\pounds{}`, false, "&pound;", "pound", "£"},
+	{"yen", `\textyen{}`, false, "&yen;", "yen", "¥"},
+
+	// Mathematics
+	{"plusmn", `\textpm{}`, false, "&plusmn;", "+-", "±"},
+	{"times", `\times`, true, "&times;", "*", "×"},
+	{"divide", `\div`, true, "&divide;", "/", "÷"},
+	{"minus", `-`, true, "&minus;", "-", "−"},
+	{"le", `\le`, true, "&le;", "<=", "≤"},
+	{"ge", `\ge`, true, "&ge;", ">=", "≥"},
+	{"ne", `\ne`, true, "&ne;", "!=", "≠"},
+	{"approx", `\approx`, true, "&asymp;", "~", "≈"},
+	{"equiv", `\equiv`, true, "&equiv;", "==", "≡"},
+	{"infin", `\infty`, true, "&infin;", "[infinity]", "∞"},
+	{"infty", `\infty`, true, "&infin;", "[infinity]", "∞"},
+	{"sum", `\sum`, true, "&sum;", "[sum]", "∑"},
+	{"prod", `\prod`, true, "&prod;", "[product]", "∏"},
+	{"int", `\int`, true, "&int;", "[integral]", "∫"},
+	{"partial", `\partial`, true, "&part;", "[partial differential]", "∂"},
+	{"nabla", `\nabla`, true, "&nabla;", "[nabla]", "∇"},
+	{"forall", `\forall`, true, "&forall;", "[for all]", "∀"},
+	{"exist", `\exists`, true, "&exist;", "[there exists]", "∃"},
+	{"empty", `\emptyset`, true, "&empty;", "[empty set]", "∅"},
+	{"isin", `\in`, true, "&isin;", "[element of]", "∈"},
+	{"notin", `\notin`, true, "&notin;", "[not an element of]", "∉"},
+	{"cap", `\cap`, true, "&cap;", "[intersection]", "∩"},
+	{"cup", `\cup`, true, "&cup;", "[union]", "∪"},
+	{"sub", `\subset`, true, "&sub;", "[subset of]", "⊂"},
+	{"sup", `\supset`, true, "&sup;", "[superset of]", "⊃"},
+	{"and", `\wedge`, true, "&and;", "[logical and]", "∧"},
+	{"or", `\vee`, true, "&or;", "[logical or]", "∨"},
+	{"not", `\neg`, true, "&not;", "[angled dash]", "¬"},
+	{"there4", `\therefore`, true, "&there4;", "[therefore]", "∴"},
+
+	// Latin letters with diacritics
+	{"auml", `\"{a}`, false, "&auml;", "ae", "ä"},
+	{"ouml", `\"{o}`, false, "&ouml;", "oe", "ö"},
+	{"uuml", `\"{u}`, false, "&uuml;", "ue", "ü"},
+	{"Auml", `\"{A}`, false, "&Auml;", "Ae", "Ä"},
+	{"Ouml", `\"{O}`, false, "&Ouml;", "Oe", "Ö"},
+	{"Uuml", `\"{U}`, false, "&Uuml;", "Ue", "Ü"},
+	{"szlig", `\ss{}`, false, "&szlig;", "ss", "ß"},
+	{"aacute", `\'{a}`, false, "&aacute;", "a", "á"},
+	{"eacute", `\'{e}`, false, "&eacute;", "e", "é"},
+	{"egrave", "\\`{e}", false, "&egrave;", "e", "è"},
+	{"ccedil", `\c{c}`, false, "&ccedil;", "c", "ç"},
+	{"ntilde", `\~{n}`, false, "&ntilde;", "n", "ñ"},
+	{"oslash", `\o{}`, false, "&oslash;", "o", "ø"},
+	{"aring", `\aa{}`, false, "&aring;", "a", "å"},
+	{"aelig", `\ae{}`, false, "&aelig;", "ae", "æ"},
+}
@@ -0,0 +1,33 @@
+package entity
+
+import "testing"
+
+func TestLookup(t *testing.T) {
+	e, ok := Lookup("alpha")
+	if !ok {
+		t.Fatal("expected alpha to be known")
+	}
+	if e.UTF8 != "α" || e.HTML != "&alpha;" || e.LaTeX != `\alpha` || !e.LaTeXMath {
+		t.Errorf("unexpected alpha entity: %+v", e)
+	}
+
+	if _, ok := Lookup("Alpha2"); ok {
+		t.Error("expected unknown name to be rejected")
+	}
+	if d, _ := Lookup("Delta"); d.UTF8 != "Δ" {
+		t.Errorf("expected entity names to be case-sensitive, got %+v", d)
+	}
+}
+
+func TestTableHasNoDuplicates(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, e := range All() {
+		if seen[e.Name] {
+			t.Errorf("duplicate entity %q", e.Name)
+		}
+		seen[e.Name] = true
+		if e.UTF8 == "" || e.HTML == "" {
+			t.Errorf("entity %q is missing a rendering", e.Name)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+// Package anki extracts flashcards from drill headlines, in the style of
+// org-drill, and writes them in Anki's text import format or as an
+// AnkiConnect addNotes request.
+//
+// The body of a drill headline is the question and its subheadings hold
+// the answer. Emphasis in the question (bold by default) marks cloze
+// deletions; a card with any becomes an Anki cloze note.
+package anki
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/export/text"
+	"github.com/justyntemme/organelle/redact"
+)
+
+// Card is a single note
+type Card struct {
+	Front string // Question; contains {{cN::...}} deletions for cloze cards
+	Back  string
+	Cloze bool
+	Tags  []string // Headline tags other than the drill tag
+}
+
+// Extractor finds drill headlines and converts them to cards
+type Extractor struct {
+	tag      string
+	cloze    ast.InlineType
+	archived redact.Archived
+}
+
+// Option is a functional option for configuring the Extractor
+type Option func(*Extractor)
+
+// WithTag sets the tag that marks drill headlines (default "drill")
+func WithTag(tag string) Option {
+	return func(x *Extractor) {
+		x.tag = tag
+	}
+}
+
+// WithClozeMarker sets the emphasis that marks cloze deletions (default
+// InlineBold)
+func WithClozeMarker(t ast.InlineType) Option {
+	return func(x *Extractor) {
+		x.cloze = t
+	}
+}
+
+// IncludeArchived extracts cards from subtrees tagged :ARCHIVE:; see
+// redact.Archived
+func IncludeArchived() Option {
+	return func(x *Extractor) {
+		x.archived = true
+	}
+}
+
+// New creates an Extractor
+func New(opts ...Option) *Extractor {
+	x := &Extractor{tag: "drill", cloze: ast.InlineBold}
+	for _, opt := range opts {
+		opt(x)
+	}
+	return x
+}
+
+// Cards returns the cards in doc in document order. Subtrees of a drill
+// headline are part of its answer and are not searched for more cards.
+func (x *Extractor) Cards(doc *ast.Document) []Card {
+	var cards []Card
+	archived := x.archived.For(doc)
+	var walk func([]ast.Node)
+	walk = func(nodes []ast.Node) {
+		for _, n := range nodes {
+			h, ok := n.(*ast.Headline)
+			if !ok || archived.Skip(h) {
+				continue
+			}
+			if h.HasTag(x.tag) {
+				cards = append(cards, x.card(h))
+				continue
+			}
+			walk(h.Children)
+		}
+	}
+	walk(doc.Children)
+	return cards
+}
+
+func (x *Extractor) card(h *ast.Headline) Card {
+	c := Card{}
+	for _, t := range h.Tags {
+		if t != x.tag {
+			c.Tags = append(c.Tags, t)
+		}
+	}
+
+	var front, back []string
+	n := 0
+	for _, child := range h.BodyNodes() {
+		switch node := child.(type) {
+		case *ast.Paragraph:
+			line, deletions := x.renderQuestion(node.Inline, &n)
+			if strings.TrimSpace(line) != "" {
+				front = append(front, line)
+			}
+			c.Cloze = c.Cloze || deletions
+		case *ast.List:
+			for _, item := range node.Items {
+				front = append(front, "- "+item.Content)
+			}
+		}
+	}
+	for _, sub := range h.Subheadlines() {
+		back = append(back, answer(sub)...)
+	}
+	if len(front) == 0 {
+		front = []string{h.Title}
+	}
+	c.Front = strings.Join(front, "\n")
+	c.Back = strings.Join(back, "\n")
+	return c
+}
+
+// renderQuestion renders inline elements as plain text with cloze markers
+// wrapped as {{cN::...}}. n numbers the deletions across the card.
+func (x *Extractor) renderQuestion(elems []ast.InlineElement, n *int) (string, bool) {
+	var out strings.Builder
+	found := false
+	for _, e := range elems {
+		rendered := text.RenderInline([]ast.InlineElement{e})
+		if e.Type == x.cloze {
+			*n++
+			fmt.Fprintf(&out, "{{c%d::%s}}", *n, rendered)
+			found = true
+			continue
+		}
+		out.WriteString(rendered)
+	}
+	return out.String(), found
+}
+
+// answer returns the text of an answer subtree, without its title for the
+// conventional "Answer" heading
+func answer(h *ast.Headline) []string {
+	var out []string
+	if !strings.EqualFold(h.Title, "Answer") {
+		out = append(out, h.Title)
+	}
+	for _, child := range h.BodyNodes() {
+		switch node := child.(type) {
+		case *ast.Paragraph:
+			out = append(out, text.RenderInline(node.Inline))
+		case *ast.List:
+			for _, item := range node.Items {
+				out = append(out, "- "+item.Content)
+			}
+		case *ast.Block:
+			out = append(out, strings.TrimSuffix(node.Content, "\n"))
+		}
+	}
+	for _, sub := range h.Subheadlines() {
+		out = append(out, answer(sub)...)
+	}
+	return out
+}
+
+// WriteText writes cards in Anki's tab-separated import format. Basic
+// and cloze cards need different note types, so callers importing both
+// should split them first.
+func WriteText(w io.Writer, cards []Card) error {
+	if _, err := io.WriteString(w, "#separator:tab\n#html:false\n#tags column:3\n"); err != nil {
+		return err
+	}
+	for _, c := range cards {
+		line := field(c.Front) + "\t" + field(c.Back) + "\t" + strings.Join(c.Tags, " ") + "\n"
+		if _, err := io.WriteString(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// field quotes a field for the import format when it contains tabs,
+// newlines or quotes
+func field(s string) string {
+	if !strings.ContainsAny(s, "\t\n\"") {
+		return s
+	}
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+type ankiNote struct {
+	DeckName  string            `json:"deckName"`
+	ModelName string            `json:"modelName"`
+	Fields    map[string]string `json:"fields"`
+	Tags      []string          `json:"tags"`
+}
+
+// WriteAnkiConnect writes an AnkiConnect "addNotes" request adding cards
+// to deck. Cloze cards use the "Cloze" note type, others "Basic".
+func WriteAnkiConnect(w io.Writer, deck string, cards []Card) error {
+	notes := make([]ankiNote, 0, len(cards))
+	for _, c := range cards {
+		note := ankiNote{DeckName: deck, ModelName: "Basic", Tags: c.Tags}
+		if note.Tags == nil {
+			note.Tags = []string{}
+		}
+		if c.Cloze {
+			note.ModelName = "Cloze"
+			note.Fields = map[string]string{"Text": c.Front, "Back Extra": c.Back}
+		} else {
+			note.Fields = map[string]string{"Front": c.Front, "Back": c.Back}
+		}
+		notes = append(notes, note)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(map[string]any{
+		"action":  "addNotes",
+		"version": 6,
+		"params":  map[string]any{"notes": notes},
+	})
+}
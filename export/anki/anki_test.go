@@ -0,0 +1,90 @@
+package anki
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+const deck = `* Vocabulary
+** Word :drill:spanish:
+What does "gato" mean?
+*** Answer
+cat
+** Capital :drill:
+The capital of France is *Paris*.
+** Notes
+Not a card.
+`
+
+func TestCards(t *testing.T) {
+	cards := New().Cards(parser.New(lexer.New(deck)).ParseDocument())
+	if len(cards) != 2 {
+		t.Fatalf("expected 2 cards, got=%d", len(cards))
+	}
+
+	basic := cards[0]
+	if basic.Front != `What does "gato" mean?` || basic.Back != "cat" || basic.Cloze {
+		t.Errorf("unexpected basic card %+v", basic)
+	}
+	if len(basic.Tags) != 1 || basic.Tags[0] != "spanish" {
+		t.Errorf("expected drill tag to be dropped, got %v", basic.Tags)
+	}
+
+	cloze := cards[1]
+	if !cloze.Cloze || cloze.Front != "The capital of France is {{c1::Paris}}." {
+		t.Errorf("unexpected cloze card %+v", cloze)
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	cards := New().Cards(parser.New(lexer.New(deck)).ParseDocument())
+	var buf bytes.Buffer
+	if err := WriteText(&buf, cards); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("expected 3 header lines and 2 cards, got:\n%s", buf.String())
+	}
+	if lines[3] != "\"What does \"\"gato\"\" mean?\"\tcat\tspanish" {
+		t.Errorf("unexpected card line %q", lines[3])
+	}
+}
+
+func TestWriteAnkiConnect(t *testing.T) {
+	cards := New().Cards(parser.New(lexer.New(deck)).ParseDocument())
+	var buf bytes.Buffer
+	if err := WriteAnkiConnect(&buf, "Org", cards); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	var req struct {
+		Action string
+		Params struct {
+			Notes []ankiNote
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &req); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if req.Action != "addNotes" || len(req.Params.Notes) != 2 {
+		t.Fatalf("unexpected request %+v", req)
+	}
+	if req.Params.Notes[1].ModelName != "Cloze" || req.Params.Notes[1].Fields["Text"] == "" {
+		t.Errorf("expected cloze note, got %+v", req.Params.Notes[1])
+	}
+}
+
+func TestCardsSkipArchived(t *testing.T) {
+	doc := parser.New(lexer.New("* Capital of France :drill:\nParis\n* Old :ARCHIVE:\n** Capital of Spain :drill:\nMadrid\n")).ParseDocument()
+	if cards := New().Cards(doc); len(cards) != 1 {
+		t.Errorf("got %d cards, want 1", len(cards))
+	}
+	if cards := New(IncludeArchived()).Cards(doc); len(cards) != 2 {
+		t.Errorf("IncludeArchived: got %d cards, want 2", len(cards))
+	}
+}
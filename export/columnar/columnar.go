@@ -0,0 +1,171 @@
+// Package columnar extracts task records from documents and hands them to
+// a columnar writer in batches.
+//
+// The package does not depend on any Arrow or Parquet library. Callers
+// implement Writer on top of the library of their choice; every column
+// is a plain Go slice, so the mapping is mechanical.
+package columnar
+
+import (
+	"strings"
+	"time"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/parser"
+	"github.com/justyntemme/organelle/redact"
+)
+
+// Task is a single headline carrying a TODO keyword
+type Task struct {
+	Source    string // Label of the document the task came from, usually its path
+	Path      string // Ancestor titles joined with "/"
+	Title     string
+	Keyword   string
+	Done      bool
+	Priority  string
+	Tags      []string
+	Created   time.Time // From the CREATED property; zero if absent
+	Scheduled time.Time // Zero if absent
+	Deadline  time.Time
+	Closed    time.Time
+}
+
+// Columns holds tasks in struct-of-arrays layout. All slices have the
+// same length; zero time.Time values stand for nulls.
+type Columns struct {
+	Source    []string
+	Path      []string
+	Title     []string
+	Keyword   []string
+	Done      []bool
+	Priority  []string
+	Tags      [][]string
+	Created   []time.Time
+	Scheduled []time.Time
+	Deadline  []time.Time
+	Closed    []time.Time
+}
+
+// Len returns the number of rows
+func (c *Columns) Len() int {
+	return len(c.Title)
+}
+
+// Writer receives batches of rows. Implementations typically map each
+// slice to an Arrow array or Parquet column chunk.
+type Writer interface {
+	WriteColumns(c *Columns) error
+}
+
+// Option is a functional option for configuring Collect
+type Option func(*config)
+
+type config struct {
+	archived redact.Archived
+}
+
+// IncludeArchived collects tasks in subtrees tagged :ARCHIVE:; see
+// redact.Archived
+func IncludeArchived() Option {
+	return func(c *config) {
+		c.archived = true
+	}
+}
+
+// Collect returns the tasks of doc in document order
+func Collect(source string, doc *ast.Document, opts ...Option) []Task {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.archived = c.archived.For(doc)
+	var tasks []Task
+	c.collect(doc.Children, source, nil, &tasks)
+	return tasks
+}
+
+func (c *config) collect(nodes []ast.Node, source string, path []string, tasks *[]Task) {
+	for _, n := range nodes {
+		hl, ok := n.(*ast.Headline)
+		if !ok || c.archived.Skip(hl) {
+			continue
+		}
+		if hl.Keyword != "" {
+			*tasks = append(*tasks, newTask(hl, source, path))
+		}
+		c.collect(hl.Children, source, append(path[:len(path):len(path)], hl.Title), tasks)
+	}
+}
+
+func newTask(hl *ast.Headline, source string, path []string) Task {
+	t := Task{
+		Source:   source,
+		Path:     strings.Join(path, "/"),
+		Title:    hl.Title,
+		Keyword:  hl.Keyword,
+		Done:     hl.Done,
+		Priority: hl.Priority,
+		Tags:     hl.Tags,
+	}
+	if created, ok := hl.Property("CREATED"); ok {
+		if ts := parser.ParseTimestamp(created); ts != nil {
+			t.Created = timestampTime(ts)
+		} else if d, err := time.Parse("2006-01-02", strings.TrimSpace(created)); err == nil {
+			t.Created = d
+		}
+	}
+	t.Scheduled = timestampTime(hl.Scheduled)
+	t.Deadline = timestampTime(hl.Deadline)
+	t.Closed = timestampTime(hl.Closed)
+	return t
+}
+
+// timestampTime converts ts to a time, or the zero time if ts is nil
+func timestampTime(ts *ast.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	layout, value := "2006-01-02", ts.Date
+	if ts.Time != "" {
+		layout, value = "2006-01-02 15:04", ts.Date+" "+ts.Time
+	}
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// ToColumns converts tasks to columnar layout
+func ToColumns(tasks []Task) *Columns {
+	c := &Columns{}
+	for _, t := range tasks {
+		c.Source = append(c.Source, t.Source)
+		c.Path = append(c.Path, t.Path)
+		c.Title = append(c.Title, t.Title)
+		c.Keyword = append(c.Keyword, t.Keyword)
+		c.Done = append(c.Done, t.Done)
+		c.Priority = append(c.Priority, t.Priority)
+		c.Tags = append(c.Tags, t.Tags)
+		c.Created = append(c.Created, t.Created)
+		c.Scheduled = append(c.Scheduled, t.Scheduled)
+		c.Deadline = append(c.Deadline, t.Deadline)
+		c.Closed = append(c.Closed, t.Closed)
+	}
+	return c
+}
+
+// Export writes tasks to w in batches of at most batchSize rows. A
+// batchSize of zero or less writes a single batch.
+func Export(w Writer, tasks []Task, batchSize int) error {
+	if batchSize <= 0 {
+		batchSize = len(tasks)
+	}
+	for start := 0; start < len(tasks); start += batchSize {
+		end := min(start+batchSize, len(tasks))
+		if err := w.WriteColumns(ToColumns(tasks[start:end])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
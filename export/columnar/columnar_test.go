@@ -0,0 +1,95 @@
+package columnar
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+type recordingWriter struct {
+	batches []*Columns
+	err     error
+}
+
+func (w *recordingWriter) WriteColumns(c *Columns) error {
+	w.batches = append(w.batches, c)
+	return w.err
+}
+
+const input = `* Project
+** DONE Ship it :release:
+CLOSED: [2024-01-20 Sat 17:00] SCHEDULED: <2024-01-15 Mon>
+:PROPERTIES:
+:CREATED: [2024-01-02 Tue]
+:END:
+** TODO [#B] Follow up
+** Notes
+*** TODO Read paper
+`
+
+func TestCollect(t *testing.T) {
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	tasks := Collect("tasks.org", doc)
+
+	if len(tasks) != 3 {
+		t.Fatalf("expected 3 tasks, got=%d", len(tasks))
+	}
+
+	shipped := tasks[0]
+	if !shipped.Done || shipped.Path != "Project" || shipped.Source != "tasks.org" {
+		t.Errorf("unexpected task: %+v", shipped)
+	}
+	if !shipped.Created.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected Created %v", shipped.Created)
+	}
+	if !shipped.Closed.Equal(time.Date(2024, 1, 20, 17, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected Closed %v", shipped.Closed)
+	}
+	if shipped.Scheduled.IsZero() || !shipped.Deadline.IsZero() {
+		t.Errorf("unexpected planning: scheduled=%v deadline=%v", shipped.Scheduled, shipped.Deadline)
+	}
+	if tasks[2].Path != "Project/Notes" {
+		t.Errorf("expected nested path, got=%q", tasks[2].Path)
+	}
+}
+
+func TestExportBatches(t *testing.T) {
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	tasks := Collect("tasks.org", doc)
+
+	w := &recordingWriter{}
+	if err := Export(w, tasks, 2); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if len(w.batches) != 2 || w.batches[0].Len() != 2 || w.batches[1].Len() != 1 {
+		t.Fatalf("unexpected batches: %d", len(w.batches))
+	}
+	if w.batches[1].Title[0] != "Read paper" || w.batches[0].Priority[1] != "B" {
+		t.Errorf("columns out of order: %+v", w.batches)
+	}
+
+	failing := &recordingWriter{err: errors.New("disk full")}
+	if err := Export(failing, tasks, 1); err == nil || len(failing.batches) != 1 {
+		t.Errorf("expected Export to stop at the first error, got err=%v batches=%d", err, len(failing.batches))
+	}
+}
+
+func TestCollectSkipsArchived(t *testing.T) {
+	doc := parser.New(lexer.New("* TODO A\n* B :ARCHIVE:\n** TODO C\n")).ParseDocument()
+	if tasks := Collect("tasks.org", doc); len(tasks) != 1 || tasks[0].Title != "A" {
+		t.Errorf("unexpected tasks: %+v", tasks)
+	}
+	if tasks := Collect("tasks.org", doc, IncludeArchived()); len(tasks) != 2 {
+		t.Errorf("IncludeArchived: got %d tasks, want 2", len(tasks))
+	}
+}
+
+func TestCollectCustomDoneKeyword(t *testing.T) {
+	doc := parser.New(lexer.New("#+TODO: TODO | CANCELLED\n* CANCELLED Trip\n")).ParseDocument()
+	if tasks := Collect("tasks.org", doc); len(tasks) != 1 || !tasks[0].Done {
+		t.Errorf("CANCELLED not collected as done: %+v", tasks)
+	}
+}
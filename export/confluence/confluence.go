@@ -0,0 +1,259 @@
+// Package confluence renders documents in Confluence wiki markup so that
+// engineering docs kept in Org can be pushed to Confluence pages.
+//
+// Headlines become h1. to h6. headings, source blocks {code} macros and
+// tables ||header|| rows above the first separator.
+package confluence
+
+import (
+	"io"
+	"strings"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/entity"
+	"github.com/justyntemme/organelle/export"
+	"github.com/justyntemme/organelle/parser"
+)
+
+// Exporter renders documents as Confluence wiki markup
+type Exporter struct {
+	offset int
+}
+
+// Option is a functional option for configuring the Exporter
+type Option func(*Exporter)
+
+// WithHeadingOffset shifts every heading down by n levels, for pages that
+// already carry the document title as their page title
+func WithHeadingOffset(n int) Option {
+	return func(e *Exporter) {
+		e.offset = n
+	}
+}
+
+// New creates a Confluence wiki markup exporter
+func New(opts ...Option) *Exporter {
+	e := &Exporter{}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Export writes doc as wiki markup to w
+func (e *Exporter) Export(w io.Writer, doc *ast.Document) error {
+	return export.Render(w, doc, e.Backend())
+}
+
+// Backend returns an export.Backend that renders with e's settings. The
+// backend keeps per-render state and must not be shared between renders.
+func (e *Exporter) Backend() export.Backend {
+	return &backend{Exporter: e}
+}
+
+func init() {
+	export.Register("confluence", func() export.Backend { return New().Backend() })
+}
+
+// backend implements the export.Backend hooks
+type backend struct {
+	export.Base
+	*Exporter
+	para    []string
+	markers string // List markers of the enclosing items, such as "*#"
+}
+
+func (b *backend) Extension() string {
+	return ".wiki"
+}
+
+func (b *backend) Headline(c *export.Context, h *ast.Headline) error {
+	level := min(max(h.Level+b.offset, 1), 6)
+	title := inline(h.Title)
+	if h.Keyword != "" {
+		title = h.Keyword + " " + title
+	}
+	c.Printf("h%d. %s\n\n", level, title)
+	return c.Render(h.Children)
+}
+
+func (b *backend) Paragraph(c *export.Context, p *ast.Paragraph) error {
+	if text := strings.TrimSpace(RenderInline(p.Inline)); text != "" {
+		b.para = append(b.para, text)
+	}
+	if c.ContinuesParagraph() || len(b.para) == 0 {
+		return nil
+	}
+	// Wiki markup keeps line breaks, so the lines of a paragraph are joined
+	c.WriteString(strings.Join(b.para, " ") + "\n")
+	if b.markers == "" {
+		c.WriteString("\n")
+	}
+	b.para = b.para[:0]
+	return nil
+}
+
+func (b *backend) List(c *export.Context, l *ast.List) error {
+	marker := "*"
+	if l.Ordered {
+		marker = "#"
+	}
+	markers := b.markers
+	b.markers += marker
+	defer func() { b.markers = markers }()
+
+	for _, item := range l.Items {
+		text := inline(item.Content)
+		switch item.Checkbox {
+		case ast.CheckboxUnchecked:
+			text = "(x) " + text
+		case ast.CheckboxChecked:
+			text = "(/) " + text
+		case ast.CheckboxPartial:
+			text = "(i) " + text
+		}
+		c.WriteString(b.markers + " " + text + "\n")
+		if err := c.Render(item.Children); err != nil {
+			return err
+		}
+	}
+	// Nested lists run on within their parent list
+	if markers == "" {
+		c.WriteString("\n")
+	}
+	return nil
+}
+
+func (b *backend) Block(c *export.Context, blk *ast.Block) error {
+	content := strings.TrimSuffix(blk.Content, "\n")
+	switch blk.Type {
+	case "EXPORT":
+		if strings.EqualFold(blk.Language, "confluence") {
+			c.WriteString(content + "\n\n")
+		}
+		return nil
+	case "COMMENT":
+		return nil
+	case "SRC":
+		if blk.Language != "" {
+			c.Printf("{code:language=%s}\n", blk.Language)
+		} else {
+			c.WriteString("{code}\n")
+		}
+		c.WriteString(content + "\n{code}\n")
+	case "QUOTE", "VERSE":
+		c.WriteString("{quote}\n" + Escape(content) + "\n{quote}\n")
+	default:
+		c.WriteString("{noformat}\n" + content + "\n{noformat}\n")
+	}
+	c.WriteString("\n")
+	return nil
+}
+
+// Table writes header cells for the rows above the first separator; tables
+// without a separator have no header
+func (b *backend) Table(c *export.Context, t *ast.Table) error {
+	// Wiki markup has no column alignment, so cookie rows are dropped
+	end := t.HeaderEnd()
+	for r, row := range t.Rows {
+		if row.Separator || row.IsCookieRow() {
+			continue
+		}
+		sep := "|"
+		if r < end {
+			sep = "||"
+		}
+		var line strings.Builder
+		for _, cell := range row.Cells {
+			text := " "
+			if cell != "" {
+				text = Escape(cell)
+			}
+			line.WriteString(sep + text)
+		}
+		c.WriteString(line.String() + sep + "\n")
+	}
+	c.WriteString("\n")
+	return nil
+}
+
+func (b *backend) HorizontalRule(c *export.Context, hr *ast.HorizontalRule) error {
+	c.WriteString("----\n\n")
+	return nil
+}
+
+// FootnoteDefinition writes the definition as a paragraph led by its
+// label in superscript, matching how references render
+func (b *backend) FootnoteDefinition(c *export.Context, f *ast.FootnoteDefinition) error {
+	text := []string{"^" + Escape(f.Label) + "^"}
+	for _, n := range f.Children {
+		if p, ok := n.(*ast.Paragraph); ok {
+			if t := strings.TrimSpace(RenderInline(p.Inline)); t != "" {
+				text = append(text, t)
+			}
+		}
+	}
+	c.WriteString(strings.Join(text, " ") + "\n\n")
+	return nil
+}
+
+// Keywords, comments, drawers and calls produce no output
+
+// inline renders text, such as a headline title or list item, whose
+// markup the parser leaves unparsed
+func inline(text string) string {
+	if nodes, _ := parser.ParseFragment(text); len(nodes) == 1 {
+		if p, ok := nodes[0].(*ast.Paragraph); ok {
+			return RenderInline(p.Inline)
+		}
+	}
+	return Escape(text)
+}
+
+// RenderInline renders inline elements as wiki markup
+func RenderInline(elems []ast.InlineElement) string {
+	var out strings.Builder
+	for _, e := range elems {
+		switch e.Type {
+		case ast.InlineText:
+			out.WriteString(Escape(e.Content))
+		case ast.InlineBold:
+			out.WriteString("*" + RenderInline(e.Children) + "*")
+		case ast.InlineItalic:
+			out.WriteString("_" + RenderInline(e.Children) + "_")
+		case ast.InlineUnderline:
+			out.WriteString("+" + RenderInline(e.Children) + "+")
+		case ast.InlineStrikethrough:
+			out.WriteString("-" + RenderInline(e.Children) + "-")
+		case ast.InlineCode, ast.InlineVerbatim:
+			out.WriteString("{{" + Escape(e.Content) + "}}")
+		case ast.InlineLink:
+			if len(e.Children) == 0 {
+				out.WriteString("[" + e.URL + "]")
+			} else {
+				out.WriteString("[" + RenderInline(e.Children) + "|" + e.URL + "]")
+			}
+		case ast.InlineEntity:
+			if ent, ok := entity.Lookup(e.Content); ok {
+				out.WriteString(ent.UTF8)
+			}
+		case ast.InlineFootnoteRef:
+			out.WriteString("^" + Escape(e.Content) + "^")
+		case ast.InlineTimestamp:
+			out.WriteString(Escape(e.Content))
+		default:
+			out.WriteString(RenderInline(e.Children))
+		}
+	}
+	return out.String()
+}
+
+var escaper = strings.NewReplacer(
+	`\`, `\\`, "{", `\{`, "}", `\}`, "[", `\[`, "]", `\]`, "|", `\|`,
+	"*", `\*`, "_", `\_`, "^", `\^`, "~", `\~`, "!", `\!`,
+)
+
+// Escape guards the characters that start wiki markup
+func Escape(s string) string {
+	return escaper.Replace(s)
+}
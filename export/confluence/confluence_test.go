@@ -0,0 +1,111 @@
+package confluence
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+func TestExport(t *testing.T) {
+	input := `* Design
+Uses *bold*, /italic/ and ~make~
+across two lines with {braces}.
+- first
+  - nested [[https://example.com][link]]
+- [X] done
+** Code
+#+BEGIN_SRC go
+fmt.Println("hi")
+#+END_SRC
+| Name | Value |
+|------+-------|
+| a    | 1     |
+1. step
+`
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	var buf bytes.Buffer
+	if err := New().Export(&buf, doc); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	expected := "h1. Design\n\n" +
+		"Uses *bold*, _italic_ and {{make}} across two lines with \\{braces\\}.\n\n" +
+		"* first\n" +
+		"** nested [link|https://example.com]\n" +
+		"* (/) done\n\n" +
+		"h2. Code\n\n" +
+		"{code:language=go}\nfmt.Println(\"hi\")\n{code}\n\n" +
+		"||Name||Value||\n|a|1|\n\n" +
+		"# step\n\n"
+	if buf.String() != expected {
+		t.Errorf("unexpected output\nexpected=%q\ngot=     %q", expected, buf.String())
+	}
+}
+
+func TestHeadingOffset(t *testing.T) {
+	doc := parser.New(lexer.New("* One\n****** Six\n")).ParseDocument()
+	var buf bytes.Buffer
+	if err := New(WithHeadingOffset(1)).Export(&buf, doc); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	expected := "h2. One\n\nh6. Six\n\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got=%q", expected, buf.String())
+	}
+}
+
+func TestExportSeparatesParagraphs(t *testing.T) {
+	doc := parser.New(lexer.New("a\nb\n\nc\n")).ParseDocument()
+	var buf bytes.Buffer
+	if err := New().Export(&buf, doc); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if expected := "a b\n\nc\n\n"; buf.String() != expected {
+		t.Errorf("expected %q, got=%q", expected, buf.String())
+	}
+}
+
+func TestExportTimestamp(t *testing.T) {
+	doc := parser.New(lexer.New("Meet on <2024-02-02 Fri>.\n")).ParseDocument()
+	var buf bytes.Buffer
+	if err := New().Export(&buf, doc); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if expected := "Meet on <2024-02-02 Fri>.\n\n"; buf.String() != expected {
+		t.Errorf("expected %q, got=%q", expected, buf.String())
+	}
+}
+
+func TestExportFootnoteDefinition(t *testing.T) {
+	doc := parser.New(lexer.New("A claim[fn:1].\n\n[fn:1] The source,\nover two lines.\n")).ParseDocument()
+	var buf bytes.Buffer
+	if err := New().Export(&buf, doc); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if expected := "A claim^1^.\n\n^1^ The source, over two lines.\n\n"; buf.String() != expected {
+		t.Errorf("expected %q, got=%q", expected, buf.String())
+	}
+}
+
+func TestExportInlineTitle(t *testing.T) {
+	doc := parser.New(lexer.New("* Ship /v2/ with ~make~ {now}\n")).ParseDocument()
+	var buf bytes.Buffer
+	if err := New().Export(&buf, doc); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if expected := "h1. Ship _v2_ with {{make}} \\{now\\}\n\n"; buf.String() != expected {
+		t.Errorf("expected %q, got=%q", expected, buf.String())
+	}
+}
+
+func TestExportTableCookies(t *testing.T) {
+	doc := parser.New(lexer.New("| Item | Qty |\n| <l> | <r> |\n|------+-----|\n| pen  | 2   |\n" + "\n| a |\n|---|\n")).ParseDocument()
+	var buf bytes.Buffer
+	if err := New().Export(&buf, doc); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if expected := "||Item||Qty||\n|pen|2|\n\n|a|\n\n"; buf.String() != expected {
+		t.Errorf("expected %q, got=%q", expected, buf.String())
+	}
+}
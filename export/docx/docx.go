@@ -0,0 +1,442 @@
+// Package docx exports documents as Office Open XML (.docx) files so that
+// reports kept in Org can be reviewed in Word.
+//
+// Headlines map to the built-in Heading styles, source and example blocks
+// to a monospaced Code style, lists to bulleted or numbered paragraphs and
+// tables to Word tables whose rows above the first separator repeat as
+// header rows. The package writes only the parts Word requires and uses
+// nothing outside the standard library.
+package docx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/entity"
+	"github.com/justyntemme/organelle/export"
+	"github.com/justyntemme/organelle/parser"
+)
+
+// Exporter writes documents as .docx files
+type Exporter struct{}
+
+// Option is a functional option for configuring the Exporter
+type Option func(*Exporter)
+
+// New creates a docx exporter
+func New(opts ...Option) *Exporter {
+	e := &Exporter{}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Export writes doc to w as a .docx package
+func (e *Exporter) Export(w io.Writer, doc *ast.Document) error {
+	b := &backend{}
+	var body bytes.Buffer
+	if err := export.Render(&body, doc, b); err != nil {
+		return err
+	}
+
+	z := zip.NewWriter(w)
+	parts := []struct {
+		name, content string
+	}{
+		{"[Content_Types].xml", contentTypes},
+		{"_rels/.rels", packageRels},
+		{"docProps/core.xml", coreProperties(doc)},
+		{"word/document.xml", documentHeader + body.String() + documentFooter},
+		{"word/styles.xml", styles},
+		{"word/numbering.xml", b.numbering()},
+		{"word/_rels/document.xml.rels", b.relationships()},
+	}
+	for _, p := range parts {
+		f, err := z.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, p.content); err != nil {
+			return err
+		}
+	}
+	return z.Close()
+}
+
+// backend renders the body of word/document.xml
+type backend struct {
+	export.Base
+	para  []string // Rendered lines of the current paragraph
+	depth int      // Depth of list items being rendered
+	links []string // Hyperlink targets, numbered from firstLinkRel
+	lists []bool   // One numbering instance per list; true if ordered
+}
+
+// firstLinkRel is the relationship number of the first hyperlink; the
+// ones before it belong to the styles and numbering parts
+const firstLinkRel = 3
+
+func (b *backend) Begin(c *export.Context) error {
+	if title := c.Document().Keyword("TITLE"); title != "" {
+		c.WriteString(paragraph(`<w:pStyle w:val="Title"/>`, run(title, runProps{})))
+	}
+	return nil
+}
+
+func (b *backend) Headline(c *export.Context, h *ast.Headline) error {
+	title := h.Title
+	if h.Keyword != "" {
+		title = h.Keyword + " " + title
+	}
+	style := fmt.Sprintf(`<w:pStyle w:val="Heading%d"/>`, min(max(h.Level, 1), 6))
+	c.WriteString(paragraph(style, run(title, runProps{})))
+	return c.Render(h.Children)
+}
+
+func (b *backend) Paragraph(c *export.Context, p *ast.Paragraph) error {
+	if strings.TrimSpace(p.Content) != "" {
+		b.para = append(b.para, b.runs(p.Inline, runProps{}))
+	}
+	if c.ContinuesParagraph() || len(b.para) == 0 {
+		return nil
+	}
+	props := ""
+	if b.depth > 0 {
+		props = fmt.Sprintf(`<w:pStyle w:val="ListParagraph"/><w:ind w:left="%d"/>`, 720*b.depth)
+	}
+	c.WriteString(paragraph(props, strings.Join(b.para, run(" ", runProps{}))))
+	b.para = b.para[:0]
+	return nil
+}
+
+func (b *backend) List(c *export.Context, l *ast.List) error {
+	b.lists = append(b.lists, l.Ordered)
+	num := len(b.lists)
+	props := fmt.Sprintf(`<w:pStyle w:val="ListParagraph"/><w:numPr><w:ilvl w:val="%d"/><w:numId w:val="%d"/></w:numPr>`, min(b.depth, 8), num)
+	for _, item := range l.Items {
+		prefix := ""
+		switch item.Checkbox {
+		case ast.CheckboxUnchecked:
+			prefix = "☐ "
+		case ast.CheckboxChecked:
+			prefix = "☒ "
+		case ast.CheckboxPartial:
+			prefix = "◩ "
+		}
+		content := b.item(item.Content)
+		if prefix != "" {
+			content = run(prefix, runProps{}) + content
+		}
+		c.WriteString(paragraph(props, content))
+
+		b.depth++
+		err := c.Render(item.Children)
+		b.depth--
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *backend) Block(c *export.Context, blk *ast.Block) error {
+	content := strings.TrimSuffix(blk.Content, "\n")
+	style := `<w:pStyle w:val="Code"/>`
+	switch blk.Type {
+	case "EXPORT", "COMMENT":
+		return nil
+	case "QUOTE", "VERSE", "CENTER":
+		style = `<w:pStyle w:val="Quote"/>`
+	}
+	for _, line := range strings.Split(content, "\n") {
+		c.WriteString(paragraph(style, run(line, runProps{})))
+	}
+	return nil
+}
+
+// Table writes a Word table. Rows above the first separator are header
+// rows, repeated on every page and set in bold.
+func (b *backend) Table(c *export.Context, t *ast.Table) error {
+	cols := 0
+	for _, row := range t.Rows {
+		cols = max(cols, len(row.Cells))
+	}
+	if cols == 0 {
+		return nil
+	}
+	end, aligns := t.HeaderEnd(), t.Alignments()
+	c.WriteString(`<w:tbl><w:tblPr><w:tblStyle w:val="TableGrid"/><w:tblW w:w="0" w:type="auto"/></w:tblPr><w:tblGrid>`)
+	c.WriteString(strings.Repeat(`<w:gridCol/>`, cols) + `</w:tblGrid>`)
+	for r, row := range t.Rows {
+		if row.Separator || row.IsCookieRow() {
+			continue
+		}
+		header := r < end
+		c.WriteString(`<w:tr>`)
+		if header {
+			c.WriteString(`<w:trPr><w:tblHeader/></w:trPr>`)
+		}
+		for i := 0; i < cols; i++ {
+			text := ""
+			if i < len(row.Cells) {
+				text = row.Cells[i]
+			}
+			c.WriteString(`<w:tc><w:tcPr><w:tcW w:w="0" w:type="auto"/></w:tcPr>`)
+			c.WriteString(paragraph(justify[aligns[i]], run(text, runProps{bold: header})) + `</w:tc>`)
+		}
+		c.WriteString(`</w:tr>`)
+	}
+	// Word merges a table with a following one unless a paragraph separates them
+	c.WriteString(`</w:tbl><w:p/>`)
+	return nil
+}
+
+// justify maps column alignments to paragraph properties
+var justify = map[ast.Align]string{
+	ast.AlignLeft:   `<w:jc w:val="left"/>`,
+	ast.AlignCenter: `<w:jc w:val="center"/>`,
+	ast.AlignRight:  `<w:jc w:val="right"/>`,
+}
+
+func (b *backend) HorizontalRule(c *export.Context, hr *ast.HorizontalRule) error {
+	c.WriteString(`<w:p><w:pPr><w:pBdr><w:bottom w:val="single" w:sz="6" w:space="1" w:color="auto"/></w:pBdr></w:pPr></w:p>`)
+	return nil
+}
+
+// item renders the text of a list item, whose inline markup the parser
+// leaves unparsed
+func (b *backend) item(content string) string {
+	if nodes, _ := parser.ParseFragment(content); len(nodes) == 1 {
+		if p, ok := nodes[0].(*ast.Paragraph); ok {
+			return b.runs(p.Inline, runProps{})
+		}
+	}
+	return run(content, runProps{})
+}
+
+// runProps are the character properties of a run
+type runProps struct {
+	style  string
+	bold   bool
+	italic bool
+	strike bool
+	under  bool
+	super  bool
+}
+
+// runs renders inline elements as runs, adding hyperlinks to b.links
+func (b *backend) runs(elems []ast.InlineElement, props runProps) string {
+	var out strings.Builder
+	for _, e := range elems {
+		p := props
+		switch e.Type {
+		case ast.InlineText:
+			out.WriteString(run(e.Content, p))
+		case ast.InlineBold:
+			p.bold = true
+			out.WriteString(b.runs(e.Children, p))
+		case ast.InlineItalic:
+			p.italic = true
+			out.WriteString(b.runs(e.Children, p))
+		case ast.InlineStrikethrough:
+			p.strike = true
+			out.WriteString(b.runs(e.Children, p))
+		case ast.InlineUnderline:
+			p.under = true
+			out.WriteString(b.runs(e.Children, p))
+		case ast.InlineCode, ast.InlineVerbatim:
+			p.style = "CodeChar"
+			out.WriteString(run(e.Content, p))
+		case ast.InlineLink:
+			text := run(e.URL, p)
+			if !external(e.URL) {
+				if len(e.Children) > 0 {
+					text = b.runs(e.Children, p)
+				}
+				out.WriteString(text)
+				continue
+			}
+			p.style = "Hyperlink"
+			text = run(e.URL, p)
+			if len(e.Children) > 0 {
+				text = b.runs(e.Children, p)
+			}
+			b.links = append(b.links, e.URL)
+			fmt.Fprintf(&out, `<w:hyperlink r:id="rId%d">%s</w:hyperlink>`, firstLinkRel+len(b.links)-1, text)
+		case ast.InlineEntity:
+			if ent, ok := entity.Lookup(e.Content); ok {
+				out.WriteString(run(ent.UTF8, p))
+			}
+		case ast.InlineFootnoteRef:
+			p.super = true
+			out.WriteString(run(e.Content, p))
+		case ast.InlineTimestamp:
+			out.WriteString(run(e.Content, p))
+		default:
+			out.WriteString(b.runs(e.Children, p))
+		}
+	}
+	return out.String()
+}
+
+// external reports whether url leaves the document
+func external(url string) bool {
+	return strings.Contains(url, "://") || strings.HasPrefix(url, "mailto:")
+}
+
+// run renders text with props. Properties are written in the order the
+// schema requires.
+func run(text string, props runProps) string {
+	var rPr strings.Builder
+	if props.style != "" {
+		rPr.WriteString(`<w:rStyle w:val="` + props.style + `"/>`)
+	}
+	if props.bold {
+		rPr.WriteString(`<w:b/>`)
+	}
+	if props.italic {
+		rPr.WriteString(`<w:i/>`)
+	}
+	if props.strike {
+		rPr.WriteString(`<w:strike/>`)
+	}
+	if props.under {
+		rPr.WriteString(`<w:u w:val="single"/>`)
+	}
+	if props.super {
+		rPr.WriteString(`<w:vertAlign w:val="superscript"/>`)
+	}
+	out := `<w:r>`
+	if rPr.Len() > 0 {
+		out += `<w:rPr>` + rPr.String() + `</w:rPr>`
+	}
+	return out + `<w:t xml:space="preserve">` + escape(text) + `</w:t></w:r>`
+}
+
+// paragraph wraps runs in a paragraph with the given properties
+func paragraph(props, runs string) string {
+	if props != "" {
+		props = `<w:pPr>` + props + `</w:pPr>`
+	}
+	return `<w:p>` + props + runs + `</w:p>`
+}
+
+// escape escapes text for XML character data and attributes
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// numbering returns word/numbering.xml with a numbering instance for each
+// list, so that every numbered list starts at 1
+func (b *backend) numbering() string {
+	var out strings.Builder
+	out.WriteString(xml.Header)
+	out.WriteString(`<w:numbering xmlns:w="` + wordNS + `">`)
+	for id, format := range []string{"bullet", "decimal"} {
+		fmt.Fprintf(&out, `<w:abstractNum w:abstractNumId="%d"><w:multiLevelType w:val="hybridMultilevel"/>`, id)
+		for lvl := 0; lvl < 9; lvl++ {
+			text := "•"
+			if format == "decimal" {
+				text = fmt.Sprintf("%%%d.", lvl+1)
+			}
+			fmt.Fprintf(&out, `<w:lvl w:ilvl="%d"><w:start w:val="1"/><w:numFmt w:val="%s"/><w:lvlText w:val="%s"/><w:lvlJc w:val="left"/><w:pPr><w:ind w:left="%d" w:hanging="360"/></w:pPr></w:lvl>`,
+				lvl, format, text, 720*(lvl+1))
+		}
+		out.WriteString(`</w:abstractNum>`)
+	}
+	for i, ordered := range b.lists {
+		abstract := 0
+		if ordered {
+			abstract = 1
+		}
+		fmt.Fprintf(&out, `<w:num w:numId="%d"><w:abstractNumId w:val="%d"/>`, i+1, abstract)
+		for lvl := 0; ordered && lvl < 9; lvl++ {
+			fmt.Fprintf(&out, `<w:lvlOverride w:ilvl="%d"><w:startOverride w:val="1"/></w:lvlOverride>`, lvl)
+		}
+		out.WriteString(`</w:num>`)
+	}
+	out.WriteString(`</w:numbering>`)
+	return out.String()
+}
+
+// relationships returns word/_rels/document.xml.rels
+func (b *backend) relationships() string {
+	var out strings.Builder
+	out.WriteString(xml.Header)
+	out.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	out.WriteString(`<Relationship Id="rId1" Type="` + relNS + `/styles" Target="styles.xml"/>`)
+	out.WriteString(`<Relationship Id="rId2" Type="` + relNS + `/numbering" Target="numbering.xml"/>`)
+	for i, url := range b.links {
+		fmt.Fprintf(&out, `<Relationship Id="rId%d" Type="%s/hyperlink" Target="%s" TargetMode="External"/>`,
+			firstLinkRel+i, relNS, escape(url))
+	}
+	out.WriteString(`</Relationships>`)
+	return out.String()
+}
+
+// coreProperties returns docProps/core.xml with the title and author
+func coreProperties(doc *ast.Document) string {
+	return xml.Header +
+		`<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/">` +
+		`<dc:title>` + escape(doc.Keyword("TITLE")) + `</dc:title>` +
+		`<dc:creator>` + escape(doc.Keyword("AUTHOR")) + `</dc:creator>` +
+		`</cp:coreProperties>`
+}
+
+const (
+	wordNS = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+	relNS  = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+)
+
+const contentTypes = xml.Header +
+	`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>` +
+	`<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>` +
+	`<Override PartName="/word/numbering.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.numbering+xml"/>` +
+	`<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>` +
+	`</Types>`
+
+const packageRels = xml.Header +
+	`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="` + relNS + `/officeDocument" Target="word/document.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>` +
+	`</Relationships>`
+
+const documentHeader = xml.Header +
+	`<w:document xmlns:w="` + wordNS + `" xmlns:r="` + relNS + `"><w:body>`
+
+const documentFooter = `<w:sectPr/></w:body></w:document>`
+
+var styles = xml.Header +
+	`<w:styles xmlns:w="` + wordNS + `">` +
+	`<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/><w:pPr><w:spacing w:after="160"/></w:pPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="Title"><w:name w:val="Title"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:rPr><w:sz w:val="56"/></w:rPr></w:style>` +
+	heading(1, 32) + heading(2, 28) + heading(3, 26) + heading(4, 24) + heading(5, 22) + heading(6, 22) +
+	`<w:style w:type="paragraph" w:styleId="Code"><w:name w:val="Code"/><w:basedOn w:val="Normal"/><w:pPr><w:spacing w:after="0"/></w:pPr><w:rPr><w:rFonts w:ascii="Courier New" w:hAnsi="Courier New" w:cs="Courier New"/><w:sz w:val="20"/></w:rPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="Quote"><w:name w:val="Quote"/><w:basedOn w:val="Normal"/><w:pPr><w:ind w:left="720" w:right="720"/></w:pPr><w:rPr><w:i/></w:rPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="ListParagraph"><w:name w:val="List Paragraph"/><w:basedOn w:val="Normal"/><w:pPr><w:spacing w:after="0"/></w:pPr></w:style>` +
+	`<w:style w:type="character" w:styleId="CodeChar"><w:name w:val="Code Char"/><w:rPr><w:rFonts w:ascii="Courier New" w:hAnsi="Courier New" w:cs="Courier New"/></w:rPr></w:style>` +
+	`<w:style w:type="character" w:styleId="Hyperlink"><w:name w:val="Hyperlink"/><w:rPr><w:color w:val="0563C1"/><w:u w:val="single"/></w:rPr></w:style>` +
+	`<w:style w:type="table" w:styleId="TableGrid"><w:name w:val="Table Grid"/><w:tblPr><w:tblBorders>` +
+	`<w:top w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:left w:val="single" w:sz="4" w:space="0" w:color="auto"/>` +
+	`<w:bottom w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:right w:val="single" w:sz="4" w:space="0" w:color="auto"/>` +
+	`<w:insideH w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:insideV w:val="single" w:sz="4" w:space="0" w:color="auto"/>` +
+	`</w:tblBorders></w:tblPr></w:style>` +
+	`</w:styles>`
+
+// heading returns the style of a heading level with a size in half-points
+func heading(level, size int) string {
+	return fmt.Sprintf(`<w:style w:type="paragraph" w:styleId="Heading%d"><w:name w:val="heading %d"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/>`+
+		`<w:pPr><w:keepNext/><w:spacing w:before="240" w:after="80"/><w:outlineLvl w:val="%d"/></w:pPr><w:rPr><w:b/><w:sz w:val="%d"/></w:rPr></w:style>`,
+		level, level, level-1, size)
+}
@@ -0,0 +1,165 @@
+package docx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+func TestExport(t *testing.T) {
+	input := `#+TITLE: Report & Review
+* Summary
+Some *bold* and ~code~
+with [[https://example.com?a=1&b=2][a link]].
+- first
+  - nested
+- [X] done
+** Details
+#+BEGIN_SRC go
+x := 1 < 2
+#+END_SRC
+| Name | Value |
+|------+-------|
+| a    | 1     |
+1. step
+`
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	var buf bytes.Buffer
+	if err := New().Export(&buf, doc); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("expected a zip package: %v", err)
+	}
+	parts := map[string]string{}
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		parts[f.Name] = string(data)
+
+		// Every part must be well-formed XML
+		dec := xml.NewDecoder(bytes.NewReader(data))
+		for {
+			if _, err := dec.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s is not well-formed: %v", f.Name, err)
+			}
+		}
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "word/document.xml", "word/styles.xml", "word/numbering.xml", "word/_rels/document.xml.rels"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("missing part %s", name)
+		}
+	}
+
+	body := parts["word/document.xml"]
+	for _, want := range []string{
+		`<w:pStyle w:val="Title"/></w:pPr><w:r><w:t xml:space="preserve">Report &amp; Review</w:t>`,
+		`<w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t xml:space="preserve">Summary</w:t>`,
+		`<w:pStyle w:val="Heading2"/>`,
+		`<w:r><w:rPr><w:b/></w:rPr><w:t xml:space="preserve">bold</w:t></w:r>`,
+		`<w:rStyle w:val="CodeChar"/></w:rPr><w:t xml:space="preserve">code</w:t>`,
+		`<w:hyperlink r:id="rId3"><w:r><w:rPr><w:rStyle w:val="Hyperlink"/></w:rPr><w:t xml:space="preserve">a link</w:t></w:r></w:hyperlink>`,
+		`<w:numPr><w:ilvl w:val="0"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t xml:space="preserve">first</w:t>`,
+		`<w:numPr><w:ilvl w:val="1"/><w:numId w:val="2"/></w:numPr>`,
+		`<w:t xml:space="preserve">☒ </w:t>`,
+		`<w:pStyle w:val="Code"/></w:pPr><w:r><w:t xml:space="preserve">x := 1 &lt; 2</w:t>`,
+		`<w:trPr><w:tblHeader/></w:trPr>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected document.xml to contain %s", want)
+		}
+	}
+	if strings.Count(body, `<w:tblHeader/>`) != 1 {
+		t.Errorf("expected one header row, got=%d", strings.Count(body, `<w:tblHeader/>`))
+	}
+	if !strings.Contains(parts["word/_rels/document.xml.rels"], `Target="https://example.com?a=1&amp;b=2" TargetMode="External"`) {
+		t.Error("expected an external hyperlink relationship")
+	}
+	numbering := parts["word/numbering.xml"]
+	if !strings.Contains(numbering, `<w:num w:numId="2"><w:abstractNumId w:val="0"/>`) {
+		t.Error("expected the nested list to be bulleted")
+	}
+	if !strings.Contains(numbering, `<w:num w:numId="3"><w:abstractNumId w:val="1"/><w:lvlOverride w:ilvl="0"><w:startOverride w:val="1"/>`) {
+		t.Error("expected the ordered list to be numbered from 1")
+	}
+}
+
+func TestExportSeparatesParagraphs(t *testing.T) {
+	doc := parser.New(lexer.New("a\nb\n\nc\n")).ParseDocument()
+	var buf bytes.Buffer
+	if err := New().Export(&buf, doc); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	body := documentXML(t, buf.Bytes())
+	if n := strings.Count(body, "<w:p>"); n != 2 {
+		t.Errorf("expected 2 paragraphs, got %d:\n%s", n, body)
+	}
+	if !strings.Contains(body, `<w:t xml:space="preserve">a</w:t></w:r><w:r><w:t xml:space="preserve"> </w:t></w:r><w:r><w:t xml:space="preserve">b</w:t>`) {
+		t.Errorf("expected a and b in one paragraph:\n%s", body)
+	}
+}
+
+func TestExportTimestamp(t *testing.T) {
+	doc := parser.New(lexer.New("Meet on <2024-02-02 Fri>.\n")).ParseDocument()
+	var buf bytes.Buffer
+	if err := New().Export(&buf, doc); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if body := documentXML(t, buf.Bytes()); !strings.Contains(body, "&lt;2024-02-02 Fri&gt;") {
+		t.Errorf("expected the timestamp in the document:\n%s", body)
+	}
+}
+
+func TestExportTableAlignment(t *testing.T) {
+	doc := parser.New(lexer.New("| Item | Qty |\n| <l> | <r> |\n|------+-----|\n| pen  | 2   |\n")).ParseDocument()
+	var buf bytes.Buffer
+	if err := New().Export(&buf, doc); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	body := documentXML(t, buf.Bytes())
+	if strings.Count(body, "<w:tr>") != 2 || strings.Count(body, "<w:tblHeader/>") != 1 {
+		t.Errorf("expected a header row and a body row without the cookies:\n%s", body)
+	}
+	if strings.Count(body, `<w:jc w:val="right"/>`) != 2 || strings.Count(body, `<w:jc w:val="left"/>`) != 2 {
+		t.Errorf("expected cells aligned by the cookies:\n%s", body)
+	}
+}
+
+// documentXML returns word/document.xml from a DOCX package
+func documentXML(t *testing.T, pkg []byte) string {
+	t.Helper()
+	r, err := zip.NewReader(bytes.NewReader(pkg), int64(len(pkg)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range r.File {
+		if f.Name == "word/document.xml" {
+			rc, err := f.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rc.Close()
+			data, err := io.ReadAll(rc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return string(data)
+		}
+	}
+	t.Fatal("no word/document.xml in the package")
+	return ""
+}
@@ -0,0 +1,308 @@
+// Package dot exports the structure of documents as Graphviz DOT graphs.
+//
+// In Outline mode every document is a node with edges to its top-level
+// headlines, and every headline has edges to its subheadlines. In Links
+// mode the edges are the links between headlines: id: links, file: links
+// to other documents of the collection (optionally with a ::search part),
+// and internal links such as [[*Title]] or [[#custom-id]]. Only documents
+// and the headlines that take part in a link appear in the link graph.
+package dot
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/justyntemme/organelle/anchor"
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/parser"
+	"github.com/justyntemme/organelle/redact"
+)
+
+// Mode selects the graph to draw
+type Mode int
+
+const (
+	Outline Mode = iota // Headline hierarchy
+	Links               // Link graph
+)
+
+// Document is a named member of a collection. Names are slash-separated
+// paths that file: links are resolved against.
+type Document struct {
+	Name string
+	Doc  *ast.Document
+}
+
+// Exporter builds DOT graphs
+type Exporter struct {
+	mode     Mode
+	name     string
+	archived redact.Archived
+}
+
+// Option is a functional option for configuring the Exporter
+type Option func(*Exporter)
+
+// WithMode sets the graph to draw (default Outline)
+func WithMode(m Mode) Option {
+	return func(e *Exporter) {
+		e.mode = m
+	}
+}
+
+// WithName sets the graph's name (default "org")
+func WithName(name string) Option {
+	return func(e *Exporter) {
+		e.name = name
+	}
+}
+
+// IncludeArchived draws subtrees tagged :ARCHIVE:; see redact.Archived.
+// Links into archived subtrees are dropped with them.
+func IncludeArchived() Option {
+	return func(e *Exporter) {
+		e.archived = true
+	}
+}
+
+// New creates a DOT exporter
+func New(opts ...Option) *Exporter {
+	e := &Exporter{name: "org"}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Export writes the graph of a single document to w. The document is
+// named after its #+TITLE.
+func (e *Exporter) Export(w io.Writer, doc *ast.Document) error {
+	name := doc.Keyword("TITLE")
+	if name == "" {
+		name = "document"
+	}
+	return e.ExportAll(w, []Document{{Name: name, Doc: doc}})
+}
+
+// ExportAll writes the graph of a collection of documents to w
+func (e *Exporter) ExportAll(w io.Writer, docs []Document) error {
+	visible := make([]Document, len(docs))
+	for i, d := range docs {
+		visible[i] = d
+		if archived := e.archived.For(d.Doc); !archived {
+			visible[i].Doc = redact.Apply(d.Doc, archived.Skip)
+		}
+	}
+	docs = visible
+	g := newGraph(docs)
+	if e.mode == Links {
+		g.links()
+	} else {
+		g.outline()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", quote(e.name))
+	b.WriteString("  node [shape=box];\n")
+	for _, n := range g.nodes {
+		if !n.used {
+			continue
+		}
+		attrs := "label=" + quote(n.label)
+		if n.doc {
+			attrs += ", shape=folder"
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", n.id, attrs)
+	}
+	for _, edge := range g.edges {
+		fmt.Fprintf(&b, "  %s -> %s;\n", edge[0].id, edge[1].id)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+type node struct {
+	id    string
+	label string
+	doc   bool
+	used  bool // Drawn in the graph
+}
+
+// graph numbers the documents and headlines of a collection in order
+type graph struct {
+	docs      []Document
+	nodes     []*node
+	byDoc     map[*ast.Document]*node
+	byHead    map[*ast.Headline]*node
+	byName    map[string]*ast.Document
+	anchors   map[*ast.Document]*anchor.Set
+	edges     [][2]*node
+	seenEdges map[[2]*node]bool
+}
+
+func newGraph(docs []Document) *graph {
+	g := &graph{
+		docs:      docs,
+		byDoc:     map[*ast.Document]*node{},
+		byHead:    map[*ast.Headline]*node{},
+		byName:    map[string]*ast.Document{},
+		anchors:   map[*ast.Document]*anchor.Set{},
+		seenEdges: map[[2]*node]bool{},
+	}
+	for _, d := range docs {
+		g.byDoc[d.Doc] = g.add(d.Name, true)
+		g.byName[path.Clean(d.Name)] = d.Doc
+		g.anchors[d.Doc] = anchor.New(d.Doc)
+		walk(d.Doc.Children, func(h *ast.Headline) {
+			label := h.Title
+			if h.Keyword != "" {
+				label = h.Keyword + " " + label
+			}
+			g.byHead[h] = g.add(label, false)
+		})
+	}
+	return g
+}
+
+func (g *graph) add(label string, doc bool) *node {
+	n := &node{id: fmt.Sprintf("n%d", len(g.nodes)), label: label, doc: doc}
+	g.nodes = append(g.nodes, n)
+	return n
+}
+
+func (g *graph) edge(from, to *node) {
+	if from == to || g.seenEdges[[2]*node{from, to}] {
+		return
+	}
+	g.seenEdges[[2]*node{from, to}] = true
+	from.used, to.used = true, true
+	g.edges = append(g.edges, [2]*node{from, to})
+}
+
+func (g *graph) outline() {
+	for _, d := range g.docs {
+		root := g.byDoc[d.Doc]
+		root.used = true
+		var tree func(parent *node, nodes []ast.Node)
+		tree = func(parent *node, nodes []ast.Node) {
+			for _, n := range nodes {
+				if h, ok := n.(*ast.Headline); ok {
+					g.edge(parent, g.byHead[h])
+					tree(g.byHead[h], h.Children)
+				}
+			}
+		}
+		tree(root, d.Doc.Children)
+	}
+}
+
+func (g *graph) links() {
+	for _, d := range g.docs {
+		g.byDoc[d.Doc].used = true
+		var scan func(from *node, nodes []ast.Node)
+		scan = func(from *node, nodes []ast.Node) {
+			for _, n := range nodes {
+				if h, ok := n.(*ast.Headline); ok {
+					scan(g.byHead[h], h.Children)
+					continue
+				}
+				for _, url := range nodeLinks(n) {
+					if to := g.resolve(d, url); to != nil {
+						g.edge(from, to)
+					}
+				}
+			}
+		}
+		scan(g.byDoc[d.Doc], d.Doc.Children)
+	}
+}
+
+// resolve finds the node a link in document d points to, or nil
+func (g *graph) resolve(d Document, url string) *node {
+	switch {
+	case strings.HasPrefix(url, "id:"):
+		for _, other := range g.docs {
+			if h, _, ok := g.anchors[other.Doc].Resolve(url); ok {
+				return g.byHead[h]
+			}
+		}
+		return nil
+	case strings.HasPrefix(url, "file:"):
+		file, search, _ := strings.Cut(strings.TrimPrefix(url, "file:"), "::")
+		if !path.IsAbs(file) {
+			file = path.Join(path.Dir(d.Name), file)
+		}
+		target, ok := g.byName[path.Clean(file)]
+		if !ok {
+			return nil
+		}
+		if search != "" {
+			if h, _, ok := g.anchors[target].Resolve(search); ok {
+				return g.byHead[h]
+			}
+		}
+		return g.byDoc[target]
+	case strings.Contains(url, "://") || strings.HasPrefix(url, "mailto:"):
+		return nil
+	}
+	if h, _, ok := g.anchors[d.Doc].Resolve(url); ok {
+		return g.byHead[h]
+	}
+	return nil
+}
+
+// nodeLinks returns the link targets within a non-headline node
+func nodeLinks(n ast.Node) []string {
+	var out []string
+	switch n := n.(type) {
+	case *ast.Section:
+		for _, c := range n.Children {
+			out = append(out, nodeLinks(c)...)
+		}
+	case *ast.FootnoteDefinition:
+		for _, c := range n.Children {
+			out = append(out, nodeLinks(c)...)
+		}
+	case *ast.Paragraph:
+		inlineLinks(n.Inline, &out)
+	case *ast.List:
+		for _, item := range n.Items {
+			// List item text is left unparsed by the parser
+			if nodes, _ := parser.ParseFragment(item.Content); len(nodes) == 1 {
+				out = append(out, nodeLinks(nodes[0])...)
+			}
+			for _, c := range item.Children {
+				out = append(out, nodeLinks(c)...)
+			}
+		}
+	}
+	return out
+}
+
+func inlineLinks(elems []ast.InlineElement, out *[]string) {
+	for _, e := range elems {
+		if e.Type == ast.InlineLink {
+			*out = append(*out, e.URL)
+		}
+		inlineLinks(e.Children, out)
+	}
+}
+
+func walk(nodes []ast.Node, fn func(*ast.Headline)) {
+	for _, n := range nodes {
+		if h, ok := n.(*ast.Headline); ok {
+			fn(h)
+			walk(h.Children, fn)
+		}
+	}
+}
+
+var quoter = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// quote returns s as a DOT string
+func quote(s string) string {
+	return `"` + quoter.Replace(s) + `"`
+}
@@ -0,0 +1,93 @@
+package dot
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+func parse(input string) *ast.Document {
+	return parser.New(lexer.New(input)).ParseDocument()
+}
+
+func TestOutline(t *testing.T) {
+	doc := parse("#+TITLE: Notes \"v2\"\n* TODO One\n** Two\n* Three\n")
+	var buf bytes.Buffer
+	if err := New().Export(&buf, doc); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	expected := "digraph \"org\" {\n" +
+		"  node [shape=box];\n" +
+		"  n0 [label=\"Notes \\\"v2\\\"\", shape=folder];\n" +
+		"  n1 [label=\"TODO One\"];\n" +
+		"  n2 [label=\"Two\"];\n" +
+		"  n3 [label=\"Three\"];\n" +
+		"  n0 -> n1;\n" +
+		"  n1 -> n2;\n" +
+		"  n0 -> n3;\n" +
+		"}\n"
+	if buf.String() != expected {
+		t.Errorf("unexpected output\nexpected=%q\ngot=     %q", expected, buf.String())
+	}
+}
+
+func TestLinks(t *testing.T) {
+	docs := []Document{
+		{Name: "notes/a.org", Doc: parse(`* Source
+See [[id:42][the target]] and [[file:b.org::*Other]].
+** Child
+- refers to [[*Source]]
+* Lonely
+Nothing here but [[https://example.com]].
+`)},
+		{Name: "notes/b.org", Doc: parse(`* Target
+:PROPERTIES:
+:ID: 42
+:END:
+Back to [[file:a.org]].
+* Other
+`)},
+	}
+	var buf bytes.Buffer
+	if err := New(WithMode(Links), WithName("notes")).ExportAll(&buf, docs); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	expected := "digraph \"notes\" {\n" +
+		"  node [shape=box];\n" +
+		"  n0 [label=\"notes/a.org\", shape=folder];\n" +
+		"  n1 [label=\"Source\"];\n" +
+		"  n2 [label=\"Child\"];\n" +
+		"  n4 [label=\"notes/b.org\", shape=folder];\n" +
+		"  n5 [label=\"Target\"];\n" +
+		"  n6 [label=\"Other\"];\n" +
+		"  n1 -> n5;\n" +
+		"  n1 -> n6;\n" +
+		"  n2 -> n1;\n" +
+		"  n5 -> n0;\n" +
+		"}\n"
+	if buf.String() != expected {
+		t.Errorf("unexpected output\nexpected=%q\ngot=     %q", expected, buf.String())
+	}
+}
+
+func TestOutlineSkipsArchived(t *testing.T) {
+	doc := parse("* A\n* B :ARCHIVE:\n** C\n")
+	var buf bytes.Buffer
+	if err := New().Export(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.Contains(out, `"A"`) || strings.Contains(out, `"C"`) {
+		t.Errorf("archived subtree not skipped:\n%s", out)
+	}
+	buf.Reset()
+	if err := New(IncludeArchived()).Export(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"C"`) {
+		t.Errorf("IncludeArchived did not draw the archived subtree:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,296 @@
+// Package email exports a subtree as a MIME message for "mail this
+// subtree" automation. The headline becomes the subject and its contents
+// the body; files referenced by attachment: links are attached.
+//
+// The plain-text part is rendered with export/text. An HTML alternative
+// is included when an HTML renderer is configured with WithHTML.
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/export/text"
+	"github.com/justyntemme/organelle/writer"
+)
+
+// Renderer renders a document body; the exporters in this module satisfy it
+type Renderer interface {
+	Export(w io.Writer, doc *ast.Document) error
+}
+
+// Exporter builds MIME messages from subtrees
+type Exporter struct {
+	from    string
+	to      []string
+	text    Renderer
+	html    Renderer
+	baseDir string
+	now     func() time.Time
+}
+
+// Option is a functional option for configuring the Exporter
+type Option func(*Exporter)
+
+// WithFrom sets the From header
+func WithFrom(addr string) Option {
+	return func(e *Exporter) {
+		e.from = addr
+	}
+}
+
+// WithTo sets the To header
+func WithTo(addrs ...string) Option {
+	return func(e *Exporter) {
+		e.to = append(e.to, addrs...)
+	}
+}
+
+// WithText replaces the plain-text renderer (default text.New())
+func WithText(r Renderer) Option {
+	return func(e *Exporter) {
+		e.text = r
+	}
+}
+
+// WithHTML adds a text/html alternative rendered by r
+func WithHTML(r Renderer) Option {
+	return func(e *Exporter) {
+		e.html = r
+	}
+}
+
+// WithBaseDir sets the directory attachment directories are resolved
+// against, normally the directory of the Org file (default ".")
+func WithBaseDir(dir string) Option {
+	return func(e *Exporter) {
+		e.baseDir = dir
+	}
+}
+
+// WithClock sets the function used for the Date header (default time.Now)
+func WithClock(now func() time.Time) Option {
+	return func(e *Exporter) {
+		e.now = now
+	}
+}
+
+// New creates an email exporter
+func New(opts ...Option) *Exporter {
+	e := &Exporter{text: text.New(), baseDir: ".", now: time.Now}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+var attachmentLink = regexp.MustCompile(`\[\[attachment:([^\]]+)\]`)
+
+// Attachments returns the paths of the files referenced by attachment:
+// links in the subtree, resolved against the headline's attachment
+// directory. Duplicates are removed. A link that names a file outside the
+// attachment directory, through "..", an absolute path or a symlink, is
+// an error, so a document cannot mail arbitrary files.
+func (e *Exporter) Attachments(h *ast.Headline) ([]string, error) {
+	dir := e.AttachmentDir(h)
+	var out []string
+	seen := map[string]bool{}
+	for _, m := range attachmentLink.FindAllStringSubmatch(writer.Node(h), -1) {
+		if !filepath.IsLocal(m[1]) {
+			return nil, fmt.Errorf("attachment %s: outside %s", m[1], dir)
+		}
+		path := filepath.Join(dir, m[1])
+		if err := within(dir, path); err != nil {
+			return nil, fmt.Errorf("attachment %s: %w", m[1], err)
+		}
+		if !seen[path] {
+			seen[path] = true
+			out = append(out, path)
+		}
+	}
+	return out, nil
+}
+
+// within returns an error if path resolves, through symlinks, to a file
+// outside dir. Paths that do not exist are left for the caller to report.
+func within(dir, path string) error {
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return nil
+	}
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return nil
+	}
+	if rel, err := filepath.Rel(realDir, real); err != nil || !filepath.IsLocal(rel) {
+		return fmt.Errorf("resolves outside %s", dir)
+	}
+	return nil
+}
+
+// AttachmentDir returns the directory holding the headline's attachments:
+// the DIR property if set, otherwise data/<ID[:2]>/<ID[2:]> as used by
+// org-attach
+func (e *Exporter) AttachmentDir(h *ast.Headline) string {
+	if dir, ok := h.Property("DIR"); ok && dir != "" {
+		if filepath.IsAbs(dir) {
+			return dir
+		}
+		return filepath.Join(e.baseDir, dir)
+	}
+	if id, ok := h.Property("ID"); ok && len(id) > 2 {
+		return filepath.Join(e.baseDir, "data", id[:2], id[2:])
+	}
+	return e.baseDir
+}
+
+// Export writes the subtree rooted at h to w as an RFC 5322 message
+func (e *Exporter) Export(w io.Writer, h *ast.Headline) error {
+	body := &ast.Document{Children: h.Children}
+
+	var plain bytes.Buffer
+	if err := e.text.Export(&plain, body); err != nil {
+		return err
+	}
+	var html bytes.Buffer
+	if e.html != nil {
+		if err := e.html.Export(&html, body); err != nil {
+			return err
+		}
+	}
+
+	var msg bytes.Buffer
+	if e.from != "" {
+		fmt.Fprintf(&msg, "From: %s\r\n", e.from)
+	}
+	if len(e.to) > 0 {
+		fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(e.to, ", "))
+	}
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", h.Title))
+	fmt.Fprintf(&msg, "Date: %s\r\n", e.now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+
+	attachments, err := e.Attachments(h)
+	if err != nil {
+		return err
+	}
+	if len(attachments) == 0 {
+		if err := writeBody(&msg, plain.Bytes(), html.Bytes(), e.html != nil); err != nil {
+			return err
+		}
+		_, err := w.Write(msg.Bytes())
+		return err
+	}
+
+	mixed := multipart.NewWriter(&msg)
+	fmt.Fprintf(&msg, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", mixed.Boundary())
+
+	var inner bytes.Buffer
+	if err := writeBody(&inner, plain.Bytes(), html.Bytes(), e.html != nil); err != nil {
+		return err
+	}
+	header, rest, _ := bytes.Cut(inner.Bytes(), []byte("\r\n\r\n"))
+	part, err := mixed.CreatePart(parseHeader(header))
+	if err != nil {
+		return err
+	}
+	part.Write(rest)
+
+	for _, path := range attachments {
+		if err := writeAttachment(mixed, path); err != nil {
+			return err
+		}
+	}
+	if err := mixed.Close(); err != nil {
+		return err
+	}
+	_, err = w.Write(msg.Bytes())
+	return err
+}
+
+// writeBody writes the Content-Type header and body for the text part, or
+// a multipart/alternative when an HTML rendering is present
+func writeBody(w *bytes.Buffer, plain, html []byte, withHTML bool) error {
+	if !withHTML {
+		w.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+		w.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		return writeQP(w, plain)
+	}
+
+	alt := multipart.NewWriter(w)
+	fmt.Fprintf(w, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", alt.Boundary())
+	for _, p := range []struct {
+		contentType string
+		body        []byte
+	}{{"text/plain; charset=utf-8", plain}, {"text/html; charset=utf-8", html}} {
+		part, err := alt.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return err
+		}
+		if err := writeQP(part, p.body); err != nil {
+			return err
+		}
+	}
+	return alt.Close()
+}
+
+func writeQP(w io.Writer, body []byte) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write(body); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+func writeAttachment(mw *multipart.Writer, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("attachment %s: %w", path, err)
+	}
+	name := filepath.Base(path)
+	contentType := mime.TypeByExtension(filepath.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	part, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": name})},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return err
+	}
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		part.Write([]byte(encoded[:76] + "\r\n"))
+		encoded = encoded[76:]
+	}
+	_, err = part.Write([]byte(encoded + "\r\n"))
+	return err
+}
+
+// parseHeader turns "Key: value" lines into a MIME header
+func parseHeader(raw []byte) textproto.MIMEHeader {
+	h := textproto.MIMEHeader{}
+	for _, line := range strings.Split(string(raw), "\r\n") {
+		if k, v, ok := strings.Cut(line, ":"); ok {
+			h.Add(strings.TrimSpace(k), strings.TrimSpace(v))
+		}
+	}
+	return h
+}
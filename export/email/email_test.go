@@ -0,0 +1,145 @@
+package email
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+type htmlStub struct{}
+
+func (htmlStub) Export(w io.Writer, doc *ast.Document) error {
+	_, err := io.WriteString(w, "<p>html body</p>")
+	return err
+}
+
+func headline(t *testing.T, input string) *ast.Headline {
+	t.Helper()
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	return doc.Children[0].(*ast.Headline)
+}
+
+func clock() time.Time {
+	return time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+}
+
+func TestExportPlainText(t *testing.T) {
+	h := headline(t, "* Weekly summary\nAll tasks are done.\n")
+	var buf bytes.Buffer
+	err := New(WithFrom("me@example.com"), WithTo("team@example.com"), WithClock(clock)).Export(&buf, h)
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	msg, err := mail.ReadMessage(&buf)
+	if err != nil {
+		t.Fatalf("invalid message: %v", err)
+	}
+	if got := msg.Header.Get("Subject"); got != "Weekly summary" {
+		t.Errorf("expected subject from headline, got=%q", got)
+	}
+	if got := msg.Header.Get("To"); got != "team@example.com" {
+		t.Errorf("unexpected To header %q", got)
+	}
+	if ct := msg.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("expected text/plain body, got=%q", ct)
+	}
+	body, _ := io.ReadAll(msg.Body)
+	if !strings.Contains(string(body), "All tasks are done.") {
+		t.Errorf("expected rendered body, got=%q", body)
+	}
+}
+
+func TestExportAlternativeWithAttachment(t *testing.T) {
+	dir := t.TempDir()
+	attachDir := filepath.Join(dir, "data", "ab", "cdef")
+	if err := os.MkdirAll(attachDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(attachDir, "report.txt"), []byte("figures"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	h := headline(t, `* Report
+:PROPERTIES:
+:ID: abcdef
+:END:
+See [[attachment:report.txt]] for details.
+`)
+	var buf bytes.Buffer
+	err := New(WithHTML(htmlStub{}), WithBaseDir(dir), WithClock(clock)).Export(&buf, h)
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	msg, err := mail.ReadMessage(&buf)
+	if err != nil {
+		t.Fatalf("invalid message: %v", err)
+	}
+	mediaType, params, _ := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if mediaType != "multipart/mixed" {
+		t.Fatalf("expected multipart/mixed, got=%q", mediaType)
+	}
+
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	first, err := mr.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct, _, _ := mime.ParseMediaType(first.Header.Get("Content-Type")); ct != "multipart/alternative" {
+		t.Errorf("expected multipart/alternative first, got=%q", ct)
+	}
+	second, err := mr.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.FileName() != "report.txt" {
+		t.Errorf("expected report.txt attachment, got=%q", second.FileName())
+	}
+}
+
+func TestMissingAttachment(t *testing.T) {
+	h := headline(t, "* Report\nSee [[attachment:missing.pdf]].\n")
+	var buf bytes.Buffer
+	if err := New(WithBaseDir(t.TempDir())).Export(&buf, h); err == nil {
+		t.Error("expected an error for a missing attachment")
+	}
+}
+
+func TestAttachmentOutsideDir(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "secret.txt")
+	if err := os.WriteFile(secret, []byte("password"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	attachDir := filepath.Join(dir, "attach")
+	if err := os.Mkdir(attachDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(secret, filepath.Join(attachDir, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	e := New(WithBaseDir(dir))
+	for _, target := range []string{"../secret.txt", "../../etc/passwd", secret, "link.txt"} {
+		h := headline(t, "* Report\n:PROPERTIES:\n:DIR: attach\n:END:\nSee [[attachment:"+target+"]].\n")
+		if paths, err := e.Attachments(h); err == nil {
+			t.Errorf("expected %q to be rejected, got=%v", target, paths)
+		}
+		var buf bytes.Buffer
+		if err := e.Export(&buf, h); err == nil || strings.Contains(buf.String(), "password") {
+			t.Errorf("expected exporting %q to fail, err=%v", target, err)
+		}
+	}
+}
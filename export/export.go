@@ -0,0 +1,292 @@
+// Package export provides the traversal shared by document exporters.
+//
+// A Backend supplies one render hook per node type; Render walks the AST
+// and dispatches each node to the matching hook. Hooks that own children
+// (headlines, list items) decide where the children go by calling
+// Context.Render. Embedding Base gives no-op hooks, so a backend only
+// implements the node types it cares about.
+//
+// Backends can be registered by name so that tools can pick one at run
+// time, for example from a command-line flag.
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/justyntemme/organelle/ast"
+)
+
+// Backend renders individual node types
+type Backend interface {
+	// Begin is called before the document's children are rendered
+	Begin(c *Context) error
+	// End is called after the document's children are rendered
+	End(c *Context) error
+
+	Headline(c *Context, h *ast.Headline) error
+	Paragraph(c *Context, p *ast.Paragraph) error
+	List(c *Context, l *ast.List) error
+	Block(c *Context, b *ast.Block) error
+	Table(c *Context, t *ast.Table) error
+	Drawer(c *Context, d *ast.Drawer) error
+	Keyword(c *Context, k *ast.Keyword) error
+	Comment(c *Context, cm *ast.Comment) error
+	Call(c *Context, call *ast.Call) error
+	HorizontalRule(c *Context, hr *ast.HorizontalRule) error
+}
+
+// NodeRenderer is implemented by backends that handle node types outside
+// the Backend interface, such as nodes added by parser extensions
+type NodeRenderer interface {
+	Node(c *Context, n ast.Node) error
+}
+
+// FootnoteRenderer is implemented by backends that render footnote
+// definitions themselves. Other backends see the text of a definition as
+// ordinary paragraphs.
+type FootnoteRenderer interface {
+	FootnoteDefinition(c *Context, f *ast.FootnoteDefinition) error
+}
+
+// Extensioner is implemented by backends that know the file extension of
+// their output, such as ".tex"
+type Extensioner interface {
+	Extension() string
+}
+
+// Base implements every Backend hook as a no-op, except Headline which
+// renders the headline's children
+type Base struct{}
+
+func (Base) Begin(c *Context) error                                  { return nil }
+func (Base) End(c *Context) error                                    { return nil }
+func (Base) Headline(c *Context, h *ast.Headline) error              { return c.Render(h.Children) }
+func (Base) Paragraph(c *Context, p *ast.Paragraph) error            { return nil }
+func (Base) List(c *Context, l *ast.List) error                      { return nil }
+func (Base) Block(c *Context, b *ast.Block) error                    { return nil }
+func (Base) Table(c *Context, t *ast.Table) error                    { return nil }
+func (Base) Drawer(c *Context, d *ast.Drawer) error                  { return nil }
+func (Base) Keyword(c *Context, k *ast.Keyword) error                { return nil }
+func (Base) Comment(c *Context, cm *ast.Comment) error               { return nil }
+func (Base) Call(c *Context, call *ast.Call) error                   { return nil }
+func (Base) HorizontalRule(c *Context, hr *ast.HorizontalRule) error { return nil }
+
+// Context carries the output and traversal position into render hooks
+type Context struct {
+	*bufio.Writer
+	backend  Backend
+	doc      *ast.Document
+	parents  []ast.Node
+	siblings []ast.Node
+	index    int
+	archived string // "t" renders archived subtrees, "headline" only their headlines
+}
+
+// RenderOption configures a single Render
+type RenderOption func(*Context)
+
+// IncludeArchived renders subtrees tagged :ARCHIVE:; see redact.Archived.
+// #+OPTIONS: arch:headline in the document renders their headlines only.
+func IncludeArchived() RenderOption {
+	return func(c *Context) {
+		c.archived = "t"
+	}
+}
+
+// Render renders doc to w with backend. Archived subtrees are left out
+// unless the document sets arch:t (or arch:headline, which keeps just
+// their headlines) in #+OPTIONS or IncludeArchived is passed.
+func Render(w io.Writer, doc *ast.Document, backend Backend, opts ...RenderOption) error {
+	c := &Context{
+		Writer:  bufio.NewWriter(w),
+		backend: backend,
+		doc:     doc,
+	}
+	c.archived, _ = doc.ExportOption("arch")
+	for _, opt := range opts {
+		opt(c)
+	}
+	if err := backend.Begin(c); err != nil {
+		return err
+	}
+	if err := c.Render(doc.Children); err != nil {
+		return err
+	}
+	if err := backend.End(c); err != nil {
+		return err
+	}
+	return c.Flush()
+}
+
+// Render dispatches each node to the backend. Hooks call it to render
+// the children of the node they are handling.
+func (c *Context) Render(nodes []ast.Node) error {
+	siblings, index := c.siblings, c.index
+	defer func() { c.siblings, c.index = siblings, index }()
+
+	if len(c.siblings) > 0 {
+		c.parents = append(c.parents, c.siblings[c.index])
+		defer func() { c.parents = c.parents[:len(c.parents)-1] }()
+	}
+	c.siblings = nodes
+	for i, n := range nodes {
+		c.index = i
+		if err := c.dispatch(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Context) dispatch(node ast.Node) error {
+	b := c.backend
+	switch n := node.(type) {
+	case *ast.Headline:
+		if n.IsArchived() {
+			switch c.archived {
+			case "t":
+			case "headline":
+				cp := *n
+				cp.Children = nil
+				return b.Headline(c, &cp)
+			default:
+				return nil
+			}
+		}
+		return b.Headline(c, n)
+	case *ast.Paragraph:
+		return b.Paragraph(c, n)
+	case *ast.List:
+		return b.List(c, n)
+	case *ast.Block:
+		return b.Block(c, n)
+	case *ast.Table:
+		return b.Table(c, n)
+	case *ast.Drawer:
+		return b.Drawer(c, n)
+	case *ast.Keyword:
+		if n == nil { // parseKeyword may yield a typed nil
+			return nil
+		}
+		return b.Keyword(c, n)
+	case *ast.Comment:
+		return b.Comment(c, n)
+	case *ast.Call:
+		return b.Call(c, n)
+	case *ast.HorizontalRule:
+		return b.HorizontalRule(c, n)
+	case *ast.Section:
+		// Sections only group nodes; backends see their children
+		return c.Render(n.Children)
+	case *ast.FootnoteDefinition:
+		if fr, ok := b.(FootnoteRenderer); ok {
+			return fr.FootnoteDefinition(c, n)
+		}
+		return c.Render(n.Children)
+	case nil:
+		return nil
+	}
+	if nr, ok := b.(NodeRenderer); ok {
+		return nr.Node(c, node)
+	}
+	return nil
+}
+
+// Document returns the document being rendered
+func (c *Context) Document() *ast.Document {
+	return c.doc
+}
+
+// Parent returns the node whose children are being rendered, or nil at
+// the top level
+func (c *Context) Parent() ast.Node {
+	if len(c.parents) == 0 {
+		return nil
+	}
+	return c.parents[len(c.parents)-1]
+}
+
+// Depth returns the number of enclosing nodes
+func (c *Context) Depth() int {
+	return len(c.parents)
+}
+
+// Prev returns the previous sibling of the current node, or nil
+func (c *Context) Prev() ast.Node {
+	if c.index == 0 || c.index > len(c.siblings) {
+		return nil
+	}
+	return c.siblings[c.index-1]
+}
+
+// Next returns the next sibling of the current node, or nil
+func (c *Context) Next() ast.Node {
+	if c.index+1 >= len(c.siblings) {
+		return nil
+	}
+	return c.siblings[c.index+1]
+}
+
+// ContinuesParagraph reports whether the next sibling is a paragraph line
+// directly below the current one. The parser makes every line its own
+// Paragraph and drops blank lines, so backends that reflow lines use this
+// to tell where one paragraph ends and the next begins.
+func (c *Context) ContinuesParagraph() bool {
+	next, ok := c.Next().(*ast.Paragraph)
+	if !ok || c.index >= len(c.siblings) {
+		return false
+	}
+	cur, ok := c.siblings[c.index].(*ast.Paragraph)
+	return ok && next.Token.Line <= cur.Token.Line+1
+}
+
+// Printf writes formatted output
+func (c *Context) Printf(format string, args ...any) {
+	fmt.Fprintf(c.Writer, format, args...)
+}
+
+// Factory creates a fresh backend for a single render
+type Factory func() Backend
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{}
+)
+
+// Register makes a backend available by name. Registering the same name
+// twice panics, as with database/sql drivers.
+func Register(name string, f Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, dup := registry[name]; dup {
+		panic("export: Register called twice for backend " + name)
+	}
+	registry[name] = f
+}
+
+// Lookup returns a new instance of the named backend
+func Lookup(name string) (Backend, bool) {
+	registryMu.RLock()
+	f, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return f(), true
+}
+
+// Backends returns the names of the registered backends, sorted
+func Backends() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
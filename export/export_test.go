@@ -0,0 +1,147 @@
+package export_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/export"
+	_ "github.com/justyntemme/organelle/export/latex"
+	_ "github.com/justyntemme/organelle/export/text"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+// outline renders headlines and paragraphs only, relying on Base for
+// everything else
+type outline struct {
+	export.Base
+}
+
+func (outline) Headline(c *export.Context, h *ast.Headline) error {
+	c.Printf("%s%s\n", strings.Repeat("  ", c.Depth()), h.Title)
+	return c.Render(h.Children)
+}
+
+func (outline) Paragraph(c *export.Context, p *ast.Paragraph) error {
+	parent, _ := c.Parent().(*ast.Headline)
+	_, last := c.Next().(*ast.Paragraph)
+	c.Printf("%s%q in %q, more=%v\n", strings.Repeat("  ", c.Depth()), p.Content, parent.Title, last)
+	return nil
+}
+
+func TestRenderDispatchesHooks(t *testing.T) {
+	input := `* A
+one
+two
+** B
+- ignored list
+three
+`
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	var buf bytes.Buffer
+	if err := export.Render(&buf, doc, outline{}); err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	want := `A
+  "one" in "A", more=true
+  "two" in "A", more=false
+  B
+    "three" in "B", more=false
+`
+	if buf.String() != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+type custom struct{ ast.Paragraph }
+
+type withNodes struct {
+	export.Base
+	seen []string
+}
+
+func (w *withNodes) Node(c *export.Context, n ast.Node) error {
+	w.seen = append(w.seen, fmt.Sprintf("%T", n))
+	return nil
+}
+
+func TestRenderUnknownNodes(t *testing.T) {
+	doc := &ast.Document{Children: []ast.Node{&custom{}, nil}}
+	b := &withNodes{}
+	if err := export.Render(&bytes.Buffer{}, doc, b); err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if len(b.seen) != 1 || b.seen[0] != "*export_test.custom" {
+		t.Errorf("expected custom node to reach Node, got %v", b.seen)
+	}
+}
+
+func TestRenderSections(t *testing.T) {
+	input := "#+TITLE: Doc\nIntro\n* Title\nBody\n"
+	var want, got bytes.Buffer
+	b, _ := export.Lookup("text")
+	if err := export.Render(&want, parser.New(lexer.New(input)).ParseDocument(), b); err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	b, _ = export.Lookup("text")
+	if err := export.Render(&got, parser.New(lexer.New(input), parser.WithSections()).ParseDocument(), b); err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if got.String() != want.String() {
+		t.Errorf("expected sections to render like loose nodes\ngot=  %q\nwant= %q", got.String(), want.String())
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	names := strings.Join(export.Backends(), ",")
+	if !strings.Contains(names, "latex") || !strings.Contains(names, "text") {
+		t.Fatalf("expected latex and text to be registered, got %v", names)
+	}
+	b, ok := export.Lookup("text")
+	if !ok {
+		t.Fatal("expected text backend")
+	}
+	doc := parser.New(lexer.New("* Title\nBody\n")).ParseDocument()
+	var buf bytes.Buffer
+	if err := export.Render(&buf, doc, b); err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if buf.String() != "Title\n=====\n\nBody\n\n" {
+		t.Errorf("unexpected text output %q", buf.String())
+	}
+	if _, ok := export.Lookup("missing"); ok {
+		t.Error("expected unknown backend lookup to fail")
+	}
+}
+
+func TestRenderArchived(t *testing.T) {
+	input := `* Active
+* Old :ARCHIVE:
+kept
+** Nested
+`
+	tests := []struct {
+		name  string
+		input string
+		opts  []export.RenderOption
+		want  string
+	}{
+		{"default", input, nil, "Active\n"},
+		{"option", input, []export.RenderOption{export.IncludeArchived()}, "Active\nOld\n  \"kept\" in \"Old\", more=false\n  Nested\n"},
+		{"arch:t", "#+OPTIONS: arch:t\n" + input, nil, "Active\nOld\n  \"kept\" in \"Old\", more=false\n  Nested\n"},
+		{"arch:headline", "#+OPTIONS: arch:headline\n" + input, nil, "Active\nOld\n"},
+	}
+	for _, tt := range tests {
+		doc := parser.New(lexer.New(tt.input)).ParseDocument()
+		var buf bytes.Buffer
+		if err := export.Render(&buf, doc, outline{}, tt.opts...); err != nil {
+			t.Fatalf("%s: render failed: %v", tt.name, err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", tt.name, buf.String(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+// Package exporttest compares export output with golden files, for the
+// tests of this module's backends and of custom backends built on package
+// export.
+//
+// Golden files live in the testdata directory of the package under test,
+// named after the test. Run the tests with -exporttest.update to write
+// them:
+//
+//	go test ./mybackend -exporttest.update
+package exporttest
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/export"
+)
+
+// update is namespaced so that it does not clash with an -update flag of
+// the package under test
+var update = flag.Bool("exporttest.update", false, "write golden files instead of comparing with them")
+
+const (
+	// context is the number of unchanged lines Diff shows around a change
+	context = 2
+	// maxCells bounds the table Diff builds to find the longest common
+	// subsequence of the changed lines; past it, they are shown as all
+	// removed and all added
+	maxCells = 1 << 22
+)
+
+// Golden renders doc with backend and compares the output with the golden
+// file testdata/<test name>.golden
+func Golden(t testing.TB, doc *ast.Document, backend export.Backend) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := export.Render(&buf, doc, backend); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	GoldenFile(t, Path(t), buf.Bytes())
+}
+
+// Path returns the golden file for t: testdata/<test name>.golden, with
+// the slashes of subtest names replaced
+func Path(t testing.TB) string {
+	return filepath.Join("testdata", strings.ReplaceAll(t.Name(), "/", "_")+".golden")
+}
+
+// GoldenFile compares got with the file at path, or writes it there when
+// the tests run with -exporttest.update
+func GoldenFile(t testing.TB, path string, got []byte) {
+	t.Helper()
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run with -exporttest.update to create it)", err)
+	}
+	if !bytes.Equal(want, got) {
+		t.Errorf("output differs from %s (-want +got):\n%s", path, Diff(string(want), string(got)))
+	}
+}
+
+// Diff returns a line diff of want and got, with lines only in want
+// prefixed by "-", lines only in got by "+", and a few unchanged lines
+// around each change for context. It is empty if they are equal.
+func Diff(want, got string) string {
+	if want == got {
+		return ""
+	}
+	a, b := lines(want), lines(got)
+
+	// Common leading and trailing lines need no table
+	var ops []string
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		ops = append(ops, " "+a[0])
+		a, b = a[1:], b[1:]
+	}
+	var tail []string
+	for len(a) > 0 && len(b) > 0 && a[len(a)-1] == b[len(b)-1] {
+		tail = append(tail, " "+a[len(a)-1])
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+	ops = append(ops, change(a, b)...)
+	for k := len(tail) - 1; k >= 0; k-- {
+		ops = append(ops, tail[k])
+	}
+
+	var out strings.Builder
+	last := -1 // Index of the last op written
+	for k, op := range ops {
+		if op[0] == ' ' && !nearChange(ops, k) {
+			continue
+		}
+		if last >= 0 && k > last+1 {
+			out.WriteString("...\n")
+		}
+		fmt.Fprintf(&out, "%s\n", op)
+		last = k
+	}
+	return out.String()
+}
+
+// change returns the diff ops turning a into b
+func change(a, b []string) []string {
+	var ops []string
+	if (len(a)+1)*(len(b)+1) > maxCells {
+		for _, l := range a {
+			ops = append(ops, "-"+l)
+		}
+		for _, l := range b {
+			ops = append(ops, "+"+l)
+		}
+		return ops
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, " "+a[i])
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, "-"+a[i])
+			i++
+		default:
+			ops = append(ops, "+"+b[j])
+			j++
+		}
+	}
+	return ops
+}
+
+// nearChange reports whether ops[k] is within context lines of a change
+func nearChange(ops []string, k int) bool {
+	for i := max(0, k-context); i <= min(len(ops)-1, k+context); i++ {
+		if ops[i][0] != ' ' {
+			return true
+		}
+	}
+	return false
+}
+
+// lines splits s into lines, marking a missing final newline so that it
+// shows up in the diff
+func lines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	if !strings.HasSuffix(s, "\n") {
+		s += "\\ no newline at end\n"
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,70 @@
+package exporttest
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/export"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+func TestDiff(t *testing.T) {
+	want := "a\nb\nc\nd\ne\nf\nx\ng\nh\n"
+	got := "a\nb\nc\nD\ne\nf\nx\ng\nh\ni\n"
+	expected := ` b
+ c
+-d
++D
+ e
+ f
+...
+ g
+ h
++i
+`
+	if d := Diff(want, got); d != expected {
+		t.Errorf("unexpected diff:\n%s", d)
+	}
+	if d := Diff("x\n", "x"); d != "-x\n+x\\ no newline at end\n" {
+		t.Errorf("expected a missing newline to show, got:\n%s", d)
+	}
+	if d := Diff("same\n", "same\n"); d != "" {
+		t.Errorf("expected no diff, got:\n%s", d)
+	}
+
+	// Past maxCells the changed lines are listed without a table
+	var big, other strings.Builder
+	for i := range 3000 {
+		fmt.Fprintf(&big, "a%d\n", i)
+		fmt.Fprintf(&other, "b%d\n", i)
+	}
+	d := Diff("same\n"+big.String()+"end\n", "same\n"+other.String()+"end\n")
+	if !strings.HasPrefix(d, " same\n-a0\n-a1\n") || !strings.HasSuffix(d, "+b2998\n+b2999\n end\n") {
+		t.Errorf("unexpected diff of large outputs:\n%.200s", d)
+	}
+}
+
+// titles writes each headline title on its own line
+type titles struct {
+	export.Base
+}
+
+func (titles) Headline(c *export.Context, h *ast.Headline) error {
+	c.Printf("%d %s\n", h.Level, h.Title)
+	return c.Render(h.Children)
+}
+
+func TestGolden(t *testing.T) {
+	doc := parser.New(lexer.New("* One\n** Two\n* Three\n")).ParseDocument()
+	Golden(t, doc, titles{})
+}
+
+func TestUpdateFlagIsNamespaced(t *testing.T) {
+	if flag.Lookup("exporttest.update") == nil || flag.Lookup("update") != nil {
+		t.Error("expected only -exporttest.update to be registered")
+	}
+}
@@ -0,0 +1,363 @@
+// Package feed exports headlines as an Atom or RSS 2.0 feed for blogs
+// kept in a single Org file.
+//
+// When any headline carries a date property (PUBDATE, EXPORT_DATE or
+// DATE) those headlines are the entries, at any level. Otherwise every
+// top-level headline is an entry. Entry links point at the headline's
+// anchor below the feed's link.
+package feed
+
+import (
+	"encoding/xml"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/justyntemme/organelle/anchor"
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/export/text"
+	"github.com/justyntemme/organelle/parser"
+	"github.com/justyntemme/organelle/redact"
+)
+
+// Format selects the feed syntax
+type Format int
+
+const (
+	Atom Format = iota
+	RSS
+)
+
+// DateProperties are the properties that date an entry, in order of
+// precedence
+var DateProperties = []string{"PUBDATE", "EXPORT_DATE", "DATE"}
+
+// Renderer renders an entry body; the exporters in this module satisfy it
+type Renderer interface {
+	Export(w io.Writer, doc *ast.Document) error
+}
+
+// Entry is a feed item
+type Entry struct {
+	Headline *ast.Headline
+	Title    string
+	Anchor   string
+	Date     time.Time // Zero when the headline has no date property
+}
+
+// Exporter builds feeds
+type Exporter struct {
+	format   Format
+	title    string
+	link     string
+	author   string
+	content  Renderer
+	html     bool
+	now      func() time.Time
+	archived redact.Archived
+}
+
+// Option is a functional option for configuring the Exporter
+type Option func(*Exporter)
+
+// WithFormat sets the feed syntax (default Atom)
+func WithFormat(f Format) Option {
+	return func(e *Exporter) {
+		e.format = f
+	}
+}
+
+// WithTitle sets the feed title (default #+TITLE)
+func WithTitle(title string) Option {
+	return func(e *Exporter) {
+		e.title = title
+	}
+}
+
+// WithLink sets the URL of the page the feed belongs to
+func WithLink(url string) Option {
+	return func(e *Exporter) {
+		e.link = url
+	}
+}
+
+// WithAuthor sets the feed author (default #+AUTHOR)
+func WithAuthor(name string) Option {
+	return func(e *Exporter) {
+		e.author = name
+	}
+}
+
+// WithHTML renders entry bodies with r as HTML content. By default bodies
+// are plain text rendered with export/text.
+func WithHTML(r Renderer) Option {
+	return func(e *Exporter) {
+		e.content = r
+		e.html = true
+	}
+}
+
+// WithClock sets the time source used for the feed's update time when no
+// entry is dated
+func WithClock(now func() time.Time) Option {
+	return func(e *Exporter) {
+		e.now = now
+	}
+}
+
+// IncludeArchived publishes entries from subtrees tagged :ARCHIVE:; see
+// redact.Archived
+func IncludeArchived() Option {
+	return func(e *Exporter) {
+		e.archived = true
+	}
+}
+
+// New creates a feed exporter
+func New(opts ...Option) *Exporter {
+	e := &Exporter{content: text.New(text.WithWidth(0)), now: time.Now}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Entries returns the feed entries of doc: the dated headlines newest
+// first or, if none is dated, the top-level headlines in document order.
+// Archived subtrees are skipped.
+func Entries(doc *ast.Document) []Entry {
+	return collectEntries(doc, false)
+}
+
+func collectEntries(doc *ast.Document, archived redact.Archived) []Entry {
+	archived = archived.For(doc)
+	anchors := anchor.New(doc)
+	var dated, all []Entry
+	var walk func([]ast.Node, bool)
+	walk = func(nodes []ast.Node, top bool) {
+		for _, n := range nodes {
+			h, ok := n.(*ast.Headline)
+			if !ok || archived.Skip(h) {
+				continue
+			}
+			entry := Entry{Headline: h, Title: h.Title, Anchor: anchors.For(h), Date: Date(h)}
+			if !entry.Date.IsZero() {
+				dated = append(dated, entry)
+			} else if top {
+				all = append(all, entry)
+			}
+			walk(h.Children, false)
+		}
+	}
+	walk(doc.Children, true)
+	if len(dated) == 0 {
+		return all
+	}
+	sort.SliceStable(dated, func(i, j int) bool { return dated[i].Date.After(dated[j].Date) })
+	return dated
+}
+
+// Date returns the date of h from its date properties, accepting Org
+// timestamps and bare YYYY-MM-DD dates, or the zero time
+func Date(h *ast.Headline) time.Time {
+	for _, key := range DateProperties {
+		v, ok := h.Property(key)
+		if !ok {
+			continue
+		}
+		v = strings.TrimSpace(v)
+		date, clock := v, ""
+		if ts := parser.ParseTimestamp(v); ts != nil {
+			date, clock = ts.Date, ts.Time
+		}
+		if clock != "" {
+			if t, err := time.Parse("2006-01-02 15:04", date+" "+clock); err == nil {
+				return t
+			}
+		}
+		if t, err := time.Parse("2006-01-02", date); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Export writes the feed for doc to w
+func (e *Exporter) Export(w io.Writer, doc *ast.Document) error {
+	title := e.title
+	if title == "" {
+		title = doc.Keyword("TITLE")
+	}
+	author := e.author
+	if author == "" {
+		author = doc.Keyword("AUTHOR")
+	}
+
+	entries := collectEntries(doc, e.archived)
+	updated := e.now()
+	for _, entry := range entries {
+		if !entry.Date.IsZero() {
+			updated = entry.Date // Entries are sorted newest first
+			break
+		}
+	}
+
+	var items []item
+	for _, entry := range entries {
+		body, err := e.body(entry.Headline)
+		if err != nil {
+			return err
+		}
+		date := entry.Date
+		if date.IsZero() {
+			date = updated
+		}
+		items = append(items, item{entry: entry, link: e.link + "#" + entry.Anchor, date: date, body: body})
+	}
+
+	var v any
+	if e.format == RSS {
+		v = e.rss(title, updated, items)
+	} else {
+		v = e.atom(title, author, updated, items)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// body renders the contents of h without the headline itself
+func (e *Exporter) body(h *ast.Headline) (string, error) {
+	doc := &ast.Document{}
+	for _, c := range h.BodyNodes() {
+		if d, ok := c.(*ast.Drawer); ok && d.Name == "PROPERTIES" {
+			continue
+		}
+		doc.Children = append(doc.Children, c)
+	}
+	for _, c := range h.Subheadlines() {
+		doc.Children = append(doc.Children, c)
+	}
+	var b strings.Builder
+	if err := e.content.Export(&b, doc); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+type item struct {
+	entry Entry
+	link  string
+	date  time.Time
+	body  string
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Link    *atomLink   `xml:"link,omitempty"`
+	Updated string      `xml:"updated"`
+	Author  *atomAuthor `xml:"author,omitempty"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Link    *atomLink   `xml:"link,omitempty"`
+	Updated string      `xml:"updated"`
+	Content atomContent `xml:"content"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+func (e *Exporter) atom(title, author string, updated time.Time, items []item) atomFeed {
+	f := atomFeed{Title: title, ID: e.link, Updated: updated.Format(time.RFC3339)}
+	if e.link != "" {
+		f.Link = &atomLink{Href: e.link}
+	}
+	if author != "" {
+		f.Author = &atomAuthor{Name: author}
+	}
+	contentType := "text"
+	if e.html {
+		contentType = "html"
+	}
+	for _, it := range items {
+		entry := atomEntry{
+			Title:   it.entry.Title,
+			ID:      it.link,
+			Updated: it.date.Format(time.RFC3339),
+			Content: atomContent{Type: contentType, Body: it.body},
+		}
+		if e.link != "" {
+			entry.Link = &atomLink{Href: it.link}
+		}
+		f.Entries = append(f.Entries, entry)
+	}
+	return f
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link,omitempty"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+	Description string  `xml:"description"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+func (e *Exporter) rss(title string, updated time.Time, items []item) rssFeed {
+	c := rssChannel{Title: title, Link: e.link, Description: title, LastBuildDate: updated.Format(time.RFC1123Z)}
+	for _, it := range items {
+		ri := rssItem{
+			Title:       it.entry.Title,
+			GUID:        rssGUID{IsPermaLink: e.link != "", Value: it.link},
+			PubDate:     it.date.Format(time.RFC1123Z),
+			Description: it.body,
+		}
+		if e.link != "" {
+			ri.Link = it.link
+		}
+		c.Items = append(c.Items, ri)
+	}
+	return rssFeed{Version: "2.0", Channel: c}
+}
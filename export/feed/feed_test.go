@@ -0,0 +1,107 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+const blog = `#+TITLE: Field Notes
+#+AUTHOR: Ada
+* Posts
+** First post
+:PROPERTIES:
+:PUBDATE: <2024-03-01 Fri>
+:END:
+Hello there.
+** Second post
+:PROPERTIES:
+:EXPORT_DATE: 2024-04-02
+:END:
+More *news*.
+** Draft
+`
+
+func clock() time.Time {
+	return time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+}
+
+func TestEntries(t *testing.T) {
+	entries := Entries(parser.New(lexer.New(blog)).ParseDocument())
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 dated entries, got=%d", len(entries))
+	}
+	if entries[0].Title != "Second post" || entries[1].Title != "First post" {
+		t.Errorf("expected newest first, got %q, %q", entries[0].Title, entries[1].Title)
+	}
+	if entries[1].Anchor != "first-post" {
+		t.Errorf("expected anchor first-post, got=%q", entries[1].Anchor)
+	}
+
+	undated := Entries(parser.New(lexer.New("* One\n* Two\n** Nested\n")).ParseDocument())
+	if len(undated) != 2 || undated[0].Title != "One" {
+		t.Errorf("expected top-level headlines without dates, got %+v", undated)
+	}
+}
+
+func TestExportAtom(t *testing.T) {
+	doc := parser.New(lexer.New(blog)).ParseDocument()
+	var buf bytes.Buffer
+	if err := New(WithLink("https://example.com/notes"), WithClock(clock)).Export(&buf, doc); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	var f atomFeed
+	if err := xml.Unmarshal(buf.Bytes(), &f); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, buf.String())
+	}
+	if f.Title != "Field Notes" || f.Author == nil || f.Author.Name != "Ada" {
+		t.Errorf("unexpected feed metadata %+v", f)
+	}
+	if f.Updated != "2024-04-02T00:00:00Z" {
+		t.Errorf("expected updated from the newest entry, got=%q", f.Updated)
+	}
+	if len(f.Entries) != 2 {
+		t.Fatalf("expected 2 entries, got=%d", len(f.Entries))
+	}
+	e := f.Entries[0]
+	if e.ID != "https://example.com/notes#second-post" || e.Content.Type != "text" || e.Content.Body != "More news." {
+		t.Errorf("unexpected entry %+v", e)
+	}
+}
+
+func TestExportRSS(t *testing.T) {
+	doc := parser.New(lexer.New(blog)).ParseDocument()
+	var buf bytes.Buffer
+	if err := New(WithFormat(RSS), WithLink("https://example.com/notes")).Export(&buf, doc); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	var f rssFeed
+	if err := xml.Unmarshal(buf.Bytes(), &f); err != nil {
+		t.Fatalf("invalid XML: %v", err)
+	}
+	if f.Version != "2.0" || len(f.Channel.Items) != 2 {
+		t.Fatalf("unexpected feed %+v", f)
+	}
+	if item := f.Channel.Items[1]; !strings.HasPrefix(item.PubDate, "Fri, 01 Mar 2024") || item.Description != "Hello there." {
+		t.Errorf("unexpected item %+v", item)
+	}
+}
+
+func TestEntriesSkipArchived(t *testing.T) {
+	doc := parser.New(lexer.New("* One\n* Two :ARCHIVE:\n* Three\n")).ParseDocument()
+	if entries := Entries(doc); len(entries) != 2 || entries[1].Headline.Title != "Three" {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+	var buf bytes.Buffer
+	if err := New(IncludeArchived()).Export(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "Two") {
+		t.Errorf("IncludeArchived did not publish the archived entry:\n%s", buf.String())
+	}
+}
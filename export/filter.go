@@ -0,0 +1,73 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/justyntemme/organelle/ast"
+)
+
+// DocumentFilter transforms a document before it is rendered. backend is
+// the name the document is exported with, so a filter can act on some
+// backends only. A filter may modify doc in place or return a replacement.
+type DocumentFilter func(doc *ast.Document, backend string) (*ast.Document, error)
+
+// OutputFilter transforms the rendered output of a backend
+type OutputFilter func(out []byte, backend string) ([]byte, error)
+
+// Filters are transforms run around a render, like Org's export filters.
+// The zero value runs none.
+type Filters struct {
+	document []DocumentFilter
+	output   []OutputFilter
+}
+
+// AddDocument appends a filter run on the AST before rendering. Filters
+// run in the order they were added.
+func (f *Filters) AddDocument(fn DocumentFilter) {
+	f.document = append(f.document, fn)
+}
+
+// AddOutput appends a filter run on the output after rendering. Filters
+// run in the order they were added.
+func (f *Filters) AddOutput(fn OutputFilter) {
+	f.output = append(f.output, fn)
+}
+
+// Render renders doc to w with backend and opts, passing name to the
+// filters
+func (f *Filters) Render(w io.Writer, doc *ast.Document, name string, backend Backend, opts ...RenderOption) error {
+	for _, fn := range f.document {
+		var err error
+		if doc, err = fn(doc, name); err != nil {
+			return err
+		}
+	}
+	if len(f.output) == 0 {
+		return Render(w, doc, backend, opts...)
+	}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, doc, backend, opts...); err != nil {
+		return err
+	}
+	out := buf.Bytes()
+	for _, fn := range f.output {
+		var err error
+		if out, err = fn(out, name); err != nil {
+			return err
+		}
+	}
+	_, err := w.Write(out)
+	return err
+}
+
+// Export renders doc to w with the registered backend name
+func (f *Filters) Export(w io.Writer, doc *ast.Document, name string, opts ...RenderOption) error {
+	backend, ok := Lookup(name)
+	if !ok {
+		return fmt.Errorf("export: unknown backend %q", name)
+	}
+	return f.Render(w, doc, name, backend, opts...)
+}
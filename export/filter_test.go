@@ -0,0 +1,111 @@
+package export_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/export"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+func TestFilters(t *testing.T) {
+	var f export.Filters
+	var seen []string
+	f.AddDocument(func(doc *ast.Document, backend string) (*ast.Document, error) {
+		seen = append(seen, backend)
+		for _, n := range doc.Children {
+			if h, ok := n.(*ast.Headline); ok {
+				h.Title = "Renamed"
+			}
+		}
+		return doc, nil
+	})
+	f.AddOutput(func(out []byte, backend string) ([]byte, error) {
+		return append([]byte("% generated\n"), out...), nil
+	})
+	f.AddOutput(func(out []byte, backend string) ([]byte, error) {
+		return bytes.ReplaceAll(out, []byte("Body"), []byte("Text")), nil
+	})
+
+	doc := parser.New(lexer.New("* Title\nBody\n")).ParseDocument()
+	var buf bytes.Buffer
+	if err := f.Export(&buf, doc, "text"); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	expected := "% generated\nRenamed\n=======\n\nText\n\n"
+	if buf.String() != expected {
+		t.Errorf("unexpected output\nexpected=%q\ngot=     %q", expected, buf.String())
+	}
+	if len(seen) != 1 || seen[0] != "text" {
+		t.Errorf("expected filter to see backend text, got=%v", seen)
+	}
+}
+
+func TestFiltersRenderOptions(t *testing.T) {
+	var f export.Filters
+	f.AddOutput(func(out []byte, backend string) ([]byte, error) {
+		return bytes.ToUpper(out), nil
+	})
+	doc := parser.New(lexer.New("* Old :ARCHIVE:\nBody\n* New\n")).ParseDocument()
+
+	var buf bytes.Buffer
+	if err := f.Export(&buf, doc, "text"); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("OLD")) || !bytes.Contains(buf.Bytes(), []byte("NEW")) {
+		t.Errorf("expected the archived subtree left out, got=%q", buf.String())
+	}
+
+	buf.Reset()
+	if err := f.Export(&buf, doc, "text", export.IncludeArchived()); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("OLD")) || !bytes.Contains(buf.Bytes(), []byte("BODY")) {
+		t.Errorf("expected IncludeArchived to reach the render, got=%q", buf.String())
+	}
+}
+
+func TestFiltersErrors(t *testing.T) {
+	doc := parser.New(lexer.New("Body\n")).ParseDocument()
+	var f export.Filters
+	if err := f.Export(&bytes.Buffer{}, doc, "missing"); err == nil {
+		t.Error("expected an error for an unknown backend")
+	}
+
+	boom := errors.New("boom")
+	f.AddOutput(func(out []byte, backend string) ([]byte, error) { return nil, boom })
+	var buf bytes.Buffer
+	if err := f.Export(&buf, doc, "text"); !errors.Is(err, boom) {
+		t.Errorf("expected filter error, got=%v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no output after a failed filter, got=%q", buf.String())
+	}
+}
+
+func TestDetectLang(t *testing.T) {
+	input := "* 会議メモ\n今日は新しい parser を試しました。\n結果は良好です。\nPlain English.\n"
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	if _, err := export.DetectLang(export.DetectScript)(doc, "text"); err != nil {
+		t.Fatal(err)
+	}
+	h := doc.Children[0].(*ast.Headline)
+	if h.Lang != "ja" {
+		t.Errorf("expected the headline to be ja, got=%q", h.Lang)
+	}
+	if p := h.Children[0].(*ast.Paragraph); p.Lang != "ja" {
+		t.Errorf("expected the paragraph to be ja, got=%q", p.Lang)
+	}
+	if p := h.Children[2].(*ast.Paragraph); p.Lang != "" {
+		t.Errorf("expected English to be left alone, got=%q", p.Lang)
+	}
+
+	for text, want := range map[string]string{"中文文本": "zh", "한국어": "ko", "漢字とかな": "ja", "plain": ""} {
+		if got := export.DetectScript(text); got != want {
+			t.Errorf("DetectScript(%q): expected %q, got=%q", text, want, got)
+		}
+	}
+}
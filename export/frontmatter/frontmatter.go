@@ -0,0 +1,191 @@
+// Package frontmatter exports documents as static-site pages for Hugo or
+// Jekyll: a YAML or TOML front matter block built from the file keywords
+// and the first headline's properties, followed by the rendered body.
+package frontmatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/export"
+	"github.com/justyntemme/organelle/export/text"
+)
+
+// Format selects the front matter syntax
+type Format int
+
+const (
+	YAML Format = iota // Delimited by ---
+	TOML               // Delimited by +++
+)
+
+// Field is a single front matter entry. Value is a string or a []string.
+type Field struct {
+	Key   string
+	Value any
+}
+
+// Exporter writes front matter followed by the body
+type Exporter struct {
+	format Format
+	body   func() export.Backend
+}
+
+// Option is a functional option for configuring the Exporter
+type Option func(*Exporter)
+
+// WithFormat sets the front matter syntax (default YAML)
+func WithFormat(f Format) Option {
+	return func(e *Exporter) {
+		e.format = f
+	}
+}
+
+// WithBody sets the backend that renders the body (default plain text)
+func WithBody(f func() export.Backend) Option {
+	return func(e *Exporter) {
+		e.body = f
+	}
+}
+
+// New creates a front matter exporter
+func New(opts ...Option) *Exporter {
+	e := &Exporter{body: func() export.Backend { return text.New().Backend() }}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Export writes doc's front matter and body to w. #+TITLE is left out of
+// the body since the site generator renders it from the front matter.
+func (e *Exporter) Export(w io.Writer, doc *ast.Document) error {
+	if err := Write(w, Fields(doc), e.format); err != nil {
+		return err
+	}
+	return export.Render(w, &ast.Document{Children: withoutTitle(doc.Children)}, e.body())
+}
+
+// withoutTitle returns nodes without #+TITLE lines, looking into the
+// zeroth section
+func withoutTitle(nodes []ast.Node) []ast.Node {
+	var out []ast.Node
+	for _, c := range nodes {
+		switch n := c.(type) {
+		case *ast.Keyword:
+			if n != nil && strings.EqualFold(n.Key, "TITLE") {
+				continue
+			}
+		case *ast.Section:
+			c = ast.NewSection(withoutTitle(n.Children))
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+var timestampDateRegex = regexp.MustCompile(`^[<\[](\d{4}-\d{2}-\d{2})(?: [A-Za-z]+)?(?: (\d{1,2}:\d{2}))?[>\]]$`)
+
+// Fields returns the front matter of doc: title, date and tags from
+// #+TITLE, #+DATE and #+FILETAGS, then the properties of the first
+// headline with lowercased keys, in drawer order. A property whose key is
+// already set, by a file keyword or an earlier property, is left out.
+func Fields(doc *ast.Document) []Field {
+	var fields []Field
+	if title := doc.Keyword("TITLE"); title != "" {
+		fields = append(fields, Field{"title", title})
+	}
+	if date := doc.Keyword("DATE"); date != "" {
+		if m := timestampDateRegex.FindStringSubmatch(date); m != nil {
+			date = m[1]
+			if t := m[2]; t != "" {
+				if len(t) == 4 {
+					t = "0" + t
+				}
+				date += "T" + t + ":00"
+			}
+		}
+		fields = append(fields, Field{"date", date})
+	}
+	if tags := doc.FileTags(); len(tags) > 0 {
+		fields = append(fields, Field{"tags", tags})
+	}
+	seen := map[string]bool{}
+	for _, f := range fields {
+		seen[f.Key] = true
+	}
+
+	for _, c := range doc.Children {
+		h, ok := c.(*ast.Headline)
+		if !ok {
+			continue
+		}
+		for _, hc := range h.BodyNodes() {
+			if d, ok := hc.(*ast.Drawer); ok && d.Name == "PROPERTIES" {
+				for _, key := range d.PropertyKeys() {
+					if k := strings.ToLower(key); !seen[k] {
+						seen[k] = true
+						fields = append(fields, Field{k, d.Properties[key]})
+					}
+				}
+			}
+		}
+		break
+	}
+	return fields
+}
+
+var bareDateRegex = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}(T\d{2}:\d{2}:\d{2})?$`)
+
+// Write writes fields as a front matter block in the given format
+func Write(w io.Writer, fields []Field, format Format) error {
+	delim, sep := "---", ": "
+	if format == TOML {
+		delim, sep = "+++", " = "
+	}
+	var b strings.Builder
+	b.WriteString(delim + "\n")
+	for _, f := range fields {
+		b.WriteString(key(f.Key) + sep)
+		switch v := f.Value.(type) {
+		case []string:
+			quoted := make([]string, len(v))
+			for i, s := range v {
+				quoted[i] = quote(s)
+			}
+			b.WriteString("[" + strings.Join(quoted, ", ") + "]")
+		case string:
+			if bareDateRegex.MatchString(v) {
+				b.WriteString(v) // Native date in both YAML and TOML
+			} else {
+				b.WriteString(quote(v))
+			}
+		default:
+			b.WriteString(quote(fmt.Sprint(v)))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(delim + "\n\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var bareKeyRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func key(k string) string {
+	if bareKeyRegex.MatchString(k) {
+		return k
+	}
+	return quote(k)
+}
+
+// quote returns s as a double-quoted string. JSON string syntax is valid
+// in both YAML and TOML.
+func quote(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
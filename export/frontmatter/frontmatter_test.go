@@ -0,0 +1,81 @@
+package frontmatter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+const post = `#+TITLE: Hello "World"
+#+DATE: <2024-05-01 Wed 9:30>
+#+FILETAGS: :go:org:
+* Post
+:PROPERTIES:
+:SLUG: hello-world
+:Draft: true
+:END:
+Body text.
+`
+
+func TestExportYAML(t *testing.T) {
+	doc := parser.New(lexer.New(post)).ParseDocument()
+	var buf bytes.Buffer
+	if err := New().Export(&buf, doc); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	expected := `---
+title: "Hello \"World\""
+date: 2024-05-01T09:30:00
+tags: ["go", "org"]
+slug: "hello-world"
+draft: "true"
+---
+
+`
+	out := buf.String()
+	if !strings.HasPrefix(out, expected) {
+		t.Fatalf("unexpected front matter\nexpected=%q\ngot=%q", expected, out)
+	}
+	body := strings.TrimPrefix(out, expected)
+	if strings.Contains(body, "Hello") || !strings.Contains(body, "Body text.") {
+		t.Errorf("expected body without the title, got=%q", body)
+	}
+}
+
+func TestWriteTOML(t *testing.T) {
+	fields := []Field{{"title", "Notes"}, {"date", "2024-05-01"}, {"odd key", "x"}}
+	var buf bytes.Buffer
+	if err := Write(&buf, fields, TOML); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	expected := "+++\ntitle = \"Notes\"\ndate = 2024-05-01\n\"odd key\" = \"x\"\n+++\n\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got=%q", expected, buf.String())
+	}
+}
+
+func TestFieldsSkipDuplicateKeys(t *testing.T) {
+	input := `#+TITLE: Notes
+#+FILETAGS: :go:
+#+FILETAGS: :org:
+* Post
+:PROPERTIES:
+:DATE: 2024-01-01
+:Title: Other
+:slug: notes
+:SLUG: again
+:END:
+`
+	fields := Fields(parser.New(lexer.New(input)).ParseDocument())
+	var buf bytes.Buffer
+	if err := Write(&buf, fields, TOML); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	expected := "+++\ntitle = \"Notes\"\ntags = [\"go\", \"org\"]\ndate = 2024-01-01\nslug = \"notes\"\n+++\n\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got=%q", expected, buf.String())
+	}
+}
@@ -0,0 +1,268 @@
+// Package ical exports scheduled items as an RFC 5545 iCalendar file so
+// that agenda entries can be subscribed to from calendar applications.
+//
+// TODO headlines become VTODO components with DTSTART taken from
+// SCHEDULED and DUE from DEADLINE. Other headlines with a planning line
+// become one VEVENT per timestamp, prefixed "S: " or "DL: " as in Org's
+// own iCalendar export. Repeaters are translated to RRULE.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/redact"
+)
+
+// Exporter renders the scheduled items of a document as iCalendar
+type Exporter struct {
+	name     string
+	now      func() time.Time
+	archived redact.Archived
+}
+
+// Option is a functional option for configuring the Exporter
+type Option func(*Exporter)
+
+// WithCalendarName sets X-WR-CALNAME. By default the document's #+TITLE
+// is used.
+func WithCalendarName(name string) Option {
+	return func(e *Exporter) {
+		e.name = name
+	}
+}
+
+// WithClock sets the function used for DTSTAMP (default time.Now)
+func WithClock(now func() time.Time) Option {
+	return func(e *Exporter) {
+		e.now = now
+	}
+}
+
+// IncludeArchived exports entries in subtrees tagged :ARCHIVE:; see
+// redact.Archived
+func IncludeArchived() Option {
+	return func(e *Exporter) {
+		e.archived = true
+	}
+}
+
+// New creates an iCalendar exporter
+func New(opts ...Option) *Exporter {
+	e := &Exporter{now: time.Now}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Export writes the scheduled items of doc to w as a VCALENDAR
+func (e *Exporter) Export(w io.Writer, doc *ast.Document) error {
+	bw := bufio.NewWriter(w)
+	stamp := e.now().UTC().Format("20060102T150405Z")
+
+	writeLine(bw, "BEGIN:VCALENDAR")
+	writeLine(bw, "VERSION:2.0")
+	writeLine(bw, "PRODID:-//organelle//Org export//EN")
+	writeLine(bw, "CALSCALE:GREGORIAN")
+	name := e.name
+	if name == "" {
+		name = doc.Keyword("TITLE")
+	}
+	if name != "" {
+		writeLine(bw, "X-WR-CALNAME:"+escapeText(name))
+	}
+	walk(doc.Children, e.archived.For(doc), func(h *ast.Headline) {
+		writeHeadline(bw, h, stamp)
+	})
+	writeLine(bw, "END:VCALENDAR")
+	return bw.Flush()
+}
+
+func walk(nodes []ast.Node, archived redact.Archived, fn func(*ast.Headline)) {
+	for _, n := range nodes {
+		if h, ok := n.(*ast.Headline); ok && !archived.Skip(h) {
+			fn(h)
+			walk(h.Children, archived, fn)
+		}
+	}
+}
+
+func writeHeadline(w *bufio.Writer, h *ast.Headline, stamp string) {
+	scheduled, deadline := h.Scheduled, h.Deadline
+	if scheduled == nil && deadline == nil {
+		return
+	}
+
+	if h.Keyword != "" {
+		writeLine(w, "BEGIN:VTODO")
+		writeLine(w, "UID:"+uid(h, "TODO"))
+		writeLine(w, "DTSTAMP:"+stamp)
+		writeLine(w, "SUMMARY:"+escapeText(h.Title))
+		writeCommon(w, h)
+		if scheduled != nil {
+			writeLine(w, dateProperty("DTSTART", scheduled.Date, scheduled.Time))
+			writeRepeat(w, scheduled)
+		} else {
+			writeRepeat(w, deadline)
+		}
+		if deadline != nil {
+			writeLine(w, dateProperty("DUE", deadline.Date, deadline.Time))
+		}
+		if h.Done {
+			writeLine(w, "STATUS:COMPLETED")
+		} else {
+			writeLine(w, "STATUS:NEEDS-ACTION")
+		}
+		writeLine(w, "END:VTODO")
+		return
+	}
+
+	for _, ev := range []struct {
+		kind, prefix string
+		ts           *ast.Timestamp
+	}{{"SC", "S: ", scheduled}, {"DL", "DL: ", deadline}} {
+		if ev.ts == nil {
+			continue
+		}
+		writeLine(w, "BEGIN:VEVENT")
+		writeLine(w, "UID:"+uid(h, ev.kind))
+		writeLine(w, "DTSTAMP:"+stamp)
+		writeLine(w, "SUMMARY:"+escapeText(ev.prefix+h.Title))
+		writeCommon(w, h)
+		writeLine(w, dateProperty("DTSTART", ev.ts.Date, ev.ts.Time))
+		if end := endProperty(ev.ts); end != "" {
+			writeLine(w, end)
+		}
+		writeRepeat(w, ev.ts)
+		writeLine(w, "END:VEVENT")
+	}
+}
+
+// writeCommon writes the properties shared by events and todos
+func writeCommon(w *bufio.Writer, h *ast.Headline) {
+	if len(h.Tags) > 0 {
+		tags := make([]string, len(h.Tags))
+		for i, t := range h.Tags {
+			tags[i] = escapeText(t)
+		}
+		writeLine(w, "CATEGORIES:"+strings.Join(tags, ","))
+	}
+	if loc, ok := h.Property("LOCATION"); ok {
+		writeLine(w, "LOCATION:"+escapeText(loc))
+	}
+	switch h.Priority {
+	case "A":
+		writeLine(w, "PRIORITY:1")
+	case "B":
+		writeLine(w, "PRIORITY:5")
+	case "C":
+		writeLine(w, "PRIORITY:9")
+	}
+}
+
+func writeRepeat(w *bufio.Writer, ts *ast.Timestamp) {
+	if rule := RRule(ts.Repeat); rule != "" {
+		writeLine(w, "RRULE:"+rule)
+	}
+}
+
+// uid derives a stable identifier from the ID property, falling back to
+// a hash of the title and planning line
+func uid(h *ast.Headline, kind string) string {
+	if id, ok := h.Property("ID"); ok && id != "" {
+		return kind + "-" + id
+	}
+	f := fnv.New64a()
+	f.Write([]byte(h.Title))
+	f.Write([]byte(h.Planning()))
+	return fmt.Sprintf("%s-%016x@organelle", kind, f.Sum64())
+}
+
+// dateProperty formats a DATE or floating DATE-TIME property
+func dateProperty(name, date, clock string) string {
+	d := strings.ReplaceAll(date, "-", "")
+	if clock == "" {
+		return name + ";VALUE=DATE:" + d
+	}
+	return name + ":" + d + "T" + strings.ReplaceAll(clock, ":", "") + "00"
+}
+
+// endProperty returns DTEND for a timestamp: the end of a range, or the
+// following day for all-day entries
+func endProperty(ts *ast.Timestamp) string {
+	switch {
+	case ts.EndDate != "" || ts.EndTime != "":
+		date := ts.EndDate
+		if date == "" {
+			date = ts.Date
+		}
+		if ts.Time == "" {
+			return dateProperty("DTEND", nextDay(date), "")
+		}
+		clock := ts.EndTime
+		if clock == "" {
+			clock = ts.Time
+		}
+		return dateProperty("DTEND", date, clock)
+	case ts.Time == "":
+		return dateProperty("DTEND", nextDay(ts.Date), "")
+	}
+	return ""
+}
+
+func nextDay(date string) string {
+	d, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return date
+	}
+	return d.AddDate(0, 0, 1).Format("2006-01-02")
+}
+
+var frequencies = map[byte]string{
+	'h': "HOURLY",
+	'd': "DAILY",
+	'w': "WEEKLY",
+	'm': "MONTHLY",
+	'y': "YEARLY",
+}
+
+// RRule converts an Org repeater such as "+1w", ".+2d" or "++1m" to an
+// RRULE value. It returns "" if repeat is empty or malformed.
+func RRule(repeat string) string {
+	r, ok := ast.ParseRepeater(repeat)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("FREQ=%s;INTERVAL=%d", frequencies[r.Unit], r.Value)
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, `;`, `\;`, `,`, `\,`, "\n", `\n`)
+
+// escapeText quotes TEXT values per RFC 5545 section 3.3.11
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// writeLine writes a content line terminated by CRLF, folding it at 75
+// octets without splitting UTF-8 sequences
+func writeLine(w *bufio.Writer, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74 // continuation lines start with a space
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
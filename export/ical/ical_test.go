@@ -0,0 +1,131 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+func export(t *testing.T, input string) string {
+	t.Helper()
+	p := parser.New(lexer.New(input))
+	doc := p.ParseDocument()
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	clock := func() time.Time { return time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC) }
+	var buf bytes.Buffer
+	if err := New(WithClock(clock)).Export(&buf, doc); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	return buf.String()
+}
+
+func TestExportTodoAndEvents(t *testing.T) {
+	input := `#+TITLE: Work
+* TODO [#A] Write report :work:
+DEADLINE: <2024-02-10 Sat> SCHEDULED: <2024-02-01 Thu 09:30>
+* Standup
+SCHEDULED: <2024-02-05 Mon 10:00 +1w>
+* Plain headline
+Nothing scheduled here.
+`
+	out := export(t, input)
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"X-WR-CALNAME:Work\r\n",
+		"BEGIN:VTODO\r\n",
+		"SUMMARY:Write report\r\n",
+		"CATEGORIES:work\r\n",
+		"PRIORITY:1\r\n",
+		"DTSTART:20240201T093000\r\n",
+		"DUE;VALUE=DATE:20240210\r\n",
+		"STATUS:NEEDS-ACTION\r\n",
+		"BEGIN:VEVENT\r\n",
+		"SUMMARY:S: Standup\r\n",
+		"DTSTART:20240205T100000\r\n",
+		"RRULE:FREQ=WEEKLY;INTERVAL=1\r\n",
+		"DTSTAMP:20240101T120000Z\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Plain headline") {
+		t.Errorf("expected unscheduled headline to be skipped, got:\n%s", out)
+	}
+	if n := strings.Count(out, "BEGIN:VEVENT"); n != 1 {
+		t.Errorf("expected 1 VEVENT, got=%d", n)
+	}
+}
+
+func TestRRule(t *testing.T) {
+	tests := map[string]string{
+		"+1w":  "FREQ=WEEKLY;INTERVAL=1",
+		".+2d": "FREQ=DAILY;INTERVAL=2",
+		"++1m": "FREQ=MONTHLY;INTERVAL=1",
+		"+1y":  "FREQ=YEARLY;INTERVAL=1",
+		"":     "",
+		"+w":   "",
+		"+1q":  "",
+	}
+	for in, want := range tests {
+		if got := RRule(in); got != want {
+			t.Errorf("RRule(%q): expected %q, got=%q", in, want, got)
+		}
+	}
+}
+
+func TestLineFolding(t *testing.T) {
+	title := strings.Repeat("long title, ", 12)
+	out := export(t, "* Meeting "+title+"\nSCHEDULED: <2024-03-01 Fri>\n")
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line exceeds 75 octets: %q", line)
+		}
+	}
+	if !strings.Contains(out, `long title\, `) {
+		t.Errorf("expected commas to be escaped, got:\n%s", out)
+	}
+	if !strings.Contains(out, "DTEND;VALUE=DATE:20240302\r\n") {
+		t.Errorf("expected all-day DTEND on the following day, got:\n%s", out)
+	}
+}
+
+func TestExportSkipsArchived(t *testing.T) {
+	input := "* TODO Active\nSCHEDULED: <2024-01-15 Mon>\n* Old :ARCHIVE:\n** TODO Nested\nSCHEDULED: <2024-01-10 Wed>\n"
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	clock := func() time.Time { return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) }
+	var buf bytes.Buffer
+	if err := New(WithClock(clock)).Export(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.Contains(out, "Active") || strings.Contains(out, "Nested") {
+		t.Errorf("archived entry not skipped:\n%s", out)
+	}
+	buf.Reset()
+	if err := New(WithClock(clock), IncludeArchived()).Export(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "Nested") {
+		t.Errorf("IncludeArchived did not export the archived entry:\n%s", buf.String())
+	}
+}
+
+func TestExportCustomDoneKeyword(t *testing.T) {
+	input := "#+TODO: TODO | CANCELLED\n* CANCELLED Trip\nDEADLINE: <2024-01-15 Mon>\n"
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	var buf bytes.Buffer
+	if err := New().Export(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "STATUS:COMPLETED") {
+		t.Errorf("CANCELLED not exported as completed:\n%s", buf.String())
+	}
+}
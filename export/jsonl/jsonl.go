@@ -0,0 +1,158 @@
+// Package jsonl exports a document as a stream of flat JSON records, one
+// per headline, for consumption by jq, search indexers and data pipelines.
+package jsonl
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/redact"
+)
+
+// Record is the flattened view of a single headline
+type Record struct {
+	Title      string            `json:"title"`
+	Path       []string          `json:"path"` // Titles of the ancestors, outermost first
+	Level      int               `json:"level"`
+	Line       int               `json:"line"`
+	Todo       string            `json:"todo,omitempty"`
+	Priority   string            `json:"priority,omitempty"`
+	Tags       []string          `json:"tags,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"` // Encoded with sorted keys
+	Scheduled  string            `json:"scheduled,omitempty"`  // 2024-01-15 or 2024-01-15 10:00
+	Deadline   string            `json:"deadline,omitempty"`
+	Body       string            `json:"body,omitempty"` // Plain text of the headline's own content
+}
+
+// Option is a functional option for configuring Write and Records
+type Option func(*config)
+
+type config struct {
+	archived redact.Archived
+}
+
+// IncludeArchived emits records for subtrees tagged :ARCHIVE:; see
+// redact.Archived
+func IncludeArchived() Option {
+	return func(c *config) {
+		c.archived = true
+	}
+}
+
+// Write streams one JSON object per headline to w, in document order
+func Write(w io.Writer, doc *ast.Document, opts ...Option) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return newConfig(doc, opts).walk(doc.Children, nil, func(r Record) error {
+		return enc.Encode(r)
+	})
+}
+
+// Records returns the records Write would emit
+func Records(doc *ast.Document, opts ...Option) []Record {
+	var out []Record
+	_ = newConfig(doc, opts).walk(doc.Children, nil, func(r Record) error {
+		out = append(out, r)
+		return nil
+	})
+	return out
+}
+
+func newConfig(doc *ast.Document, opts []Option) *config {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.archived = c.archived.For(doc)
+	return c
+}
+
+func (c *config) walk(nodes []ast.Node, path []string, emit func(Record) error) error {
+	for _, n := range nodes {
+		hl, ok := n.(*ast.Headline)
+		if !ok || c.archived.Skip(hl) {
+			continue
+		}
+		if err := emit(newRecord(hl, path)); err != nil {
+			return err
+		}
+		childPath := append(path[:len(path):len(path)], hl.Title)
+		if err := c.walk(hl.Children, childPath, emit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newRecord(hl *ast.Headline, path []string) Record {
+	r := Record{
+		Title:    hl.Title,
+		Path:     append([]string{}, path...),
+		Level:    hl.Level,
+		Line:     hl.Token.Line,
+		Todo:     hl.Keyword,
+		Priority: hl.Priority,
+		Tags:     append([]string(nil), hl.Tags...),
+	}
+
+	var body []string
+	r.Scheduled, r.Deadline = planningDate(hl.Scheduled), planningDate(hl.Deadline)
+	for _, c := range hl.BodyNodes() {
+		switch n := c.(type) {
+		case *ast.Drawer:
+			if n.Name == "PROPERTIES" && len(n.Properties) > 0 {
+				// A copy, so that changing the record leaves the document alone
+				r.Properties = make(map[string]string, len(n.Properties))
+				for k, v := range n.Properties {
+					r.Properties[k] = v
+				}
+			}
+		case *ast.Paragraph:
+			body = append(body, paragraphText(n))
+		case *ast.List:
+			body = append(body, listText(n)...)
+		case *ast.Block:
+			body = append(body, n.Content)
+		}
+	}
+	r.Body = strings.Join(body, "\n")
+	return r
+}
+
+// planningDate formats ts as 2024-01-15 or 2024-01-15 10:00, or "" if ts
+// is nil
+func planningDate(ts *ast.Timestamp) string {
+	if ts == nil {
+		return ""
+	}
+	if ts.Time != "" {
+		return ts.Date + " " + ts.Time
+	}
+	return ts.Date
+}
+
+func paragraphText(p *ast.Paragraph) string {
+	if len(p.Inline) == 0 {
+		return p.Content
+	}
+	var out strings.Builder
+	for _, e := range p.Inline {
+		out.WriteString(e.PlainText())
+	}
+	return out.String()
+}
+
+func listText(l *ast.List) []string {
+	var out []string
+	for _, item := range l.Items {
+		out = append(out, item.Content)
+		for _, c := range item.Children {
+			if nested, ok := c.(*ast.List); ok {
+				out = append(out, listText(nested)...)
+			}
+		}
+	}
+	return out
+}
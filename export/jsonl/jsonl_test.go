@@ -0,0 +1,119 @@
+package jsonl
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/export/exporttest"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+func TestWrite(t *testing.T) {
+	input := `#+TITLE: Tasks
+* Project :work:
+** TODO [#A] Write report :urgent:
+SCHEDULED: <2024-01-15 Mon 10:00> DEADLINE: <2024-01-20 Sat>
+:PROPERTIES:
+:CLIENT: Acme
+:END:
+Draft the *quarterly* numbers.
+- include charts
+** DONE Call Bob
+`
+	doc := parser.New(lexer.New(input)).ParseDocument()
+
+	var buf bytes.Buffer
+	if err := Write(&buf, doc); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 records, got=%d\n%s", len(lines), buf.String())
+	}
+
+	var r Record
+	if err := json.Unmarshal([]byte(lines[1]), &r); err != nil {
+		t.Fatalf("invalid JSON line: %v", err)
+	}
+
+	if r.Title != "Write report" || r.Todo != "TODO" || r.Priority != "A" {
+		t.Errorf("unexpected headline fields: %+v", r)
+	}
+	if len(r.Path) != 1 || r.Path[0] != "Project" {
+		t.Errorf("expected path [Project], got=%v", r.Path)
+	}
+	if r.Scheduled != "2024-01-15 10:00" || r.Deadline != "2024-01-20" {
+		t.Errorf("unexpected planning: scheduled=%q deadline=%q", r.Scheduled, r.Deadline)
+	}
+	if r.Properties["CLIENT"] != "Acme" {
+		t.Errorf("expected CLIENT property, got=%v", r.Properties)
+	}
+	if r.Body != "Draft the quarterly numbers.\ninclude charts" {
+		t.Errorf("unexpected body %q", r.Body)
+	}
+}
+
+func TestRecordsPathIsolation(t *testing.T) {
+	doc := parser.New(lexer.New("* A\n** B\n*** C\n** D\n")).ParseDocument()
+	records := Records(doc)
+
+	if len(records) != 4 {
+		t.Fatalf("expected 4 records, got=%d", len(records))
+	}
+	if got := strings.Join(records[2].Path, "/"); got != "A/B" {
+		t.Errorf("expected path A/B for C, got=%q", got)
+	}
+	if got := strings.Join(records[3].Path, "/"); got != "A" {
+		t.Errorf("expected path A for D, got=%q", got)
+	}
+}
+
+func TestRecordsSkipArchived(t *testing.T) {
+	doc := parser.New(lexer.New("* A\n* B :ARCHIVE:\n** C\n* D\n")).ParseDocument()
+	titles := func(records []Record) string {
+		var out []string
+		for _, r := range records {
+			out = append(out, r.Title)
+		}
+		return strings.Join(out, ",")
+	}
+	if got := titles(Records(doc)); got != "A,D" {
+		t.Errorf("got %q, want A,D", got)
+	}
+	if got := titles(Records(doc, IncludeArchived())); got != "A,B,C,D" {
+		t.Errorf("with IncludeArchived got %q, want A,B,C,D", got)
+	}
+}
+
+func TestWriteGolden(t *testing.T) {
+	input := `* TODO Task :b:a:
+:PROPERTIES:
+:ZONE: eu
+:OWNER: ada
+:CLIENT: Acme
+:END:
+* Other
+`
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	var buf bytes.Buffer
+	if err := Write(&buf, doc); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	exporttest.GoldenFile(t, exporttest.Path(t), buf.Bytes())
+}
+
+func TestRecordsCopyProperties(t *testing.T) {
+	doc := parser.New(lexer.New("* Task :a:\n:PROPERTIES:\n:OWNER: ada\n:END:\n")).ParseDocument()
+	r := Records(doc)[0]
+	r.Properties["OWNER"] = "bob"
+	r.Tags[0] = "b"
+	hl := doc.Children[0].(*ast.Headline)
+	if owner, _ := hl.Property("OWNER"); owner != "ada" || hl.Tags[0] != "a" {
+		t.Errorf("expected the document unchanged, got OWNER=%q tags=%v", owner, hl.Tags)
+	}
+}
@@ -0,0 +1,66 @@
+package export
+
+import (
+	"unicode"
+
+	"github.com/justyntemme/organelle/ast"
+)
+
+// LangDetector returns the language code of text, such as "en" or "ja",
+// or "" if it cannot tell
+type LangDetector func(text string) string
+
+// DetectLang returns a document filter that sets the Lang of every
+// headline and paragraph for which detect returns a code. Nodes it cannot
+// tell keep their Lang, so backends fall back to the #+LANGUAGE keyword.
+func DetectLang(detect LangDetector) DocumentFilter {
+	return func(doc *ast.Document, backend string) (*ast.Document, error) {
+		detectNodes(doc.Children, detect)
+		return doc, nil
+	}
+}
+
+func detectNodes(nodes []ast.Node, detect LangDetector) {
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case *ast.Headline:
+			if lang := detect(n.Title); lang != "" {
+				n.Lang = lang
+			}
+			detectNodes(n.Children, detect)
+		case *ast.Paragraph:
+			if lang := detect(n.Content); lang != "" {
+				n.Lang = lang
+			}
+		case *ast.Section:
+			detectNodes(n.Children, detect)
+		case *ast.List:
+			for _, item := range n.Items {
+				detectNodes(item.Children, detect)
+			}
+		case *ast.FootnoteDefinition:
+			detectNodes(n.Children, detect)
+		}
+	}
+}
+
+// DetectScript is a LangDetector that only looks at the writing system:
+// text with kana is "ja", with Hangul "ko" and with other Han characters
+// "zh". It returns "" for everything else, including Latin text.
+func DetectScript(text string) string {
+	han := false
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			return "ja"
+		case unicode.Is(unicode.Hangul, r):
+			return "ko"
+		case unicode.Is(unicode.Han, r):
+			han = true
+		}
+	}
+	if han {
+		return "zh"
+	}
+	return ""
+}
@@ -0,0 +1,354 @@
+// Package latex exports documents as LaTeX source suitable for pdflatex.
+package latex
+
+import (
+	"io"
+	"strings"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/entity"
+	"github.com/justyntemme/organelle/export"
+	"github.com/justyntemme/organelle/parser"
+)
+
+// sectionCommands maps headline levels to sectioning commands; deeper
+// levels reuse the last entry
+var sectionCommands = []string{"section", "subsection", "subsubsection", "paragraph", "subparagraph"}
+
+// Exporter renders documents as LaTeX
+type Exporter struct {
+	documentClass string
+	packages      []string
+	standalone    bool
+}
+
+// Option is a functional option for configuring the Exporter
+type Option func(*Exporter)
+
+// WithDocumentClass sets the \documentclass (default "article")
+func WithDocumentClass(class string) Option {
+	return func(e *Exporter) {
+		e.documentClass = class
+	}
+}
+
+// WithPackages adds \usepackage lines to the preamble
+func WithPackages(packages ...string) Option {
+	return func(e *Exporter) {
+		e.packages = append(e.packages, packages...)
+	}
+}
+
+// WithBodyOnly omits the preamble and document environment so the output
+// can be \input into another file
+func WithBodyOnly() Option {
+	return func(e *Exporter) {
+		e.standalone = false
+	}
+}
+
+// New creates a LaTeX exporter
+func New(opts ...Option) *Exporter {
+	e := &Exporter{
+		documentClass: "article",
+		packages:      []string{"[utf8]{inputenc}", "[T1]{fontenc}", "{amssymb}", "[normalem]{ulem}", "{listings}", "{hyperref}"},
+		standalone:    true,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Export writes doc as LaTeX to w. #+TITLE, #+AUTHOR and #+DATE populate
+// \title, \author and \date.
+func (e *Exporter) Export(w io.Writer, doc *ast.Document) error {
+	return export.Render(w, doc, e.Backend())
+}
+
+// Backend returns the export.Backend that renders with e's settings
+func (e *Exporter) Backend() export.Backend {
+	return &backend{Exporter: e}
+}
+
+func init() {
+	export.Register("latex", func() export.Backend { return New().Backend() })
+}
+
+// backend implements the export.Backend hooks
+type backend struct {
+	export.Base
+	*Exporter
+}
+
+func (b *backend) Extension() string {
+	return ".tex"
+}
+
+func (b *backend) Begin(c *export.Context) error {
+	if !b.standalone {
+		return nil
+	}
+	doc := c.Document()
+	c.Printf("\\documentclass{%s}\n", b.documentClass)
+	for _, pkg := range b.packages {
+		if !strings.HasPrefix(pkg, "[") && !strings.HasPrefix(pkg, "{") {
+			pkg = "{" + pkg + "}"
+		}
+		c.Printf("\\usepackage%s\n", pkg)
+	}
+	title := doc.Keyword("TITLE")
+	if title != "" {
+		c.Printf("\\title{%s}\n", Escape(title))
+	}
+	if author := doc.Keyword("AUTHOR"); author != "" {
+		c.Printf("\\author{%s}\n", Escape(author))
+	}
+	if date := doc.Keyword("DATE"); date != "" {
+		c.Printf("\\date{%s}\n", Escape(date))
+	}
+	c.WriteString("\\begin{document}\n")
+	if title != "" {
+		c.WriteString("\\maketitle\n")
+	}
+	c.WriteString("\n")
+	return nil
+}
+
+func (b *backend) End(c *export.Context) error {
+	if b.standalone {
+		c.WriteString("\\end{document}\n")
+	}
+	return nil
+}
+
+func (b *backend) Headline(c *export.Context, h *ast.Headline) error {
+	level := h.Level
+	if level > len(sectionCommands) {
+		level = len(sectionCommands)
+	}
+	title := inline(h.Title)
+	if h.Keyword != "" {
+		title = "\\textbf{" + Escape(h.Keyword) + "} " + title
+	}
+	c.Printf("\\%s{%s}\n", sectionCommands[level-1], title)
+	if id, ok := h.Property("CUSTOM_ID"); ok {
+		c.Printf("\\label{%s}\n", id)
+	}
+	c.WriteString("\n")
+	return c.Render(h.Children)
+}
+
+func (b *backend) Paragraph(c *export.Context, p *ast.Paragraph) error {
+	c.WriteString(RenderInline(p.Inline))
+	c.WriteString("\n")
+	// A blank line ends the paragraph unless the next line continues it
+	if c.Next() != nil && !c.ContinuesParagraph() {
+		c.WriteString("\n")
+	}
+	return nil
+}
+
+func (b *backend) List(c *export.Context, l *ast.List) error {
+	env := "itemize"
+	if l.Ordered {
+		env = "enumerate"
+	}
+	c.Printf("\\begin{%s}\n", env)
+	for _, item := range l.Items {
+		switch item.Checkbox {
+		case ast.CheckboxUnchecked:
+			c.WriteString("\\item[$\\square$] ")
+		case ast.CheckboxChecked:
+			c.WriteString("\\item[$\\boxtimes$] ")
+		case ast.CheckboxPartial:
+			c.WriteString("\\item[$\\boxminus$] ")
+		default:
+			c.WriteString("\\item ")
+		}
+		c.WriteString(inline(item.Content))
+		c.WriteString("\n")
+		if err := c.Render(item.Children); err != nil {
+			return err
+		}
+	}
+	c.Printf("\\end{%s}\n\n", env)
+	return nil
+}
+func (b *backend) Block(c *export.Context, blk *ast.Block) error {
+	content := strings.TrimSuffix(blk.Content, "\n")
+	switch blk.Type {
+	case "SRC":
+		if blk.Language != "" {
+			c.Printf("\\begin{lstlisting}[language=%s]\n", blk.Language)
+		} else {
+			c.WriteString("\\begin{lstlisting}\n")
+		}
+		c.WriteString(content)
+		c.WriteString("\n\\end{lstlisting}\n")
+	case "EXAMPLE":
+		c.WriteString("\\begin{verbatim}\n")
+		c.WriteString(content)
+		c.WriteString("\n\\end{verbatim}\n")
+	case "EXPORT":
+		if strings.EqualFold(blk.Language, "latex") {
+			c.WriteString(content)
+			c.WriteString("\n")
+		}
+	case "QUOTE", "VERSE", "CENTER":
+		env := strings.ToLower(blk.Type)
+		c.Printf("\\begin{%s}\n", env)
+		lines := strings.Split(content, "\n")
+		for i, line := range lines {
+			c.WriteString(Escape(line))
+			if blk.Type == "VERSE" && i < len(lines)-1 {
+				c.WriteString(" \\\\")
+			}
+			c.WriteString("\n")
+		}
+		c.Printf("\\end{%s}\n", env)
+	default:
+		c.WriteString("\\begin{verbatim}\n")
+		c.WriteString(content)
+		c.WriteString("\n\\end{verbatim}\n")
+	}
+	c.WriteString("\n")
+	return nil
+}
+
+func (b *backend) Table(c *export.Context, t *ast.Table) error {
+	cols := 0
+	for _, row := range t.Rows {
+		if len(row.Cells) > cols {
+			cols = len(row.Cells)
+		}
+	}
+	if cols == 0 {
+		c.WriteString("\n")
+		return nil
+	}
+	// Alignment cookies choose the column types; Org's default is left
+	spec := make([]byte, cols)
+	for i, align := range t.Alignments() {
+		switch align {
+		case ast.AlignCenter:
+			spec[i] = 'c'
+		case ast.AlignRight:
+			spec[i] = 'r'
+		default:
+			spec[i] = 'l'
+		}
+	}
+	c.Printf("\\begin{tabular}{%s}\n", spec)
+	for _, row := range t.Rows {
+		if row.IsCookieRow() {
+			continue
+		}
+		if row.Separator {
+			c.WriteString("\\hline\n")
+			continue
+		}
+		cells := make([]string, cols)
+		for i, cell := range row.Cells {
+			cells[i] = Escape(cell)
+		}
+		c.WriteString(strings.Join(cells, " & "))
+		c.WriteString(" \\\\\n")
+	}
+	c.WriteString("\\end{tabular}\n\n")
+	return nil
+}
+
+func (b *backend) HorizontalRule(c *export.Context, hr *ast.HorizontalRule) error {
+	c.WriteString("\\noindent\\rule{\\textwidth}{0.5pt}\n\n")
+	return nil
+}
+
+// FootnoteDefinition writes the definition as a paragraph led by its
+// label in superscript, matching how references render
+func (b *backend) FootnoteDefinition(c *export.Context, f *ast.FootnoteDefinition) error {
+	c.WriteString("\\noindent\\textsuperscript{" + Escape(f.Label) + "}~")
+	for _, n := range f.Children {
+		if p, ok := n.(*ast.Paragraph); ok {
+			c.WriteString(RenderInline(p.Inline) + "\n")
+		}
+	}
+	c.WriteString("\n")
+	return nil
+}
+
+// Keywords, comments, drawers and calls produce no output
+
+// inline renders text, such as a headline title or list item, whose
+// markup the parser leaves unparsed
+func inline(text string) string {
+	if nodes, _ := parser.ParseFragment(text); len(nodes) == 1 {
+		if p, ok := nodes[0].(*ast.Paragraph); ok {
+			return RenderInline(p.Inline)
+		}
+	}
+	return Escape(text)
+}
+
+// RenderInline converts parsed inline elements to LaTeX markup
+func RenderInline(elems []ast.InlineElement) string {
+	var out strings.Builder
+	for _, e := range elems {
+		switch e.Type {
+		case ast.InlineText:
+			out.WriteString(Escape(e.Content))
+		case ast.InlineBold:
+			out.WriteString("\\textbf{" + RenderInline(e.Children) + "}")
+		case ast.InlineItalic:
+			out.WriteString("\\emph{" + RenderInline(e.Children) + "}")
+		case ast.InlineUnderline:
+			out.WriteString("\\uline{" + RenderInline(e.Children) + "}")
+		case ast.InlineStrikethrough:
+			out.WriteString("\\sout{" + RenderInline(e.Children) + "}")
+		case ast.InlineCode, ast.InlineVerbatim:
+			out.WriteString("\\texttt{" + Escape(e.Content) + "}")
+		case ast.InlineLink:
+			if len(e.Children) > 0 {
+				out.WriteString("\\href{" + escapeURL(e.URL) + "}{" + RenderInline(e.Children) + "}")
+			} else {
+				out.WriteString("\\url{" + escapeURL(e.URL) + "}")
+			}
+		case ast.InlineFootnoteRef:
+			out.WriteString("\\textsuperscript{" + Escape(e.Content) + "}")
+		case ast.InlineEntity:
+			if ent, ok := entity.Lookup(e.Content); ok {
+				if ent.LaTeXMath {
+					out.WriteString("$" + ent.LaTeX + "$")
+				} else {
+					out.WriteString(ent.LaTeX)
+				}
+			}
+		default:
+			out.WriteString(Escape(e.PlainText()))
+		}
+	}
+	return out.String()
+}
+
+var escaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`#`, `\#`,
+	`$`, `\$`,
+	`%`, `\%`,
+	`&`, `\&`,
+	`_`, `\_`,
+	`~`, `\textasciitilde{}`,
+	`^`, `\textasciicircum{}`,
+)
+
+// Escape quotes LaTeX special characters in plain text
+func Escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// escapeURL quotes the characters hyperref cannot take verbatim
+func escapeURL(u string) string {
+	return strings.NewReplacer(`%`, `\%`, `#`, `\#`).Replace(u)
+}
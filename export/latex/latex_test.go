@@ -0,0 +1,106 @@
+package latex
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+func render(t *testing.T, input string, opts ...Option) string {
+	t.Helper()
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	var buf bytes.Buffer
+	if err := New(opts...).Export(&buf, doc); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	return buf.String()
+}
+
+func TestExportDocument(t *testing.T) {
+	input := `#+TITLE: Report & Notes
+#+AUTHOR: Jane Doe
+* TODO Introduction
+Costs rose 5% with *bold* and /italic/ and ~x_y~ and \alpha{}.
+** Details
+- [X] done
+- plain
+Steps:
+1. first
+#+BEGIN_SRC go
+fmt.Println("hi")
+#+END_SRC
+| a | b |
+|---+---|
+| 1 | 2 |
+`
+	out := render(t, input)
+
+	wants := []string{
+		"\\documentclass{article}",
+		"\\title{Report \\& Notes}",
+		"\\author{Jane Doe}",
+		"\\maketitle",
+		"\\section{\\textbf{TODO} Introduction}",
+		"Costs rose 5\\% with \\textbf{bold} and \\emph{italic} and \\texttt{x\\_y} and $\\alpha$.",
+		"\\subsection{Details}",
+		"\\begin{itemize}\n\\item[$\\boxtimes$] done\n\\item plain\n\\end{itemize}",
+		"\\begin{enumerate}\n\\item first\n\\end{enumerate}",
+		"\\begin{lstlisting}[language=go]\nfmt.Println(\"hi\")\n\\end{lstlisting}",
+		"\\begin{tabular}{ll}\na & b \\\\\n\\hline\n1 & 2 \\\\\n\\end{tabular}",
+		"\\end{document}",
+	}
+	for _, want := range wants {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\n%s", want, out)
+		}
+	}
+}
+
+func TestExportBodyOnly(t *testing.T) {
+	out := render(t, "* Heading\n[[https://go.dev][Go]] site\n", WithBodyOnly())
+
+	if strings.Contains(out, "\\documentclass") || strings.Contains(out, "\\end{document}") {
+		t.Errorf("body-only output contains preamble:\n%s", out)
+	}
+	if !strings.Contains(out, "\\href{https://go.dev}{Go} site") {
+		t.Errorf("expected hyperlink in output:\n%s", out)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	if got := Escape(`a\b {c} ~^`); got != `a\textbackslash{}b \{c\} \textasciitilde{}\textasciicircum{}` {
+		t.Errorf("Escape = %q", got)
+	}
+}
+
+func TestExportSeparatesParagraphs(t *testing.T) {
+	out := render(t, "a\nb\n\nc\n", WithBodyOnly())
+	if !strings.Contains(out, "a\nb\n\nc\n") {
+		t.Errorf("expected a blank line between the paragraphs only:\n%q", out)
+	}
+}
+
+func TestExportInlineTitleAndItems(t *testing.T) {
+	out := render(t, "* Use *bold* & ~a_b~\n- see [[https://go.dev][Go]] & /more/\n- 50% off\n", WithBodyOnly())
+	wants := []string{
+		"\\section{Use \\textbf{bold} \\& \\texttt{a\\_b}}",
+		"\\item see \\href{https://go.dev}{Go} \\& \\emph{more}\n",
+		"\\item 50\\% off\n",
+	}
+	for _, want := range wants {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\n%s", want, out)
+		}
+	}
+}
+
+func TestExportTableAlignment(t *testing.T) {
+	out := render(t, "| Item | Qty |\n| <l> | <r> |\n|------+-----|\n| pen  | 2   |\n", WithBodyOnly())
+	want := "\\begin{tabular}{lr}\nItem & Qty \\\\\n\\hline\npen & 2 \\\\\n\\end{tabular}"
+	if !strings.Contains(out, want) {
+		t.Errorf("output missing %q\n%s", want, out)
+	}
+}
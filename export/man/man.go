@@ -0,0 +1,323 @@
+// Package man exports documents as man(7) pages so that command-line
+// tools can keep their manuals in Org.
+//
+// #+TITLE, #+MAN_SECTION and #+DATE fill the .TH line. Level 1 headlines
+// become .SH sections and level 2 headlines .SS subsections; deeper
+// headlines are bold paragraph tags. Tables are written for tbl(1).
+package man
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/entity"
+	"github.com/justyntemme/organelle/export"
+	"github.com/justyntemme/organelle/parser"
+)
+
+// Exporter renders documents as man pages
+type Exporter struct {
+	section string
+	manual  string
+}
+
+// Option is a functional option for configuring the Exporter
+type Option func(*Exporter)
+
+// WithSection sets the manual section used when the document has no
+// #+MAN_SECTION keyword (default "1")
+func WithSection(section string) Option {
+	return func(e *Exporter) {
+		e.section = section
+	}
+}
+
+// WithManual sets the manual name shown in the page header, such as
+// "User Commands"
+func WithManual(name string) Option {
+	return func(e *Exporter) {
+		e.manual = name
+	}
+}
+
+// New creates a man page exporter
+func New(opts ...Option) *Exporter {
+	e := &Exporter{section: "1"}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Export writes doc as a man page to w
+func (e *Exporter) Export(w io.Writer, doc *ast.Document) error {
+	return export.Render(w, doc, e.Backend())
+}
+
+// Backend returns an export.Backend that renders with e's settings. The
+// backend keeps per-render state and must not be shared between renders.
+func (e *Exporter) Backend() export.Backend {
+	return &backend{Exporter: e}
+}
+
+func init() {
+	export.Register("man", func() export.Backend { return New().Backend() })
+}
+
+// backend implements the export.Backend hooks
+type backend struct {
+	export.Base
+	*Exporter
+	inPara bool // The previous node was a paragraph line
+	depth  int  // Depth of list items being rendered
+}
+
+// Extension returns the man page file extension, the section number
+func (b *backend) Extension() string {
+	return "." + b.section
+}
+
+func (b *backend) Begin(c *export.Context) error {
+	doc := c.Document()
+	section := b.section
+	if s := doc.Keyword("MAN_SECTION"); s != "" {
+		section = s
+	}
+	c.Printf(".TH %s %s %s \"\" %s\n",
+		quote(strings.ToUpper(doc.Keyword("TITLE"))), quote(section), quote(doc.Keyword("DATE")), quote(b.manual))
+	return nil
+}
+
+func (b *backend) Headline(c *export.Context, h *ast.Headline) error {
+	b.inPara = false
+	title := h.Title
+	if h.Keyword != "" {
+		title = h.Keyword + " " + title
+	}
+	switch h.Level {
+	case 1:
+		c.WriteString(".SH " + quote(strings.ToUpper(title)) + "\n")
+	case 2:
+		c.WriteString(".SS " + quote(title) + "\n")
+	default:
+		c.WriteString(".TP\n" + line(`\fB`+Escape(title)+`\fR`) + "\n")
+	}
+	return c.Render(h.Children)
+}
+
+func (b *backend) Paragraph(c *export.Context, p *ast.Paragraph) error {
+	text := strings.TrimSpace(RenderInline(p.Inline))
+	if text == "" {
+		return nil
+	}
+	// Consecutive lines form one paragraph; roff fills them
+	if !b.inPara {
+		if b.depth > 0 {
+			c.WriteString(".IP\n")
+		} else {
+			c.WriteString(".PP\n")
+		}
+	}
+	c.WriteString(line(text) + "\n")
+	b.inPara = true
+	return nil
+}
+
+func (b *backend) List(c *export.Context, l *ast.List) error {
+	b.inPara = false
+	if b.depth > 0 {
+		c.WriteString(".RS\n")
+	}
+	for i, item := range l.Items {
+		tag, width := `\(bu`, 2
+		if l.Ordered {
+			tag, width = fmt.Sprintf("%d.", i+1), 4
+		}
+		switch item.Checkbox {
+		case ast.CheckboxUnchecked:
+			tag = "[ ]"
+		case ast.CheckboxChecked:
+			tag = "[X]"
+		case ast.CheckboxPartial:
+			tag = "[-]"
+		}
+		if item.Checkbox != ast.CheckboxNone {
+			width = 4
+		}
+		c.Printf(".IP \"%s\" %d\n", tag, width)
+		c.WriteString(line(renderItem(item.Content)) + "\n")
+
+		b.depth++
+		err := c.Render(item.Children)
+		b.depth--
+		b.inPara = false
+		if err != nil {
+			return err
+		}
+	}
+	if b.depth > 0 {
+		c.WriteString(".RE\n")
+	}
+	return nil
+}
+
+func (b *backend) Block(c *export.Context, blk *ast.Block) error {
+	b.inPara = false
+	content := strings.TrimSuffix(blk.Content, "\n")
+	switch blk.Type {
+	case "EXPORT":
+		if strings.EqualFold(blk.Language, "man") {
+			c.WriteString(content + "\n")
+		}
+	case "QUOTE", "VERSE", "CENTER":
+		c.WriteString(".RS\n")
+		if blk.Type == "VERSE" {
+			c.WriteString(".nf\n")
+		}
+		for _, l := range strings.Split(content, "\n") {
+			c.WriteString(line(Escape(l)) + "\n")
+		}
+		if blk.Type == "VERSE" {
+			c.WriteString(".fi\n")
+		}
+		c.WriteString(".RE\n")
+	default:
+		c.WriteString(".PP\n.RS 4\n.nf\n")
+		for _, l := range strings.Split(content, "\n") {
+			c.WriteString(line(literal(l)) + "\n")
+		}
+		c.WriteString(".fi\n.RE\n")
+	}
+	return nil
+}
+
+// Table writes a tbl(1) table. Rows above the first separator are the
+// header; the separator becomes a horizontal rule.
+func (b *backend) Table(c *export.Context, t *ast.Table) error {
+	b.inPara = false
+	cols := 0
+	for _, row := range t.Rows {
+		cols = max(cols, len(row.Cells))
+	}
+	if cols == 0 {
+		return nil
+	}
+	c.WriteString(".TS\ntab(\t);\n")
+	c.WriteString(strings.TrimSpace(strings.Repeat("l ", cols)) + ".\n")
+	for _, row := range t.Rows {
+		if row.Separator {
+			c.WriteString("_\n")
+			continue
+		}
+		cells := make([]string, cols)
+		for i, cell := range row.Cells {
+			cells[i] = Escape(cell)
+		}
+		c.WriteString(line(strings.Join(cells, "\t")) + "\n")
+	}
+	c.WriteString(".TE\n")
+	return nil
+}
+
+func (b *backend) HorizontalRule(c *export.Context, hr *ast.HorizontalRule) error {
+	b.inPara = false
+	c.WriteString(".PP\n\\l'\\n(.lu'\n")
+	return nil
+}
+
+// FootnoteDefinition writes the definition as a paragraph led by its
+// label in brackets, matching how references render
+func (b *backend) FootnoteDefinition(c *export.Context, f *ast.FootnoteDefinition) error {
+	b.inPara = false
+	text := []string{"[" + Escape(f.Label) + "]"}
+	for _, n := range f.Children {
+		if p, ok := n.(*ast.Paragraph); ok {
+			if t := strings.TrimSpace(RenderInline(p.Inline)); t != "" {
+				text = append(text, t)
+			}
+		}
+	}
+	c.WriteString(".PP\n" + line(strings.Join(text, " ")) + "\n")
+	return nil
+}
+
+func (b *backend) Keyword(c *export.Context, k *ast.Keyword) error {
+	b.inPara = false
+	return nil
+}
+
+func (b *backend) Drawer(c *export.Context, d *ast.Drawer) error {
+	b.inPara = false
+	return nil
+}
+
+// renderItem renders the text of a list item, whose inline markup the
+// parser leaves unparsed
+func renderItem(content string) string {
+	if nodes, _ := parser.ParseFragment(content); len(nodes) == 1 {
+		if p, ok := nodes[0].(*ast.Paragraph); ok {
+			return RenderInline(p.Inline)
+		}
+	}
+	return Escape(content)
+}
+
+// RenderInline renders inline elements with roff font escapes
+func RenderInline(elems []ast.InlineElement) string {
+	var out strings.Builder
+	for _, e := range elems {
+		switch e.Type {
+		case ast.InlineText:
+			out.WriteString(Escape(e.Content))
+		case ast.InlineBold:
+			out.WriteString(`\fB` + RenderInline(e.Children) + `\fR`)
+		case ast.InlineItalic, ast.InlineUnderline:
+			out.WriteString(`\fI` + RenderInline(e.Children) + `\fR`)
+		case ast.InlineCode, ast.InlineVerbatim:
+			out.WriteString(`\fB` + literal(e.Content) + `\fR`)
+		case ast.InlineLink:
+			if len(e.Children) == 0 {
+				out.WriteString(`\fI` + Escape(e.URL) + `\fR`)
+			} else {
+				out.WriteString(RenderInline(e.Children) + ` <\fI` + Escape(e.URL) + `\fR>`)
+			}
+		case ast.InlineEntity:
+			if ent, ok := entity.Lookup(e.Content); ok {
+				out.WriteString(ent.UTF8)
+			}
+		case ast.InlineFootnoteRef:
+			out.WriteString("[" + Escape(e.Content) + "]")
+		case ast.InlineTimestamp:
+			out.WriteString(Escape(e.Content))
+		default:
+			out.WriteString(RenderInline(e.Children))
+		}
+	}
+	return out.String()
+}
+
+// Escape quotes backslashes in plain text
+func Escape(s string) string {
+	return strings.ReplaceAll(s, `\`, `\e`)
+}
+
+// literal escapes text that must be typed exactly, such as commands and
+// options, keeping hyphens as ASCII minus signs
+func literal(s string) string {
+	return strings.ReplaceAll(Escape(s), "-", `\-`)
+}
+
+// line protects an output line that would otherwise be read as a request
+func line(s string) string {
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		return `\&` + s
+	}
+	return s
+}
+
+// quote returns s as a macro argument
+func quote(s string) string {
+	return `"` + strings.ReplaceAll(Escape(s), `"`, `\(dq`) + `"`
+}
@@ -0,0 +1,100 @@
+package man
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+func TestExport(t *testing.T) {
+	input := `#+TITLE: orgtool
+#+DATE: 2024-05-01
+* Name
+orgtool - convert *Org* files
+* Options
+** Output
+Write to =-o file= with /care/.
+.dot leads this line
+- \ backslash
+- *bold* item
+| flag | meaning |
+|------+---------|
+| -v   | verbose |
+`
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	var buf bytes.Buffer
+	if err := New(WithManual("User Commands")).Export(&buf, doc); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	expected := ".TH \"ORGTOOL\" \"1\" \"2024-05-01\" \"\" \"User Commands\"\n" +
+		".SH \"NAME\"\n" +
+		".PP\norgtool - convert \\fBOrg\\fR files\n" +
+		".SH \"OPTIONS\"\n" +
+		".SS \"Output\"\n" +
+		".PP\nWrite to \\fB\\-o file\\fR with \\fIcare\\fR.\n\\&.dot leads this line\n" +
+		".IP \"\\(bu\" 2\n\\e backslash\n" +
+		".IP \"\\(bu\" 2\n\\fBbold\\fR item\n" +
+		".TS\ntab(\t);\nl l.\nflag\tmeaning\n_\n-v\tverbose\n.TE\n"
+	if buf.String() != expected {
+		t.Errorf("unexpected output\nexpected=%q\ngot=     %q", expected, buf.String())
+	}
+}
+
+func TestSection(t *testing.T) {
+	tests := []struct {
+		input    string
+		opts     []Option
+		expected string
+	}{
+		{"#+TITLE: x\n", nil, ".TH \"X\" \"1\" \"\" \"\" \"\"\n"},
+		{"#+TITLE: x\n", []Option{WithSection("5")}, ".TH \"X\" \"5\" \"\" \"\" \"\"\n"},
+		{"#+TITLE: x\n#+MAN_SECTION: 8\n", []Option{WithSection("5")}, ".TH \"X\" \"8\" \"\" \"\" \"\"\n"},
+	}
+	for _, tt := range tests {
+		doc := parser.New(lexer.New(tt.input)).ParseDocument()
+		var buf bytes.Buffer
+		if err := New(tt.opts...).Export(&buf, doc); err != nil {
+			t.Fatalf("export failed: %v", err)
+		}
+		if buf.String() != tt.expected {
+			t.Errorf("input %q: expected=%q, got=%q", tt.input, tt.expected, buf.String())
+		}
+	}
+}
+
+func TestNestedList(t *testing.T) {
+	doc := parser.New(lexer.New("- one\n  - two\n")).ParseDocument()
+	var buf bytes.Buffer
+	if err := New().Export(&buf, doc); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	expected := ".TH \"\" \"1\" \"\" \"\" \"\"\n" +
+		".IP \"\\(bu\" 2\none\n.RS\n.IP \"\\(bu\" 2\ntwo\n.RE\n"
+	if buf.String() != expected {
+		t.Errorf("unexpected output\nexpected=%q\ngot=     %q", expected, buf.String())
+	}
+}
+
+func TestExportTimestamp(t *testing.T) {
+	doc := parser.New(lexer.New("Meet on <2024-02-02 Fri>.\n")).ParseDocument()
+	var buf bytes.Buffer
+	if err := New().Export(&buf, doc); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("\nMeet on <2024-02-02 Fri>.\n")) {
+		t.Errorf("expected the timestamp in the output, got=%q", buf.String())
+	}
+}
+
+func TestExportFootnoteDefinition(t *testing.T) {
+	doc := parser.New(lexer.New("A claim[fn:1].\n\n[fn:1] The source,\nover two lines.\n")).ParseDocument()
+	var buf bytes.Buffer
+	if err := New().Export(&buf, doc); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if expected := "\n.PP\nA claim[1].\n.PP\n[1] The source, over two lines.\n"; !bytes.Contains(buf.Bytes(), []byte(expected)) {
+		t.Errorf("expected %q in the output, got=%q", expected, buf.String())
+	}
+}
@@ -0,0 +1,377 @@
+// Package pandoc exports documents as Pandoc's JSON AST, the format read
+// by `pandoc -f json`, so any Pandoc writer (docx, epub, odt, ...) can
+// produce the final output.
+package pandoc
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/justyntemme/organelle/anchor"
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/entity"
+	"github.com/justyntemme/organelle/export"
+)
+
+// APIVersion is the pandoc-types version the output conforms to
+var APIVersion = []int{1, 23, 1}
+
+// Element is a Pandoc AST element: a tag and its contents
+type Element struct {
+	T string `json:"t"`
+	C any    `json:"c,omitempty"`
+}
+
+// Exporter renders documents as Pandoc JSON
+type Exporter struct {
+	indent bool
+}
+
+// Option is a functional option for configuring the Exporter
+type Option func(*Exporter)
+
+// WithIndent pretty-prints the JSON output
+func WithIndent() Option {
+	return func(e *Exporter) {
+		e.indent = true
+	}
+}
+
+// New creates a Pandoc JSON exporter
+func New(opts ...Option) *Exporter {
+	e := &Exporter{}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Export writes doc as Pandoc JSON to w. #+TITLE, #+AUTHOR and #+DATE
+// become document metadata.
+func (e *Exporter) Export(w io.Writer, doc *ast.Document) error {
+	return export.Render(w, doc, e.Backend())
+}
+
+// Backend returns the export.Backend that renders with e's settings
+func (e *Exporter) Backend() export.Backend {
+	return &backend{Exporter: e}
+}
+
+func init() {
+	export.Register("pandoc", func() export.Backend { return New().Backend() })
+}
+
+// backend collects blocks while the document is walked and encodes them
+// in End
+type backend struct {
+	export.Base
+	*Exporter
+	anchors *anchor.Set
+	doc     *ast.Document
+	noting  map[string]bool // Footnotes being expanded, to stop cycles
+	blocks  []Element
+	out     *[]Element // Where blocks go: the document or a list item
+	para    *Element   // Open Para that following paragraph lines join
+}
+
+func (b *backend) Extension() string {
+	return ".json"
+}
+
+func (b *backend) add(el Element) {
+	*b.out = append(*b.out, el)
+	b.para = nil
+}
+
+func (b *backend) Begin(c *export.Context) error {
+	b.anchors = anchor.New(c.Document())
+	b.doc, b.noting = c.Document(), map[string]bool{}
+	b.out = &b.blocks
+	return nil
+}
+
+func (b *backend) End(c *export.Context) error {
+	meta := map[string]Element{}
+	doc := c.Document()
+	for _, key := range []string{"TITLE", "AUTHOR", "DATE"} {
+		if v := doc.Keyword(key); v != "" {
+			meta[strings.ToLower(key)] = Element{T: "MetaInlines", C: Words(v)}
+		}
+	}
+	blocks := b.blocks
+	if blocks == nil {
+		blocks = []Element{}
+	}
+	enc := json.NewEncoder(c)
+	enc.SetEscapeHTML(false)
+	if b.indent {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(map[string]any{
+		"pandoc-api-version": APIVersion,
+		"meta":               meta,
+		"blocks":             blocks,
+	})
+}
+
+func (b *backend) Headline(c *export.Context, h *ast.Headline) error {
+	title := Words(h.Title)
+	if h.Keyword != "" {
+		title = append([]Element{{T: "Span", C: []any{attr("", h.Keyword), Words(h.Keyword)}}, {T: "Space"}}, title...)
+	}
+	b.add(Element{T: "Header", C: []any{h.Level, attr(b.anchors.For(h)), title}})
+	return c.Render(h.Children)
+}
+
+func (b *backend) Paragraph(c *export.Context, p *ast.Paragraph) error {
+	inlines := b.inlines(p.Inline)
+	if b.para != nil {
+		if _, ok := c.Prev().(*ast.Paragraph); ok {
+			joined := append(b.para.C.([]Element), Element{T: "SoftBreak"})
+			b.para.C = append(joined, inlines...)
+			return nil
+		}
+	}
+	b.add(Element{T: "Para", C: inlines})
+	b.para = &(*b.out)[len(*b.out)-1]
+	return nil
+}
+
+func (b *backend) List(c *export.Context, l *ast.List) error {
+	items := make([][]Element, len(l.Items))
+	parent := b.out
+	for i, item := range l.Items {
+		plain := Words(item.Content)
+		switch item.Checkbox {
+		case ast.CheckboxUnchecked:
+			plain = append([]Element{{T: "Str", C: "☐"}, {T: "Space"}}, plain...)
+		case ast.CheckboxChecked:
+			plain = append([]Element{{T: "Str", C: "☒"}, {T: "Space"}}, plain...)
+		case ast.CheckboxPartial:
+			plain = append([]Element{{T: "Str", C: "[-]"}, {T: "Space"}}, plain...)
+		}
+		items[i] = []Element{{T: "Plain", C: plain}}
+		b.out, b.para = &items[i], nil
+		if err := c.Render(item.Children); err != nil {
+			return err
+		}
+	}
+	b.out = parent
+	if l.Ordered {
+		style := []any{1, Element{T: "Decimal"}, Element{T: "Period"}}
+		b.add(Element{T: "OrderedList", C: []any{style, items}})
+	} else {
+		b.add(Element{T: "BulletList", C: items})
+	}
+	return nil
+}
+
+func (b *backend) Block(c *export.Context, blk *ast.Block) error {
+	content := strings.TrimSuffix(blk.Content, "\n")
+	switch blk.Type {
+	case "SRC":
+		b.add(Element{T: "CodeBlock", C: []any{attr("", blk.Language), content}})
+	case "EXPORT":
+		b.add(Element{T: "RawBlock", C: []any{strings.ToLower(blk.Language), content}})
+	case "QUOTE":
+		b.add(Element{T: "BlockQuote", C: paras(content)})
+	case "CENTER":
+		b.add(Element{T: "Div", C: []any{attr("", "center"), paras(content)}})
+	case "VERSE":
+		var lines [][]Element
+		for _, line := range strings.Split(content, "\n") {
+			lines = append(lines, Words(line))
+		}
+		b.add(Element{T: "LineBlock", C: lines})
+	default:
+		b.add(Element{T: "CodeBlock", C: []any{attr(""), content}})
+	}
+	return nil
+}
+
+func (b *backend) Table(c *export.Context, t *ast.Table) error {
+	cols := 0
+	for _, row := range t.Rows {
+		if len(row.Cells) > cols {
+			cols = len(row.Cells)
+		}
+	}
+	if cols == 0 {
+		return nil
+	}
+
+	// Rows above the first separator form the header, as in Org
+	head, body := []any{}, []any{}
+	end := t.HeaderEnd()
+	for i, row := range t.Rows {
+		if i == end {
+			head, body = body, []any{}
+		}
+		if row.Separator || row.IsCookieRow() {
+			continue
+		}
+		cells := make([]any, cols)
+		for j := range cells {
+			blocks := []Element{}
+			if j < len(row.Cells) && row.Cells[j] != "" {
+				blocks = []Element{{T: "Plain", C: Words(row.Cells[j])}}
+			}
+			cells[j] = []any{attr(""), Element{T: "AlignDefault"}, 1, 1, blocks}
+		}
+		body = append(body, []any{attr(""), cells})
+	}
+
+	specs := make([]any, cols)
+	aligns := t.Alignments()
+	for i := range specs {
+		specs[i] = []any{Element{T: alignments[aligns[i]]}, Element{T: "ColWidthDefault"}}
+	}
+	b.add(Element{T: "Table", C: []any{
+		attr(""),
+		[]any{nil, []any{}},
+		specs,
+		[]any{attr(""), head},
+		[]any{[]any{attr(""), 0, []any{}, body}},
+		[]any{attr(""), []any{}},
+	}})
+	return nil
+}
+
+// alignments maps column alignments to Pandoc's
+var alignments = map[ast.Align]string{
+	ast.AlignDefault: "AlignDefault",
+	ast.AlignLeft:    "AlignLeft",
+	ast.AlignCenter:  "AlignCenter",
+	ast.AlignRight:   "AlignRight",
+}
+
+func (b *backend) HorizontalRule(c *export.Context, hr *ast.HorizontalRule) error {
+	b.add(Element{T: "HorizontalRule"})
+	return nil
+}
+
+// FootnoteDefinition writes nothing: definitions become Note inlines at
+// their references, which Pandoc writers number and place
+func (b *backend) FootnoteDefinition(c *export.Context, f *ast.FootnoteDefinition) error {
+	return nil
+}
+
+// inlines is Inlines with footnote references turned into notes holding
+// their definitions
+func (b *backend) inlines(elems []ast.InlineElement) []Element {
+	return convert(elems, b.note)
+}
+
+// note returns a Note with the blocks of the definition of label, or false
+// if it has none or is already being expanded
+func (b *backend) note(label string) (Element, bool) {
+	def, ok := b.doc.Footnote(label)
+	if !ok || b.noting[label] {
+		return Element{}, false
+	}
+	b.noting[label] = true
+	defer delete(b.noting, label)
+	para := []Element{}
+	for _, n := range def.Children {
+		if p, ok := n.(*ast.Paragraph); ok {
+			if len(para) > 0 {
+				para = append(para, Element{T: "SoftBreak"})
+			}
+			para = append(para, b.inlines(p.Inline)...)
+		}
+	}
+	return Element{T: "Note", C: []Element{{T: "Para", C: para}}}, true
+}
+
+// Keywords, comments, drawers and calls produce no output
+
+// Inlines converts inline elements to Pandoc inlines. Footnote references
+// become their label in superscript; the exporter turns them into notes.
+func Inlines(elems []ast.InlineElement) []Element {
+	return convert(elems, nil)
+}
+
+// convert converts inline elements, using note, if not nil, for footnote
+// references
+func convert(elems []ast.InlineElement, note func(label string) (Element, bool)) []Element {
+	out := []Element{}
+	for _, e := range elems {
+		switch e.Type {
+		case ast.InlineText:
+			out = append(out, Words(e.Content)...)
+		case ast.InlineBold:
+			out = append(out, Element{T: "Strong", C: convert(e.Children, note)})
+		case ast.InlineItalic:
+			out = append(out, Element{T: "Emph", C: convert(e.Children, note)})
+		case ast.InlineUnderline:
+			out = append(out, Element{T: "Underline", C: convert(e.Children, note)})
+		case ast.InlineStrikethrough:
+			out = append(out, Element{T: "Strikeout", C: convert(e.Children, note)})
+		case ast.InlineCode, ast.InlineVerbatim:
+			out = append(out, Element{T: "Code", C: []any{attr(""), e.Content}})
+		case ast.InlineLink:
+			desc := convert(e.Children, note)
+			if len(e.Children) == 0 {
+				desc = []Element{{T: "Str", C: e.URL}}
+			}
+			out = append(out, Element{T: "Link", C: []any{attr(""), desc, []string{e.URL, ""}}})
+		case ast.InlineEntity:
+			if ent, ok := entity.Lookup(e.Content); ok {
+				out = append(out, Element{T: "Str", C: ent.UTF8})
+			}
+		case ast.InlineFootnoteRef:
+			if note != nil {
+				if n, ok := note(e.Content); ok {
+					out = append(out, n)
+					continue
+				}
+			}
+			out = append(out, Element{T: "Superscript", C: []Element{{T: "Str", C: e.Content}}})
+		default:
+			out = append(out, Words(e.PlainText())...)
+		}
+	}
+	return out
+}
+
+// Words splits plain text into Str elements separated by Space
+func Words(s string) []Element {
+	out := []Element{}
+	if s != "" && strings.TrimLeft(s, " \t") != s {
+		out = append(out, Element{T: "Space"})
+	}
+	for i, w := range strings.Fields(s) {
+		if i > 0 {
+			out = append(out, Element{T: "Space"})
+		}
+		out = append(out, Element{T: "Str", C: w})
+	}
+	if len(out) > 0 && strings.TrimRight(s, " \t") != s {
+		out = append(out, Element{T: "Space"})
+	}
+	return out
+}
+
+// paras splits block content into Para elements on blank lines
+func paras(content string) []Element {
+	out := []Element{}
+	for _, p := range strings.Split(content, "\n\n") {
+		if strings.TrimSpace(p) != "" {
+			out = append(out, Element{T: "Para", C: Words(p)})
+		}
+	}
+	return out
+}
+
+// attr builds an Attr triple of identifier, non-empty classes and no
+// key-values
+func attr(id string, classes ...string) []any {
+	nonEmpty := []string{}
+	for _, class := range classes {
+		if class != "" {
+			nonEmpty = append(nonEmpty, class)
+		}
+	}
+	return []any{id, nonEmpty, [][]string{}}
+}
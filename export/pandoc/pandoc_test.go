@@ -0,0 +1,140 @@
+package pandoc
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+type document struct {
+	Version []int `json:"pandoc-api-version"`
+	Meta    map[string]json.RawMessage
+	Blocks  []struct {
+		T string
+		C json.RawMessage
+	}
+}
+
+func render(t *testing.T, input string) document {
+	t.Helper()
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	var buf bytes.Buffer
+	if err := New().Export(&buf, doc); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	var out document
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	return out
+}
+
+func TestExport(t *testing.T) {
+	out := render(t, `#+TITLE: Guide
+* TODO Install Go
+First line with *bold*
+second line.
+- one
+- [X] two
+#+BEGIN_SRC go
+fmt.Println()
+#+END_SRC
+`)
+	if len(out.Version) != 3 || out.Version[0] != 1 {
+		t.Errorf("unexpected API version %v", out.Version)
+	}
+	if _, ok := out.Meta["title"]; !ok {
+		t.Error("expected title metadata")
+	}
+
+	var types []string
+	for _, b := range out.Blocks {
+		types = append(types, b.T)
+	}
+	expected := []string{"Header", "Para", "BulletList", "CodeBlock"}
+	if len(types) != len(expected) {
+		t.Fatalf("expected blocks %v, got=%v", expected, types)
+	}
+	for i := range expected {
+		if types[i] != expected[i] {
+			t.Errorf("block %d: expected %s, got=%s", i, expected[i], types[i])
+		}
+	}
+
+	var header []json.RawMessage
+	json.Unmarshal(out.Blocks[0].C, &header)
+	if string(header[0]) != "1" || string(header[1]) != `["install-go",[],[]]` {
+		t.Errorf("unexpected header %s", out.Blocks[0].C)
+	}
+
+	para := string(out.Blocks[1].C)
+	expectedPara := `[{"t":"Str","c":"First"},{"t":"Space"},{"t":"Str","c":"line"},{"t":"Space"},{"t":"Str","c":"with"},{"t":"Space"},{"t":"Strong","c":[{"t":"Str","c":"bold"}]},{"t":"SoftBreak"},{"t":"Str","c":"second"},{"t":"Space"},{"t":"Str","c":"line."}]`
+	if para != expectedPara {
+		t.Errorf("unexpected paragraph\nexpected=%s\ngot=     %s", expectedPara, para)
+	}
+
+	if code := string(out.Blocks[3].C); code != `[["",["go"],[]],"fmt.Println()"]` {
+		t.Errorf("unexpected code block %s", code)
+	}
+}
+
+func TestTableHeader(t *testing.T) {
+	out := render(t, "| Name | Age |\n|------+-----|\n| Ada  | 36  |\n")
+	if len(out.Blocks) != 1 || out.Blocks[0].T != "Table" {
+		t.Fatalf("expected a single table, got %+v", out.Blocks)
+	}
+	var table []json.RawMessage
+	json.Unmarshal(out.Blocks[0].C, &table)
+	if len(table) != 6 {
+		t.Fatalf("expected 6 table fields, got=%d", len(table))
+	}
+	var head []json.RawMessage
+	json.Unmarshal(table[3], &head)
+	var headRows []json.RawMessage
+	json.Unmarshal(head[1], &headRows)
+	if len(headRows) != 1 {
+		t.Errorf("expected 1 header row, got=%d", len(headRows))
+	}
+}
+
+func TestFootnoteNote(t *testing.T) {
+	out := render(t, "A claim[fn:1].\n\n[fn:1] The *source*,\nover two lines.\n")
+	if len(out.Blocks) != 1 {
+		t.Fatalf("expected the definition to move into the paragraph, got %+v", out.Blocks)
+	}
+	expected := `[{"t":"Str","c":"A"},{"t":"Space"},{"t":"Str","c":"claim"},{"t":"Note","c":[{"t":"Para","c":[{"t":"Str","c":"The"},{"t":"Space"},{"t":"Strong","c":[{"t":"Str","c":"source"}]},{"t":"Str","c":","},{"t":"SoftBreak"},{"t":"Str","c":"over"},{"t":"Space"},{"t":"Str","c":"two"},{"t":"Space"},{"t":"Str","c":"lines."}]}]},{"t":"Str","c":"."}]`
+	if got := string(out.Blocks[0].C); got != expected {
+		t.Errorf("unexpected paragraph\nexpected=%s\ngot=     %s", expected, got)
+	}
+
+	out = render(t, "See[fn:x] and[fn:none].\n\n[fn:x] Loops[fn:x].\n")
+	got := string(out.Blocks[0].C)
+	if !strings.Contains(got, `{"t":"Note","c":[{"t":"Para","c":[{"t":"Str","c":"Loops"},{"t":"Superscript","c":[{"t":"Str","c":"x"}]}`) ||
+		!strings.Contains(got, `{"t":"Superscript","c":[{"t":"Str","c":"none"}]}`) {
+		t.Errorf("expected a self-reference and an undefined label in superscript, got=%s", got)
+	}
+}
+
+func TestTableAlignment(t *testing.T) {
+	out := render(t, "| Item | Qty |\n| <l> | <r> |\n|------+-----|\n| pen  | 2   |\n")
+	var table []json.RawMessage
+	json.Unmarshal(out.Blocks[0].C, &table)
+	if got := string(table[2]); got != `[[{"t":"AlignLeft"},{"t":"ColWidthDefault"}],[{"t":"AlignRight"},{"t":"ColWidthDefault"}]]` {
+		t.Errorf("unexpected column specs %s", got)
+	}
+	if bytes.Contains(out.Blocks[0].C, []byte("<r>")) {
+		t.Errorf("expected the cookie row to be dropped, got %s", out.Blocks[0].C)
+	}
+
+	out = render(t, "| a |\n|---|\n")
+	json.Unmarshal(out.Blocks[0].C, &table)
+	var head []json.RawMessage
+	json.Unmarshal(table[3], &head)
+	if string(head[1]) != "[]" {
+		t.Errorf("expected no header without rows below the separator, got %s", head[1])
+	}
+}
@@ -0,0 +1,245 @@
+// Package slack renders documents in Slack's mrkdwn dialect so bots can
+// post Org subtrees as formatted messages. Slack has no headings, so
+// headlines become bold lines; tables and code go into ``` fences.
+package slack
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/entity"
+	"github.com/justyntemme/organelle/export"
+	"github.com/justyntemme/organelle/parser"
+)
+
+// Exporter renders documents as mrkdwn
+type Exporter struct{}
+
+// Option is a functional option for configuring the Exporter
+type Option func(*Exporter)
+
+// New creates a Slack mrkdwn exporter
+func New(opts ...Option) *Exporter {
+	e := &Exporter{}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Export writes doc as mrkdwn to w
+func (e *Exporter) Export(w io.Writer, doc *ast.Document) error {
+	return export.Render(w, doc, e.Backend())
+}
+
+// ExportHeadline writes the subtree rooted at h as mrkdwn to w
+func (e *Exporter) ExportHeadline(w io.Writer, h *ast.Headline) error {
+	return e.Export(w, &ast.Document{Children: []ast.Node{h}})
+}
+
+// Backend returns an export.Backend that renders with e's settings. The
+// backend keeps per-render state and must not be shared between renders.
+func (e *Exporter) Backend() export.Backend {
+	return &backend{Exporter: e}
+}
+
+func init() {
+	export.Register("slack", func() export.Backend { return New().Backend() })
+}
+
+// backend implements the export.Backend hooks
+type backend struct {
+	export.Base
+	*Exporter
+	para  []string
+	depth int // Depth of list items being rendered
+}
+
+func (b *backend) Extension() string {
+	return ".txt"
+}
+
+func (b *backend) Headline(c *export.Context, h *ast.Headline) error {
+	title := Escape(h.Title)
+	if h.Keyword != "" {
+		title = h.Keyword + " " + title
+	}
+	c.WriteString("*" + title + "*\n")
+	return c.Render(h.Children)
+}
+
+func (b *backend) Paragraph(c *export.Context, p *ast.Paragraph) error {
+	if text := strings.TrimSpace(RenderInline(p.Inline)); text != "" {
+		b.para = append(b.para, text)
+	}
+	if c.ContinuesParagraph() || len(b.para) == 0 {
+		return nil
+	}
+	// Slack keeps line breaks, so the lines of a paragraph are joined
+	c.WriteString(strings.Join(b.para, " ") + "\n\n")
+	b.para = b.para[:0]
+	return nil
+}
+
+func (b *backend) List(c *export.Context, l *ast.List) error {
+	indent := strings.Repeat("    ", b.depth)
+	for i, item := range l.Items {
+		bullet := "• "
+		if l.Ordered {
+			bullet = fmt.Sprintf("%d. ", i+1)
+		}
+		switch item.Checkbox {
+		case ast.CheckboxUnchecked:
+			bullet += "☐ "
+		case ast.CheckboxChecked:
+			bullet += "☑ "
+		case ast.CheckboxPartial:
+			bullet += "◩ "
+		}
+		c.WriteString(indent + bullet + renderItem(item.Content) + "\n")
+
+		b.depth++
+		err := c.Render(item.Children)
+		b.depth--
+		if err != nil {
+			return err
+		}
+	}
+	// Nested lists run on within their parent list
+	if b.depth == 0 {
+		c.WriteString("\n")
+	}
+	return nil
+}
+
+func (b *backend) Block(c *export.Context, blk *ast.Block) error {
+	content := strings.TrimSuffix(blk.Content, "\n")
+	switch blk.Type {
+	case "EXPORT", "COMMENT":
+		return nil
+	case "QUOTE", "VERSE":
+		for _, line := range strings.Split(content, "\n") {
+			c.WriteString(strings.TrimRight("> "+Escape(line), " ") + "\n")
+		}
+	default:
+		c.WriteString("```\n" + content + "\n```\n")
+	}
+	c.WriteString("\n")
+	return nil
+}
+
+// Table renders tables as aligned text in a code fence, since mrkdwn has
+// no table syntax
+func (b *backend) Table(c *export.Context, t *ast.Table) error {
+	var widths []int
+	for _, row := range t.Rows {
+		for i, cell := range row.Cells {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+	c.WriteString("```\n")
+	for _, row := range t.Rows {
+		cells := make([]string, len(widths))
+		for i, width := range widths {
+			if row.Separator {
+				cells[i] = strings.Repeat("-", width)
+				continue
+			}
+			cell := ""
+			if i < len(row.Cells) {
+				cell = row.Cells[i]
+			}
+			cells[i] = cell + strings.Repeat(" ", width-utf8.RuneCountInString(cell))
+		}
+		sep := " | "
+		if row.Separator {
+			sep = "-+-"
+		}
+		c.WriteString(strings.TrimRight(strings.Join(cells, sep), " ") + "\n")
+	}
+	c.WriteString("```\n\n")
+	return nil
+}
+
+func (b *backend) HorizontalRule(c *export.Context, hr *ast.HorizontalRule) error {
+	c.WriteString("───\n\n")
+	return nil
+}
+
+// FootnoteDefinition writes the definition as a paragraph led by its
+// label in brackets, matching how references render
+func (b *backend) FootnoteDefinition(c *export.Context, f *ast.FootnoteDefinition) error {
+	text := []string{"[" + f.Label + "]"}
+	for _, n := range f.Children {
+		if p, ok := n.(*ast.Paragraph); ok {
+			if t := strings.TrimSpace(RenderInline(p.Inline)); t != "" {
+				text = append(text, t)
+			}
+		}
+	}
+	c.WriteString(strings.Join(text, " ") + "\n\n")
+	return nil
+}
+
+// Keywords, comments, drawers and calls produce no output
+
+// renderItem renders the text of a list item, whose inline markup the
+// parser leaves unparsed
+func renderItem(content string) string {
+	if nodes, _ := parser.ParseFragment(content); len(nodes) == 1 {
+		if p, ok := nodes[0].(*ast.Paragraph); ok {
+			return RenderInline(p.Inline)
+		}
+	}
+	return Escape(content)
+}
+
+// RenderInline renders inline elements as mrkdwn
+func RenderInline(elems []ast.InlineElement) string {
+	var out strings.Builder
+	for _, e := range elems {
+		switch e.Type {
+		case ast.InlineText:
+			out.WriteString(Escape(e.Content))
+		case ast.InlineBold:
+			out.WriteString("*" + RenderInline(e.Children) + "*")
+		case ast.InlineItalic:
+			out.WriteString("_" + RenderInline(e.Children) + "_")
+		case ast.InlineStrikethrough:
+			out.WriteString("~" + RenderInline(e.Children) + "~")
+		case ast.InlineCode, ast.InlineVerbatim:
+			out.WriteString("`" + e.Content + "`")
+		case ast.InlineLink:
+			if len(e.Children) == 0 {
+				out.WriteString("<" + e.URL + ">")
+			} else {
+				out.WriteString("<" + e.URL + "|" + RenderInline(e.Children) + ">")
+			}
+		case ast.InlineEntity:
+			if ent, ok := entity.Lookup(e.Content); ok {
+				out.WriteString(ent.UTF8)
+			}
+		case ast.InlineFootnoteRef:
+			out.WriteString("[" + e.Content + "]")
+		case ast.InlineTimestamp:
+			out.WriteString(Escape(e.Content))
+		default:
+			// mrkdwn has no underline
+			out.WriteString(RenderInline(e.Children))
+		}
+	}
+	return out.String()
+}
+
+var escaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Escape replaces the three characters Slack requires to be escaped
+func Escape(s string) string {
+	return escaper.Replace(s)
+}
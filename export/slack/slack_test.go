@@ -0,0 +1,82 @@
+package slack
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+func TestExport(t *testing.T) {
+	input := `* TODO Release 1.2
+Ship *today* with /care/ and =make release=
+after the freeze & review.
+- tag the build
+  - push <tags>
+- see [[https://example.com/notes][notes]]
+#+BEGIN_SRC sh
+make release
+#+END_SRC
+`
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	var buf bytes.Buffer
+	if err := New().Export(&buf, doc); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	expected := "*TODO Release 1.2*\n" +
+		"Ship *today* with _care_ and `make release` after the freeze &amp; review.\n\n" +
+		"• tag the build\n" +
+		"    • push &lt;tags&gt;\n" +
+		"• see <https://example.com/notes|notes>\n\n" +
+		"```\nmake release\n```\n\n"
+	if buf.String() != expected {
+		t.Errorf("unexpected output\nexpected=%q\ngot=     %q", expected, buf.String())
+	}
+}
+
+func TestExportHeadline(t *testing.T) {
+	doc := parser.New(lexer.New("* One\nfirst\n* Two\n| a | bb |\n")).ParseDocument()
+	var buf bytes.Buffer
+	if err := New().ExportHeadline(&buf, doc.Children[1].(*ast.Headline)); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	expected := "*Two*\n```\na | bb\n```\n\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got=%q", expected, buf.String())
+	}
+}
+
+func TestExportSeparatesParagraphs(t *testing.T) {
+	doc := parser.New(lexer.New("a\nb\n\nc\n")).ParseDocument()
+	var buf bytes.Buffer
+	if err := New().Export(&buf, doc); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if expected := "a b\n\nc\n\n"; buf.String() != expected {
+		t.Errorf("expected %q, got=%q", expected, buf.String())
+	}
+}
+
+func TestExportTimestamp(t *testing.T) {
+	doc := parser.New(lexer.New("Meet on <2024-02-02 Fri>.\n")).ParseDocument()
+	var buf bytes.Buffer
+	if err := New().Export(&buf, doc); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if expected := "Meet on &lt;2024-02-02 Fri&gt;.\n\n"; buf.String() != expected {
+		t.Errorf("expected %q, got=%q", expected, buf.String())
+	}
+}
+
+func TestExportFootnoteDefinition(t *testing.T) {
+	doc := parser.New(lexer.New("A claim[fn:1].\n\n[fn:1] The source,\nover two lines.\n")).ParseDocument()
+	var buf bytes.Buffer
+	if err := New().Export(&buf, doc); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if expected := "A claim[1].\n\n[1] The source, over two lines.\n\n"; buf.String() != expected {
+		t.Errorf("expected %q, got=%q", expected, buf.String())
+	}
+}
@@ -0,0 +1,203 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/token"
+)
+
+// Subtree is a headline exported to its own file through the
+// EXPORT_FILE_NAME property
+type Subtree struct {
+	Headline *ast.Headline
+	FileName string        // EXPORT_FILE_NAME, as written in the property
+	Backend  string        // EXPORT_BACKEND, a registered backend name, or empty
+	Document *ast.Document // Standalone document for the subtree
+}
+
+// Subtrees returns the headlines of doc that set EXPORT_FILE_NAME, in
+// document order. Subtrees nested inside an exported subtree are returned
+// as well and stay part of their parent's export. Archived subtrees are
+// skipped unless the document sets arch:t.
+func Subtrees(doc *ast.Document) []Subtree {
+	var out []Subtree
+	arch, _ := doc.ExportOption("arch")
+	archived := arch == "t"
+	var walk func([]ast.Node)
+	walk = func(nodes []ast.Node) {
+		for _, n := range nodes {
+			h, ok := n.(*ast.Headline)
+			if !ok || h.IsArchived() && !archived {
+				continue
+			}
+			if name, ok := h.Property("EXPORT_FILE_NAME"); ok && name != "" {
+				backend, _ := h.Property("EXPORT_BACKEND")
+				out = append(out, Subtree{Headline: h, FileName: name, Backend: backend, Document: SubtreeDocument(doc, h)})
+			}
+			walk(h.Children)
+		}
+	}
+	walk(doc.Children)
+	return out
+}
+
+// SubtreeDocument builds a standalone document from h, as Org does for a
+// subtree export. The file's keywords carry over; the headline's title
+// becomes #+TITLE unless EXPORT_TITLE is set, EXPORT_AUTHOR, EXPORT_DATE
+// and EXPORT_OPTIONS override their keywords, and the subheadings are
+// promoted so that the first level below h becomes level 1. The original
+// tree is not modified.
+func SubtreeDocument(doc *ast.Document, h *ast.Headline) *ast.Document {
+	values := map[string]string{"TITLE": h.Title}
+	for _, key := range []string{"TITLE", "AUTHOR", "DATE", "OPTIONS"} {
+		if v, ok := h.Property("EXPORT_" + key); ok {
+			values[key] = v
+		}
+	}
+
+	sub := &ast.Document{}
+	for _, key := range []string{"TITLE", "AUTHOR", "DATE"} {
+		if v, ok := values[key]; ok {
+			sub.Children = append(sub.Children, keyword(key, v))
+		}
+	}
+	for _, kw := range doc.Keywords() {
+		if _, override := values[strings.ToUpper(kw.Key)]; override && !strings.EqualFold(kw.Key, "OPTIONS") {
+			continue
+		}
+		sub.Children = append(sub.Children, kw)
+	}
+	// EXPORT_OPTIONS goes after the file's #+OPTIONS lines so that its
+	// items take precedence
+	if v, ok := values["OPTIONS"]; ok {
+		sub.Children = append(sub.Children, keyword("OPTIONS", v))
+	}
+
+	for _, c := range h.BodyNodes() {
+		if d, ok := c.(*ast.Drawer); ok && d.Name == "PROPERTIES" {
+			continue
+		}
+		sub.Children = append(sub.Children, c)
+	}
+	for _, c := range h.Subheadlines() {
+		sub.Children = append(sub.Children, promote(c, h.Level))
+	}
+	return sub
+}
+
+func keyword(key, value string) *ast.Keyword {
+	return &ast.Keyword{
+		Token: token.Token{Type: token.KEYWORD, Literal: "#+" + key + ":"},
+		Key:   key,
+		Value: value,
+	}
+}
+
+// promote returns n with every headline in it raised by levels, copying
+// headlines rather than changing them in place
+func promote(n ast.Node, levels int) ast.Node {
+	h, ok := n.(*ast.Headline)
+	if !ok {
+		return n
+	}
+	c := *h
+	c.Level -= levels
+	c.Children = make([]ast.Node, len(h.Children))
+	for i, child := range h.Children {
+		c.Children[i] = promote(child, levels)
+	}
+	return &c
+}
+
+// WriteSubtrees exports every subtree of doc that sets EXPORT_FILE_NAME
+// with a fresh backend from f, or from the registered backend named by
+// its EXPORT_BACKEND property. File names are relative to dir and get an
+// extension appended when they have none: ext, or for an EXPORT_BACKEND
+// that implements Extensioner, its own. A name that leads outside dir,
+// through "..", an absolute path or a symlink, is an error, so a document
+// cannot choose where to write. It returns the paths written.
+func WriteSubtrees(dir string, doc *ast.Document, ext string, f Factory) ([]string, error) {
+	var paths []string
+	for _, s := range Subtrees(doc) {
+		backend, fileExt := Backend(nil), ext
+		if s.Backend == "" {
+			backend = f()
+		} else {
+			var ok bool
+			if backend, ok = Lookup(s.Backend); !ok {
+				return paths, fmt.Errorf("export %s: unknown backend %q", s.FileName, s.Backend)
+			}
+			if e, ok := backend.(Extensioner); ok {
+				fileExt = e.Extension()
+			}
+		}
+
+		name := filepath.Clean(filepath.FromSlash(s.FileName))
+		if filepath.Ext(name) == "" {
+			name += fileExt
+		}
+		if !filepath.IsLocal(name) {
+			return paths, fmt.Errorf("export %s: outside %s", s.FileName, dir)
+		}
+		path := filepath.Join(dir, name)
+		if err := within(dir, path); err != nil {
+			return paths, fmt.Errorf("export %s: %w", s.FileName, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return paths, err
+		}
+		out, err := os.Create(path)
+		if err != nil {
+			return paths, err
+		}
+		err = Render(out, s.Document, backend)
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return paths, fmt.Errorf("export %s: %w", s.FileName, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// within returns an error if path resolves through symlinks to a place
+// outside dir. Only the part of path that exists already is resolved, so
+// it can be checked before any directory is created.
+func within(dir, path string) error {
+	realDir, err := resolve(dir)
+	if err != nil {
+		return err
+	}
+	real, err := resolve(path)
+	if err != nil {
+		return err
+	}
+	if rel, err := filepath.Rel(realDir, real); err != nil || !filepath.IsLocal(rel) {
+		return fmt.Errorf("resolves outside %s", dir)
+	}
+	return nil
+}
+
+// resolve evaluates the symlinks in the longest existing prefix of path
+// and appends the rest
+func resolve(path string) (string, error) {
+	real, err := filepath.EvalSymlinks(path)
+	if err == nil || !os.IsNotExist(err) {
+		return real, err
+	}
+	parent := filepath.Dir(path)
+	if parent == path {
+		return path, nil
+	}
+	realParent, err := resolve(parent)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(realParent, filepath.Base(path)), nil
+}
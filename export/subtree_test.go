@@ -0,0 +1,158 @@
+package export_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/export"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+const notes = `#+TITLE: Notebook
+#+AUTHOR: Ada
+#+OPTIONS: toc:t num:nil
+* Journal
+* Chapter One
+:PROPERTIES:
+:EXPORT_FILE_NAME: chapters/one
+:EXPORT_OPTIONS: toc:nil
+:END:
+Opening.
+** Scene
+Text.
+`
+
+func TestSubtrees(t *testing.T) {
+	doc := parser.New(lexer.New(notes)).ParseDocument()
+	subs := export.Subtrees(doc)
+	if len(subs) != 1 {
+		t.Fatalf("expected 1 subtree, got=%d", len(subs))
+	}
+	s := subs[0]
+	if s.Headline.Title != "Chapter One" || s.FileName != "chapters/one" {
+		t.Fatalf("unexpected subtree %q -> %q", s.Headline.Title, s.FileName)
+	}
+
+	sub := s.Document
+	if got := sub.Keyword("TITLE"); got != "Chapter One" {
+		t.Errorf("expected headline title as #+TITLE, got=%q", got)
+	}
+	if got := sub.Keyword("AUTHOR"); got != "Ada" {
+		t.Errorf("expected file keywords to carry over, got author=%q", got)
+	}
+	if v, _ := sub.ExportOption("toc"); v != "nil" {
+		t.Errorf("expected EXPORT_OPTIONS to override toc, got=%q", v)
+	}
+	if v, _ := sub.ExportOption("num"); v != "nil" {
+		t.Errorf("expected file options to carry over, got num=%q", v)
+	}
+
+	var scene *ast.Headline
+	for _, c := range sub.Children {
+		if _, ok := c.(*ast.Drawer); ok {
+			t.Error("expected the PROPERTIES drawer to be dropped")
+		}
+		if h, ok := c.(*ast.Headline); ok {
+			scene = h
+		}
+	}
+	if scene == nil || scene.Level != 1 {
+		t.Fatalf("expected Scene promoted to level 1, got %+v", scene)
+	}
+	if orig := s.Headline.Children[2].(*ast.Headline); orig.Level != 2 {
+		t.Errorf("expected the original tree untouched, got level=%d", orig.Level)
+	}
+}
+
+func TestWriteSubtrees(t *testing.T) {
+	doc := parser.New(lexer.New(notes)).ParseDocument()
+	dir := t.TempDir()
+	f := func() export.Backend { b, _ := export.Lookup("text"); return b }
+	paths, err := export.WriteSubtrees(dir, doc, ".txt", f)
+	if err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	want := filepath.Join(dir, "chapters", "one.txt")
+	if len(paths) != 1 || paths[0] != want {
+		t.Fatalf("expected %s, got %v", want, paths)
+	}
+	data, err := os.ReadFile(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "Scene") || strings.Contains(string(data), "Journal") {
+		t.Errorf("unexpected subtree output %q", data)
+	}
+}
+
+func TestWriteSubtreesBackendOverride(t *testing.T) {
+	input := `* Slides
+:PROPERTIES:
+:EXPORT_FILE_NAME: slides
+:EXPORT_BACKEND: latex
+:END:
+Hello
+* Page
+:PROPERTIES:
+:EXPORT_FILE_NAME: page
+:END:
+Hello
+`
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	dir := t.TempDir()
+	f := func() export.Backend { b, _ := export.Lookup("text"); return b }
+	paths, err := export.WriteSubtrees(dir, doc, ".txt", f)
+	if err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if len(paths) != 2 || paths[0] != filepath.Join(dir, "slides.tex") || paths[1] != filepath.Join(dir, "page.txt") {
+		t.Fatalf("expected the backend's own extension, got %v", paths)
+	}
+	data, err := os.ReadFile(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `\documentclass`) {
+		t.Errorf("expected LaTeX output, got %q", data)
+	}
+
+	doc = parser.New(lexer.New(strings.Replace(input, "latex", "nonesuch", 1))).ParseDocument()
+	if _, err := export.WriteSubtrees(t.TempDir(), doc, ".txt", f); err == nil || !strings.Contains(err.Error(), `"nonesuch"`) {
+		t.Errorf("expected unknown backend error, got %v", err)
+	}
+}
+
+func TestWriteSubtreesOutsideDir(t *testing.T) {
+	outside := t.TempDir()
+	for _, name := range []string{"/abs/x", "../x", "sub/../../x", filepath.Join(outside, "x")} {
+		input := "* Escape\n:PROPERTIES:\n:EXPORT_FILE_NAME: " + name + "\n:END:\nHello\n"
+		doc := parser.New(lexer.New(input)).ParseDocument()
+		dir := filepath.Join(t.TempDir(), "out")
+		f := func() export.Backend { b, _ := export.Lookup("text"); return b }
+		paths, err := export.WriteSubtrees(dir, doc, ".txt", f)
+		if err == nil || !strings.Contains(err.Error(), "outside") {
+			t.Errorf("%s: expected an error for a name outside the directory, got paths=%v err=%v", name, paths, err)
+		}
+		if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "x.txt")); err == nil {
+			t.Errorf("%s: expected nothing written next to the directory", name)
+		}
+	}
+
+	// A symlink inside dir that points elsewhere does not help either
+	dir := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(dir, "link")); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	doc := parser.New(lexer.New("* Escape\n:PROPERTIES:\n:EXPORT_FILE_NAME: link/sub/x\n:END:\n")).ParseDocument()
+	f := func() export.Backend { b, _ := export.Lookup("text"); return b }
+	if _, err := export.WriteSubtrees(dir, doc, ".txt", f); err == nil || !strings.Contains(err.Error(), "outside") {
+		t.Errorf("expected an error for a symlink leading outside, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "sub")); err == nil {
+		t.Error("expected nothing created through the symlink")
+	}
+}
@@ -0,0 +1,68 @@
+// Package table exports Org tables as CSV or TSV so they can be used as
+// lightweight datasets.
+package table
+
+import (
+	"encoding/csv"
+	"io"
+
+	"github.com/justyntemme/organelle/ast"
+)
+
+// Named is a table labelled with #+NAME
+type Named struct {
+	Name  string
+	Table *ast.Table
+}
+
+// WriteCSV writes the rows of t to w as comma-separated values.
+// Separator rows are skipped and short rows are padded to the width of
+// the widest row.
+func WriteCSV(t *ast.Table, w io.Writer) error {
+	return write(t, w, ',')
+}
+
+// WriteTSV writes the rows of t to w as tab-separated values
+func WriteTSV(t *ast.Table, w io.Writer) error {
+	return write(t, w, '\t')
+}
+
+func write(t *ast.Table, w io.Writer, comma rune) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	if err := cw.WriteAll(Records(t)); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// Records returns the data rows of t as rectangular string slices. Rows
+// of column cookies such as <r> are left out.
+func Records(t *ast.Table) [][]string {
+	width := 0
+	for _, row := range t.Rows {
+		width = max(width, len(row.Cells))
+	}
+	var out [][]string
+	for _, row := range t.Rows {
+		if row.Separator || row.IsCookieRow() {
+			continue
+		}
+		rec := make([]string, width)
+		copy(rec, row.Cells)
+		out = append(out, rec)
+	}
+	return out
+}
+
+// NamedTables returns every #+NAME labelled table in doc, in document
+// order
+func NamedTables(doc *ast.Document) []Named {
+	var out []Named
+	for _, ne := range doc.NamedElements() {
+		if t, ok := ne.Node.(*ast.Table); ok {
+			out = append(out, Named{Name: ne.Name, Table: t})
+		}
+	}
+	return out
+}
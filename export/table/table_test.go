@@ -0,0 +1,67 @@
+package table
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+const input = `#+NAME: prices
+| item   | price |
+|--------+-------|
+| apple  | 1,20  |
+| "pear" |       |
+
+Unnamed:
+| x | y |
+
+* Data
+#+NAME: sizes
+#+CAPTION: Sizes
+| s | m | l |
+`
+
+func TestWriteCSV(t *testing.T) {
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	tables := NamedTables(doc)
+	if len(tables) != 2 {
+		t.Fatalf("expected 2 named tables, got=%d", len(tables))
+	}
+	if tables[0].Name != "prices" || tables[1].Name != "sizes" {
+		t.Errorf("unexpected names %q, %q", tables[0].Name, tables[1].Name)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(tables[0].Table, &buf); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	want := "item,price\napple,\"1,20\"\n\"\"\"pear\"\"\",\n"
+	if buf.String() != want {
+		t.Errorf("expected %q, got=%q", want, buf.String())
+	}
+}
+
+func TestWriteTSV(t *testing.T) {
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	var buf bytes.Buffer
+	if err := WriteTSV(NamedTables(doc)[1].Table, &buf); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if buf.String() != "s\tm\tl\n" {
+		t.Errorf("unexpected TSV %q", buf.String())
+	}
+}
+
+func TestRecordsSkipCookies(t *testing.T) {
+	doc := parser.New(lexer.New("| <l> | <r5> |\n| a | 1 |\n")).ParseDocument()
+	var buf bytes.Buffer
+	if err := WriteCSV(doc.Children[0].(*ast.Table), &buf); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if buf.String() != "a,1\n" {
+		t.Errorf("expected the cookie row to be left out, got=%q", buf.String())
+	}
+}
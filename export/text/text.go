@@ -0,0 +1,418 @@
+// Package text renders documents as plain text for terminal previews and
+// email bodies: inline markers are stripped, nested structure is indented
+// and paragraphs are wrapped at a configurable width.
+package text
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/export"
+	"github.com/justyntemme/organelle/parser"
+)
+
+// DefaultWidth is the wrapping column used when none is configured
+const DefaultWidth = 72
+
+// Exporter renders documents as plain text
+type Exporter struct {
+	width int
+}
+
+// Option is a functional option for configuring the Exporter
+type Option func(*Exporter)
+
+// WithWidth sets the column at which paragraphs are wrapped. A width of
+// zero or less disables wrapping.
+func WithWidth(width int) Option {
+	return func(e *Exporter) {
+		e.width = width
+	}
+}
+
+// New creates a plain-text exporter
+func New(opts ...Option) *Exporter {
+	e := &Exporter{width: DefaultWidth}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Export writes doc as plain text to w
+func (e *Exporter) Export(w io.Writer, doc *ast.Document) error {
+	return export.Render(w, doc, e.Backend())
+}
+
+// Backend returns an export.Backend that renders with e's settings. The
+// backend keeps per-render state and must not be shared between renders.
+func (e *Exporter) Backend() export.Backend {
+	return &backend{Exporter: e}
+}
+
+func init() {
+	export.Register("text", func() export.Backend { return New().Backend() })
+}
+
+// backend implements the export.Backend hooks. Every line is prefixed by
+// indent; runs of paragraph lines are reflowed together as a single
+// paragraph.
+type backend struct {
+	export.Base
+	*Exporter
+	indent string
+	para   []string
+	lang   string // Language of the paragraph lines in para
+	items  int    // Depth of list items being rendered
+}
+
+func (b *backend) Extension() string {
+	return ".txt"
+}
+
+func (b *backend) Begin(c *export.Context) error {
+	if title := c.Document().Keyword("TITLE"); title != "" {
+		c.WriteString(title)
+		c.WriteString("\n")
+		c.WriteString(strings.Repeat("=", utf8.RuneCountInString(title)))
+		c.WriteString("\n\n")
+	}
+	return nil
+}
+
+func (b *backend) Paragraph(c *export.Context, p *ast.Paragraph) error {
+	if text := strings.TrimSpace(RenderInline(p.Inline)); text != "" {
+		if len(b.para) == 0 {
+			b.lang = lang(c, p.Lang)
+		}
+		b.para = append(b.para, text)
+	}
+	if c.ContinuesParagraph() || len(b.para) == 0 {
+		return nil
+	}
+	if cjk(b.lang) {
+		c.WriteString(wrapCJK(strings.Join(b.para, "\n"), b.width, b.indent, b.indent))
+	} else {
+		c.WriteString(wrap(strings.Join(b.para, " "), b.width, b.indent, b.indent))
+	}
+	c.WriteString("\n\n")
+	b.para = b.para[:0]
+	return nil
+}
+
+func (b *backend) Headline(c *export.Context, h *ast.Headline) error {
+	title := inline(h.Title)
+	if h.Keyword != "" {
+		title = h.Keyword + " " + title
+	}
+	c.WriteString(b.indent + title + "\n")
+	width := utf8.RuneCountInString(title)
+	if cjk(lang(c, h.Lang)) {
+		width = displayWidth(title)
+	}
+	switch h.Level {
+	case 1:
+		c.WriteString(b.indent + strings.Repeat("=", width) + "\n")
+	case 2:
+		c.WriteString(b.indent + strings.Repeat("-", width) + "\n")
+	}
+	c.WriteString("\n")
+
+	if h.Level <= 2 {
+		return c.Render(h.Children)
+	}
+	return b.indented(b.indent+"  ", func() error { return c.Render(h.Children) })
+}
+
+// FootnoteDefinition writes the definition as a paragraph starting with
+// its label, as references render it
+func (b *backend) FootnoteDefinition(c *export.Context, f *ast.FootnoteDefinition) error {
+	label := "[" + f.Label + "] "
+	text := []string{strings.TrimSpace(label)}
+	for _, n := range f.Children {
+		if p, ok := n.(*ast.Paragraph); ok {
+			if t := strings.TrimSpace(RenderInline(p.Inline)); t != "" {
+				text = append(text, t)
+			}
+		}
+	}
+	hanging := b.indent + strings.Repeat(" ", utf8.RuneCountInString(label))
+	c.WriteString(wrap(strings.Join(text, " "), b.width, b.indent, hanging))
+	c.WriteString("\n\n")
+	return nil
+}
+
+// indented runs fn with the indent temporarily set to indent
+func (b *backend) indented(indent string, fn func() error) error {
+	saved := b.indent
+	b.indent = indent
+	defer func() { b.indent = saved }()
+	return fn()
+}
+
+func (b *backend) List(c *export.Context, l *ast.List) error {
+	for i, item := range l.Items {
+		bullet := "- "
+		if l.Ordered {
+			bullet = fmt.Sprintf("%d. ", i+1)
+		}
+		switch item.Checkbox {
+		case ast.CheckboxUnchecked:
+			bullet += "[ ] "
+		case ast.CheckboxChecked:
+			bullet += "[X] "
+		case ast.CheckboxPartial:
+			bullet += "[-] "
+		}
+		hanging := b.indent + strings.Repeat(" ", utf8.RuneCountInString(bullet))
+		c.WriteString(wrap(inline(item.Content), b.width, b.indent+bullet, hanging))
+		c.WriteString("\n")
+
+		b.items++
+		err := b.indented(hanging, func() error { return c.Render(item.Children) })
+		b.items--
+		if err != nil {
+			return err
+		}
+	}
+	// Nested lists run on within their parent list
+	if b.items == 0 {
+		c.WriteString("\n")
+	}
+	return nil
+}
+
+func (b *backend) Block(c *export.Context, blk *ast.Block) error {
+	if blk.Type == "EXPORT" && !strings.EqualFold(blk.Language, "ascii") && !strings.EqualFold(blk.Language, "text") {
+		return nil
+	}
+	content := strings.TrimSuffix(blk.Content, "\n")
+	if blk.Type == "QUOTE" {
+		c.WriteString(wrap(strings.Join(strings.Fields(content), " "), b.width, b.indent+"  ", b.indent+"  "))
+		c.WriteString("\n\n")
+		return nil
+	}
+	for _, line := range strings.Split(content, "\n") {
+		c.WriteString(strings.TrimRight(b.indent+"  "+line, " ") + "\n")
+	}
+	c.WriteString("\n")
+	return nil
+}
+
+func (b *backend) Table(c *export.Context, t *ast.Table) error {
+	writeTable(c.Writer, t, b.indent)
+	c.WriteString("\n")
+	return nil
+}
+
+func (b *backend) HorizontalRule(c *export.Context, hr *ast.HorizontalRule) error {
+	c.WriteString(b.indent + strings.Repeat("-", max(b.width-len(b.indent), 5)) + "\n\n")
+	return nil
+}
+
+// Keywords, comments, drawers and calls produce no output
+
+func writeTable(w *bufio.Writer, t *ast.Table, indent string) {
+	var widths []int
+	for _, row := range t.Rows {
+		for i, c := range row.Cells {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], utf8.RuneCountInString(c))
+		}
+	}
+	for _, row := range t.Rows {
+		var line strings.Builder
+		line.WriteString(indent)
+		for i, width := range widths {
+			if i > 0 {
+				if row.Separator {
+					line.WriteString("-+-")
+				} else {
+					line.WriteString(" | ")
+				}
+			}
+			if row.Separator {
+				line.WriteString(strings.Repeat("-", width))
+				continue
+			}
+			cell := ""
+			if i < len(row.Cells) {
+				cell = row.Cells[i]
+			}
+			line.WriteString(cell + strings.Repeat(" ", width-utf8.RuneCountInString(cell)))
+		}
+		w.WriteString(strings.TrimRight(line.String(), " ") + "\n")
+	}
+}
+
+// RenderInline returns the text of inline elements with all markup
+// removed. Links with a description render as "description (url)".
+func RenderInline(elems []ast.InlineElement) string {
+	var out strings.Builder
+	for _, e := range elems {
+		switch e.Type {
+		case ast.InlineLink:
+			if len(e.Children) == 0 {
+				out.WriteString(e.URL)
+				continue
+			}
+			desc := RenderInline(e.Children)
+			out.WriteString(desc)
+			if desc != e.URL {
+				out.WriteString(" (" + e.URL + ")")
+			}
+		case ast.InlineText, ast.InlineCode, ast.InlineVerbatim, ast.InlineEntity, ast.InlineTimestamp:
+			out.WriteString(e.PlainText())
+		case ast.InlineFootnoteRef:
+			out.WriteString("[" + e.Content + "]")
+		default:
+			out.WriteString(RenderInline(e.Children))
+		}
+	}
+	return out.String()
+}
+
+// inline returns text, such as a headline title or list item, whose
+// markup the parser leaves unparsed, with the markup removed
+func inline(text string) string {
+	if nodes, _ := parser.ParseFragment(text); len(nodes) == 1 {
+		if p, ok := nodes[0].(*ast.Paragraph); ok {
+			return RenderInline(p.Inline)
+		}
+	}
+	return text
+}
+
+// wrap breaks s into lines of at most width runes (unless a single word
+// is longer). The first line starts with first, following lines with rest.
+func wrap(s string, width int, first, rest string) string {
+	words := strings.Fields(s)
+	if len(words) == 0 {
+		return strings.TrimRight(first, " ")
+	}
+	var out strings.Builder
+	line := first
+	lineLen := utf8.RuneCountInString(first)
+	empty := true
+	for _, word := range words {
+		wordLen := utf8.RuneCountInString(word)
+		if !empty && width > 0 && lineLen+1+wordLen > width {
+			out.WriteString(line + "\n")
+			line, lineLen, empty = rest, utf8.RuneCountInString(rest), true
+		}
+		if !empty {
+			line += " "
+			lineLen++
+		}
+		line += word
+		lineLen += wordLen
+		empty = false
+	}
+	out.WriteString(line)
+	return out.String()
+}
+
+// lang returns the language of a node: its own, or the document's
+// #+LANGUAGE
+func lang(c *export.Context, own string) string {
+	if own != "" {
+		return own
+	}
+	return c.Document().Keyword("LANGUAGE")
+}
+
+// cjk reports whether lang is Chinese, Japanese or Korean, which are
+// wrapped by display width and may break between any two characters
+func cjk(lang string) bool {
+	primary, _, _ := strings.Cut(strings.ToLower(lang), "-")
+	return primary == "ja" || primary == "zh" || primary == "ko"
+}
+
+// wide reports whether r takes two columns in a terminal
+func wide(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		r >= 0x3000 && r <= 0x303f || // CJK punctuation
+		r >= 0xff01 && r <= 0xff60 || r >= 0xffe0 && r <= 0xffe6 // Fullwidth forms
+}
+
+func displayWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		n++
+		if wide(r) {
+			n++
+		}
+	}
+	return n
+}
+
+// noBreakBefore holds closing punctuation that must not start a line
+const noBreakBefore = "、。，．・：；？！ー）」』】〉》〕｝,.:;?!)]}"
+
+// wrapCJK is wrap for text in which lines may break after any wide
+// character, counting wide characters as two columns. Runs of narrow
+// characters, such as embedded English words, break only at spaces. A
+// newline between two wide characters is dropped rather than turned into
+// a space.
+func wrapCJK(s string, width int, first, rest string) string {
+	type unit struct {
+		text  string
+		width int
+		space bool // Separated from the previous unit by a space
+	}
+	var units []unit
+	space, joinable, prevWide, newline := false, false, false, false
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			space = len(units) > 0
+			joinable = false
+			newline = newline || r == '\n' && prevWide
+			continue
+		}
+		if newline && wide(r) {
+			space = false
+		}
+		switch {
+		case len(units) > 0 && !space && (joinable && !wide(r) || strings.ContainsRune(noBreakBefore, r)):
+			u := &units[len(units)-1]
+			u.text += string(r)
+			u.width += displayWidth(string(r))
+		default:
+			units = append(units, unit{string(r), displayWidth(string(r)), space})
+		}
+		space, joinable, prevWide, newline = false, !wide(r), wide(r), false
+	}
+	if len(units) == 0 {
+		return strings.TrimRight(first, " ")
+	}
+
+	var out strings.Builder
+	line := first
+	lineLen := displayWidth(first)
+	empty := true
+	for _, u := range units {
+		sep := 0
+		if u.space && !empty {
+			sep = 1
+		}
+		if !empty && width > 0 && lineLen+sep+u.width > width {
+			out.WriteString(line + "\n")
+			line, lineLen, empty, sep = rest, displayWidth(rest), true, 0
+		}
+		if sep > 0 {
+			line += " "
+		}
+		line += u.text
+		lineLen += sep + u.width
+		empty = false
+	}
+	out.WriteString(line)
+	return out.String()
+}
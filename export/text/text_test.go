@@ -0,0 +1,157 @@
+package text
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/justyntemme/organelle/export/exporttest"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+func render(t *testing.T, input string, opts ...Option) string {
+	t.Helper()
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	var buf bytes.Buffer
+	if err := New(opts...).Export(&buf, doc); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	return buf.String()
+}
+
+func TestExportWrapsParagraphs(t *testing.T) {
+	input := `#+TITLE: Notes
+* TODO Plan
+This line has *bold* and /italic/ words
+and continues on a second line with a [[https://go.dev][link]].
+`
+	out := render(t, input, WithWidth(30))
+
+	want := `Notes
+=====
+
+TODO Plan
+=========
+
+This line has bold and italic
+words and continues on a
+second line with a link
+(https://go.dev).
+
+`
+	if out != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestExportIndentsNestedStructure(t *testing.T) {
+	input := `* A
+** B
+*** C
+- [X] first item that is rather long
+  - nested
+| x | long |
+|---+------|
+| 1 | 2 |
+`
+	out := render(t, input, WithWidth(24))
+
+	wants := []string{
+		"B\n-\n",
+		"C\n\n",
+		"  - [X] first item that\n        is rather long\n",
+		"        - nested\n",
+		"  x | long\n  --+-----\n  1 | 2\n",
+	}
+	for _, want := range wants {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\n%s", want, out)
+		}
+	}
+}
+
+func TestWrapDisabled(t *testing.T) {
+	long := strings.Repeat("word ", 40)
+	out := render(t, long, WithWidth(0))
+	if strings.Count(out, "\n") != 2 {
+		t.Errorf("expected a single unwrapped line, got:\n%s", out)
+	}
+}
+
+func TestExportFootnotes(t *testing.T) {
+	input := `A claim[fn:1] to check.
+[fn:1] The source of the claim,
+with a second line.
+`
+	out := render(t, input, WithWidth(24))
+
+	want := `A claim[1] to check.
+
+[1] The source of the
+    claim, with a second
+    line.
+
+`
+	if out != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestExportGolden(t *testing.T) {
+	input := `#+TITLE: Golden
+* TODO Plan
+Write *everything* down[fn:1].
+- [X] Outline
+- [ ] Draft
+** Notes
+#+BEGIN_SRC go
+fmt.Println("hi")
+#+END_SRC
+| a | b |
+|---+---|
+| 1 | 2 |
+
+[fn:1] Or most of it.
+`
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	exporttest.Golden(t, doc, New().Backend())
+}
+
+func TestExportWrapsCJK(t *testing.T) {
+	input := "#+LANGUAGE: ja\n* 会議メモ\n今日は新しい parser を試しました。\n結果はとても良好でした。\n"
+	out := render(t, input, WithWidth(20))
+
+	want := `会議メモ
+========
+
+今日は新しい parser
+を試しました。結果は
+とても良好でした。
+
+`
+	if out != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", out, want)
+	}
+
+	// Without a language the same text wraps only at spaces
+	out = render(t, strings.TrimPrefix(input, "#+LANGUAGE: ja\n"), WithWidth(20))
+	if !strings.Contains(out, "今日は新しい parser\nを試しました。 結果はとても良好でした。") {
+		t.Errorf("unexpected output without a language:\n%s", out)
+	}
+}
+
+func TestExportSeparatesParagraphs(t *testing.T) {
+	out := render(t, "a\nb\n\nc\n")
+	if want := "a b\n\nc\n\n"; out != want {
+		t.Errorf("expected %q, got=%q", want, out)
+	}
+}
+
+func TestExportStripsMarkupInTitlesAndItems(t *testing.T) {
+	out := render(t, "* TODO Read /it/ and *bold*\n- a =code= [[https://example.com][link]]\n")
+	want := "TODO Read it and bold\n=====================\n\n- a code link (https://example.com)\n\n"
+	if out != want {
+		t.Errorf("expected %q, got=%q", want, out)
+	}
+}
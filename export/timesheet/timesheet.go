@@ -0,0 +1,191 @@
+// Package timesheet exports the finished CLOCK entries of a document as
+// time entries, one row per clock, in CSV or JSON Lines, so invoicing and
+// time tracking systems can import them.
+package timesheet
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/redact"
+)
+
+// DefaultClientProperty is the property that names the client of an entry
+const DefaultClientProperty = "CLIENT"
+
+// timeLayout formats the start and end of an entry. Org timestamps carry
+// no zone, so none is written.
+const timeLayout = "2006-01-02T15:04"
+
+// Entry is a single finished clock
+type Entry struct {
+	Start    time.Time
+	End      time.Time
+	Duration time.Duration // As recorded after "=>", or End minus Start
+	Path     []string      // Titles of the ancestors, outermost first
+	Title    string
+	Line     int      // Line of the CLOCK entry
+	Tags     []string // Including inherited tags and #+FILETAGS
+	Client   string   // From the client property, inherited from ancestors
+}
+
+// Option is a functional option for configuring Entries, WriteCSV and
+// WriteJSON
+type Option func(*config)
+
+type config struct {
+	client   string
+	from, to time.Time
+	archived redact.Archived
+}
+
+// WithClientProperty sets the property that names the client (default
+// DefaultClientProperty)
+func WithClientProperty(name string) Option {
+	return func(c *config) {
+		c.client = name
+	}
+}
+
+// WithRange exports only clocks that started at or after from and before
+// to. A zero time leaves that end open.
+func WithRange(from, to time.Time) Option {
+	return func(c *config) {
+		c.from, c.to = from, to
+	}
+}
+
+// IncludeArchived exports clocks in subtrees tagged :ARCHIVE:; see
+// redact.Archived
+func IncludeArchived() Option {
+	return func(c *config) {
+		c.archived = true
+	}
+}
+
+// Entries returns the finished clocks of doc in document order. Running
+// clocks and clocks whose start does not parse are left out.
+func Entries(doc *ast.Document, opts ...Option) []Entry {
+	c := &config{client: DefaultClientProperty}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.archived = c.archived.For(doc)
+	var out []Entry
+	c.walk(doc, doc.Children, nil, "", &out)
+	return out
+}
+
+func (c *config) walk(doc *ast.Document, nodes []ast.Node, path []string, client string, out *[]Entry) {
+	for _, n := range nodes {
+		h, ok := n.(*ast.Headline)
+		if !ok || c.archived.Skip(h) {
+			continue
+		}
+		own := client
+		if v, ok := h.Property(c.client); ok {
+			own = strings.TrimSpace(v)
+		}
+		var tags []string
+		for _, clock := range h.Clocks() {
+			if clock.Running() {
+				continue
+			}
+			start, err := clock.Start.ToTime()
+			if err != nil {
+				continue
+			}
+			if (!c.from.IsZero() && start.Before(c.from)) || (!c.to.IsZero() && !start.Before(c.to)) {
+				continue
+			}
+			end, err := clock.End.ToTime()
+			if err != nil {
+				end = start.Add(clock.Duration)
+			}
+			if tags == nil {
+				tags = doc.AllTags(h, false)
+			}
+			*out = append(*out, Entry{
+				Start:    start,
+				End:      end,
+				Duration: clock.Duration,
+				Path:     append([]string{}, path...),
+				Title:    h.Title,
+				Line:     clock.Token.Line,
+				Tags:     append([]string(nil), tags...),
+				Client:   own,
+			})
+		}
+		c.walk(doc, h.Children, append(path[:len(path):len(path)], h.Title), own, out)
+	}
+}
+
+// header is the first row of the CSV output
+var header = []string{"start", "end", "minutes", "path", "title", "tags", "client"}
+
+// WriteCSV writes the entries of doc to w as comma-separated values with
+// a header row. The path is joined with "/" and the tags with ":"; the
+// duration is in whole minutes.
+func WriteCSV(w io.Writer, doc *ast.Document, opts ...Option) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, e := range Entries(doc, opts...) {
+		tags := ""
+		if len(e.Tags) > 0 {
+			tags = ":" + strings.Join(e.Tags, ":") + ":"
+		}
+		err := cw.Write([]string{
+			e.Start.Format(timeLayout),
+			e.End.Format(timeLayout),
+			strconv.Itoa(int(e.Duration / time.Minute)),
+			strings.Join(e.Path, "/"),
+			e.Title,
+			tags,
+			e.Client,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// record is the JSON form of an Entry
+type record struct {
+	Start   string   `json:"start"`
+	End     string   `json:"end"`
+	Minutes int      `json:"minutes"`
+	Path    []string `json:"path"`
+	Title   string   `json:"title"`
+	Tags    []string `json:"tags,omitempty"`
+	Client  string   `json:"client,omitempty"`
+}
+
+// WriteJSON writes one JSON object per entry to w, in document order
+func WriteJSON(w io.Writer, doc *ast.Document, opts ...Option) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, e := range Entries(doc, opts...) {
+		err := enc.Encode(record{
+			Start:   e.Start.Format(timeLayout),
+			End:     e.End.Format(timeLayout),
+			Minutes: int(e.Duration / time.Minute),
+			Path:    e.Path,
+			Title:   e.Title,
+			Tags:    e.Tags,
+			Client:  e.Client,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
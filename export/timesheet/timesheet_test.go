@@ -0,0 +1,97 @@
+package timesheet
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+const input = `#+FILETAGS: :billable:
+* Website :web:
+:PROPERTIES:
+:CLIENT: Acme
+:END:
+** Design
+:LOGBOOK:
+CLOCK: [2024-01-15 Mon 09:00]--[2024-01-15 Mon 10:30] =>  1:30
+CLOCK: [2024-01-16 Tue 14:00]
+:END:
+** Hosting
+:PROPERTIES:
+:CLIENT: Globex, Inc.
+:END:
+:LOGBOOK:
+CLOCK: [2024-01-16 Tue 11:00]--[2024-01-16 Tue 11:20] =>  0:20
+:END:
+* Old :ARCHIVE:
+:LOGBOOK:
+CLOCK: [2024-01-10 Wed 09:00]--[2024-01-10 Wed 10:00] =>  1:00
+:END:
+`
+
+func parse(t *testing.T) *ast.Document {
+	t.Helper()
+	return parser.New(lexer.New(input)).ParseDocument()
+}
+
+func TestEntries(t *testing.T) {
+	entries := Entries(parse(t))
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 finished clocks, got=%+v", entries)
+	}
+	e := entries[0]
+	if !e.Start.Equal(time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)) || !e.End.Equal(time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)) {
+		t.Errorf("unexpected start and end %v %v", e.Start, e.End)
+	}
+	if e.Duration != 90*time.Minute || e.Title != "Design" || e.Line != 8 || e.Client != "Acme" {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if len(e.Path) != 1 || e.Path[0] != "Website" {
+		t.Errorf("expected path [Website], got=%v", e.Path)
+	}
+	if len(e.Tags) != 2 || e.Tags[0] != "billable" || e.Tags[1] != "web" {
+		t.Errorf("expected inherited tags, got=%v", e.Tags)
+	}
+	if entries[1].Client != "Globex, Inc." {
+		t.Errorf("expected the nearer client to win, got=%q", entries[1].Client)
+	}
+
+	if got := Entries(parse(t), IncludeArchived()); len(got) != 3 || got[2].Client != "" {
+		t.Errorf("expected the archived clock without a client, got=%+v", got)
+	}
+	from := time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC)
+	if got := Entries(parse(t), WithRange(from, time.Time{})); len(got) != 1 || got[0].Title != "Hosting" {
+		t.Errorf("expected only the clock in range, got=%+v", got)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, parse(t)); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+	expected := `start,end,minutes,path,title,tags,client
+2024-01-15T09:00,2024-01-15T10:30,90,Website,Design,:billable:web:,Acme
+2024-01-16T11:00,2024-01-16T11:20,20,Website,Hosting,:billable:web:,"Globex, Inc."
+`
+	if buf.String() != expected {
+		t.Errorf("unexpected CSV.\nexpected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, parse(t), WithClientProperty("NONE")); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	expected := `{"start":"2024-01-15T09:00","end":"2024-01-15T10:30","minutes":90,"path":["Website"],"title":"Design","tags":["billable","web"]}
+{"start":"2024-01-16T11:00","end":"2024-01-16T11:20","minutes":20,"path":["Website"],"title":"Hosting","tags":["billable","web"]}
+`
+	if buf.String() != expected {
+		t.Errorf("unexpected JSON.\nexpected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
@@ -0,0 +1,270 @@
+// Package issues synchronizes headlines with issues in a forge such as
+// GitHub or GitLab.
+//
+// A headline is linked to an issue by its ISSUE_URL property. Sync
+// metadata lives next to it: ISSUE_TITLE and ISSUE_STATE hold the values
+// last seen on both sides, and ISSUE_SYNCED the time of the last sync.
+// Comparing them with the headline and the remote issue tells which side
+// changed. The package does no networking itself; callers supply a
+// Transport for their forge.
+package issues
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/org"
+)
+
+// Sync metadata property keys
+const (
+	PropURL    = "ISSUE_URL"
+	PropTitle  = "ISSUE_TITLE"
+	PropState  = "ISSUE_STATE"
+	PropSynced = "ISSUE_SYNCED"
+)
+
+// Issue states as recorded in ISSUE_STATE
+const (
+	StateOpen   = "open"
+	StateClosed = "closed"
+)
+
+// Issue is the forge-independent view of an issue
+type Issue struct {
+	URL    string
+	Title  string
+	State  string // StateOpen or StateClosed
+	Labels []string
+	Body   string
+}
+
+// Transport talks to a forge
+type Transport interface {
+	// List returns the issues to import
+	List(ctx context.Context) ([]Issue, error)
+	// Update pushes a changed title or state to the forge
+	Update(ctx context.Context, issue Issue) error
+}
+
+// Result summarizes a sync run
+type Result struct {
+	Imported  int      // New subtrees created from remote issues
+	Pulled    int      // Headlines updated from remote changes
+	Pushed    int      // Issues updated from local changes
+	Conflicts []string // URLs changed on both sides; left untouched
+}
+
+// Syncer maps headlines to issues
+type Syncer struct {
+	transport   Transport
+	todoKeyword string
+	doneKeyword string
+	level       int
+	now         func() time.Time
+}
+
+// Option is a functional option for configuring the Syncer
+type Option func(*Syncer)
+
+// WithKeywords sets the TODO keywords used for open and closed issues
+// (default TODO and DONE)
+func WithKeywords(open, closed string) Option {
+	return func(s *Syncer) {
+		s.todoKeyword = open
+		s.doneKeyword = closed
+	}
+}
+
+// WithLevel sets the headline level of imported issues (default 1)
+func WithLevel(level int) Option {
+	return func(s *Syncer) {
+		s.level = level
+	}
+}
+
+// WithClock sets the function used for ISSUE_SYNCED (default time.Now)
+func WithClock(now func() time.Time) Option {
+	return func(s *Syncer) {
+		s.now = now
+	}
+}
+
+// New creates a Syncer using transport
+func New(transport Transport, opts ...Option) *Syncer {
+	s := &Syncer{
+		transport:   transport,
+		todoKeyword: "TODO",
+		doneKeyword: "DONE",
+		level:       1,
+		now:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Sync pulls remote issues into doc and pushes local changes back. Issues
+// without a linked headline are appended to doc as new subtrees. When
+// both sides changed since the last sync the issue is reported as a
+// conflict and neither side is modified.
+func (s *Syncer) Sync(ctx context.Context, doc *ast.Document) (Result, error) {
+	var res Result
+	remote, err := s.transport.List(ctx)
+	if err != nil {
+		return res, err
+	}
+	byURL := make(map[string]Issue, len(remote))
+	for _, is := range remote {
+		byURL[is.URL] = is
+	}
+
+	linked := Linked(doc)
+	seen := make(map[string]bool, len(linked))
+	for _, h := range linked {
+		url, _ := h.Property(PropURL)
+		seen[url] = true
+		is, ok := byURL[url]
+		if !ok {
+			continue
+		}
+		changed, err := s.reconcile(ctx, h, is, &res)
+		if err != nil {
+			return res, err
+		}
+		if changed {
+			s.record(h, s.issueFor(h, is))
+		}
+	}
+
+	for _, is := range remote {
+		if seen[is.URL] {
+			continue
+		}
+		doc.Children = append(doc.Children, s.Headline(is))
+		res.Imported++
+	}
+	return res, nil
+}
+
+// reconcile applies whichever side changed since the last sync and
+// reports whether the metadata needs refreshing
+func (s *Syncer) reconcile(ctx context.Context, h *ast.Headline, remote Issue, res *Result) (bool, error) {
+	lastTitle, _ := h.Property(PropTitle)
+	lastState, _ := h.Property(PropState)
+	localState := s.state(h)
+
+	localChanged := h.Title != lastTitle || localState != lastState
+	remoteChanged := remote.Title != lastTitle || remote.State != lastState
+
+	switch {
+	case localChanged && remoteChanged:
+		if h.Title == remote.Title && localState == remote.State {
+			return true, nil // Both sides made the same change
+		}
+		res.Conflicts = append(res.Conflicts, remote.URL)
+		return false, nil
+	case localChanged:
+		if err := s.transport.Update(ctx, s.issueFor(h, remote)); err != nil {
+			return false, err
+		}
+		res.Pushed++
+		return true, nil
+	case remoteChanged:
+		h.Title = remote.Title
+		h.Keyword = s.keyword(remote.State)
+		h.Done = remote.State == StateClosed
+		res.Pulled++
+		return true, nil
+	}
+	return false, nil
+}
+
+// Headline builds a new subtree for an issue
+func (s *Syncer) Headline(is Issue) *ast.Headline {
+	h := &ast.Headline{
+		Level:   s.level,
+		Keyword: s.keyword(is.State),
+		Done:    is.State == StateClosed,
+		Title:   is.Title,
+		Tags:    tags(is.Labels),
+	}
+	org.SetProperty(h, PropURL, is.URL)
+	s.record(h, is)
+	for _, line := range strings.Split(strings.TrimSpace(is.Body), "\n") {
+		if line = strings.TrimRight(line, " \t\r"); line != "" {
+			h.Children = append(h.Children, &ast.Paragraph{Content: line})
+		}
+	}
+	return h
+}
+
+// Linked returns every headline carrying an ISSUE_URL property, in
+// document order
+func Linked(doc *ast.Document) []*ast.Headline {
+	var out []*ast.Headline
+	var walk func([]ast.Node)
+	walk = func(nodes []ast.Node) {
+		for _, n := range nodes {
+			h, ok := n.(*ast.Headline)
+			if !ok {
+				continue
+			}
+			if url, ok := h.Property(PropURL); ok && url != "" {
+				out = append(out, h)
+			}
+			walk(h.Children)
+		}
+	}
+	walk(doc.Children)
+	return out
+}
+
+// record stores the synced title and state on the headline
+func (s *Syncer) record(h *ast.Headline, is Issue) {
+	org.SetProperty(h, PropTitle, is.Title)
+	org.SetProperty(h, PropState, is.State)
+	org.SetProperty(h, PropSynced, s.now().Format("[2006-01-02 Mon 15:04]"))
+}
+
+// issueFor returns remote with the headline's title and state applied
+func (s *Syncer) issueFor(h *ast.Headline, remote Issue) Issue {
+	remote.Title = h.Title
+	remote.State = s.state(h)
+	return remote
+}
+
+func (s *Syncer) state(h *ast.Headline) string {
+	if h.Keyword == s.doneKeyword {
+		return StateClosed
+	}
+	return StateOpen
+}
+
+func (s *Syncer) keyword(state string) string {
+	if state == StateClosed {
+		return s.doneKeyword
+	}
+	return s.todoKeyword
+}
+
+// tags converts labels to valid Org tags, which cannot contain spaces or
+// colons
+func tags(labels []string) []string {
+	var out []string
+	for _, l := range labels {
+		t := strings.Map(func(r rune) rune {
+			if r == ' ' || r == ':' || r == '\t' {
+				return '_'
+			}
+			return r
+		}, strings.TrimSpace(l))
+		if t != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
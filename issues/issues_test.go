@@ -0,0 +1,134 @@
+package issues
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+	"github.com/justyntemme/organelle/writer"
+)
+
+type fakeTransport struct {
+	issues  []Issue
+	updates []Issue
+}
+
+func (f *fakeTransport) List(ctx context.Context) ([]Issue, error) {
+	return f.issues, nil
+}
+
+func (f *fakeTransport) Update(ctx context.Context, is Issue) error {
+	f.updates = append(f.updates, is)
+	return nil
+}
+
+func clock() time.Time {
+	return time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+}
+
+func TestSyncImportsNewIssues(t *testing.T) {
+	tr := &fakeTransport{issues: []Issue{{
+		URL:    "https://example.com/o/r/issues/1",
+		Title:  "Crash on start",
+		State:  StateOpen,
+		Labels: []string{"bug", "needs triage"},
+		Body:   "Steps to reproduce\n\nRun it.",
+	}}}
+	doc := &ast.Document{}
+
+	res, err := New(tr, WithClock(clock)).Sync(context.Background(), doc)
+	if err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if res.Imported != 1 {
+		t.Fatalf("expected 1 imported issue, got=%d", res.Imported)
+	}
+
+	out := writer.String(doc)
+	want := `* TODO Crash on start :bug:needs_triage:
+:PROPERTIES:
+:ISSUE_URL: https://example.com/o/r/issues/1
+:ISSUE_TITLE: Crash on start
+:ISSUE_STATE: open
+:ISSUE_SYNCED: [2024-03-01 Fri 09:00]
+:END:
+Steps to reproduce
+Run it.
+`
+	if out != want {
+		t.Errorf("unexpected import:\n%s", out)
+	}
+}
+
+func linkedDoc(t *testing.T, keyword, title string) *ast.Document {
+	t.Helper()
+	input := "* " + keyword + " " + title + `
+:PROPERTIES:
+:ISSUE_URL: u1
+:ISSUE_TITLE: Old title
+:ISSUE_STATE: open
+:END:
+`
+	return parser.New(lexer.New(input)).ParseDocument()
+}
+
+func TestSyncPushesLocalChanges(t *testing.T) {
+	tr := &fakeTransport{issues: []Issue{{URL: "u1", Title: "Old title", State: StateOpen}}}
+	doc := linkedDoc(t, "DONE", "Old title")
+
+	res, err := New(tr, WithClock(clock)).Sync(context.Background(), doc)
+	if err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if res.Pushed != 1 || len(tr.updates) != 1 || tr.updates[0].State != StateClosed {
+		t.Fatalf("expected closing update to be pushed, got %+v / %+v", res, tr.updates)
+	}
+	h := doc.Children[0].(*ast.Headline)
+	if state, _ := h.Property(PropState); state != StateClosed {
+		t.Errorf("expected recorded state closed, got=%q", state)
+	}
+}
+
+func TestSyncPullsRemoteChanges(t *testing.T) {
+	tr := &fakeTransport{issues: []Issue{{URL: "u1", Title: "New title", State: StateClosed}}}
+	doc := linkedDoc(t, "TODO", "Old title")
+
+	res, err := New(tr, WithClock(clock)).Sync(context.Background(), doc)
+	if err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	h := doc.Children[0].(*ast.Headline)
+	if res.Pulled != 1 || h.Title != "New title" || h.Keyword != "DONE" {
+		t.Errorf("expected remote change to be applied, got %+v, %q %q", res, h.Keyword, h.Title)
+	}
+	if len(tr.updates) != 0 {
+		t.Errorf("expected nothing pushed, got %+v", tr.updates)
+	}
+}
+
+func TestSyncReportsConflicts(t *testing.T) {
+	tr := &fakeTransport{issues: []Issue{{URL: "u1", Title: "Remote title", State: StateOpen}}}
+	doc := linkedDoc(t, "TODO", "Local title")
+
+	res, err := New(tr, WithClock(clock)).Sync(context.Background(), doc)
+	if err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if len(res.Conflicts) != 1 || res.Conflicts[0] != "u1" {
+		t.Errorf("expected conflict for u1, got %+v", res)
+	}
+	h := doc.Children[0].(*ast.Headline)
+	if h.Title != "Local title" || len(tr.updates) != 0 {
+		t.Errorf("expected neither side to change on conflict")
+	}
+	if synced, _ := h.Property(PropSynced); synced != "" {
+		t.Errorf("expected sync metadata untouched, got %q", synced)
+	}
+	if strings.Contains(writer.String(doc), "Remote title") {
+		t.Errorf("expected remote title not to be written")
+	}
+}
@@ -3,6 +3,7 @@ package lexer
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"strings"
 	"unicode/utf8"
@@ -22,6 +23,27 @@ var ErrInputTooLarge = errors.New("input exceeds maximum allowed size")
 // ErrLineTooLong is returned when a line exceeds the maximum length
 var ErrLineTooLong = errors.New("line exceeds maximum allowed length")
 
+// LineError reports a problem with a single input line that the lexer
+// recovered from
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// ErrNotStreaming is returned by Append on a lexer created with New
+var ErrNotStreaming = errors.New("lexer is not in streaming mode")
+
+// ErrStreamClosed is returned by Append after Close
+var ErrStreamClosed = errors.New("lexer stream is closed")
+
 // Lexer follows the standard Rob Pike style state handling, adapted for
 // struct-based iteration for easier integration with the parser.
 type Lexer struct {
@@ -33,16 +55,33 @@ type Lexer struct {
 	line           int  // line number for error reporting
 	column         int  // column number for error reporting
 	logger         *slog.Logger
+	debug          bool // logger is enabled at Debug level; checked before building log arguments
 	ctx            context.Context
 	maxInputSize   int
 	maxLineLength  int
 	err            error // stores any error encountered during lexing
+	base           int   // offset of input[0] in the whole stream; non-zero once consumed input is dropped
+	dropped        int   // bytes of consumed input discarded by Append
+	streaming      bool  // input arrives through Append
+	closed         bool  // no more input will be appended
+	fatalLongLines bool  // an overlong line stops lexing instead of being split
+	longLine       bool  // in the middle of splitting an overlong line
+	diagnostics    []error
+
+	// The last decided drawerCloses scan, as offsets in the whole stream:
+	// no :END: or headline line starts in [scanFrom, scanStop), and the
+	// line at scanStop is an :END: line if scanCloses is set
+	scanFrom   int
+	scanStop   int
+	scanCloses bool
 }
 
 // Option is a functional option for configuring the Lexer
 type Option func(*Lexer)
 
-// WithLogger sets a custom logger for the lexer
+// WithLogger sets a custom logger for the lexer. A nil logger discards
+// everything. Whether Debug messages are logged is checked once, when the
+// lexer is created, so that disabled debug logging costs nothing per token.
 func WithLogger(logger *slog.Logger) Option {
 	return func(l *Lexer) {
 		l.logger = logger
@@ -70,6 +109,26 @@ func WithMaxLineLength(length int) Option {
 	}
 }
 
+// WithFatalLongLines makes a line longer than the maximum line length
+// stop lexing with ErrLineTooLong, as in earlier versions. By default the
+// line is split into TEXT tokens of at most the maximum length and a
+// LineError is recorded in Diagnostics.
+func WithFatalLongLines() Option {
+	return func(l *Lexer) {
+		l.fatalLongLines = true
+	}
+}
+
+// WithOrigin makes the input start at the given byte offset and line of a
+// larger text, so tokens carry positions in that text. It is used when
+// lexing a slice of a file.
+func WithOrigin(offset, line int) Option {
+	return func(l *Lexer) {
+		l.base = offset
+		l.line = line
+	}
+}
+
 // New creates a new Lexer with the given input and options
 func New(input string, opts ...Option) *Lexer {
 	l := &Lexer{
@@ -85,6 +144,10 @@ func New(input string, opts ...Option) *Lexer {
 	for _, opt := range opts {
 		opt(l)
 	}
+	if l.logger == nil {
+		l.logger = slog.New(slog.DiscardHandler)
+	}
+	l.debug = l.logger.Enabled(l.ctx, slog.LevelDebug)
 
 	// Validate input size
 	if len(input) > l.maxInputSize {
@@ -92,16 +155,107 @@ func New(input string, opts ...Option) *Lexer {
 		l.logger.Error("input too large", "size", len(input), "max", l.maxInputSize)
 	}
 
-	l.logger.Debug("lexer initialized", "input_length", len(input))
+	if l.debug {
+		l.logger.Debug("lexer initialized", "input_length", len(input))
+	}
 	l.readChar()
 	return l
 }
 
+// NewStream creates a Lexer that receives its input incrementally through
+// Append, for tailing a growing journal or reading a pipe.
+//
+// A streaming lexer only tokenizes complete lines. When no complete line
+// is buffered NextToken returns EOF without consuming anything; after more
+// input is appended it continues where it stopped. Call Close once the
+// input has ended so that a final unterminated line is tokenized. Done
+// tells a temporary EOF from the real one.
+//
+// Consumed input is discarded on every Append, so the maximum input size
+// applies to the buffered, not-yet-tokenized input rather than the whole
+// stream. Token offsets and line numbers stay relative to the start of the
+// stream.
+func NewStream(opts ...Option) *Lexer {
+	l := New("", opts...)
+	l.streaming = true
+	return l
+}
+
+// Append adds a chunk of input to a streaming lexer
+func (l *Lexer) Append(chunk string) error {
+	if !l.streaming {
+		return ErrNotStreaming
+	}
+	if l.closed {
+		return ErrStreamClosed
+	}
+	if l.err != nil {
+		return l.err
+	}
+
+	// Drop consumed input so a long-running stream does not grow without bound
+	consumed := min(l.position, len(l.input))
+	exhausted := l.ch == 0 && l.position >= len(l.input)
+	l.input = l.input[consumed:] + chunk
+	l.base += consumed
+	l.dropped += consumed
+	l.position -= consumed
+	l.readPosition -= consumed
+
+	if len(l.input) > l.maxInputSize {
+		l.err = ErrInputTooLarge
+		l.logger.Error("buffered input too large", "size", len(l.input), "max", l.maxInputSize)
+		return l.err
+	}
+
+	// readChar ran past the end of the old input; step back and read the
+	// first appended character instead
+	if exhausted && chunk != "" {
+		l.readPosition = l.position
+		l.ch = l.prevCh
+		l.column--
+		l.readChar()
+	}
+	if l.debug {
+		l.logger.Debug("lexer input appended", "chunk_length", len(chunk), "buffered", len(l.input))
+	}
+	return nil
+}
+
+// Close marks the end of a streaming lexer's input
+func (l *Lexer) Close() {
+	l.closed = true
+}
+
+// Done reports whether the lexer has consumed all of its input and no
+// more can arrive. It is always true at EOF for lexers created with New.
+func (l *Lexer) Done() bool {
+	if l.err != nil {
+		return true
+	}
+	return l.ch == 0 && l.position >= len(l.input) && (!l.streaming || l.closed)
+}
+
+// lineIncomplete reports whether a streaming lexer is positioned at a
+// line that has not been fully appended yet
+func (l *Lexer) lineIncomplete() bool {
+	if !l.streaming || l.closed || l.position >= len(l.input) || l.ch == '\n' {
+		return false
+	}
+	return strings.IndexByte(l.input[l.position:], '\n') == -1
+}
+
 // Err returns any error encountered during lexing
 func (l *Lexer) Err() error {
 	return l.err
 }
 
+// Diagnostics returns the problems the lexer recovered from so far, as
+// *LineError values in input order
+func (l *Lexer) Diagnostics() []error {
+	return l.diagnostics
+}
+
 // checkContext checks if the context has been cancelled
 func (l *Lexer) checkContext() bool {
 	select {
@@ -155,6 +309,7 @@ func (l *Lexer) NextToken() token.Token {
 	var tok token.Token
 	tok.Line = l.line
 	tok.Column = l.column
+	tok.Offset = l.base + l.position
 
 	// Check for errors or cancellation
 	if l.err != nil {
@@ -170,14 +325,44 @@ func (l *Lexer) NextToken() token.Token {
 		return tok
 	}
 
-	// Check for line-start specific tokens (Headlines, Keywords)
-	isLineStart := l.position == 0 || l.prevCh == '\n'
+	// Wait for the rest of a partially appended line
+	if l.lineIncomplete() {
+		tok.Type = token.EOF
+		tok.Literal = ""
+		return tok
+	}
+
+	// Check for line-start specific tokens (Headlines, Keywords). A streaming
+	// lexer drops consumed input, so input[0] may be in the middle of a line.
+	isLineStart := l.dropped+l.position == 0 || l.prevCh == '\n'
+
+	if isLineStart && l.position < len(l.input) {
+		if tok, ok := l.readIllegalLine(); ok {
+			return tok
+		}
+		if l.lineTooLong() {
+			if l.fatalLongLines {
+				l.err = ErrLineTooLong
+				l.logger.Error("line too long", "line", l.line, "max", l.maxLineLength)
+				tok.Type = token.EOF
+				return tok
+			}
+			l.diagnostics = append(l.diagnostics, &LineError{Line: l.line, Err: ErrLineTooLong})
+			l.logger.Warn("line too long, splitting", "line", l.line, "max", l.maxLineLength)
+			l.longLine = true
+		}
+	}
+	if l.longLine {
+		return l.readLongLinePiece()
+	}
 
 	switch l.ch {
 	case 0:
 		tok.Literal = ""
 		tok.Type = token.EOF
-		l.logger.Debug("token", "type", tok.Type, "line", tok.Line)
+		if l.debug {
+			l.logger.Debug("token", "type", tok.Type, "line", tok.Line)
+		}
 		return tok
 
 	case '\n':
@@ -192,13 +377,17 @@ func (l *Lexer) NextToken() token.Token {
 			if l.ch == ' ' {
 				tok.Type = token.STARS
 				tok.Literal = stars
-				l.logger.Debug("token", "type", tok.Type, "literal", tok.Literal, "line", tok.Line)
+				if l.debug {
+					l.logger.Debug("token", "type", tok.Type, "literal", tok.Literal, "line", tok.Line)
+				}
 				return tok
 			}
 			// Not a headline, treat as text
 			tok.Type = token.TEXT
 			tok.Literal = stars + l.readToEndOfLine()
-			l.logger.Debug("token", "type", tok.Type, "line", tok.Line)
+			if l.debug {
+				l.logger.Debug("token", "type", tok.Type, "line", tok.Line)
+			}
 			return tok
 		}
 		tok = l.readTextLine()
@@ -280,8 +469,10 @@ func (l *Lexer) NextToken() token.Token {
 }
 
 func (l *Lexer) newToken(tokenType token.TokenType, ch rune) token.Token {
-	tok := token.Token{Type: tokenType, Literal: string(ch), Line: l.line, Column: l.column}
-	l.logger.Debug("token", "type", tokenType, "literal", string(ch), "line", l.line)
+	tok := token.Token{Type: tokenType, Literal: string(ch), Line: l.line, Column: l.column, Offset: l.base + l.position}
+	if l.debug {
+		l.logger.Debug("token", "type", tokenType, "literal", string(ch), "line", l.line)
+	}
 	return tok
 }
 
@@ -308,6 +499,62 @@ func (l *Lexer) readToEndOfLine() string {
 	return l.input[position:l.position]
 }
 
+// readIllegalLine returns the current line as a single ILLEGAL token if it
+// contains invalid UTF-8 or a NUL byte. Such lines are never split into
+// structural tokens; the parser reports them and keeps them as text. A NUL
+// byte would otherwise be taken for the end of input.
+func (l *Lexer) readIllegalLine() (token.Token, bool) {
+	rest := l.input[l.position:]
+	end := strings.IndexByte(rest, '\n')
+	if end == -1 {
+		end = len(rest)
+	}
+	literal := rest[:end]
+	if utf8.ValidString(literal) && strings.IndexByte(literal, 0) == -1 {
+		return token.Token{}, false
+	}
+
+	tok := token.Token{Type: token.ILLEGAL, Literal: literal, Line: l.line, Column: l.column, Offset: l.base + l.position}
+	// Skip to the end of the line without decoding it
+	l.column += end - 1
+	l.readPosition = l.position + end
+	l.readChar()
+	if l.debug {
+		l.logger.Debug("token", "type", tok.Type, "line", tok.Line, "length", len(literal))
+	}
+	return tok, true
+}
+
+// lineTooLong reports whether the line at the current position has more
+// than maxLineLength characters
+func (l *Lexer) lineTooLong() bool {
+	rest := l.input[l.position:]
+	if end := strings.IndexByte(rest, '\n'); end != -1 {
+		rest = rest[:end]
+	}
+	return len(rest) > l.maxLineLength && utf8.RuneCountInString(rest) > l.maxLineLength
+}
+
+// readLongLinePiece returns the next piece of an overlong line as a TEXT
+// token of at most maxLineLength characters. Pieces are never lexed as
+// structure, since they may start in the middle of the line.
+func (l *Lexer) readLongLinePiece() token.Token {
+	position := l.position
+	line := l.line
+	col := l.column
+	for n := 0; n < l.maxLineLength && l.ch != '\n' && l.position < len(l.input); n++ {
+		l.readChar()
+	}
+	if l.ch == '\n' || l.position >= len(l.input) {
+		l.longLine = false
+	}
+	literal := l.input[position:l.position]
+	if l.debug {
+		l.logger.Debug("token", "type", token.TEXT, "line", line, "note", "long_line_piece")
+	}
+	return token.Token{Type: token.TEXT, Literal: literal, Line: line, Column: col, Offset: l.base + position}
+}
+
 // readOrgDirective handles #+KEYWORD, #+BEGIN_X, #+END_X
 func (l *Lexer) readOrgDirective() token.Token {
 	position := l.position
@@ -324,16 +571,22 @@ func (l *Lexer) readOrgDirective() token.Token {
 
 	// Check for BEGIN/END blocks
 	if strings.HasPrefix(upperLiteral, "#+BEGIN_") {
-		l.logger.Debug("token", "type", token.BLOCK_BEGIN, "literal", literal, "line", line)
-		return token.Token{Type: token.BLOCK_BEGIN, Literal: literal, Line: line, Column: col}
+		if l.debug {
+			l.logger.Debug("token", "type", token.BLOCK_BEGIN, "literal", literal, "line", line)
+		}
+		return token.Token{Type: token.BLOCK_BEGIN, Literal: literal, Line: line, Column: col, Offset: l.base + position}
 	}
 	if strings.HasPrefix(upperLiteral, "#+END_") {
-		l.logger.Debug("token", "type", token.BLOCK_END, "literal", literal, "line", line)
-		return token.Token{Type: token.BLOCK_END, Literal: literal, Line: line, Column: col}
+		if l.debug {
+			l.logger.Debug("token", "type", token.BLOCK_END, "literal", literal, "line", line)
+		}
+		return token.Token{Type: token.BLOCK_END, Literal: literal, Line: line, Column: col, Offset: l.base + position}
 	}
 
-	l.logger.Debug("token", "type", token.KEYWORD, "literal", literal, "line", line)
-	return token.Token{Type: token.KEYWORD, Literal: literal, Line: line, Column: col}
+	if l.debug {
+		l.logger.Debug("token", "type", token.KEYWORD, "literal", literal, "line", line)
+	}
+	return token.Token{Type: token.KEYWORD, Literal: literal, Line: line, Column: col, Offset: l.base + position}
 }
 
 // readComment handles # comment lines
@@ -347,38 +600,111 @@ func (l *Lexer) readComment() token.Token {
 	}
 
 	literal := l.input[position:l.position]
-	l.logger.Debug("token", "type", token.COMMENT, "literal", literal, "line", line)
-	return token.Token{Type: token.COMMENT, Literal: literal, Line: line, Column: col}
+	if l.debug {
+		l.logger.Debug("token", "type", token.COMMENT, "literal", literal, "line", line)
+	}
+	return token.Token{Type: token.COMMENT, Literal: literal, Line: line, Column: col, Offset: l.base + position}
 }
 
-// readDrawerOrProperty handles :NAME: lines
+// readDrawerOrProperty handles :NAME: lines.
+//
+// A :NAME: line only opens a drawer when an :END: line follows before the
+// next headline, as in Org; otherwise it is plain text. This keeps a
+// stray drawer line from swallowing the rest of the section. Lines such
+// as :KEY: value and unmatched :END: lines are left to the parser.
 func (l *Lexer) readDrawerOrProperty() token.Token {
 	position := l.position
 	line := l.line
 	col := l.column
 
+	end := len(l.input)
+	if i := strings.IndexByte(l.input[position:], '\n'); i >= 0 {
+		end = position + i
+	}
+	literal := l.input[position:end]
+	trimmed := strings.TrimSpace(literal)
+	isDrawer := strings.HasPrefix(trimmed, ":") && strings.HasSuffix(trimmed, ":") && strings.Count(trimmed, ":") == 2
+	closes, decided := false, true
+	if isDrawer && strings.ToUpper(trimmed) != ":END:" {
+		if closes, decided = l.drawerCloses(end); !decided {
+			// Wait for more input before deciding; nothing is consumed
+			return token.Token{Type: token.EOF, Line: line, Column: col, Offset: l.base + position}
+		}
+	}
 	for l.ch != '\n' && l.ch != 0 {
 		l.readChar()
 	}
 
-	literal := l.input[position:l.position]
-	trimmed := strings.TrimSpace(literal)
-
 	// Check for :END:
 	if strings.ToUpper(trimmed) == ":END:" {
-		l.logger.Debug("token", "type", token.DRAWER_END, "literal", literal, "line", line)
-		return token.Token{Type: token.DRAWER_END, Literal: literal, Line: line, Column: col}
+		if l.debug {
+			l.logger.Debug("token", "type", token.DRAWER_END, "literal", literal, "line", line)
+		}
+		return token.Token{Type: token.DRAWER_END, Literal: literal, Line: line, Column: col, Offset: l.base + position}
 	}
 
 	// Check for drawer start :NAME: (must be only :NAME: on the line, possibly with whitespace)
-	if strings.HasPrefix(trimmed, ":") && strings.HasSuffix(trimmed, ":") && strings.Count(trimmed, ":") == 2 {
-		l.logger.Debug("token", "type", token.DRAWER_BEGIN, "literal", literal, "line", line)
-		return token.Token{Type: token.DRAWER_BEGIN, Literal: literal, Line: line, Column: col}
+	if isDrawer {
+		if closes {
+			if l.debug {
+				l.logger.Debug("token", "type", token.DRAWER_BEGIN, "literal", literal, "line", line)
+			}
+			return token.Token{Type: token.DRAWER_BEGIN, Literal: literal, Line: line, Column: col, Offset: l.base + position}
+		}
+		if l.debug {
+			l.logger.Debug("token", "type", token.TEXT, "literal", literal, "line", line, "note", "unclosed_drawer")
+		}
+		return token.Token{Type: token.TEXT, Literal: literal, Line: line, Column: col, Offset: l.base + position}
 	}
 
 	// Otherwise it's text (could be a property inside a drawer, parser will handle)
-	l.logger.Debug("token", "type", token.TEXT, "literal", literal, "line", line)
-	return token.Token{Type: token.TEXT, Literal: literal, Line: line, Column: col}
+	if l.debug {
+		l.logger.Debug("token", "type", token.TEXT, "literal", literal, "line", line)
+	}
+	return token.Token{Type: token.TEXT, Literal: literal, Line: line, Column: col, Offset: l.base + position}
+}
+
+// drawerCloses scans the lines after from for an :END: line, stopping at
+// the next headline. decided is false when a streaming lexer has not yet
+// buffered enough input to tell.
+//
+// The result of the last scan is kept, so that the :NAME: lines of a
+// section that has no :END: are not each scanned to the next headline.
+func (l *Lexer) drawerCloses(from int) (closes, decided bool) {
+	if abs := l.base + from; abs >= l.scanFrom && abs < l.scanStop {
+		return l.scanCloses, true
+	}
+	rest := l.input[from:] // Starts at the newline ending the drawer line
+	for rest != "" {
+		text, _, found := strings.Cut(rest[1:], "\n")
+		if !found && l.streaming && !l.closed {
+			return false, false
+		}
+		stop := len(l.input) - len(rest) + 1
+		if isHeadlineLine(text) {
+			return l.remember(from, stop, false), true
+		}
+		if strings.HasPrefix(text, ":") && strings.ToUpper(strings.TrimSpace(text)) == ":END:" {
+			return l.remember(from, stop, true), true
+		}
+		rest = rest[1+len(text):]
+	}
+	if l.streaming && !l.closed {
+		return false, false
+	}
+	return l.remember(from, len(l.input), false), true
+}
+
+// remember records a decided drawerCloses scan and returns closes
+func (l *Lexer) remember(from, stop int, closes bool) bool {
+	l.scanFrom, l.scanStop, l.scanCloses = l.base+from, l.base+stop, closes
+	return closes
+}
+
+// isHeadlineLine reports whether line starts with stars followed by a space
+func isHeadlineLine(line string) bool {
+	stars := len(line) - len(strings.TrimLeft(line, "*"))
+	return stars > 0 && stars < len(line) && line[stars] == ' '
 }
 
 // readDashLine handles - list items or ----- horizontal rules
@@ -396,8 +722,10 @@ func (l *Lexer) readDashLine() token.Token {
 	// Horizontal rule: 5+ dashes followed by end of line
 	if dashCount >= 5 && (l.ch == '\n' || l.ch == 0) {
 		literal := l.input[position:l.position]
-		l.logger.Debug("token", "type", token.TEXT, "literal", literal, "line", line, "note", "horizontal_rule")
-		return token.Token{Type: token.TEXT, Literal: literal, Line: line, Column: col}
+		if l.debug {
+			l.logger.Debug("token", "type", token.TEXT, "literal", literal, "line", line, "note", "horizontal_rule")
+		}
+		return token.Token{Type: token.TEXT, Literal: literal, Line: line, Column: col, Offset: l.base + position}
 	}
 
 	// List item: - followed by space
@@ -407,8 +735,10 @@ func (l *Lexer) readDashLine() token.Token {
 			l.readChar()
 		}
 		literal := l.input[position:l.position]
-		l.logger.Debug("token", "type", token.LIST_ITEM, "literal", literal, "line", line)
-		return token.Token{Type: token.LIST_ITEM, Literal: literal, Line: line, Column: col}
+		if l.debug {
+			l.logger.Debug("token", "type", token.LIST_ITEM, "literal", literal, "line", line)
+		}
+		return token.Token{Type: token.LIST_ITEM, Literal: literal, Line: line, Column: col, Offset: l.base + position}
 	}
 
 	// Not a list item or rule, read as text
@@ -416,8 +746,10 @@ func (l *Lexer) readDashLine() token.Token {
 		l.readChar()
 	}
 	literal := l.input[position:l.position]
-	l.logger.Debug("token", "type", token.TEXT, "literal", literal, "line", line)
-	return token.Token{Type: token.TEXT, Literal: literal, Line: line, Column: col}
+	if l.debug {
+		l.logger.Debug("token", "type", token.TEXT, "literal", literal, "line", line)
+	}
+	return token.Token{Type: token.TEXT, Literal: literal, Line: line, Column: col, Offset: l.base + position}
 }
 
 // readListItem handles + list items
@@ -431,8 +763,10 @@ func (l *Lexer) readListItem() token.Token {
 	}
 
 	literal := l.input[position:l.position]
-	l.logger.Debug("token", "type", token.LIST_ITEM, "literal", literal, "line", line)
-	return token.Token{Type: token.LIST_ITEM, Literal: literal, Line: line, Column: col}
+	if l.debug {
+		l.logger.Debug("token", "type", token.LIST_ITEM, "literal", literal, "line", line)
+	}
+	return token.Token{Type: token.LIST_ITEM, Literal: literal, Line: line, Column: col, Offset: l.base + position}
 }
 
 // tryReadOrderedListItem tries to read ordered list items like 1. or 1)
@@ -453,8 +787,10 @@ func (l *Lexer) tryReadOrderedListItem() token.Token {
 			l.readChar()
 		}
 		literal := l.input[position:l.position]
-		l.logger.Debug("token", "type", token.LIST_ITEM, "literal", literal, "line", line)
-		return token.Token{Type: token.LIST_ITEM, Literal: literal, Line: line, Column: col}
+		if l.debug {
+			l.logger.Debug("token", "type", token.LIST_ITEM, "literal", literal, "line", line)
+		}
+		return token.Token{Type: token.LIST_ITEM, Literal: literal, Line: line, Column: col, Offset: l.base + position}
 	}
 
 	// Not an ordered list, reset and return ILLEGAL to signal caller to read as text
@@ -463,8 +799,10 @@ func (l *Lexer) tryReadOrderedListItem() token.Token {
 		l.readChar()
 	}
 	literal := l.input[position:l.position]
-	l.logger.Debug("token", "type", token.TEXT, "literal", literal, "line", line)
-	return token.Token{Type: token.TEXT, Literal: literal, Line: line, Column: col}
+	if l.debug {
+		l.logger.Debug("token", "type", token.TEXT, "literal", literal, "line", line)
+	}
+	return token.Token{Type: token.TEXT, Literal: literal, Line: line, Column: col, Offset: l.base + position}
 }
 
 // tryReadIndentedListItem tries to read indented list items (for nested lists)
@@ -486,8 +824,10 @@ func (l *Lexer) tryReadIndentedListItem() token.Token {
 				l.readChar()
 			}
 			literal := l.input[position:l.position]
-			l.logger.Debug("token", "type", token.LIST_ITEM, "literal", literal, "line", line)
-			return token.Token{Type: token.LIST_ITEM, Literal: literal, Line: line, Column: col}
+			if l.debug {
+				l.logger.Debug("token", "type", token.LIST_ITEM, "literal", literal, "line", line)
+			}
+			return token.Token{Type: token.LIST_ITEM, Literal: literal, Line: line, Column: col, Offset: l.base + position}
 		}
 	}
 
@@ -503,8 +843,10 @@ func (l *Lexer) tryReadIndentedListItem() token.Token {
 				l.readChar()
 			}
 			literal := l.input[position:l.position]
-			l.logger.Debug("token", "type", token.LIST_ITEM, "literal", literal, "line", line)
-			return token.Token{Type: token.LIST_ITEM, Literal: literal, Line: line, Column: col}
+			if l.debug {
+				l.logger.Debug("token", "type", token.LIST_ITEM, "literal", literal, "line", line)
+			}
+			return token.Token{Type: token.LIST_ITEM, Literal: literal, Line: line, Column: col, Offset: l.base + position}
 		}
 		// Not a list, need to continue reading - reset position tracking
 		_ = startDigit // unused but keeps track
@@ -515,8 +857,10 @@ func (l *Lexer) tryReadIndentedListItem() token.Token {
 		l.readChar()
 	}
 	literal := l.input[position:l.position]
-	l.logger.Debug("token", "type", token.TEXT, "literal", literal, "line", line)
-	return token.Token{Type: token.TEXT, Literal: literal, Line: line, Column: col}
+	if l.debug {
+		l.logger.Debug("token", "type", token.TEXT, "literal", literal, "line", line)
+	}
+	return token.Token{Type: token.TEXT, Literal: literal, Line: line, Column: col, Offset: l.base + position}
 }
 
 // readTableRow handles | table | rows |
@@ -538,12 +882,16 @@ func (l *Lexer) readTableRow() token.Token {
 		!strings.ContainsAny(strings.Trim(trimmed, "|"), "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
 
 	if isSeparator && strings.Contains(trimmed, "-") {
-		l.logger.Debug("token", "type", token.TABLE_SEP, "literal", literal, "line", line)
-		return token.Token{Type: token.TABLE_SEP, Literal: literal, Line: line, Column: col}
+		if l.debug {
+			l.logger.Debug("token", "type", token.TABLE_SEP, "literal", literal, "line", line)
+		}
+		return token.Token{Type: token.TABLE_SEP, Literal: literal, Line: line, Column: col, Offset: l.base + position}
 	}
 
-	l.logger.Debug("token", "type", token.TABLE_ROW, "literal", literal, "line", line)
-	return token.Token{Type: token.TABLE_ROW, Literal: literal, Line: line, Column: col}
+	if l.debug {
+		l.logger.Debug("token", "type", token.TABLE_ROW, "literal", literal, "line", line)
+	}
+	return token.Token{Type: token.TABLE_ROW, Literal: literal, Line: line, Column: col, Offset: l.base + position}
 }
 
 // readTextLine reads until the next newline
@@ -557,6 +905,8 @@ func (l *Lexer) readTextLine() token.Token {
 	}
 
 	literal := l.input[position:l.position]
-	l.logger.Debug("token", "type", token.TEXT, "literal", literal, "line", line)
-	return token.Token{Type: token.TEXT, Literal: literal, Line: line, Column: col}
+	if l.debug {
+		l.logger.Debug("token", "type", token.TEXT, "literal", literal, "line", line)
+	}
+	return token.Token{Type: token.TEXT, Literal: literal, Line: line, Column: col, Offset: l.base + position}
 }
@@ -1,6 +1,8 @@
 package lexer
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/justyntemme/organelle/token"
@@ -44,3 +46,268 @@ Some paragraph text.`
 		}
 	}
 }
+
+func TestStreamMatchesWholeInput(t *testing.T) {
+	input := "* TODO Task\n:PROPERTIES:\n:ID: 1\n:END:\n- item\n| a | b |\nlast line without newline"
+
+	var want []token.Token
+	whole := New(input)
+	for {
+		tok := whole.NextToken()
+		want = append(want, tok)
+		if tok.Type == token.EOF {
+			break
+		}
+	}
+
+	l := NewStream()
+	var got []token.Token
+	for _, chunk := range []string{"* TO", "DO Task\n:PROP", "ERTIES:\n:ID: 1\n:END:\n- it", "em\n| a | b |\nlast line", " without newline"} {
+		if err := l.Append(chunk); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+			got = append(got, tok)
+		}
+		if l.Done() {
+			t.Fatal("expected stream not to be done before Close")
+		}
+	}
+	l.Close()
+	for {
+		tok := l.NextToken()
+		got = append(got, tok)
+		if tok.Type == token.EOF {
+			break
+		}
+	}
+	if !l.Done() {
+		t.Error("expected stream to be done after Close")
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("expected %d tokens, got=%d\n%v", len(want), len(got), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("token %d: expected %+v, got=%+v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestStreamDropsConsumedInput(t *testing.T) {
+	l := NewStream(WithMaxInputSize(16))
+	for i := 0; i < 100; i++ {
+		if err := l.Append("line of text\n"); err != nil {
+			t.Fatalf("append %d failed: %v", i, err)
+		}
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		}
+	}
+	if err := l.Append("x"); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	l.Close()
+	tok := l.NextToken()
+	if tok.Literal != "x" || tok.Line != 101 || tok.Offset != 1300 {
+		t.Errorf("unexpected final token %+v", tok)
+	}
+	if err := l.Append("more"); err != ErrStreamClosed {
+		t.Errorf("expected ErrStreamClosed, got=%v", err)
+	}
+	if err := New("").Append("x"); err != ErrNotStreaming {
+		t.Errorf("expected ErrNotStreaming, got=%v", err)
+	}
+}
+
+func TestStreamAppendMidLine(t *testing.T) {
+	whole := New("* - item title\nmore\n")
+	whole.NextToken()
+	want := whole.NextToken()
+
+	l := NewStream()
+	if err := l.Append("* - item title\n"); err != nil {
+		t.Fatal(err)
+	}
+	if tok := l.NextToken(); tok.Type != token.STARS {
+		t.Fatalf("expected STARS, got=%+v", tok)
+	}
+	// Appending drops the stars; the title is still not at a line start
+	if err := l.Append("more\n"); err != nil {
+		t.Fatal(err)
+	}
+	if tok := l.NextToken(); tok.Type != want.Type || tok.Literal != want.Literal {
+		t.Errorf("expected %s %q, got=%s %q", want.Type, want.Literal, tok.Type, tok.Literal)
+	}
+}
+
+func TestDrawerNeedsEnd(t *testing.T) {
+	tests := []struct {
+		input string
+		want  token.TokenType
+	}{
+		{":LOGBOOK:\n- note\n:END:\n", token.DRAWER_BEGIN},
+		{":LOGBOOK:\n- note\n* Next\n:END:\n", token.TEXT},
+		{":LOGBOOK:\n- note", token.TEXT},
+		{":LOGBOOK:\n  :END:\n", token.TEXT}, // Indented :END: does not close a drawer
+	}
+	for _, tt := range tests {
+		if got := New(tt.input).NextToken().Type; got != tt.want {
+			t.Errorf("%q: expected %q, got=%q", tt.input, tt.want, got)
+		}
+	}
+
+	// A streaming lexer waits until the drawer is decided
+	l := NewStream()
+	l.Append(":LOGBOOK:\n- note\n")
+	if tok := l.NextToken(); tok.Type != token.EOF {
+		t.Fatalf("expected to wait for more input, got %+v", tok)
+	}
+	l.Append(":END:\n")
+	if tok := l.NextToken(); tok.Type != token.DRAWER_BEGIN || tok.Literal != ":LOGBOOK:" {
+		t.Errorf("expected drawer once :END: arrived, got %+v", tok)
+	}
+}
+
+func TestDrawerScanIsReused(t *testing.T) {
+	// Unclosed :NAME: lines share one scan up to the headline, and the
+	// drawer after it is still scanned on its own
+	input := ":A:\n:B:\ntext\n:C:\n* H\n:D:\n:END:\n"
+	l := New(input)
+	var got []string
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		if tok.Type == token.TEXT || tok.Type == token.DRAWER_BEGIN {
+			got = append(got, string(tok.Type)+" "+tok.Literal)
+		}
+	}
+	want := []string{"TEXT :A:", "TEXT :B:", "TEXT text", "TEXT :C:", "TEXT  H", "DRAWER_BEGIN :D:"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestIllegalLines(t *testing.T) {
+	input := "* Ok\nbad \xff byte\n* nul\x00here\ntext\n"
+	l := New(input)
+	var got []token.Token
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		if tok.Type != token.NEWLINE {
+			got = append(got, tok)
+		}
+	}
+	expected := []struct {
+		typ    token.TokenType
+		line   int
+		offset int
+	}{
+		{token.STARS, 1, 0},
+		{token.TEXT, 1, 1},
+		{token.ILLEGAL, 2, 5},
+		{token.ILLEGAL, 3, 16},
+		{token.TEXT, 4, 27},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d tokens, got=%d: %+v", len(expected), len(got), got)
+	}
+	for i, e := range expected {
+		if got[i].Type != e.typ || got[i].Line != e.line || got[i].Offset != e.offset {
+			t.Errorf("token %d: expected %s at line %d offset %d, got %+v", i, e.typ, e.line, e.offset, got[i])
+		}
+	}
+	if got[3].Literal != "* nul\x00here" {
+		t.Errorf("expected the NUL line in full, got=%q", got[3].Literal)
+	}
+}
+
+// FuzzNextToken checks that the lexer terminates on any input and that
+// every token starts at or after the previous one
+func FuzzNextToken(f *testing.F) {
+	for _, seed := range []string{
+		"* TODO Title :tag:\nText\n",
+		":PROPERTIES:\n:ID: x\n:END:\n",
+		"#+BEGIN_SRC go\ncode\n#+END_SRC\n",
+		"- item\n  1. nested\n| a | b |\n|---+---|\n",
+		"\x00", "\xff\n*", ":\n:END:", "  \t- ", "1)", "-----",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		l := New(input)
+		last := -1
+		for n := 0; ; n++ {
+			if n > 2*len(input)+2 {
+				t.Fatalf("lexer does not make progress on %q", input)
+			}
+			tok := l.NextToken()
+			if tok.Type == token.EOF {
+				break
+			}
+			if tok.Offset <= last {
+				t.Fatalf("token %+v does not advance past offset %d in %q", tok, last, input)
+			}
+			last = tok.Offset
+		}
+		if !l.Done() {
+			t.Fatalf("lexer stopped early on %q", input)
+		}
+	})
+}
+
+func TestLongLineSplit(t *testing.T) {
+	input := "* Head\n" + "abcdefghij" + "klmnopqrst" + "uv\n* Next\n"
+	l := New(input, WithMaxLineLength(10))
+	var pieces []string
+	var next token.Token
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		if tok.Line == 2 && tok.Type != token.NEWLINE {
+			if tok.Type != token.TEXT {
+				t.Fatalf("expected TEXT pieces, got %+v", tok)
+			}
+			pieces = append(pieces, tok.Literal)
+		}
+		if tok.Type == token.STARS && tok.Line == 3 {
+			next = tok
+		}
+	}
+	if l.Err() != nil {
+		t.Fatalf("expected no fatal error, got %v", l.Err())
+	}
+	if len(pieces) != 3 || pieces[0] != "abcdefghij" || pieces[2] != "uv" {
+		t.Errorf("expected the line split into 10-character pieces, got %q", pieces)
+	}
+	if next.Literal != "*" {
+		t.Error("expected lexing to continue after the long line")
+	}
+	diags := l.Diagnostics()
+	if len(diags) != 1 || !errors.Is(diags[0], ErrLineTooLong) || diags[0].Error() != "line 2: line exceeds maximum allowed length" {
+		t.Errorf("unexpected diagnostics %v", diags)
+	}
+
+	fatal := New(input, WithMaxLineLength(10), WithFatalLongLines())
+	for tok := fatal.NextToken(); tok.Type != token.EOF; tok = fatal.NextToken() {
+		if tok.Line > 1 {
+			t.Fatalf("expected lexing to stop at line 2, got %+v", tok)
+		}
+	}
+	if fatal.Err() != ErrLineTooLong {
+		t.Errorf("expected ErrLineTooLong, got %v", fatal.Err())
+	}
+}
+
+func TestDiagnosticsInInputOrder(t *testing.T) {
+	input := "abcdefghijklmnop\nok\nqrstuvwxyzabcdef\n"
+	l := New(input, WithMaxLineLength(10))
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+	}
+	var got []string
+	for _, d := range l.Diagnostics() {
+		got = append(got, d.Error())
+	}
+	want := []string{
+		"line 1: line exceeds maximum allowed length",
+		"line 3: line exceeds maximum allowed length",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected %q, got=%q", want, got)
+	}
+}
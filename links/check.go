@@ -0,0 +1,262 @@
+package links
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/justyntemme/organelle/validate"
+)
+
+// Checker requests the targets of HTTP links to find dead ones
+type Checker struct {
+	client    *http.Client
+	perHost   int
+	retries   int
+	backoff   time.Duration
+	cachePath string
+	cacheTTL  time.Duration
+	now       func() time.Time
+}
+
+// CheckerOption is a functional option for configuring the Checker
+type CheckerOption func(*Checker)
+
+// WithClient sets the HTTP client (default one with a 10 second timeout)
+func WithClient(client *http.Client) CheckerOption {
+	return func(c *Checker) {
+		c.client = client
+	}
+}
+
+// WithPerHost limits the requests in flight to a single host (default 2)
+func WithPerHost(n int) CheckerOption {
+	return func(c *Checker) {
+		c.perHost = n
+	}
+}
+
+// WithRetries sets how often a request that fails with a network error,
+// 429 or a 5xx status is retried (default 2). The wait before a retry
+// starts at backoff and doubles each time (default 500ms).
+func WithRetries(n int, backoff time.Duration) CheckerOption {
+	return func(c *Checker) {
+		c.retries = n
+		c.backoff = backoff
+	}
+}
+
+// WithCache keeps results in a JSON file at path and reuses those younger
+// than ttl instead of requesting the URL again
+func WithCache(path string, ttl time.Duration) CheckerOption {
+	return func(c *Checker) {
+		c.cachePath = path
+		c.cacheTTL = ttl
+	}
+}
+
+// NewChecker creates a Checker
+func NewChecker(opts ...CheckerOption) *Checker {
+	c := &Checker{
+		client:  &http.Client{Timeout: 10 * time.Second},
+		perHost: 2,
+		retries: 2,
+		backoff: 500 * time.Millisecond,
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.perHost < 1 {
+		c.perHost = 1
+	}
+	return c
+}
+
+// Result is the outcome of requesting a URL
+type Result struct {
+	Status  int       `json:"status,omitempty"` // HTTP status, 0 if the request failed
+	Error   string    `json:"error,omitempty"`
+	Checked time.Time `json:"checked"`
+}
+
+// Dead reports whether the URL could not be fetched
+func (r Result) Dead() bool {
+	return r.Status == 0 || r.Status >= 400
+}
+
+func (r Result) String() string {
+	if r.Status == 0 {
+		return r.Error
+	}
+	return fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status))
+}
+
+// Check requests every distinct HTTP link target of files, which maps
+// file names to their links, concurrently within the per-host limit. Dead
+// links are reported at each place they appear, sorted by file and line
+// and then in document order. The error is only about reading or writing
+// the cache; a canceled ctx stops the remaining requests and reports
+// nothing for them.
+func (c *Checker) Check(ctx context.Context, files map[string][]Link) ([]validate.Violation, error) {
+	cache, err := c.loadCache()
+	if err != nil {
+		return nil, err
+	}
+
+	results := map[string]Result{}
+	var todo []string
+	for _, ls := range files {
+		for _, l := range ls {
+			if l.Kind != HTTP {
+				continue
+			}
+			if _, seen := results[l.URL]; seen {
+				continue
+			}
+			if r, ok := cache[l.URL]; ok && c.now().Sub(r.Checked) < c.cacheTTL {
+				results[l.URL] = r
+				continue
+			}
+			results[l.URL] = Result{}
+			todo = append(todo, l.URL)
+		}
+	}
+
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		hosts = map[string]chan struct{}{}
+	)
+	for _, u := range todo {
+		host := u
+		if parsed, err := url.Parse(u); err == nil {
+			host = parsed.Host
+		}
+		sem, ok := hosts[host]
+		if !ok {
+			sem = make(chan struct{}, c.perHost)
+			hosts[host] = sem
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			r, done := c.request(ctx, u)
+			<-sem
+			if !done {
+				return
+			}
+			mu.Lock()
+			results[u] = r
+			cache[u] = r
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	var out []validate.Violation
+	for name, ls := range files {
+		for _, l := range ls {
+			r := results[l.URL]
+			if l.Kind != HTTP || r.Checked.IsZero() || !r.Dead() {
+				continue
+			}
+			v := validate.Violation{File: name, Line: l.Line, Message: fmt.Sprintf("dead link %s: %s", l.URL, r)}
+			if l.Headline != nil {
+				v.Headline = l.Headline.Title
+			}
+			out = append(out, v)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].File != out[j].File {
+			return out[i].File < out[j].File
+		}
+		return out[i].Line < out[j].Line
+	})
+	return out, c.saveCache(cache)
+}
+
+// request fetches u with HEAD, falling back to GET for servers that do not
+// allow HEAD, and retries transient failures. done is false if ctx was
+// canceled first.
+func (c *Checker) request(ctx context.Context, u string) (r Result, done bool) {
+	wait := c.backoff
+	for attempt := 0; ; attempt++ {
+		r = c.fetch(ctx, http.MethodHead, u)
+		if r.Status == http.StatusMethodNotAllowed || r.Status == http.StatusNotImplemented {
+			r = c.fetch(ctx, http.MethodGet, u)
+		}
+		if ctx.Err() != nil {
+			return r, false
+		}
+		transient := r.Status == 0 || r.Status == http.StatusTooManyRequests || r.Status >= 500
+		if !transient || attempt >= c.retries {
+			return r, true
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return r, false
+		}
+		wait *= 2
+	}
+}
+
+func (c *Checker) fetch(ctx context.Context, method, u string) Result {
+	r := Result{Checked: c.now()}
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	resp.Body.Close()
+	r.Status = resp.StatusCode
+	return r
+}
+
+func (c *Checker) loadCache() (map[string]Result, error) {
+	cache := map[string]Result{}
+	if c.cachePath == "" {
+		return cache, nil
+	}
+	data, err := os.ReadFile(c.cachePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, fmt.Errorf("%s: %w", c.cachePath, err)
+	}
+	return cache, nil
+}
+
+func (c *Checker) saveCache(cache map[string]Result) error {
+	if c.cachePath == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.cachePath, append(data, '\n'), 0o644)
+}
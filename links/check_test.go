@@ -0,0 +1,144 @@
+package links
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+func TestChecker(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		flaky    int
+		inFlight int
+		maxSeen  int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		maxSeen = max(maxSeen, inFlight)
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+
+		switch r.URL.Path {
+		case "/ok":
+		case "/flaky":
+			mu.Lock()
+			flaky++
+			n := flaky
+			mu.Unlock()
+			if n == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		case "/get-only":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	input := "See [[" + srv.URL + "/ok]] and [[" + srv.URL + "/gone][gone]].\n" +
+		"* Notes\n[[" + srv.URL + "/flaky]] [[" + srv.URL + "/get-only]] [[#local]]\n" +
+		"Again: [[" + srv.URL + "/gone]]\n"
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	files := map[string][]Link{"notes.org": Extract(doc)}
+
+	cache := filepath.Join(t.TempDir(), "links.json")
+	c := NewChecker(WithPerHost(1), WithRetries(2, time.Millisecond), WithCache(cache, time.Hour))
+	got, err := c.Check(context.Background(), files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"notes.org:1: : dead link " + srv.URL + "/gone: 404 Not Found",
+		"notes.org:4: Notes: dead link " + srv.URL + "/gone: 404 Not Found",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d violations, got=%v", len(want), got)
+	}
+	for i, v := range got {
+		if v.String() != want[i] {
+			t.Errorf("violation %d: expected %q, got=%q", i, want[i], v.String())
+		}
+	}
+	if flaky != 2 {
+		t.Errorf("expected the flaky link to be retried once, got %d requests", flaky)
+	}
+	if maxSeen != 1 {
+		t.Errorf("expected one request at a time to the host, got %d", maxSeen)
+	}
+
+	// The cache answers without the server
+	srv.Close()
+	got, err = NewChecker(WithCache(cache, time.Hour)).Check(context.Background(), files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Errorf("expected cached results, got=%v", got)
+	}
+}
+
+func TestCheckerUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	doc := parser.New(lexer.New("[[" + url + "/x]]\n")).ParseDocument()
+	got, err := NewChecker(WithRetries(1, time.Millisecond)).Check(context.Background(), map[string][]Link{"a.org": Extract(doc)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Line != 1 {
+		t.Errorf("expected an unreachable host to be reported, got=%v", got)
+	}
+}
+
+func TestCheckerOrder(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	files := map[string][]Link{}
+	for _, name := range []string{"c.org", "a.org", "b.org"} {
+		input := "[[" + srv.URL + "/z]] [[" + srv.URL + "/y]]\n* H\n[[" + srv.URL + "/x]]\n"
+		files[name] = Extract(parser.New(lexer.New(input)).ParseDocument())
+	}
+	var want []string
+	for _, name := range []string{"a.org", "b.org", "c.org"} {
+		want = append(want,
+			name+":1: : dead link "+srv.URL+"/z: 404 Not Found",
+			name+":1: : dead link "+srv.URL+"/y: 404 Not Found",
+			name+":3: H: dead link "+srv.URL+"/x: 404 Not Found",
+		)
+	}
+	// Map iteration order varies between runs; the result must not
+	for range 5 {
+		got, err := NewChecker().Check(context.Background(), files)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("expected %d violations, got=%v", len(want), got)
+		}
+		for i, v := range got {
+			if v.String() != want[i] {
+				t.Fatalf("violation %d: expected %q, got=%q", i, want[i], v.String())
+			}
+		}
+	}
+}
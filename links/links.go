@@ -0,0 +1,262 @@
+// Package links lists the links of a document with where they appear, what
+// kind of target they have and whether the target could be found, for
+// broken-link checks, backlink indexes and prefetching.
+package links
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/justyntemme/organelle/anchor"
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/parser"
+)
+
+// Kind classifies a link by its target
+type Kind int
+
+const (
+	Internal Kind = iota // A headline, CUSTOM_ID or named element of the same document
+	File                 // file: links and bare paths
+	ID                   // id: links
+	HTTP                 // http: and https: links
+	Custom               // Any other scheme, such as mailto: or doi:
+)
+
+// String returns the name of the kind
+func (k Kind) String() string {
+	switch k {
+	case Internal:
+		return "internal"
+	case File:
+		return "file"
+	case ID:
+		return "id"
+	case HTTP:
+		return "http"
+	case Custom:
+		return "custom"
+	}
+	return "unknown"
+}
+
+// Status says whether a link's target was found
+type Status int
+
+const (
+	Unchecked Status = iota // The target cannot be checked from the document alone
+	Resolved
+	Broken
+)
+
+// String returns the name of the status
+func (s Status) String() string {
+	switch s {
+	case Unchecked:
+		return "unchecked"
+	case Resolved:
+		return "resolved"
+	case Broken:
+		return "broken"
+	}
+	return "unknown"
+}
+
+// Link is one link of a document
+type Link struct {
+	URL         string
+	Description string // Plain text of the description, empty if there is none
+	Kind        Kind
+	Status      Status
+
+	// Where the link starts. Offset is a byte offset in the input; Offset
+	// and Column are 0 for links in headline titles, whose position within
+	// the line is not recorded.
+	Line   int
+	Column int
+	Offset int
+
+	Headline *ast.Headline // The headline the link is under, nil at the top
+	Target   *ast.Headline // The headline an internal or id: link resolves to
+}
+
+// Extractor finds links
+type Extractor struct {
+	dir string
+}
+
+// Option is a functional option for configuring the Extractor
+type Option func(*Extractor)
+
+// WithDir checks file links relative to dir, the document's directory.
+// Without it file links are Unchecked.
+func WithDir(dir string) Option {
+	return func(e *Extractor) {
+		e.dir = dir
+	}
+}
+
+// New creates an Extractor
+func New(opts ...Option) *Extractor {
+	e := &Extractor{}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Extract returns the links of doc with New(opts...)
+func Extract(doc *ast.Document, opts ...Option) []Link {
+	return New(opts...).Extract(doc)
+}
+
+// Extract returns the links of doc in document order: those in headline
+// titles, paragraphs, list items and footnote definitions
+func (e *Extractor) Extract(doc *ast.Document) []Link {
+	x := &extraction{Extractor: e, doc: doc, anchors: anchor.New(doc)}
+	x.nodes(doc.Children, nil)
+	return x.out
+}
+
+type extraction struct {
+	*Extractor
+	doc     *ast.Document
+	anchors *anchor.Set
+	out     []Link
+}
+
+func (x *extraction) nodes(nodes []ast.Node, h *ast.Headline) {
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case *ast.Headline:
+			if strings.Contains(n.Title, "[[") {
+				if frag, _ := parser.ParseFragment(n.Title); len(frag) == 1 {
+					if p, ok := frag[0].(*ast.Paragraph); ok {
+						x.inline(p.Inline, n, n.Token.Line, -1, 0)
+					}
+				}
+			}
+			x.nodes(n.Children, n)
+		case *ast.Section:
+			x.nodes(n.Children, h)
+		case *ast.FootnoteDefinition:
+			x.nodes(n.Children, h)
+		case *ast.Paragraph:
+			x.inline(n.Inline, h, n.Token.Line, n.Token.Column, n.Token.Offset)
+		case *ast.List:
+			for _, item := range n.Items {
+				if strings.Contains(item.Content, "[[") {
+					// List item text is left unparsed by the parser
+					if frag, _ := parser.ParseFragment(item.Content); len(frag) == 1 {
+						if p, ok := frag[0].(*ast.Paragraph); ok {
+							skip := len(item.Token.Literal) - len(item.Content)
+							if !strings.HasSuffix(item.Token.Literal, item.Content) {
+								skip = 0
+							}
+							x.inline(p.Inline, h, item.Token.Line, item.Token.Column+skip, item.Token.Offset+skip)
+						}
+					}
+				}
+				x.nodes(item.Children, h)
+			}
+		}
+	}
+}
+
+// inline adds the links among elems, whose offsets are relative to a
+// text starting at column and offset; a negative column means the
+// position is unknown
+func (x *extraction) inline(elems []ast.InlineElement, h *ast.Headline, line, column, offset int) {
+	for _, el := range elems {
+		if el.Type == ast.InlineLink {
+			l := Link{URL: el.URL, Kind: Classify(el.URL), Line: line, Headline: h}
+			for _, c := range el.Children {
+				l.Description += c.PlainText()
+			}
+			if column >= 0 {
+				l.Column, l.Offset = column+el.Start, offset+el.Start
+			}
+			x.resolve(&l)
+			x.out = append(x.out, l)
+			continue
+		}
+		x.inline(el.Children, h, line, column, offset)
+	}
+}
+
+func (x *extraction) resolve(l *Link) {
+	switch l.Kind {
+	case Internal:
+		l.Status = Broken
+		if h, _, ok := x.anchors.Resolve(l.URL); ok {
+			l.Status, l.Target = Resolved, h
+		} else if h, _, ok := x.anchors.Resolve("*" + l.URL); ok {
+			// A bare target also matches a headline title
+			l.Status, l.Target = Resolved, h
+		} else if x.doc.NamedElement(l.URL) != nil {
+			l.Status = Resolved
+		}
+	case ID:
+		// The ID may belong to another file
+		if h, _, ok := x.anchors.Resolve(l.URL); ok {
+			l.Status, l.Target = Resolved, h
+		}
+	case File:
+		if x.dir == "" {
+			return
+		}
+		path := FilePath(l.URL)
+		if strings.HasPrefix(path, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return
+			}
+			path = filepath.Join(home, path[2:])
+		} else if !filepath.IsAbs(path) {
+			path = filepath.Join(x.dir, path)
+		}
+		l.Status = Broken
+		if _, err := os.Stat(path); err == nil {
+			l.Status = Resolved
+		}
+	}
+}
+
+// Classify returns the kind of a link target
+func Classify(url string) Kind {
+	switch {
+	case strings.HasPrefix(url, "file:"), strings.HasPrefix(url, "/"), strings.HasPrefix(url, "./"),
+		strings.HasPrefix(url, "../"), strings.HasPrefix(url, "~/"):
+		return File
+	case strings.HasPrefix(url, "id:"):
+		return ID
+	case strings.HasPrefix(url, "http://"), strings.HasPrefix(url, "https://"):
+		return HTTP
+	}
+	if scheme, _, ok := strings.Cut(url, ":"); ok && isScheme(scheme) {
+		return Custom
+	}
+	return Internal
+}
+
+// isScheme reports whether s looks like a URL scheme
+func isScheme(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		letter := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+		if !letter && (i == 0 || !(r >= '0' && r <= '9' || r == '+' || r == '-' || r == '.')) {
+			return false
+		}
+	}
+	return true
+}
+
+// FilePath returns the path of a file link without the file: prefix and
+// any ::search option
+func FilePath(url string) string {
+	path, _, _ := strings.Cut(strings.TrimPrefix(url, "file:"), "::")
+	return path
+}
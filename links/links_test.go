@@ -0,0 +1,111 @@
+package links
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+const input = `See [[https://go.dev][the *Go* site]] and [[#setup]].
+* Setup
+:PROPERTIES:
+:CUSTOM_ID: setup
+:ID: 42
+:END:
+- read [[file:notes.org::*Intro][notes]] and [[./missing.org]]
+* Usage
+Back to [[id:42]], [[id:elsewhere]], [[Setup]], [[table1]] and [[Nowhere]].
+#+NAME: table1
+| a |
+* Contact [[mailto:me@example.com][mail]]
+`
+
+func TestExtract(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "notes.org"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	links := Extract(doc, WithDir(dir))
+
+	tests := []struct {
+		url, desc string
+		kind      Kind
+		status    Status
+		line      int
+		headline  string
+		target    string
+	}{
+		{"https://go.dev", "the Go site", HTTP, Unchecked, 1, "", ""},
+		{"#setup", "", Internal, Resolved, 1, "", "Setup"},
+		{"file:notes.org::*Intro", "notes", File, Resolved, 7, "Setup", ""},
+		{"./missing.org", "", File, Broken, 7, "Setup", ""},
+		{"id:42", "", ID, Resolved, 9, "Usage", "Setup"},
+		{"id:elsewhere", "", ID, Unchecked, 9, "Usage", ""},
+		{"Setup", "", Internal, Resolved, 9, "Usage", "Setup"},
+		{"table1", "", Internal, Resolved, 9, "Usage", ""},
+		{"Nowhere", "", Internal, Broken, 9, "Usage", ""},
+		{"mailto:me@example.com", "mail", Custom, Unchecked, 12, "Contact [[mailto:me@example.com][mail]]", ""},
+	}
+	if len(links) != len(tests) {
+		t.Fatalf("expected %d links, got=%d: %+v", len(tests), len(links), links)
+	}
+	for i, tt := range tests {
+		l := links[i]
+		headline, target := "", ""
+		if l.Headline != nil {
+			headline = l.Headline.Title
+		}
+		if l.Target != nil {
+			target = l.Target.Title
+		}
+		if l.URL != tt.url || l.Description != tt.desc || l.Kind != tt.kind || l.Status != tt.status ||
+			l.Line != tt.line || headline != tt.headline || target != tt.target {
+			t.Errorf("link %d: expected %+v, got=%s %q %s %s line %d under %q to %q",
+				i, tt, l.URL, l.Description, l.Kind, l.Status, l.Line, headline, target)
+		}
+	}
+
+	// Positions point at the opening brackets
+	for _, i := range []int{0, 1, 2, 3, 4} {
+		l := links[i]
+		if got := input[l.Offset : l.Offset+2]; got != "[[" {
+			t.Errorf("link %d: expected offset at [[, got %q", i, got)
+		}
+	}
+	if links[1].Column != 43 {
+		t.Errorf("expected column 43, got=%d", links[1].Column)
+	}
+	if links[9].Offset != 0 {
+		t.Errorf("expected no offset for a headline link, got=%d", links[2].Offset)
+	}
+	if l := Extract(doc)[2]; l.Status != Unchecked {
+		t.Errorf("expected file links to be unchecked without a directory, got=%s", l.Status)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := map[string]Kind{
+		"https://x.org": HTTP,
+		"http://x.org":  HTTP,
+		"file:a.org":    File,
+		"/etc/hosts":    File,
+		"../up.org":     File,
+		"~/notes.org":   File,
+		"id:123":        ID,
+		"doi:10.1000/1": Custom,
+		"elisp:(foo)":   Custom,
+		"*Heading":      Internal,
+		"#custom":       Internal,
+		"Some heading":  Internal,
+		"10:30 meeting": Internal,
+	}
+	for url, want := range tests {
+		if got := Classify(url); got != want {
+			t.Errorf("Classify(%q): expected %s, got=%s", url, want, got)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package org
+
+import (
+	"crypto/rand"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+	"github.com/justyntemme/organelle/todo"
+	"github.com/justyntemme/organelle/writer"
+)
+
+var (
+	shiftRegex = regexp.MustCompile(`^\+?(\d+)([hdwmy])$`)
+	// Active timestamps: date, optional day name, optional time or time
+	// range, and the repeater and warning left as they are
+	activeTimestampRegex = regexp.MustCompile(`<(\d{4}-\d{2}-\d{2})(?: [^\s\d>]+)?(?: (\d{1,2}:\d{2})(?:-(\d{1,2}:\d{2}))?)?([^>]*)>`)
+	// A repeater such as " +1w", " .+1d" or " ++1m", as Org removes it
+	repeaterRegex = regexp.MustCompile(` +[.+]?\+\d+[hdwmy]`)
+	// Verbatim and code spans, whose timestamps are text and never move
+	verbatimRegexes = []*regexp.Regexp{
+		regexp.MustCompile(`(?:^|[\s\-('"{])(=(?:\S|\S.*?\S)=)(?:$|[\s\-.,:!?;'")}\[])`),
+		regexp.MustCompile(`(?:^|[\s\-('"{])(~(?:\S|\S.*?\S)~)(?:$|[\s\-.,:!?;'")}\[])`),
+	}
+)
+
+// CloneSubtree returns count copies of h, like org-clone-subtree-with-time-shift.
+// Copy n has every active timestamp moved n times by shift, written as in
+// Org: "+1w", "2d", "3h", "1m" or "1y". An empty shift keeps the dates.
+// Inactive timestamps are records of the past and are kept, as is the
+// content of blocks such as src and example blocks and of =verbatim= and
+// ~code~ spans. Headlines with an ID property get a new ID in every copy
+// so that IDs stay unique. h is not modified; insert the copies after it
+// to build the series.
+//
+// As in Org, when shift is set and the subtree has a repeating timestamp,
+// the copies lose their repeaters and one more copy, moved count+1 times,
+// keeps them, so that only the end of the series repeats. Org also drops
+// the repeaters of the original entry; replace h with StripRepeaters(h)
+// to do the same.
+func CloneSubtree(h *ast.Headline, count int, shift string) ([]*ast.Headline, error) {
+	if count < 0 {
+		return nil, fmt.Errorf("org: negative clone count %d", count)
+	}
+	n, unit := 0, byte('d')
+	if shift != "" {
+		m := shiftRegex.FindStringSubmatch(shift)
+		if m == nil {
+			return nil, fmt.Errorf("org: invalid time shift %q", shift)
+		}
+		n, _ = strconv.Atoi(m[1])
+		unit = m[2][0]
+	}
+
+	src := writer.String(&ast.Document{Children: []ast.Node{h}})
+	total := count
+	repeats := shift != "" && count > 0 && hasRepeater(src)
+	if repeats {
+		total++
+	}
+	clones := make([]*ast.Headline, 0, total)
+	for i := 1; i <= total; i++ {
+		strip := repeats && i < total
+		text := mapTimestamps(src, func(ts string) string {
+			return shiftTimestamp(ts, n*i, unit, strip)
+		})
+		clone, err := reparse(h, text)
+		if err != nil {
+			return nil, err
+		}
+		if err := renewIDs(clone); err != nil {
+			return nil, err
+		}
+		clones = append(clones, clone)
+	}
+	return clones, nil
+}
+
+// StripRepeaters returns a copy of h whose active timestamps have no
+// repeaters, as org-clone-subtree-with-time-shift leaves the original
+// entry of a repeating series. IDs are kept. h is not modified.
+func StripRepeaters(h *ast.Headline) (*ast.Headline, error) {
+	src := writer.String(&ast.Document{Children: []ast.Node{h}})
+	return reparse(h, mapTimestamps(src, func(ts string) string {
+		return repeaterRegex.ReplaceAllString(ts, "")
+	}))
+}
+
+// reparse parses text, the written form of h, back into a headline
+func reparse(h *ast.Headline, text string) (*ast.Headline, error) {
+	keywords := parser.WithTodoMatcher(subtreeKeywords(h))
+	doc := parser.New(lexer.New(text), keywords).ParseDocument()
+	clone, ok := firstHeadline(doc)
+	if !ok {
+		return nil, fmt.Errorf("org: subtree %q does not parse back", h.Title)
+	}
+	return clone, nil
+}
+
+// hasRepeater reports whether an active timestamp in src repeats
+func hasRepeater(src string) bool {
+	found := false
+	mapTimestamps(src, func(ts string) string {
+		found = found || repeaterRegex.MatchString(ts)
+		return ts
+	})
+	return found
+}
+
+// mapTimestamps replaces the active timestamps in src with fn's result,
+// leaving the lines inside blocks and verbatim and code spans alone
+func mapTimestamps(src string, fn func(string) string) string {
+	var out strings.Builder
+	inBlock := false
+	for _, line := range strings.SplitAfter(src, "\n") {
+		directive := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case !inBlock && strings.HasPrefix(directive, "#+BEGIN_"):
+			inBlock = true
+		case inBlock && strings.HasPrefix(directive, "#+END_"):
+			inBlock = false
+		case !inBlock:
+			line = mapOutsideVerbatim(line, fn)
+		}
+		out.WriteString(line)
+	}
+	return out.String()
+}
+
+// mapOutsideVerbatim replaces the active timestamps in line that are not
+// inside a verbatim or code span
+func mapOutsideVerbatim(line string, fn func(string) string) string {
+	var spans [][]int
+	for _, re := range verbatimRegexes {
+		for _, m := range re.FindAllStringSubmatchIndex(line, -1) {
+			spans = append(spans, m[2:4])
+		}
+	}
+	var out strings.Builder
+	last := 0
+	for _, loc := range activeTimestampRegex.FindAllStringIndex(line, -1) {
+		if within(loc, spans) {
+			continue
+		}
+		out.WriteString(line[last:loc[0]])
+		out.WriteString(fn(line[loc[0]:loc[1]]))
+		last = loc[1]
+	}
+	out.WriteString(line[last:])
+	return out.String()
+}
+
+// within reports whether loc lies inside one of spans
+func within(loc []int, spans [][]int) bool {
+	for _, span := range spans {
+		if loc[0] >= span[0] && loc[1] <= span[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// shiftTimestamp moves an active timestamp by n units, keeping its form.
+// With strip, its repeater is dropped.
+func shiftTimestamp(ts string, n int, unit byte, strip bool) string {
+	m := activeTimestampRegex.FindStringSubmatch(ts)
+	date, err := time.Parse("2006-01-02 15:04", m[1]+" "+orMidnight(m[2]))
+	if err != nil {
+		return ts
+	}
+	move := func(t time.Time) time.Time {
+		switch unit {
+		case 'h':
+			return t.Add(time.Duration(n) * time.Hour)
+		case 'w':
+			return t.AddDate(0, 0, 7*n)
+		case 'm':
+			return t.AddDate(0, n, 0)
+		case 'y':
+			return t.AddDate(n, 0, 0)
+		}
+		return t.AddDate(0, 0, n)
+	}
+	start := move(date)
+
+	out := "<" + start.Format("2006-01-02 Mon")
+	if m[2] != "" {
+		out += " " + start.Format("15:04")
+		if m[3] != "" {
+			if end, err := time.Parse("2006-01-02 15:04", m[1]+" "+m[3]); err == nil {
+				out += "-" + move(end).Format("15:04")
+			}
+		}
+	}
+	rest := m[4]
+	if strip {
+		rest = repeaterRegex.ReplaceAllString(rest, "")
+	}
+	return out + rest + ">"
+}
+
+func orMidnight(clock string) string {
+	if clock == "" {
+		return "00:00"
+	}
+	return clock
+}
+
+// subtreeKeywords matches the keywords used in h, so that custom ones
+// survive parsing the copies
+func subtreeKeywords(h *ast.Headline) *todo.Matcher {
+	m := todo.Default
+	var walk func(*ast.Headline)
+	walk = func(h *ast.Headline) {
+		if h.Keyword != "" && !m.IsKeyword(h.Keyword) {
+			m = m.Extend(todo.Keyword{Name: h.Keyword, Done: h.Done})
+		}
+		for _, sub := range h.Subheadlines() {
+			walk(sub)
+		}
+	}
+	walk(h)
+	return m
+}
+
+func firstHeadline(doc *ast.Document) (*ast.Headline, bool) {
+	for _, n := range doc.Children {
+		if h, ok := n.(*ast.Headline); ok {
+			return h, true
+		}
+	}
+	return nil, false
+}
+
+// renewIDs gives h and its subheadlines that have an ID property a new one
+func renewIDs(h *ast.Headline) error {
+	if _, ok := h.Property("ID"); ok {
+		id, err := newID()
+		if err != nil {
+			return err
+		}
+		SetProperty(h, "ID", id)
+	}
+	for _, sub := range h.Subheadlines() {
+		if err := renewIDs(sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newID returns a random UUID, the format org-id uses by default
+func newID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40 // Version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
@@ -0,0 +1,132 @@
+package org
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+	"github.com/justyntemme/organelle/writer"
+)
+
+func TestCloneSubtree(t *testing.T) {
+	input := `#+TODO: NEXT | DONE
+* NEXT Team meeting
+SCHEDULED: <2024-01-29 Mon 10:00 +1w>
+:PROPERTIES:
+:ID: 11111111-2222-4333-8444-555555555555
+:END:
+Agenda sent on [2024-01-20 Sat], room booked for <2024-01-29 Mon 10:00-11:00>.
+** Prepare notes
+DEADLINE: <2024-01-28 Sun>
+`
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	h := doc.Children[1].(*ast.Headline)
+
+	clones, err := CloneSubtree(h, 2, "+1w")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clones) != 3 {
+		t.Fatalf("expected 2 clones and a repeating one, got=%d", len(clones))
+	}
+
+	second := clones[1]
+	if second.Keyword != "NEXT" || second.Title != "Team meeting" {
+		t.Errorf("expected the custom keyword to survive, got (%q, %q)", second.Keyword, second.Title)
+	}
+	if got := second.Scheduled.String(); got != "<2024-02-12 Mon 10:00>" {
+		t.Errorf("expected SCHEDULED two weeks later without the repeater, got=%s", got)
+	}
+	if got := clones[2].Scheduled.String(); got != "<2024-02-19 Mon 10:00 +1w>" {
+		t.Errorf("expected the last clone to keep the repeater, got=%s", got)
+	}
+	if got := second.Subheadlines()[0].Deadline.String(); got != "<2024-02-11 Sun>" {
+		t.Errorf("expected the subheadline's DEADLINE shifted, got=%s", got)
+	}
+	out := writer.String(&ast.Document{Children: []ast.Node{second}})
+	if !strings.Contains(out, "[2024-01-20 Sat]") || !strings.Contains(out, "<2024-02-12 Mon 10:00-11:00>.") {
+		t.Errorf("expected active body timestamps shifted and inactive ones kept:\n%s", out)
+	}
+
+	id0, _ := clones[0].Property("ID")
+	id1, _ := clones[1].Property("ID")
+	if orig, _ := h.Property("ID"); id0 == orig || id0 == id1 || len(id0) != 36 {
+		t.Errorf("expected fresh IDs, got %q and %q", id0, id1)
+	}
+	if h.Scheduled.String() != "<2024-01-29 Mon 10:00 +1w>" {
+		t.Errorf("expected the original to be unchanged, got %s", h.Scheduled)
+	}
+
+	stripped, err := StripRepeaters(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := stripped.Scheduled.String(); got != "<2024-01-29 Mon 10:00>" {
+		t.Errorf("expected the repeater stripped, got=%s", got)
+	}
+	if id, _ := stripped.Property("ID"); id != "11111111-2222-4333-8444-555555555555" {
+		t.Errorf("expected StripRepeaters to keep the ID, got=%q", id)
+	}
+}
+
+func TestCloneSubtreeWithoutShiftKeepsRepeaters(t *testing.T) {
+	h := &ast.Headline{Level: 1, Title: "Review", Scheduled: parser.ParseTimestamp("<2024-01-29 Mon .+1d>")}
+	clones, err := CloneSubtree(h, 2, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clones) != 2 || clones[1].Scheduled.String() != "<2024-01-29 Mon .+1d>" {
+		t.Errorf("expected plain copies, got=%+v", clones)
+	}
+}
+
+func TestCloneSubtreeShifts(t *testing.T) {
+	tests := []struct {
+		shift, want string
+	}{
+		{"", "<2024-01-31 Wed 23:00>"},
+		{"2h", "<2024-02-01 Thu 01:00>"},
+		{"1d", "<2024-02-01 Thu 23:00>"},
+		{"+1m", "<2024-03-02 Sat 23:00>"},
+		{"1y", "<2025-01-31 Fri 23:00>"},
+	}
+	for _, tt := range tests {
+		h := &ast.Headline{Level: 1, Title: "Event", Scheduled: parser.ParseTimestamp("<2024-01-31 Wed 23:00>")}
+		clones, err := CloneSubtree(h, 1, tt.shift)
+		if err != nil {
+			t.Fatalf("shift %q: %v", tt.shift, err)
+		}
+		if got := clones[0].Scheduled.String(); got != tt.want {
+			t.Errorf("shift %q: expected %s, got=%s", tt.shift, tt.want, got)
+		}
+	}
+	if _, err := CloneSubtree(&ast.Headline{Level: 1}, 1, "1 week"); err == nil {
+		t.Error("expected an invalid shift to fail")
+	}
+}
+
+func TestCloneSubtreeKeepsBlocks(t *testing.T) {
+	input := `* Release
+SCHEDULED: <2024-01-29 Mon>
+#+BEGIN_SRC sh
+echo "<2024-01-29 Mon>"
+#+END_SRC
+Ship on <2024-01-30 Tue>, not =<2024-01-30 Tue>= or ~<2024-01-30 Tue>~.
+`
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	clones, err := CloneSubtree(doc.Children[0].(*ast.Headline), 1, "1d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := writer.String(&ast.Document{Children: []ast.Node{clones[0]}})
+	want := "Ship on <2024-01-31 Wed>, not =<2024-01-30 Tue>= or ~<2024-01-30 Tue>~."
+	if !strings.Contains(out, `echo "<2024-01-29 Mon>"`) || !strings.Contains(out, want) {
+		t.Errorf("expected only timestamps outside blocks and verbatim to move:\n%s", out)
+	}
+
+	if _, err := CloneSubtree(&ast.Headline{Level: 1}, -1, ""); err == nil {
+		t.Error("expected a negative count to fail")
+	}
+}
@@ -0,0 +1,112 @@
+package org
+
+import (
+	"github.com/justyntemme/organelle/ast"
+)
+
+// checkboxCells maps checkbox states to the cell text used when a list
+// with checkboxes becomes a table
+var checkboxCells = map[ast.CheckboxState]string{
+	ast.CheckboxUnchecked: "[ ]",
+	ast.CheckboxChecked:   "[X]",
+	ast.CheckboxPartial:   "[-]",
+}
+
+// ListToTable converts a two-level list into a table: every top-level item
+// becomes a row whose first cell is the item text and whose remaining
+// cells are the texts of its nested items. When any item has a checkbox,
+// a leading column holds the checkbox state.
+func ListToTable(l *ast.List) *ast.Table {
+	table := &ast.Table{Token: l.Token}
+
+	hasCheckbox := false
+	for _, item := range l.Items {
+		if item.Checkbox != ast.CheckboxNone {
+			hasCheckbox = true
+			break
+		}
+	}
+
+	for _, item := range l.Items {
+		row := &ast.TableRow{Token: item.Token}
+		if hasCheckbox {
+			row.Cells = append(row.Cells, checkboxCells[item.Checkbox])
+		}
+		row.Cells = append(row.Cells, EscapeTableCell(item.Content))
+		for _, child := range item.Children {
+			nested, ok := child.(*ast.List)
+			if !ok {
+				continue
+			}
+			for _, sub := range nested.Items {
+				row.Cells = append(row.Cells, EscapeTableCell(sub.Content))
+			}
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table
+}
+
+// TableToList converts a table into a two-level list, the inverse of
+// ListToTable. Separator rows are dropped; a leading checkbox column is
+// turned back into item checkboxes; empty cells produce no nested item;
+// \vert{} entities become pipes again.
+func TableToList(t *ast.Table) *ast.List {
+	list := &ast.List{Token: t.Token, Items: []*ast.ListItem{}}
+
+	checkboxColumn := len(t.Rows) > 0
+	for _, row := range t.Rows {
+		if row.Separator {
+			continue
+		}
+		if len(row.Cells) == 0 || checkboxState(row.Cells[0]) == ast.CheckboxNone {
+			checkboxColumn = false
+			break
+		}
+	}
+
+	for _, row := range t.Rows {
+		if row.Separator || len(row.Cells) == 0 {
+			continue
+		}
+		cells := row.Cells
+		item := &ast.ListItem{Token: row.Token, Children: []ast.Node{}}
+		if checkboxColumn {
+			item.Checkbox = checkboxState(cells[0])
+			cells = cells[1:]
+		}
+		if len(cells) > 0 {
+			item.Content = UnescapeTableCell(cells[0])
+			cells = cells[1:]
+		}
+
+		var nested *ast.List
+		for _, cell := range cells {
+			if cell == "" {
+				continue
+			}
+			if nested == nil {
+				nested = &ast.List{Token: row.Token, Items: []*ast.ListItem{}}
+				item.Children = append(item.Children, nested)
+			}
+			nested.Items = append(nested.Items, &ast.ListItem{
+				Token:    row.Token,
+				Indent:   2,
+				Content:  UnescapeTableCell(cell),
+				Children: []ast.Node{},
+			})
+		}
+		list.Items = append(list.Items, item)
+	}
+	return list
+}
+
+// checkboxState parses a checkbox cell produced by ListToTable
+func checkboxState(cell string) ast.CheckboxState {
+	for state, text := range checkboxCells {
+		if cell == text {
+			return state
+		}
+	}
+	return ast.CheckboxNone
+}
@@ -0,0 +1,87 @@
+package org
+
+import (
+	"testing"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+func TestListToTable(t *testing.T) {
+	input := `- [X] Alice
+  - 30
+  - NYC
+- [ ] Bob
+  - 25
+`
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	table := ListToTable(doc.Children[0].(*ast.List))
+
+	if len(table.Rows) != 2 {
+		t.Fatalf("expected 2 rows, got=%d", len(table.Rows))
+	}
+	want := []string{"[X]", "Alice", "30", "NYC"}
+	if got := table.Rows[0].Cells; len(got) != len(want) {
+		t.Fatalf("expected cells %v, got=%v", want, got)
+	}
+	for i, c := range want {
+		if table.Rows[0].Cells[i] != c {
+			t.Errorf("cell %d expected %q, got=%q", i, c, table.Rows[0].Cells[i])
+		}
+	}
+}
+
+func TestTableToListRoundTrip(t *testing.T) {
+	input := `| [X] | Alice | 30 | NYC |
+|-----+-------+----+-----|
+| [-] | Bob   | 25 |     |
+`
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	list := TableToList(doc.Children[0].(*ast.Table))
+
+	if len(list.Items) != 2 {
+		t.Fatalf("expected 2 items, got=%d", len(list.Items))
+	}
+	bob := list.Items[1]
+	if bob.Checkbox != ast.CheckboxPartial || bob.Content != "Bob" {
+		t.Errorf("expected partial Bob, got checkbox=%d content=%q", bob.Checkbox, bob.Content)
+	}
+	nested := bob.Children[0].(*ast.List)
+	if len(nested.Items) != 1 || nested.Items[0].Content != "25" {
+		t.Errorf("expected single nested item '25', got=%d items", len(nested.Items))
+	}
+
+	back := ListToTable(list)
+	if len(back.Rows[0].Cells) != 4 || back.Rows[0].Cells[0] != "[X]" {
+		t.Errorf("round trip lost cells: %v", back.Rows[0].Cells)
+	}
+}
+
+func TestTableToListUnescapesPipes(t *testing.T) {
+	input := `- a|b
+  - x | y
+`
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	table := ListToTable(doc.Children[0].(*ast.List))
+	if got := table.Rows[0].Cells; got[0] != `a\vert{}b` || got[1] != `x \vert{} y` {
+		t.Fatalf("expected escaped pipes, got=%q", got)
+	}
+
+	list := TableToList(table)
+	if got := list.Items[0].Content; got != "a|b" {
+		t.Errorf("expected item %q, got=%q", "a|b", got)
+	}
+	if got := list.Items[0].Children[0].(*ast.List).Items[0].Content; got != "x | y" {
+		t.Errorf("expected nested item %q, got=%q", "x | y", got)
+	}
+}
+
+func TestTableToListWithoutCheckboxes(t *testing.T) {
+	doc := parser.New(lexer.New("| a | b |\n| c | d |\n")).ParseDocument()
+	list := TableToList(doc.Children[0].(*ast.Table))
+
+	if list.Items[0].Checkbox != ast.CheckboxNone || list.Items[0].Content != "a" {
+		t.Errorf("unexpected first item: %+v", list.Items[0])
+	}
+}
@@ -0,0 +1,126 @@
+package org
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/justyntemme/organelle/todo"
+)
+
+// zeroWidthSpace is Org's conventional escape character: inserted before
+// a marker it stops the marker from being recognized without changing
+// the rendered text
+const zeroWidthSpace = "\u200b"
+
+// tagSuffixRegex matches text that the parser would split off as tags
+var tagSuffixRegex = regexp.MustCompile(`\s:[a-zA-Z0-9_@#%:]+:\s*$`)
+
+// EscapeText makes arbitrary text safe to embed as paragraph content.
+// Every line that would start a structural element (headline, keyword,
+// table, drawer, list item, footnote definition, or a planning line right
+// below a headline) is prefixed with a zero-width space.
+func EscapeText(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if startsStructure(line) {
+			lines[i] = zeroWidthSpace + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// EscapeStrategy selects how EscapeMarkup neutralizes emphasis markers
+type EscapeStrategy int
+
+const (
+	// EscapeZeroWidthSpace follows each marker with a zero-width space.
+	// The text renders unchanged but no longer starts or ends emphasis.
+	EscapeZeroWidthSpace EscapeStrategy = iota
+	// EscapeEntities replaces markers that have an Org entity with that
+	// entity (\ast{} for *) and falls back to a zero-width space for
+	// the others.
+	EscapeEntities
+)
+
+// markerEntities holds the entities usable for markup characters
+var markerEntities = map[rune]string{
+	'*': `\ast{}`,
+}
+
+// EscapeMarkup neutralizes emphasis markers (* / ~ = + _) so text written
+// into a paragraph is read back literally instead of as markup
+func EscapeMarkup(s string, strategy EscapeStrategy) string {
+	var out strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '/', '~', '=', '+', '_':
+			if entity, ok := markerEntities[r]; ok && strategy == EscapeEntities {
+				out.WriteString(entity)
+				continue
+			}
+			out.WriteRune(r)
+			out.WriteString(zeroWidthSpace)
+		default:
+			out.WriteRune(r)
+		}
+	}
+	return out.String()
+}
+
+// EscapeTableCell makes text safe to place in a single table cell. Pipes
+// become the \vert entity and line breaks are folded into spaces.
+func EscapeTableCell(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", " ")
+	s = strings.ReplaceAll(s, "\n", " ")
+	return strings.ReplaceAll(s, "|", `\vert{}`)
+}
+
+// UnescapeTableCell turns the \vert{} entities written by EscapeTableCell
+// back into pipes. Folded line breaks stay spaces.
+func UnescapeTableCell(s string) string {
+	return strings.ReplaceAll(s, `\vert{}`, "|")
+}
+
+// EscapeHeadlineTitle makes text safe to use as a headline title. Line
+// breaks are folded, and a leading TODO keyword of m (todo.Default if
+// nil) or priority cookie and a trailing :tag: lookalike are guarded so
+// they stay part of the title.
+func EscapeHeadlineTitle(s string, m *todo.Matcher) string {
+	if m == nil {
+		m = todo.Default
+	}
+	s = strings.ReplaceAll(s, "\r\n", " ")
+	s = strings.ReplaceAll(s, "\n", " ")
+	s = strings.TrimSpace(s)
+
+	if kw, _ := m.Match(s); kw != "" || strings.HasPrefix(s, "[#") {
+		s = zeroWidthSpace + s
+	}
+	if tagSuffixRegex.MatchString(s) {
+		s = strings.TrimRight(s, " \t") + zeroWidthSpace
+	}
+	return s
+}
+
+// startsStructure reports whether the lexer would treat line as anything
+// other than paragraph text
+func startsStructure(line string) bool {
+	trimmed := strings.TrimLeft(line, " \t")
+	if trimmed == "" {
+		return false
+	}
+	switch trimmed[0] {
+	case '*', '#', '|', ':', '-', '+':
+		return true
+	}
+	for _, prefix := range []string{"[fn:", "SCHEDULED:", "DEADLINE:", "CLOSED:"} {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	i := 0
+	for i < len(trimmed) && trimmed[i] >= '0' && trimmed[i] <= '9' {
+		i++
+	}
+	return i > 0 && i < len(trimmed) && (trimmed[i] == '.' || trimmed[i] == ')')
+}
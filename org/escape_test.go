@@ -0,0 +1,101 @@
+package org
+
+import (
+	"testing"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+	"github.com/justyntemme/organelle/todo"
+)
+
+func TestEscapeText(t *testing.T) {
+	input := "* heading\n#+TITLE: x\n| a |\n:DRAWER:\n- item\n12. item\n[fn:x] note\nplain"
+	doc := parser.New(lexer.New(EscapeText(input))).ParseDocument()
+
+	for _, n := range doc.Children {
+		if _, ok := n.(*ast.Paragraph); !ok {
+			t.Errorf("expected only paragraphs, got %T", n)
+		}
+	}
+	if len(doc.Children) != 8 {
+		t.Errorf("expected 8 paragraphs, got=%d", len(doc.Children))
+	}
+}
+
+func TestEscapeTextPlanning(t *testing.T) {
+	for _, line := range []string{"SCHEDULED: <2024-02-01 Thu>", "DEADLINE: <2024-02-10 Sat>", "CLOSED: [2024-02-03 Sat 10:00]"} {
+		doc := parser.New(lexer.New("* Task\n" + EscapeText(line) + "\n")).ParseDocument()
+		hl := doc.Children[0].(*ast.Headline)
+		if hl.Scheduled != nil || hl.Deadline != nil || hl.Closed != nil {
+			t.Errorf("%q: expected no planning, got scheduled=%v deadline=%v closed=%v", line, hl.Scheduled, hl.Deadline, hl.Closed)
+		}
+		if len(hl.Children) != 1 {
+			t.Fatalf("%q: expected the line as a paragraph, got=%d children", line, len(hl.Children))
+		}
+		if _, ok := hl.Children[0].(*ast.Paragraph); !ok {
+			t.Errorf("%q: expected a paragraph, got %T", line, hl.Children[0])
+		}
+	}
+}
+
+func TestEscapeTableCell(t *testing.T) {
+	src := "| " + EscapeTableCell("a|b\nc") + " | d |\n"
+	doc := parser.New(lexer.New(src)).ParseDocument()
+
+	table := doc.Children[0].(*ast.Table)
+	if len(table.Rows[0].Cells) != 2 {
+		t.Errorf("expected 2 cells, got=%v", table.Rows[0].Cells)
+	}
+	if got := UnescapeTableCell(table.Rows[0].Cells[0]); got != "a|b c" {
+		t.Errorf("expected %q, got=%q", "a|b c", got)
+	}
+}
+
+func TestEscapeHeadlineTitle(t *testing.T) {
+	tests := []string{
+		"TODO is a word here",
+		"[#A] not a priority",
+		"ratio 1:2 is :fine:",
+		"multi\nline",
+	}
+
+	for _, title := range tests {
+		src := "* " + EscapeHeadlineTitle(title, nil) + "\n"
+		doc := parser.New(lexer.New(src)).ParseDocument()
+		hl := doc.Children[0].(*ast.Headline)
+		if hl.Keyword != "" || hl.Priority != "" || len(hl.Tags) != 0 {
+			t.Errorf("EscapeHeadlineTitle(%q) leaked structure: keyword=%q priority=%q tags=%v",
+				title, hl.Keyword, hl.Priority, hl.Tags)
+		}
+	}
+}
+
+func TestEscapeHeadlineTitleKeywords(t *testing.T) {
+	m := todo.NewMatcher([]string{"NEXT"}, []string{"SHIPPED"})
+	src := "* " + EscapeHeadlineTitle("NEXT steps", m) + "\n"
+	hl := parser.New(lexer.New(src), parser.WithTodoMatcher(m)).ParseDocument().Children[0].(*ast.Headline)
+	if hl.Keyword != "" {
+		t.Errorf("expected a custom keyword to be guarded, got keyword=%q", hl.Keyword)
+	}
+	if got := EscapeHeadlineTitle("TODO later", m); got != "TODO later" {
+		t.Errorf("expected words that are not keywords of m to stay, got=%q", got)
+	}
+}
+
+func TestEscapeMarkup(t *testing.T) {
+	text := "a*b*c and x/y/z and ~t~"
+	for _, strategy := range []EscapeStrategy{EscapeZeroWidthSpace, EscapeEntities} {
+		doc := parser.New(lexer.New(EscapeMarkup(text, strategy))).ParseDocument()
+		para := doc.Children[0].(*ast.Paragraph)
+		for _, e := range para.Inline {
+			if e.Type != ast.InlineText && e.Type != ast.InlineEntity {
+				t.Errorf("strategy %d: expected no markup, got %s", strategy, e.Type)
+			}
+		}
+	}
+
+	if got := EscapeMarkup("2*3", EscapeEntities); got != `2\ast{}3` {
+		t.Errorf("EscapeMarkup with entities = %q", got)
+	}
+}
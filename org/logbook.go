@@ -0,0 +1,105 @@
+package org
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/parser"
+)
+
+// SetClosed records t as the headline's CLOSED time
+func SetClosed(h *ast.Headline, t time.Time) {
+	h.Closed = parser.ParseTimestamp(formatLogTimestamp(t))
+}
+
+// AddNote adds a "Note taken on" entry to the headline's LOGBOOK drawer,
+// creating the drawer when needed. Entries are prepended, newest first,
+// as Org does by default.
+func AddNote(h *ast.Headline, t time.Time, note string) {
+	entry := "- Note taken on " + formatLogTimestamp(t)
+	addLogEntry(h, entry, note)
+}
+
+// AddStateChange adds a state change entry such as
+// `- State "DONE"       from "TODO"       [2024-01-15 Mon 10:00]` to the
+// headline's LOGBOOK drawer, with an optional note
+func AddStateChange(h *ast.Headline, from, to string, t time.Time, note string) {
+	entry := fmt.Sprintf("- State %-12s from %-12s %s", quoteState(to), quoteState(from), formatLogTimestamp(t))
+	addLogEntry(h, entry, note)
+}
+
+func quoteState(s string) string {
+	if s == "" {
+		return ""
+	}
+	return `"` + s + `"`
+}
+
+// addLogEntry prepends an entry with an optional indented note to the
+// LOGBOOK drawer
+func addLogEntry(h *ast.Headline, entry, note string) {
+	var lines []string
+	if note == "" {
+		lines = append(lines, strings.TrimRight(entry, " "))
+	} else {
+		lines = append(lines, strings.TrimRight(entry, " ")+` \\`)
+		for _, l := range strings.Split(note, "\n") {
+			lines = append(lines, "  "+l)
+		}
+	}
+
+	logbook := ensureLogbook(h)
+	if logbook.Content != "" {
+		lines = append(lines, logbook.Content)
+	}
+	logbook.Content = strings.Join(lines, "\n")
+}
+
+// ensureLogbook returns the headline's LOGBOOK drawer, inserting an empty
+// one after the property drawer if it does not exist
+func ensureLogbook(h *ast.Headline) *ast.Drawer {
+	nodes := body(h)
+	pos := 0
+	for i, c := range *nodes {
+		if n, ok := c.(*ast.Drawer); ok {
+			if n.Name == "LOGBOOK" {
+				return n
+			}
+			if n.Name == "PROPERTIES" {
+				pos = i + 1
+				continue
+			}
+		}
+		break
+	}
+
+	logbook := &ast.Drawer{Name: "LOGBOOK", Properties: map[string]string{}}
+	*nodes = insertNode(*nodes, pos, logbook)
+	return logbook
+}
+
+// body returns the node list holding the headline's own content: the
+// children of its section if it has one, otherwise its children
+func body(h *ast.Headline) *[]ast.Node {
+	if len(h.Children) > 0 {
+		if s, ok := h.Children[0].(*ast.Section); ok {
+			return &s.Children
+		}
+	}
+	return &h.Children
+}
+
+func insertNode(nodes []ast.Node, i int, n ast.Node) []ast.Node {
+	nodes = append(nodes, nil)
+	copy(nodes[i+1:], nodes[i:])
+	nodes[i] = n
+	return nodes
+}
+
+// formatLogTimestamp renders t as an inactive timestamp that always
+// includes the time of day, as used in planning lines and logbooks
+func formatLogTimestamp(t time.Time) string {
+	return t.Format("[2006-01-02 Mon 15:04]")
+}
@@ -0,0 +1,86 @@
+package org
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+func TestSetClosed(t *testing.T) {
+	doc := parser.New(lexer.New("* DONE Task\nSCHEDULED: <2024-01-10 Wed>\nBody\n")).ParseDocument()
+	hl := doc.Children[0].(*ast.Headline)
+
+	at := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	SetClosed(hl, at)
+	SetClosed(hl, at.Add(time.Hour))
+
+	if planning := hl.Planning(); planning != "CLOSED: [2024-01-15 Mon 11:30] SCHEDULED: <2024-01-10 Wed>" {
+		t.Errorf("unexpected planning line %q", planning)
+	}
+	if len(hl.Children) != 1 {
+		t.Errorf("expected body only, got=%d children", len(hl.Children))
+	}
+
+	bare := &ast.Headline{Level: 1, Title: "Bare"}
+	SetClosed(bare, at)
+	if got := bare.Planning(); got != "CLOSED: [2024-01-15 Mon 10:30]" {
+		t.Errorf("unexpected planning line %q", got)
+	}
+}
+
+func TestLogbookInSection(t *testing.T) {
+	input := "* TODO Task\n:PROPERTIES:\n:ID: 1\n:END:\nBody\n** Sub\n"
+	doc := parser.New(lexer.New(input), parser.WithSections()).ParseDocument()
+	hl := doc.Children[0].(*ast.Headline)
+
+	SetClosed(hl, time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC))
+	AddNote(hl, time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC), "")
+
+	if len(hl.Children) != 2 {
+		t.Fatalf("expected section and subheadline only, got=%d children", len(hl.Children))
+	}
+	if hl.Closed == nil {
+		t.Errorf("expected CLOSED timestamp")
+	}
+	body := hl.BodyNodes()
+	if d, ok := body[1].(*ast.Drawer); !ok || d.Name != "LOGBOOK" {
+		t.Errorf("expected LOGBOOK after property drawer, got=%#v", body[1])
+	}
+}
+
+func TestAddNote(t *testing.T) {
+	input := `* TODO Task
+:PROPERTIES:
+:ID: 1
+:END:
+Body
+`
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	hl := doc.Children[0].(*ast.Headline)
+
+	AddNote(hl, time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC), "first")
+	AddStateChange(hl, "TODO", "DONE", time.Date(2024, 1, 16, 9, 0, 0, 0, time.UTC), "")
+
+	logbook, ok := hl.Children[1].(*ast.Drawer)
+	if !ok || logbook.Name != "LOGBOOK" {
+		t.Fatalf("expected LOGBOOK after property drawer, got=%#v", hl.Children[1])
+	}
+
+	want := `- State "DONE"       from "TODO"       [2024-01-16 Tue 09:00]
+- Note taken on [2024-01-15 Mon 09:00] \\
+  first`
+	if logbook.Content != want {
+		t.Errorf("unexpected logbook content:\n%s", logbook.Content)
+	}
+
+	// The result must serialize and parse back into the same drawer
+	reparsed := parser.New(lexer.New(doc.String())).ParseDocument()
+	drawer := reparsed.Children[0].(*ast.Headline).Children[1].(*ast.Drawer)
+	if drawer.Name != "LOGBOOK" || !strings.Contains(drawer.Content, "Note taken on") {
+		t.Errorf("logbook did not survive a round trip: %#v", drawer)
+	}
+}
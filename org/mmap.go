@@ -0,0 +1,283 @@
+package org
+
+import (
+	"os"
+	"strings"
+	"unsafe"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+// MappedDocument is a document parsed from a memory-mapped file. The
+// lexer reads the mapping without copying it, so the document's strings
+// point into the mapping and are only valid until Close. Call Detach to
+// keep a copy that outlives the mapping.
+type MappedDocument struct {
+	*ast.Document
+	Errors []string // Parser errors
+
+	data  []byte
+	unmap func([]byte) error
+}
+
+// ParseMmap maps the file at path read-only and parses it. The input size
+// limit is raised to the file size; opts are applied after and may lower
+// it again.
+func ParseMmap(path string, opts ...lexer.Option) (*MappedDocument, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, unmap, err := mapFile(f)
+	if err != nil {
+		return nil, err
+	}
+	var input string
+	if len(data) > 0 {
+		input = unsafe.String(&data[0], len(data))
+	}
+
+	opts = append([]lexer.Option{lexer.WithMaxInputSize(len(data))}, opts...)
+	l := lexer.New(input, opts...)
+	p := parser.New(l)
+	doc := p.ParseDocument()
+	if err := l.Err(); err != nil {
+		unmap(data)
+		return nil, err
+	}
+	return &MappedDocument{Document: doc, Errors: p.Errors(), data: data, unmap: unmap}, nil
+}
+
+// Close releases the mapping. The document must not be used afterwards,
+// but documents returned by Detach remain valid.
+func (m *MappedDocument) Close() error {
+	if m.unmap == nil {
+		return nil
+	}
+	err := m.unmap(m.data)
+	m.data, m.unmap = nil, nil
+	m.Document = nil
+	return err
+}
+
+// Detach returns a copy of the document whose strings no longer refer to
+// the mapping. Nodes of types outside package ast are shared, not copied.
+func (m *MappedDocument) Detach() *ast.Document {
+	return &ast.Document{Children: detachNodes(m.Children)}
+}
+
+func detachNodes(nodes []ast.Node) []ast.Node {
+	if nodes == nil {
+		return nil
+	}
+	out := make([]ast.Node, len(nodes))
+	for i, n := range nodes {
+		out[i] = detachNode(n)
+	}
+	return out
+}
+
+func detachNode(node ast.Node) ast.Node {
+	switch n := node.(type) {
+	case *ast.Headline:
+		if n == nil {
+			return n
+		}
+		c := *n
+		c.Token.Literal = strings.Clone(n.Token.Literal)
+		c.Keyword = strings.Clone(n.Keyword)
+		c.Priority = strings.Clone(n.Priority)
+		c.Title = strings.Clone(n.Title)
+		c.Tags = detachStrings(n.Tags)
+		c.Scheduled = detachTimestamp(n.Scheduled)
+		c.Deadline = detachTimestamp(n.Deadline)
+		c.Closed = detachTimestamp(n.Closed)
+		c.Children = detachNodes(n.Children)
+		return &c
+	case *ast.Paragraph:
+		if n == nil {
+			return n
+		}
+		c := *n
+		c.Token.Literal = strings.Clone(n.Token.Literal)
+		c.Content = strings.Clone(n.Content)
+		c.Inline = detachInline(n.Inline)
+		return &c
+	case *ast.Keyword:
+		if n == nil { // parseKeyword may yield a typed nil
+			return n
+		}
+		c := *n
+		c.Token.Literal = strings.Clone(n.Token.Literal)
+		c.Key = strings.Clone(n.Key)
+		c.Value = strings.Clone(n.Value)
+		return &c
+	case *ast.Call:
+		if n == nil {
+			return n
+		}
+		c := *n
+		c.Token.Literal = strings.Clone(n.Token.Literal)
+		c.Name = strings.Clone(n.Name)
+		c.InsideHeader = strings.Clone(n.InsideHeader)
+		c.Arguments = strings.Clone(n.Arguments)
+		c.EndHeader = strings.Clone(n.EndHeader)
+		if n.Args != nil {
+			c.Args = make([]ast.CallArg, len(n.Args))
+			for i, a := range n.Args {
+				c.Args[i] = ast.CallArg{Name: strings.Clone(a.Name), Value: strings.Clone(a.Value)}
+			}
+		}
+		return &c
+	case *ast.Block:
+		if n == nil {
+			return n
+		}
+		c := *n
+		c.Token.Literal = strings.Clone(n.Token.Literal)
+		c.Type = strings.Clone(n.Type)
+		c.Language = strings.Clone(n.Language)
+		c.Params = strings.Clone(n.Params)
+		c.Content = strings.Clone(n.Content)
+		return &c
+	case *ast.Drawer:
+		if n == nil {
+			return n
+		}
+		c := *n
+		c.Token.Literal = strings.Clone(n.Token.Literal)
+		c.Name = strings.Clone(n.Name)
+		c.Keys = detachStrings(n.Keys)
+		c.Content = strings.Clone(n.Content)
+		if n.Clocks != nil {
+			c.Clocks = make([]*ast.Clock, len(n.Clocks))
+			for i, clock := range n.Clocks {
+				cc := *clock
+				cc.Token.Literal = strings.Clone(clock.Token.Literal)
+				cc.Start = detachTimestamp(clock.Start)
+				cc.End = detachTimestamp(clock.End)
+				c.Clocks[i] = &cc
+			}
+		}
+		if n.Properties != nil {
+			c.Properties = make(map[string]string, len(n.Properties))
+			for k, v := range n.Properties {
+				c.Properties[strings.Clone(k)] = strings.Clone(v)
+			}
+		}
+		return &c
+	case *ast.List:
+		if n == nil {
+			return n
+		}
+		c := *n
+		c.Token.Literal = strings.Clone(n.Token.Literal)
+		if n.Items != nil {
+			c.Items = make([]*ast.ListItem, len(n.Items))
+			for i, item := range n.Items {
+				ci := *item
+				ci.Token.Literal = strings.Clone(item.Token.Literal)
+				ci.Content = strings.Clone(item.Content)
+				ci.Children = detachNodes(item.Children)
+				c.Items[i] = &ci
+			}
+		}
+		return &c
+	case *ast.Table:
+		if n == nil {
+			return n
+		}
+		c := *n
+		c.Token.Literal = strings.Clone(n.Token.Literal)
+		if n.Rows != nil {
+			c.Rows = make([]*ast.TableRow, len(n.Rows))
+			for i, row := range n.Rows {
+				cr := *row
+				cr.Token.Literal = strings.Clone(row.Token.Literal)
+				cr.Cells = detachStrings(row.Cells)
+				c.Rows[i] = &cr
+			}
+		}
+		return &c
+	case *ast.Comment:
+		if n == nil {
+			return n
+		}
+		c := *n
+		c.Token.Literal = strings.Clone(n.Token.Literal)
+		c.Content = strings.Clone(n.Content)
+		return &c
+	case *ast.Section:
+		if n == nil {
+			return n
+		}
+		c := *n
+		c.Token.Literal = strings.Clone(n.Token.Literal)
+		c.Children = detachNodes(n.Children)
+		return &c
+	case *ast.FootnoteDefinition:
+		if n == nil {
+			return n
+		}
+		c := *n
+		c.Token.Literal = strings.Clone(n.Token.Literal)
+		c.Label = strings.Clone(n.Label)
+		c.Children = detachNodes(n.Children)
+		return &c
+	case *ast.HorizontalRule:
+		if n == nil {
+			return n
+		}
+		c := *n
+		c.Token.Literal = strings.Clone(n.Token.Literal)
+		return &c
+	}
+	return node
+}
+
+func detachInline(elems []ast.InlineElement) []ast.InlineElement {
+	if elems == nil {
+		return nil
+	}
+	out := make([]ast.InlineElement, len(elems))
+	for i, e := range elems {
+		e.Content = strings.Clone(e.Content)
+		e.URL = strings.Clone(e.URL)
+		e.Timestamp = detachTimestamp(e.Timestamp)
+		e.Children = detachInline(e.Children)
+		out[i] = e
+	}
+	return out
+}
+
+func detachTimestamp(ts *ast.Timestamp) *ast.Timestamp {
+	if ts == nil {
+		return nil
+	}
+	c := *ts
+	c.Token.Literal = strings.Clone(ts.Token.Literal)
+	c.Date = strings.Clone(ts.Date)
+	c.Day = strings.Clone(ts.Day)
+	c.Time = strings.Clone(ts.Time)
+	c.Repeat = strings.Clone(ts.Repeat)
+	c.Warning = strings.Clone(ts.Warning)
+	c.EndDate = strings.Clone(ts.EndDate)
+	c.EndDay = strings.Clone(ts.EndDay)
+	c.EndTime = strings.Clone(ts.EndTime)
+	return &c
+}
+
+func detachStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	out := make([]string, len(s))
+	for i, v := range s {
+		out[i] = strings.Clone(v)
+	}
+	return out
+}
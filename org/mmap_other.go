@@ -0,0 +1,17 @@
+//go:build !unix
+
+package org
+
+import (
+	"io"
+	"os"
+)
+
+// mapFile reads f into memory on platforms without mmap
+func mapFile(f *os.File) ([]byte, func([]byte) error, error) {
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func([]byte) error { return nil }, nil
+}
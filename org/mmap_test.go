@@ -0,0 +1,78 @@
+package org
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"unsafe"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/lexer"
+)
+
+func TestParseMmap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.org")
+	input := "#+TITLE: Archive\n* TODO Task :work:\n:PROPERTIES:\n:ID: 1\n:END:\nSome *bold* text\n- item\n| a | b |\n"
+	if err := os.WriteFile(path, []byte(input), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := ParseMmap(path)
+	if err != nil {
+		t.Fatalf("ParseMmap failed: %v", err)
+	}
+	h := m.Children[1].(*ast.Headline)
+	if h.Title != "Task" {
+		t.Fatalf("expected title Task, got=%q", h.Title)
+	}
+	start := uintptr(unsafe.Pointer(&m.data[0]))
+	if p := uintptr(unsafe.Pointer(unsafe.StringData(h.Title))); p < start || p >= start+uintptr(len(m.data)) {
+		t.Error("expected the title to point into the mapping")
+	}
+
+	doc := m.Detach()
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	// The detached copy must survive the unmapping
+	if got := doc.String(); got == "" {
+		t.Error("expected detached document to render")
+	}
+	dh := doc.Children[1].(*ast.Headline)
+	if dh == h || dh.Title != "Task" || dh.Tags[0] != "work" {
+		t.Errorf("unexpected detached headline %+v", dh)
+	}
+	if v, _ := dh.Property("ID"); v != "1" {
+		t.Errorf("expected detached property ID=1, got=%q", v)
+	}
+	if doc.Keyword("TITLE") != "Archive" {
+		t.Errorf("expected detached title keyword, got=%q", doc.Keyword("TITLE"))
+	}
+}
+
+func TestParseMmapEmptyAndLimits(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty.org")
+	if err := os.WriteFile(empty, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := ParseMmap(empty)
+	if err != nil {
+		t.Fatalf("ParseMmap failed on an empty file: %v", err)
+	}
+	if len(m.Children) != 0 {
+		t.Errorf("expected no nodes, got=%d", len(m.Children))
+	}
+	m.Close()
+
+	big := filepath.Join(dir, "big.org")
+	if err := os.WriteFile(big, []byte("* Heading\ntext\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseMmap(big, lexer.WithMaxInputSize(4)); err != lexer.ErrInputTooLarge {
+		t.Errorf("expected ErrInputTooLarge, got=%v", err)
+	}
+	if _, err := ParseMmap(filepath.Join(dir, "missing.org")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
@@ -0,0 +1,40 @@
+package org
+
+import (
+	"strings"
+
+	"github.com/justyntemme/organelle/ast"
+)
+
+// SetProperty sets key to value in the headline's PROPERTIES drawer,
+// creating the drawer when needed. An existing key is matched
+// case-insensitively and keeps its position and spelling; if several keys
+// match, the first in drawer order is updated.
+func SetProperty(h *ast.Headline, key, value string) {
+	d := ensureProperties(h)
+	for _, k := range d.PropertyKeys() {
+		if strings.EqualFold(k, key) {
+			d.Properties[k] = value
+			return
+		}
+	}
+	d.Properties[key] = value
+	d.Keys = append(d.Keys, key)
+}
+
+// ensureProperties returns the headline's PROPERTIES drawer, inserting an
+// empty one at the start of the body if it does not exist
+func ensureProperties(h *ast.Headline) *ast.Drawer {
+	nodes := body(h)
+	for _, c := range *nodes {
+		if d, ok := c.(*ast.Drawer); ok && d.Name == "PROPERTIES" {
+			if d.Properties == nil {
+				d.Properties = map[string]string{}
+			}
+			return d
+		}
+	}
+	d := &ast.Drawer{Name: "PROPERTIES", Properties: map[string]string{}}
+	*nodes = insertNode(*nodes, 0, d)
+	return d
+}
@@ -0,0 +1,47 @@
+package org
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+func TestSetProperty(t *testing.T) {
+	doc := parser.New(lexer.New("* Task\nSCHEDULED: <2024-01-10 Wed>\nBody\n")).ParseDocument()
+	hl := doc.Children[0].(*ast.Headline)
+
+	SetProperty(hl, "ID", "abc")
+	SetProperty(hl, "Effort", "1:00")
+	SetProperty(hl, "id", "def")
+
+	d, ok := hl.Children[0].(*ast.Drawer)
+	if !ok || d.Name != "PROPERTIES" {
+		t.Fatalf("expected PROPERTIES drawer first in the body, got %T", hl.Children[0])
+	}
+	if got := strings.Join(d.PropertyKeys(), ","); got != "ID,Effort" {
+		t.Errorf("expected keys in insertion order, got=%q", got)
+	}
+	if v, _ := hl.Property("ID"); v != "def" {
+		t.Errorf("expected ID to be updated in place, got=%q", v)
+	}
+}
+
+func TestPropertyCaseVariants(t *testing.T) {
+	input := "* Task\n:PROPERTIES:\n:Owner: a\n:B: x\n:OWNER: b\n:owner: c\n:END:\n"
+	// Matching used to depend on map iteration order
+	for range 20 {
+		doc := parser.New(lexer.New(input)).ParseDocument()
+		hl := doc.Children[0].(*ast.Headline)
+
+		if v, _ := hl.Property("owner"); v != "a" {
+			t.Fatalf("expected first key in drawer order to win, got=%q", v)
+		}
+		SetProperty(hl, "OWNER", "z")
+		if got := hl.String(); !strings.Contains(got, ":Owner: z\n:B: x\n:OWNER: b\n:owner: c\n") {
+			t.Fatalf("expected first key to be updated in place, got:\n%s", got)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// Package org provides high-level helpers built on top of the lexer,
+// parser and AST packages for programs that generate or transform Org
+// documents.
+package org
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+// Template executes a text/template that produces Org source and
+// validates the output by parsing it.
+type Template struct {
+	tmpl *template.Template
+}
+
+// NewTemplate creates a template with the Org helper functions installed:
+//
+//	orgEscape          neutralize text that would change document structure
+//	timestamp          format a time.Time as an active timestamp
+//	inactiveTimestamp  format a time.Time as an inactive timestamp
+//	table              render a header and rows as an Org table
+func NewTemplate(name string) *Template {
+	return &Template{tmpl: template.New(name).Funcs(FuncMap())}
+}
+
+// FuncMap returns the helper functions installed by NewTemplate, for use
+// with templates managed by the caller
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"orgEscape":         EscapeText,
+		"timestamp":         func(t time.Time) string { return formatTimestamp(t, true) },
+		"inactiveTimestamp": func(t time.Time) string { return formatTimestamp(t, false) },
+		"table":             formatTable,
+	}
+}
+
+// Funcs adds additional functions to the template
+func (t *Template) Funcs(funcs template.FuncMap) *Template {
+	t.tmpl.Funcs(funcs)
+	return t
+}
+
+// Parse parses text as the template body
+func (t *Template) Parse(text string) (*Template, error) {
+	if _, err := t.tmpl.Parse(text); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Execute renders the template with data and parses the result. The
+// rendered source is returned alongside the document so callers can write
+// it to disk; parse errors are joined into the returned error.
+func (t *Template) Execute(data any) (*ast.Document, string, error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, data); err != nil {
+		return nil, "", err
+	}
+	src := buf.String()
+
+	p := parser.New(lexer.New(src))
+	doc := p.ParseDocument()
+	if errs := p.Errors(); len(errs) > 0 {
+		return doc, src, errors.New("org: rendered template does not parse: " + strings.Join(errs, "; "))
+	}
+	return doc, src, nil
+}
+
+// formatTimestamp renders t as <2024-01-15 Mon> or, when it carries a time
+// of day, <2024-01-15 Mon 10:00>
+func formatTimestamp(t time.Time, active bool) string {
+	layout := "2006-01-02 Mon"
+	if t.Hour() != 0 || t.Minute() != 0 {
+		layout += " 15:04"
+	}
+	if active {
+		return "<" + t.Format(layout) + ">"
+	}
+	return "[" + t.Format(layout) + "]"
+}
+
+// formatTable renders a table with an optional header row followed by a
+// separator line
+func formatTable(header []string, rows [][]string) string {
+	var out strings.Builder
+	writeRow := func(cells []string) {
+		out.WriteString("|")
+		for _, c := range cells {
+			out.WriteString(" ")
+			out.WriteString(EscapeTableCell(c))
+			out.WriteString(" |")
+		}
+		out.WriteString("\n")
+	}
+	if len(header) > 0 {
+		writeRow(header)
+		out.WriteString("|")
+		for i := range header {
+			if i > 0 {
+				out.WriteString("+")
+			}
+			out.WriteString("---")
+		}
+		out.WriteString("|\n")
+	}
+	for _, r := range rows {
+		writeRow(r)
+	}
+	return out.String()
+}
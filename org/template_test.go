@@ -0,0 +1,66 @@
+package org
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justyntemme/organelle/ast"
+)
+
+func TestTemplateExecute(t *testing.T) {
+	tmpl, err := NewTemplate("report").Parse(`#+TITLE: {{.Title}}
+* Summary
+{{orgEscape .Note}}
+* Deadline {{timestamp .Due}}
+{{table .Header .Rows}}`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	data := map[string]any{
+		"Title":  "Weekly",
+		"Note":   "* not a headline\nplain line",
+		"Due":    time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+		"Header": []string{"Name", "Value"},
+		"Rows":   [][]string{{"a|b", "1"}},
+	}
+
+	doc, src, err := tmpl.Execute(data)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if !strings.Contains(src, "<2024-01-15 Mon>") {
+		t.Errorf("expected formatted timestamp in output, got:\n%s", src)
+	}
+
+	// Keyword + 2 headlines; the escaped note must not become a headline
+	if len(doc.Children) != 3 {
+		t.Fatalf("expected 3 top-level nodes, got=%d\n%s", len(doc.Children), src)
+	}
+
+	summary := doc.Children[1].(*ast.Headline)
+	if len(summary.Children) != 2 {
+		t.Fatalf("expected 2 paragraphs under Summary, got=%d", len(summary.Children))
+	}
+
+	deadline := doc.Children[2].(*ast.Headline)
+	table, ok := deadline.Children[0].(*ast.Table)
+	if !ok {
+		t.Fatalf("expected *ast.Table, got=%T", deadline.Children[0])
+	}
+	if len(table.Rows) != 3 {
+		t.Fatalf("expected 3 table rows, got=%d", len(table.Rows))
+	}
+	if len(table.Rows[2].Cells) != 2 {
+		t.Errorf("pipe in cell should be escaped, got cells=%v", table.Rows[2].Cells)
+	}
+}
+
+func TestFormatTimestampWithTime(t *testing.T) {
+	ts := time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC)
+	if got := formatTimestamp(ts, false); got != "[2024-01-15 Mon 09:30]" {
+		t.Errorf("formatTimestamp = %q", got)
+	}
+}
@@ -0,0 +1,148 @@
+package org
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/todo"
+)
+
+// Policy holds the task hygiene rules SetTodo applies, such as those of
+// org-log-done and org-log-into-drawer. Keep one Policy per workspace so
+// every file follows the same rules. The zero Policy only changes the
+// keyword.
+type Policy struct {
+	Todo *todo.Matcher // Decides which keywords are done; todo.Default if nil
+
+	LogState        bool // Record every change in the LOGBOOK drawer
+	Close           bool // Set CLOSED on entering a done state and drop it on leaving one
+	RemoveScheduled bool // Drop SCHEDULED on entering a done state
+	RemoveDeadline  bool // Drop DEADLINE on entering a done state
+
+	// Archive tags done headlines :ARCHIVE:. With an ArchiveAfter of zero
+	// SetTodo tags them at once; otherwise ArchiveDone tags those closed
+	// at least ArchiveAfter ago.
+	Archive      bool
+	ArchiveAfter time.Duration
+}
+
+func (p Policy) matcher() *todo.Matcher {
+	if p.Todo == nil {
+		return todo.Default
+	}
+	return p.Todo
+}
+
+// SetTodo sets the headline's keyword to state at time t, or removes it if
+// state is empty, and applies p. The done rules only run when the headline
+// moves from a state that is not done into one that is. Timestamps with a
+// repeater are kept, since they describe the next occurrence rather than
+// this one.
+//
+// Besides p.LogState, the logging settings of the keywords decide whether
+// the change is recorded in the LOGBOOK, as in a #+TODO line: the entry
+// flag of state, as in WAIT(w!), or failing that the exit flag of the
+// current keyword, as in WAIT(w/!).
+func SetTodo(h *ast.Headline, state string, t time.Time, p Policy) error {
+	return SetTodoWithNote(h, state, "", t, p)
+}
+
+// SetTodoWithNote is SetTodo with a note for the LOGBOOK entry, such as
+// the one Org asks for on entering WAIT(w@). A note records the change
+// even when neither p nor the keywords ask for it.
+func SetTodoWithNote(h *ast.Headline, state, note string, t time.Time, p Policy) error {
+	m := p.matcher()
+	if state != "" && !m.IsKeyword(state) {
+		return fmt.Errorf("org: unknown TODO keyword %q", state)
+	}
+	from := h.Keyword
+	if state == from {
+		return nil
+	}
+	wasDone := h.Done
+	h.Keyword, h.Done = state, m.IsDone(state)
+
+	if p.LogState || note != "" || logs(m, from, state) {
+		AddStateChange(h, from, state, t, note)
+	}
+	switch {
+	case h.Done && !wasDone:
+		if p.Close {
+			SetClosed(h, t)
+		}
+		if p.RemoveScheduled && !repeats(h.Scheduled) {
+			h.Scheduled = nil
+		}
+		if p.RemoveDeadline && !repeats(h.Deadline) {
+			h.Deadline = nil
+		}
+		if p.Archive && p.ArchiveAfter == 0 {
+			archive(h)
+		}
+	case !h.Done && wasDone:
+		if p.Close {
+			h.Closed = nil
+		}
+	}
+	return nil
+}
+
+// ArchiveDone tags :ARCHIVE: the done headlines in doc that were closed at
+// least p.ArchiveAfter before now, and returns how many it tagged. It does
+// nothing unless p.Archive is set.
+func ArchiveDone(doc *ast.Document, p Policy, now time.Time) int {
+	if !p.Archive {
+		return 0
+	}
+	n := 0
+	var walk func(hs []*ast.Headline)
+	walk = func(hs []*ast.Headline) {
+		for _, h := range hs {
+			if h.IsArchived() {
+				continue
+			}
+			if h.Done && h.Closed != nil {
+				if closed, err := h.Closed.ToTime(); err == nil && !closed.Add(p.ArchiveAfter).After(now) {
+					archive(h)
+					n++
+					continue
+				}
+			}
+			walk(h.Subheadlines())
+		}
+	}
+	var top []*ast.Headline
+	for _, c := range doc.Children {
+		if h, ok := c.(*ast.Headline); ok {
+			top = append(top, h)
+		}
+	}
+	walk(top)
+	return n
+}
+
+// logs reports whether the keyword settings of m ask to record a change
+// from one state to another. The entry flag of the new state wins over the
+// exit flag of the old one.
+func logs(m *todo.Matcher, from, to string) bool {
+	if k, ok := m.Spec(to); ok && k.Enter != todo.LogNone {
+		return true
+	}
+	k, ok := m.Spec(from)
+	return ok && k.Leave != todo.LogNone
+}
+
+func repeats(ts *ast.Timestamp) bool {
+	if ts == nil {
+		return false
+	}
+	_, ok := ts.Repeater()
+	return ok
+}
+
+func archive(h *ast.Headline) {
+	if !h.IsArchived() {
+		h.Tags = append(h.Tags, ast.ArchiveTag)
+	}
+}
@@ -0,0 +1,122 @@
+package org
+
+import (
+	"testing"
+	"time"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+	"github.com/justyntemme/organelle/todo"
+	"github.com/justyntemme/organelle/writer"
+)
+
+func TestSetTodo(t *testing.T) {
+	input := `* TODO Task
+SCHEDULED: <2024-01-10 Wed> DEADLINE: <2024-01-20 Sat>
+Body
+`
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	hl := doc.Children[0].(*ast.Headline)
+	policy := Policy{LogState: true, Close: true, RemoveScheduled: true, Archive: true}
+	at := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	if err := SetTodo(hl, "DONE", at, policy); err != nil {
+		t.Fatal(err)
+	}
+	expected := `* DONE Task :ARCHIVE:
+CLOSED: [2024-01-15 Mon 10:30] DEADLINE: <2024-01-20 Sat>
+:LOGBOOK:
+- State "DONE"       from "TODO"       [2024-01-15 Mon 10:30]
+:END:
+Body
+`
+	if got := writer.String(doc); got != expected {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", got, expected)
+	}
+
+	if err := SetTodo(hl, "TODO", at.Add(time.Hour), policy); err != nil {
+		t.Fatal(err)
+	}
+	if hl.Done || hl.Closed != nil {
+		t.Errorf("expected reopening to drop CLOSED, got %s", hl.Planning())
+	}
+	if err := SetTodo(hl, "LATER", at, policy); err == nil {
+		t.Error("expected an unknown keyword to fail")
+	}
+}
+
+func TestSetTodoKeepsRepeaters(t *testing.T) {
+	doc := parser.New(lexer.New("* TODO Water plants\nSCHEDULED: <2024-01-10 Wed +1w>\n")).ParseDocument()
+	hl := doc.Children[0].(*ast.Headline)
+	if err := SetTodo(hl, "DONE", time.Now(), Policy{RemoveScheduled: true}); err != nil {
+		t.Fatal(err)
+	}
+	if hl.Scheduled == nil {
+		t.Error("expected a repeating SCHEDULED to be kept")
+	}
+}
+
+func TestSetTodoKeywordLogging(t *testing.T) {
+	m := todo.FromKeywords(todo.ParseSpec("TODO WAIT(w@/!) NEXT | DONE(d!) CANCELED")...)
+	at := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		from, to, note string
+		want           string
+	}{
+		{"TODO", "NEXT", "", ""},
+		{"TODO", "DONE", "", `- State "DONE"       from "TODO"       [2024-01-15 Mon 10:30]`},
+		{"WAIT", "NEXT", "", `- State "NEXT"       from "WAIT"       [2024-01-15 Mon 10:30]`},
+		{"TODO", "WAIT", "Waiting on legal", `- State "WAIT"       from "TODO"       [2024-01-15 Mon 10:30] \\` + "\n  Waiting on legal"},
+		{"TODO", "CANCELED", "Out of scope", `- State "CANCELED"   from "TODO"       [2024-01-15 Mon 10:30] \\` + "\n  Out of scope"},
+	}
+	for _, tt := range tests {
+		hl := &ast.Headline{Level: 1, Keyword: tt.from, Title: "Task"}
+		if err := SetTodoWithNote(hl, tt.to, tt.note, at, Policy{Todo: m}); err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		if len(hl.Children) > 0 {
+			got = hl.Children[0].(*ast.Drawer).Content
+		}
+		if got != tt.want {
+			t.Errorf("%s to %s: expected LOGBOOK %q, got=%q", tt.from, tt.to, tt.want, got)
+		}
+	}
+}
+
+func TestArchiveDone(t *testing.T) {
+	input := `* DONE Old
+CLOSED: [2024-01-01 Mon 09:00]
+* DONE Recent
+CLOSED: [2024-01-14 Sun 09:00]
+* TODO Open
+** DONE Nested
+CLOSED: [2024-01-02 Tue 09:00]
+`
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	now := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	policy := Policy{Archive: true, ArchiveAfter: 7 * 24 * time.Hour}
+	if n := ArchiveDone(doc, policy, now); n != 2 {
+		t.Errorf("expected 2 headlines archived, got=%d", n)
+	}
+	var archived []string
+	var walk func(hs []*ast.Headline)
+	walk = func(hs []*ast.Headline) {
+		for _, hl := range hs {
+			if hl.IsArchived() {
+				archived = append(archived, hl.Title)
+			}
+			walk(hl.Subheadlines())
+		}
+	}
+	for _, c := range doc.Children {
+		walk([]*ast.Headline{c.(*ast.Headline)})
+	}
+	if len(archived) != 2 || archived[0] != "Old" || archived[1] != "Nested" {
+		t.Errorf("unexpected archived headlines %q", archived)
+	}
+	if n := ArchiveDone(doc, Policy{ArchiveAfter: time.Hour}, now); n != 0 {
+		t.Errorf("expected nothing archived without Archive, got=%d", n)
+	}
+}
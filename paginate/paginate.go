@@ -0,0 +1,204 @@
+// Package paginate splits a document into pages along its outline so that
+// large documents can be served and navigated a section at a time.
+//
+// Every headline up to the configured level starts a page holding the
+// headline's own content; deeper headlines stay on their parent's page.
+// Text before the first headline forms a preamble page. Pages use the
+// headline anchors from package anchor, so links into a document resolve
+// to the same page on every render. Sections larger than the node limit
+// are split into parts, marked as continuations of the first part.
+package paginate
+
+import (
+	"fmt"
+
+	"github.com/justyntemme/organelle/anchor"
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/redact"
+)
+
+// Page is one slice of a document
+type Page struct {
+	Index    int    // Position in the page list
+	Anchor   string // Stable identifier; empty for the preamble
+	Title    string
+	Level    int           // Headline level; 0 for the preamble
+	Headline *ast.Headline // nil for the preamble
+	Path     []string      // Titles of the enclosing headlines, outermost first
+	Nodes    []ast.Node    // Content, without headlines that have their own page
+	Anchors  []string      // Anchors of every headline shown on the page
+
+	Part  int // 1-based part of a split section
+	Parts int // Number of parts the section was split into
+}
+
+// Continued reports whether the page continues the section of the page
+// before it
+func (p *Page) Continued() bool {
+	return p.Part > 1
+}
+
+// More reports whether the section continues on the next page
+func (p *Page) More() bool {
+	return p.Part < p.Parts
+}
+
+// Pages is a paginated document
+type Pages []*Page
+
+// Find returns the page showing the headline with the given anchor. For
+// a split section it is the first part.
+func (ps Pages) Find(a string) *Page {
+	for _, p := range ps {
+		if p.Anchor == a {
+			return p
+		}
+		for _, pa := range p.Anchors {
+			if pa == a {
+				return p
+			}
+		}
+	}
+	return nil
+}
+
+// Paginator splits documents into pages
+type Paginator struct {
+	level    int
+	maxNodes int
+	archived redact.Archived
+}
+
+// Option is a functional option for configuring the Paginator
+type Option func(*Paginator)
+
+// WithLevel sets the deepest headline level that starts a page (default 1)
+func WithLevel(level int) Option {
+	return func(p *Paginator) {
+		p.level = level
+	}
+}
+
+// WithMaxNodes splits sections with more than n top-level content nodes
+// into several parts. Zero (the default) never splits.
+func WithMaxNodes(n int) Option {
+	return func(p *Paginator) {
+		p.maxNodes = n
+	}
+}
+
+// IncludeArchived gives subtrees tagged :ARCHIVE: pages of their own; see
+// redact.Archived. Their anchors are listed only then.
+func IncludeArchived() Option {
+	return func(p *Paginator) {
+		p.archived = true
+	}
+}
+
+// New creates a Paginator
+func New(opts ...Option) *Paginator {
+	p := &Paginator{level: 1}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Pages splits doc into pages in document order
+func (p *Paginator) Pages(doc *ast.Document) Pages {
+	cp := *p
+	cp.archived = p.archived.For(doc)
+	p = &cp
+	anchors := anchor.New(doc)
+	var pages Pages
+
+	add := func(base Page, nodes []ast.Node) {
+		parts := p.split(nodes)
+		for i, part := range parts {
+			pg := base
+			pg.Index = len(pages)
+			pg.Nodes = part
+			pg.Part, pg.Parts = i+1, len(parts)
+			pg.Anchors = nil
+			if i == 0 && pg.Headline != nil {
+				pg.Anchors = append(pg.Anchors, pg.Anchor)
+			}
+			if i > 0 {
+				pg.Anchor = fmt.Sprintf("%s-part-%d", base.Anchor, i+1)
+			}
+			p.collectAnchors(part, anchors, &pg.Anchors)
+			pages = append(pages, &pg)
+		}
+	}
+
+	var walk func(nodes []ast.Node, path []string)
+	walk = func(nodes []ast.Node, path []string) {
+		for _, n := range nodes {
+			h := n.(*ast.Headline)
+			var own, sub []ast.Node
+			for _, c := range h.Children {
+				if ch, ok := c.(*ast.Headline); ok && ch.Level <= p.level {
+					if p.visible(ch) {
+						sub = append(sub, ch)
+					}
+					continue
+				}
+				own = append(own, c)
+			}
+			add(Page{
+				Anchor:   anchors.For(h),
+				Title:    h.Title,
+				Level:    h.Level,
+				Headline: h,
+				Path:     path,
+			}, own)
+			walk(sub, append(path[:len(path):len(path)], h.Title))
+		}
+	}
+
+	var preamble, top []ast.Node
+	for _, n := range doc.Children {
+		if h, ok := n.(*ast.Headline); ok && h.Level <= p.level {
+			if p.visible(h) {
+				top = append(top, h)
+			}
+			continue
+		}
+		preamble = append(preamble, n)
+	}
+	if len(preamble) > 0 {
+		add(Page{}, preamble)
+	}
+	walk(top, nil)
+	return pages
+}
+
+// split divides nodes into parts of at most maxNodes nodes. A section
+// without content still yields one empty part.
+func (p *Paginator) split(nodes []ast.Node) [][]ast.Node {
+	if p.maxNodes <= 0 || len(nodes) <= p.maxNodes {
+		return [][]ast.Node{nodes}
+	}
+	var parts [][]ast.Node
+	for len(nodes) > 0 {
+		n := min(p.maxNodes, len(nodes))
+		parts = append(parts, nodes[:n:n])
+		nodes = nodes[n:]
+	}
+	return parts
+}
+
+// visible reports whether h is paginated
+func (p *Paginator) visible(h *ast.Headline) bool {
+	return !p.archived.Skip(h)
+}
+
+// collectAnchors appends the anchors of the visible headlines within nodes
+func (p *Paginator) collectAnchors(nodes []ast.Node, anchors *anchor.Set, out *[]string) {
+	for _, n := range nodes {
+		if h, ok := n.(*ast.Headline); ok && p.visible(h) {
+			*out = append(*out, anchors.For(h))
+			p.collectAnchors(h.Children, anchors, out)
+		}
+	}
+}
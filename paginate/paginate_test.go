@@ -0,0 +1,86 @@
+package paginate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+const book = `#+TITLE: Manual
+Welcome.
+* Install
+Get the binary.
+** From source
+Run make.
+*** Details
+Needs Go.
+* Usage
+one
+two
+three
+`
+
+func TestPagesByLevel(t *testing.T) {
+	doc := parser.New(lexer.New(book)).ParseDocument()
+
+	pages := New().Pages(doc)
+	var anchors []string
+	for _, p := range pages {
+		anchors = append(anchors, p.Anchor)
+	}
+	if strings.Join(anchors, ",") != ",install,usage" {
+		t.Fatalf("expected preamble, install and usage pages, got=%q", anchors)
+	}
+	if pages[0].Headline != nil || len(pages[0].Nodes) != 2 {
+		t.Errorf("expected a preamble with the keyword and paragraph, got %+v", pages[0])
+	}
+	install := pages[1]
+	if len(install.Nodes) != 2 || strings.Join(install.Anchors, ",") != "install,from-source,details" {
+		t.Errorf("expected From source on the Install page, got nodes=%d anchors=%v", len(install.Nodes), install.Anchors)
+	}
+
+	deeper := New(WithLevel(2)).Pages(doc)
+	if len(deeper) != 4 {
+		t.Fatalf("expected 4 pages at level 2, got=%d", len(deeper))
+	}
+	fromSource := deeper[2]
+	if fromSource.Title != "From source" || strings.Join(fromSource.Path, "/") != "Install" {
+		t.Errorf("unexpected page %+v", fromSource)
+	}
+	if p := deeper.Find("details"); p != fromSource {
+		t.Errorf("expected details to resolve to the From source page, got %+v", p)
+	}
+	if len(deeper[1].Nodes) != 1 {
+		t.Errorf("expected Install to keep only its own paragraph, got=%d nodes", len(deeper[1].Nodes))
+	}
+}
+
+func TestContinuation(t *testing.T) {
+	doc := parser.New(lexer.New(book)).ParseDocument()
+	pages := New(WithMaxNodes(2)).Pages(doc)
+
+	usage := pages.Find("usage")
+	if usage == nil || usage.Parts != 2 || usage.Continued() || !usage.More() {
+		t.Fatalf("expected the first of two Usage parts, got %+v", usage)
+	}
+	next := pages[usage.Index+1]
+	if next.Anchor != "usage-part-2" || !next.Continued() || next.More() || len(next.Nodes) != 1 {
+		t.Errorf("unexpected continuation page %+v", next)
+	}
+}
+
+func TestPagesSkipArchived(t *testing.T) {
+	doc := parser.New(lexer.New("* A\n* B :ARCHIVE:\n* C\n")).ParseDocument()
+	var titles []string
+	for _, p := range New().Pages(doc) {
+		titles = append(titles, p.Title)
+	}
+	if got := strings.Join(titles, ","); got != "A,C" {
+		t.Errorf("got pages %q, want A,C", got)
+	}
+	if pages := New(IncludeArchived()).Pages(doc); len(pages) != 3 {
+		t.Errorf("IncludeArchived: got %d pages, want 3", len(pages))
+	}
+}
@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"io"
+	"log/slog"
 	"strings"
 	"testing"
 
@@ -71,8 +73,8 @@ Check [[https://example.com][Example]] for more info.
 	}
 }
 
-func BenchmarkParseLargeDocument(b *testing.B) {
-	// Generate a large document
+// largeDocument generates a document with 100 headlines
+func largeDocument() string {
 	var builder strings.Builder
 	builder.WriteString("#+TITLE: Large Document\n\n")
 
@@ -85,8 +87,11 @@ func BenchmarkParseLargeDocument(b *testing.B) {
 		builder.WriteString("- List item 2\n")
 		builder.WriteString("- List item 3\n\n")
 	}
+	return builder.String()
+}
 
-	input := builder.String()
+func BenchmarkParseLargeDocument(b *testing.B) {
+	input := largeDocument()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		l := lexer.New(input)
@@ -95,6 +100,19 @@ func BenchmarkParseLargeDocument(b *testing.B) {
 	}
 }
 
+// BenchmarkParseLargeDocumentDebugLogging shows the cost of debug logging
+// when it is enabled, compared to BenchmarkParseLargeDocument
+func BenchmarkParseLargeDocumentDebugLogging(b *testing.B) {
+	input := largeDocument()
+	logger := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l := lexer.New(input, lexer.WithLogger(logger))
+		p := New(l, WithLogger(logger))
+		_ = p.ParseDocument()
+	}
+}
+
 func BenchmarkLexer(b *testing.B) {
 	input := `#+TITLE: Test
 * Headline 1
@@ -127,3 +145,32 @@ func BenchmarkParseInlineFormatting(b *testing.B) {
 		_ = p.ParseDocument()
 	}
 }
+
+// plainProse is a long line of text without any inline markup
+var plainProse = strings.Repeat("The quick brown fox jumps over the lazy dog, then rests in the shade. ", 20)
+
+func BenchmarkParseInlinePlain(b *testing.B) {
+	p := New(lexer.New(""))
+	b.SetBytes(int64(len(plainProse)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = p.parseInlineElements(plainProse)
+	}
+}
+
+func BenchmarkParsePlainParagraphs(b *testing.B) {
+	var builder strings.Builder
+	builder.WriteString("* Chapter\n")
+	for i := 0; i < 100; i++ {
+		builder.WriteString(plainProse + "\n")
+	}
+
+	input := builder.String()
+	b.SetBytes(int64(len(input)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l := lexer.New(input)
+		p := New(l)
+		_ = p.ParseDocument()
+	}
+}
@@ -2,38 +2,59 @@ package parser
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/entity"
 	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/todo"
 	"github.com/justyntemme/organelle/token"
 )
 
 var (
-	priorityRegex   = regexp.MustCompile(`^\[#([A-Z])\]\s*`)
-	tagsRegex       = regexp.MustCompile(`\s+:([a-zA-Z0-9_@#%:]+):\s*$`)
-	timestampRegex  = regexp.MustCompile(`[<\[](\d{4}-\d{2}-\d{2})(?:\s+[A-Za-z]+)?(?:\s+(\d{1,2}:\d{2}))?(?:\s+(\+\+?|\.?\+)(\d+[hdwmy]))?(?:\s+(-\d+[hdwmy]))?[>\]]`)
-	linkRegex       = regexp.MustCompile(`\[\[([^\]]+)\](?:\[([^\]]+)\])?\]`)
-	checkboxRegex   = regexp.MustCompile(`^\s*\[([ X\-])\]\s*`)
-	propertyRegex   = regexp.MustCompile(`^:([^:]+):\s*(.*)$`)
+	priorityRegex    = regexp.MustCompile(`^\[#([A-Z])\]\s*`)
+	tagsRegex        = regexp.MustCompile(`\s+:([a-zA-Z0-9_@#%:]+):\s*$`)
+	timestampRegex   = regexp.MustCompile(`^[<\[](\d{4}-\d{2}-\d{2})(?:\s+([A-Za-z]+))?(?:\s+(\d{1,2}:\d{2})(?:-(\d{1,2}:\d{2}))?)?(?:\s+(\+\+?|\.?\+)(\d+[hdwmy](?:/\d+[hdwmy])?))?(?:\s+(-\d+[hdwmy]))?[>\]]`)
+	linkRegex        = regexp.MustCompile(`\[\[([^\]]+)\](?:\[([^\]]+)\])?\]`)
+	checkboxRegex    = regexp.MustCompile(`^\s*\[([ X\-])\]\s*`)
+	propertyRegex    = regexp.MustCompile(`^:([^:]+):\s*(.*)$`)
+	footnoteRegex    = regexp.MustCompile(`^\[fn:([A-Za-z0-9_-]+)\]`)
+	footnoteDefRegex = regexp.MustCompile(`^\[fn:([A-Za-z0-9_-]+)\]\s*`)
+	clockRegex       = regexp.MustCompile(`^CLOCK:\s*(\[[^\]]+\])(?:--(\[[^\]]+\]))?(?:\s*=>\s*(\d+):(\d{2}))?\s*$`)
+	planningRegex    = regexp.MustCompile(`(SCHEDULED|DEADLINE|CLOSED):\s*([<\[][^>\]]*[>\]](?:--[<\[][^>\]]*[>\]])?)`)
+	entityRegex      = regexp.MustCompile(`^\\([a-zA-Z]+[0-9]*)(\{\})?`)
 )
 
 type Parser struct {
-	l         *lexer.Lexer
-	curToken  token.Token
-	peekToken token.Token
-	errors    []string
-	logger    *slog.Logger
-	ctx       context.Context
+	l          *lexer.Lexer
+	curToken   token.Token
+	peekToken  token.Token
+	errors     []string
+	logger     *slog.Logger
+	tracing    bool
+	trace      []TraceEvent
+	debug      bool // logger is enabled at Debug level; checked before building log arguments
+	ctx        context.Context
+	strict     bool
+	sections   bool
+	todo       *todo.Matcher
+	bufferTodo bool // todo was set from a #+TODO line
+
+	diagnostics int // Lexer diagnostics already copied to errors
 }
 
 // Option is a functional option for configuring the Parser
 type Option func(*Parser)
 
-// WithLogger sets a custom logger for the parser
+// WithLogger sets a custom logger for the parser. A nil logger discards
+// everything. Whether Debug messages are logged is checked once, when the
+// parser is created, so that disabled debug logging costs nothing per token.
 func WithLogger(logger *slog.Logger) Option {
 	return func(p *Parser) {
 		p.logger = logger
@@ -47,17 +68,66 @@ func WithContext(ctx context.Context) Option {
 	}
 }
 
+// WithStrict reports constructs that are recovered from silently by
+// default as errors: drawer lines without a matching :END:, unmatched
+// :END: lines, property lines outside a PROPERTIES drawer and lines in a
+// PROPERTIES drawer that are not properties. The resulting tree is the
+// same in both modes.
+func WithStrict() Option {
+	return func(p *Parser) {
+		p.strict = true
+	}
+}
+
+// WithSections groups body content into *ast.Section nodes: the content
+// before the first headline becomes the document's zeroth section, and
+// the content of each headline before its first subheadline becomes the
+// headline's section. Without it, body content is left as loose children.
+func WithSections() Option {
+	return func(p *Parser) {
+		p.sections = true
+	}
+}
+
+// WithTodoMatcher sets the TODO keywords recognized on headlines
+// (default todo.Default). #+TODO, #+SEQ_TODO and #+TYP_TODO lines in the
+// document replace them for the headlines that follow.
+func WithTodoMatcher(m *todo.Matcher) Option {
+	return func(p *Parser) {
+		p.todo = m
+	}
+}
+
+// WithTODOKeywords sets the TODO keywords recognized on headlines, with
+// their fast-access keys and logging settings. It is WithTodoMatcher for
+// todo.FromKeywords(kws...); keywords are parsed from #+TODO syntax with
+// todo.ParseSpec.
+func WithTODOKeywords(kws ...todo.Keyword) Option {
+	return WithTodoMatcher(todo.FromKeywords(kws...))
+}
+
+// TodoMatcher returns the TODO keywords in effect, including those read
+// from #+TODO lines so far
+func (p *Parser) TodoMatcher() *todo.Matcher {
+	return p.todo
+}
+
 func New(l *lexer.Lexer, opts ...Option) *Parser {
 	p := &Parser{
 		l:      l,
 		errors: []string{},
 		logger: slog.Default(),
 		ctx:    context.Background(),
+		todo:   todo.Default,
 	}
 
 	for _, opt := range opts {
 		opt(p)
 	}
+	if p.logger == nil {
+		p.logger = slog.New(slog.DiscardHandler)
+	}
+	p.debug = p.logger.Enabled(p.ctx, slog.LevelDebug)
 
 	// Check for lexer errors
 	if err := l.Err(); err != nil {
@@ -68,7 +138,9 @@ func New(l *lexer.Lexer, opts ...Option) *Parser {
 	p.nextToken()
 	p.nextToken()
 
-	p.logger.Debug("parser initialized")
+	if p.debug {
+		p.logger.Debug("parser initialized")
+	}
 	return p
 }
 
@@ -86,6 +158,22 @@ func (p *Parser) checkContext() bool {
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
 	p.peekToken = p.l.NextToken()
+
+	// Report the lines the lexer split or could not tokenize and keep them as text with
+	// the offending bytes replaced, wherever they occur
+	if p.curToken.Type == token.ILLEGAL {
+		p.addError("invalid UTF-8 or NUL byte; replaced with U+FFFD")
+		p.curToken.Literal = sanitize(p.curToken.Literal)
+	}
+	for _, err := range p.l.Diagnostics()[p.diagnostics:] {
+		p.errors = append(p.errors, err.Error())
+		p.diagnostics++
+	}
+}
+
+// sanitize replaces invalid UTF-8 sequences and NUL bytes with U+FFFD
+func sanitize(s string) string {
+	return strings.ReplaceAll(strings.ToValidUTF8(s, "\uFFFD"), "\x00", "\uFFFD")
 }
 
 func (p *Parser) Errors() []string {
@@ -103,7 +191,9 @@ func (p *Parser) ParseDocument() *ast.Document {
 	doc := &ast.Document{}
 	doc.Children = []ast.Node{}
 
-	p.logger.Debug("starting document parse")
+	if p.debug {
+		p.logger.Debug("starting document parse")
+	}
 
 	// We use a stack to manage headline nesting.
 	var stack []*ast.Headline
@@ -134,9 +224,11 @@ func (p *Parser) ParseDocument() *ast.Document {
 
 				if len(stack) == 0 {
 					doc.Children = append(doc.Children, hl)
+					p.note("top level")
 				} else {
 					parent := stack[len(stack)-1]
 					parent.Children = append(parent.Children, hl)
+					p.note("child of line %d", parent.Token.Line)
 				}
 
 				stack = append(stack, hl)
@@ -144,6 +236,11 @@ func (p *Parser) ParseDocument() *ast.Document {
 				// Non-headline elements
 				if len(stack) > 0 {
 					parent := stack[len(stack)-1]
+					if planning(parent, node) {
+						p.note("planning line of headline on line %d", parent.Token.Line)
+						p.nextToken()
+						continue
+					}
 					parent.Children = append(parent.Children, node)
 				} else {
 					doc.Children = append(doc.Children, node)
@@ -153,34 +250,106 @@ func (p *Parser) ParseDocument() *ast.Document {
 		p.nextToken()
 	}
 
-	p.logger.Debug("document parse complete", "children", len(doc.Children), "errors", len(p.errors))
+	if p.sections {
+		doc.Children = sectionize(doc.Children)
+	}
+
+	if p.debug {
+		p.logger.Debug("document parse complete", "children", len(doc.Children), "errors", len(p.errors))
+	}
 	return doc
 }
 
+// ParseFragment parses content that is not a complete document, such as
+// the body to insert under a headline, and returns its top-level nodes.
+// Headlines in the fragment are nested relative to each other; the first
+// one does not need to be level 1.
+func (p *Parser) ParseFragment() []ast.Node {
+	return p.ParseDocument().Children
+}
+
+// ParseFragment is a convenience wrapper that lexes and parses text as a
+// fragment. Windows line endings are normalized so pasted text parses the
+// same as text read from a file.
+func ParseFragment(text string, opts ...Option) ([]ast.Node, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	p := New(lexer.New(text), opts...)
+	nodes := p.ParseFragment()
+	if len(p.errors) > 0 {
+		return nodes, errors.New(strings.Join(p.errors, "; "))
+	}
+	return nodes, nil
+}
+
+// sectionize moves the nodes before the first headline into a Section,
+// and does the same for the children of every headline
+func sectionize(nodes []ast.Node) []ast.Node {
+	n := -1
+	for i, node := range nodes {
+		if h, ok := node.(*ast.Headline); ok {
+			h.Children = sectionize(h.Children)
+			if n < 0 {
+				n = i
+			}
+		}
+	}
+	if n < 0 {
+		n = len(nodes)
+	}
+	if n == 0 {
+		return nodes
+	}
+	section := ast.NewSection(nodes[:n:n])
+	return append([]ast.Node{section}, nodes[n:]...)
+}
+
 func (p *Parser) parseNode() ast.Node {
-	p.logger.Debug("parsing node", "token_type", p.curToken.Type, "line", p.curToken.Line)
+	if p.debug {
+		p.logger.Debug("parsing node", "token_type", p.curToken.Type, "line", p.curToken.Line)
+	}
+
+	tok := p.curToken
+	rule, node := p.parseRule()
+	if p.tracing && rule != "" {
+		p.trace = append(p.trace, TraceEvent{Token: tok, Rule: rule, Node: node})
+	}
+	return node
+}
 
+// parseRule parses the element at the current token and returns the name
+// of the rule that handled it, or "" for tokens that produce nothing
+func (p *Parser) parseRule() (string, ast.Node) {
 	switch p.curToken.Type {
 	case token.STARS:
-		return p.parseHeadline()
+		return "headline", p.parseHeadline()
 	case token.KEYWORD:
-		return p.parseKeyword()
+		if isCallLine(p.curToken.Literal) {
+			return "call", p.parseCall()
+		}
+		return "keyword", p.parseKeyword()
 	case token.BLOCK_BEGIN:
-		return p.parseBlock()
+		return "block", p.parseBlock()
 	case token.DRAWER_BEGIN:
-		return p.parseDrawer()
+		return "drawer", p.parseDrawer()
 	case token.LIST_ITEM:
-		return p.parseList()
+		return "list", p.parseList()
 	case token.TABLE_ROW, token.TABLE_SEP:
-		return p.parseTable()
+		return "table", p.parseTable()
 	case token.COMMENT:
-		return p.parseComment()
-	case token.TEXT:
-		return p.parseParagraph()
-	case token.NEWLINE:
-		return nil
+		return "comment", p.parseComment()
+	case token.TEXT, token.ILLEGAL:
+		if footnoteDefRegex.MatchString(p.curToken.Literal) {
+			return "footnote-definition", p.parseFootnoteDefinition()
+		}
+		return "paragraph", p.parseParagraph()
+	case token.DRAWER_END:
+		// An :END: without an open drawer is kept as text
+		if p.strict {
+			p.addError("unmatched %s line", strings.TrimSpace(p.curToken.Literal))
+		}
+		return "paragraph", p.parseParagraph()
 	default:
-		return nil
+		return "", nil
 	}
 }
 
@@ -202,20 +371,8 @@ func (p *Parser) parseHeadline() *ast.Headline {
 			text = strings.TrimSpace(text[:len(text)-len(matches[0])])
 		}
 
-		// Check for TODO/DONE keywords
-		if strings.HasPrefix(text, "TODO ") {
-			hl.Keyword = "TODO"
-			text = strings.TrimSpace(text[5:])
-		} else if strings.HasPrefix(text, "DONE ") {
-			hl.Keyword = "DONE"
-			text = strings.TrimSpace(text[5:])
-		} else if text == "TODO" {
-			hl.Keyword = "TODO"
-			text = ""
-		} else if text == "DONE" {
-			hl.Keyword = "DONE"
-			text = ""
-		}
+		hl.Keyword, text = p.todo.Match(text)
+		hl.Done = hl.Keyword != "" && p.todo.IsDone(hl.Keyword)
 
 		// Check for priority [#A]
 		if matches := priorityRegex.FindStringSubmatch(text); matches != nil {
@@ -226,7 +383,9 @@ func (p *Parser) parseHeadline() *ast.Headline {
 		hl.Title = text
 	}
 
-	p.logger.Debug("parsed headline", "level", hl.Level, "title", hl.Title, "keyword", hl.Keyword, "tags", hl.Tags)
+	if p.debug {
+		p.logger.Debug("parsed headline", "level", hl.Level, "title", hl.Title, "keyword", hl.Keyword, "tags", hl.Tags)
+	}
 	return hl
 }
 
@@ -256,10 +415,141 @@ func (p *Parser) parseKeyword() *ast.Keyword {
 		Key:   key,
 		Value: val,
 	}
-	p.logger.Debug("parsed keyword", "key", key, "value", val)
+	if isTodoKey(key) {
+		p.addTodoSequence(val)
+	}
+	if p.debug {
+		p.logger.Debug("parsed keyword", "key", key, "value", val)
+	}
 	return kw
 }
 
+// isTodoKey reports whether key declares TODO keywords
+func isTodoKey(key string) bool {
+	switch strings.ToUpper(key) {
+	case "TODO", "SEQ_TODO", "TYP_TODO":
+		return true
+	}
+	return false
+}
+
+// addTodoSequence recognizes the keywords of a #+TODO line from now on.
+// The first such line replaces the configured keywords and later ones
+// add to it, as in Emacs.
+func (p *Parser) addTodoSequence(value string) {
+	kws := todo.ParseSpec(value)
+	if !p.bufferTodo {
+		p.todo = todo.FromKeywords(kws...)
+		p.bufferTodo = true
+		return
+	}
+	p.todo = p.todo.Extend(kws...)
+}
+
+// isCallLine reports whether a keyword token is a #+CALL: line
+func isCallLine(literal string) bool {
+	return len(literal) >= 7 && strings.EqualFold(literal[:7], "#+CALL:")
+}
+
+// parseCall parses #+CALL: name[inside header](args) end header
+func (p *Parser) parseCall() *ast.Call {
+	call := &ast.Call{Token: p.curToken}
+	rest := strings.TrimSpace(p.curToken.Literal[7:])
+
+	nameEnd := strings.IndexAny(rest, "[( \t")
+	if nameEnd == -1 {
+		nameEnd = len(rest)
+	}
+	call.Name = rest[:nameEnd]
+	rest = rest[nameEnd:]
+
+	if call.Name == "" {
+		p.addError("missing block name in %q", p.curToken.Literal)
+	}
+
+	if strings.HasPrefix(rest, "[") {
+		end := matchingBracket(rest, '[', ']')
+		if end == -1 {
+			p.addError("unterminated header arguments in %q", p.curToken.Literal)
+			return call
+		}
+		call.InsideHeader = rest[1:end]
+		rest = rest[end+1:]
+	}
+
+	if strings.HasPrefix(rest, "(") {
+		end := matchingBracket(rest, '(', ')')
+		if end == -1 {
+			p.addError("unterminated argument list in %q", p.curToken.Literal)
+			return call
+		}
+		call.Arguments = rest[1:end]
+		call.Args = splitCallArgs(call.Arguments)
+		rest = rest[end+1:]
+	}
+
+	call.EndHeader = strings.TrimSpace(rest)
+	if p.debug {
+		p.logger.Debug("parsed call", "name", call.Name, "args", len(call.Args))
+	}
+	return call
+}
+
+// matchingBracket returns the index of the bracket closing s[0], skipping
+// nested pairs and double-quoted strings
+func matchingBracket(s string, open, close byte) int {
+	depth := 0
+	inQuote := false
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; {
+		case ch == '"':
+			inQuote = !inQuote
+		case inQuote:
+		case ch == open:
+			depth++
+		case ch == close:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitCallArgs splits "a=1, b=\"x, y\"" into name/value pairs on
+// top-level commas
+func splitCallArgs(s string) []ast.CallArg {
+	var args []ast.CallArg
+	depth := 0
+	inQuote := false
+	start := 0
+	flush := func(part string) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return
+		}
+		name, value, _ := strings.Cut(part, "=")
+		args = append(args, ast.CallArg{Name: strings.TrimSpace(name), Value: strings.TrimSpace(value)})
+	}
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; {
+		case ch == '"':
+			inQuote = !inQuote
+		case inQuote:
+		case ch == '(' || ch == '[':
+			depth++
+		case ch == ')' || ch == ']':
+			depth--
+		case ch == ',' && depth == 0:
+			flush(s[start:i])
+			start = i + 1
+		}
+	}
+	flush(s[start:])
+	return args
+}
+
 func (p *Parser) parseBlock() *ast.Block {
 	block := &ast.Block{
 		Token: p.curToken,
@@ -305,7 +595,9 @@ func (p *Parser) parseBlock() *ast.Block {
 	}
 
 	block.Content = strings.Join(contentLines, "\n")
-	p.logger.Debug("parsed block", "type", block.Type, "language", block.Language, "content_lines", len(contentLines))
+	if p.debug {
+		p.logger.Debug("parsed block", "type", block.Type, "language", block.Language, "content_lines", len(contentLines))
+	}
 	return block
 }
 
@@ -336,17 +628,32 @@ func (p *Parser) parseDrawer() *ast.Drawer {
 
 		// If this is a PROPERTIES drawer, parse properties
 		if drawer.Name == "PROPERTIES" {
-			if matches := propertyRegex.FindStringSubmatch(strings.TrimSpace(line)); matches != nil {
+			if matches := propertyRegex.FindStringSubmatch(strings.TrimSpace(line)); matches == nil {
+				if p.strict {
+					p.addError("invalid line in PROPERTIES drawer: %q", strings.TrimSpace(line))
+				}
+			} else {
+				if _, dup := drawer.Properties[matches[1]]; !dup {
+					drawer.Keys = append(drawer.Keys, matches[1])
+				}
 				drawer.Properties[matches[1]] = matches[2]
 			}
 		} else {
+			if drawer.Name == "LOGBOOK" {
+				if clock := ParseClock(line); clock != nil {
+					clock.Token = p.curToken
+					drawer.Clocks = append(drawer.Clocks, clock)
+				}
+			}
 			contentLines = append(contentLines, line)
 		}
 		p.nextToken()
 	}
 
 	drawer.Content = strings.Join(contentLines, "\n")
-	p.logger.Debug("parsed drawer", "name", drawer.Name, "properties", len(drawer.Properties))
+	if p.debug {
+		p.logger.Debug("parsed drawer", "name", drawer.Name, "properties", len(drawer.Properties))
+	}
 	return drawer
 }
 
@@ -385,7 +692,9 @@ func (p *Parser) parseList() *ast.List {
 	// Build nested structure based on indentation
 	list.Items = p.buildNestedList(allItems, baseIndent)
 
-	p.logger.Debug("parsed list", "ordered", list.Ordered, "items", len(list.Items))
+	if p.debug {
+		p.logger.Debug("parsed list", "ordered", list.Ordered, "items", len(list.Items))
+	}
 	return list
 }
 
@@ -519,7 +828,9 @@ func (p *Parser) parseTable() *ast.Table {
 		p.nextToken()
 	}
 
-	p.logger.Debug("parsed table", "rows", len(table.Rows))
+	if p.debug {
+		p.logger.Debug("parsed table", "rows", len(table.Rows))
+	}
 	return table
 }
 
@@ -556,7 +867,9 @@ func (p *Parser) parseComment() *ast.Comment {
 		comment.Content = strings.TrimPrefix(literal, "#")
 	}
 
-	p.logger.Debug("parsed comment", "content", comment.Content)
+	if p.debug {
+		p.logger.Debug("parsed comment", "content", comment.Content)
+	}
 	return comment
 }
 
@@ -565,6 +878,9 @@ func (p *Parser) parseParagraph() *ast.Paragraph {
 		Token:   p.curToken,
 		Content: p.curToken.Literal,
 	}
+	if p.strict {
+		p.checkDrawerSyntax(para.Content)
+	}
 
 	// Parse inline elements
 	para.Inline = p.parseInlineElements(para.Content)
@@ -572,6 +888,54 @@ func (p *Parser) parseParagraph() *ast.Paragraph {
 	return para
 }
 
+// parseFootnoteDefinition parses a [fn:label] line and the text lines
+// that follow it up to a blank line or any other element
+func (p *Parser) parseFootnoteDefinition() *ast.FootnoteDefinition {
+	def := &ast.FootnoteDefinition{Token: p.curToken}
+	prefix := footnoteDefRegex.FindStringSubmatch(p.curToken.Literal)
+	def.Label = prefix[1]
+
+	// The first paragraph starts after the label, so that inline offsets
+	// stay relative to its own content
+	tok := p.curToken
+	tok.Literal = tok.Literal[len(prefix[0]):]
+	tok.Offset += len(prefix[0])
+	tok.Column += len(prefix[0])
+	def.Children = append(def.Children, &ast.Paragraph{
+		Token:   tok,
+		Content: tok.Literal,
+		Inline:  p.parseInlineElements(tok.Literal),
+	})
+
+	for p.peekTokenIs(token.NEWLINE) {
+		p.nextToken()
+		if !p.peekTokenIs(token.TEXT) || footnoteDefRegex.MatchString(p.peekToken.Literal) {
+			break
+		}
+		p.nextToken()
+		def.Children = append(def.Children, p.parseParagraph())
+	}
+	return def
+}
+
+// checkDrawerSyntax reports paragraph lines that look like drawer syntax.
+// The lexer only opens a drawer when its :END: comes before the next
+// headline, so these lines are kept as text.
+func (p *Parser) checkDrawerSyntax(line string) {
+	if !strings.HasPrefix(line, ":") {
+		return
+	}
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case strings.EqualFold(trimmed, ":END:"):
+		// Reported by parseNode
+	case strings.HasSuffix(trimmed, ":") && strings.Count(trimmed, ":") == 2:
+		p.addError("drawer %s has no :END: before the next headline; treated as text", trimmed)
+	case propertyRegex.MatchString(trimmed):
+		p.addError("property line outside a PROPERTIES drawer: %q", trimmed)
+	}
+}
+
 // inlineMarkers maps opening markers to their type and closing marker
 var inlineMarkers = map[byte]struct {
 	typ     ast.InlineType
@@ -586,20 +950,31 @@ var inlineMarkers = map[byte]struct {
 	'_': {ast.InlineUnderline, '_', true},
 }
 
+// inlineMarkerChars are the bytes that can start inline markup: the
+// emphasis markers, the entity backslash, the link bracket and the
+// timestamp brackets
+const inlineMarkerChars = "*/~=+_\\[<"
+
 func (p *Parser) parseInlineElements(text string) []ast.InlineElement {
-	return p.parseInlineElementsRecursive(text, 0)
+	// Most prose has no markup at all; skip the per-byte scan
+	if text != "" && !strings.ContainsAny(text, inlineMarkerChars) {
+		return []ast.InlineElement{{Type: ast.InlineText, Content: text, Start: 0, End: len(text)}}
+	}
+	return p.parseInlineElementsRecursive(text, 0, 0)
 }
 
 // parseInlineElementsRecursive parses inline elements with support for nesting
-// depth is used to prevent infinite recursion
-func (p *Parser) parseInlineElementsRecursive(text string, depth int) []ast.InlineElement {
+// depth is used to prevent infinite recursion; base is the offset of text
+// within the paragraph so element positions are paragraph-relative
+func (p *Parser) parseInlineElementsRecursive(text string, depth int, base int) []ast.InlineElement {
 	const maxDepth = 10 // prevent infinite recursion on malformed input
 	if depth > maxDepth {
-		return []ast.InlineElement{{Type: ast.InlineText, Content: text}}
+		return []ast.InlineElement{{Type: ast.InlineText, Content: text, Start: base, End: base + len(text)}}
 	}
 
 	var elements []ast.InlineElement
 	remaining := text
+	pos := base // paragraph offset of remaining
 
 	for len(remaining) > 0 {
 		// Check for links [[url][desc]] first
@@ -611,30 +986,81 @@ func (p *Parser) parseInlineElementsRecursive(text string, depth int) []ast.Inli
 					desc = remaining[matches[4]:matches[5]]
 				}
 				elem := ast.InlineElement{
-					Type: ast.InlineLink,
-					URL:  url,
+					Type:  ast.InlineLink,
+					URL:   url,
+					Start: pos,
+					End:   pos + matches[1],
 				}
 				// Parse description for nested formatting
 				if desc != "" {
-					elem.Children = p.parseInlineElementsRecursive(desc, depth+1)
+					elem.Children = p.parseInlineElementsRecursive(desc, depth+1, pos+matches[4])
 				}
 				elements = append(elements, elem)
 				remaining = remaining[matches[1]:]
+				pos += matches[1]
 				continue
 			}
 		}
 
+		// Check for footnote references [fn:label]
+		if strings.HasPrefix(remaining, "[fn:") {
+			if m := footnoteRegex.FindStringSubmatch(remaining); m != nil {
+				elements = append(elements, ast.InlineElement{
+					Type:    ast.InlineFootnoteRef,
+					Content: m[1],
+					Start:   pos,
+					End:     pos + len(m[0]),
+				})
+				remaining = remaining[len(m[0]):]
+				pos += len(m[0])
+				continue
+			}
+		}
+
+		// Check for timestamps <2024-01-01 Mon> and ranges
+		if (remaining[0] == '<' || remaining[0] == '[') && len(remaining) > 1 && isDigit(remaining[1]) {
+			if ts, n := matchTimestamp(remaining); ts != nil {
+				elements = append(elements, ast.InlineElement{
+					Type:      ast.InlineTimestamp,
+					Content:   remaining[:n],
+					Timestamp: ts,
+					Start:     pos,
+					End:       pos + n,
+				})
+				remaining = remaining[n:]
+				pos += n
+				continue
+			}
+		}
+
+		// Check for entities \alpha or \alpha{}
+		if remaining[0] == '\\' {
+			if m := entityRegex.FindStringSubmatch(remaining); m != nil {
+				if _, known := entity.Lookup(m[1]); known {
+					elements = append(elements, ast.InlineElement{
+						Type:    ast.InlineEntity,
+						Content: m[1],
+						Start:   pos,
+						End:     pos + len(m[0]),
+					})
+					remaining = remaining[len(m[0]):]
+					pos += len(m[0])
+					continue
+				}
+			}
+		}
+
 		// Check for inline formatting markers
-		if marker, ok := inlineMarkers[remaining[0]]; ok && len(remaining) > 2 {
+		if marker, ok := inlineMarkers[remaining[0]]; ok && len(remaining) > 2 && !escapedMarker(remaining, 0) {
 			// Find the closing marker
 			end := p.findClosingMarker(remaining[1:], marker.closer)
 			if end != -1 && end > 0 {
 				innerContent := remaining[1 : end+1]
-				elem := ast.InlineElement{Type: marker.typ}
+				elem := ast.InlineElement{Type: marker.typ, Start: pos, End: pos + end + 2}
 
 				if marker.nestable {
 					// Recursively parse inner content for nested formatting
-					elem.Children = p.parseInlineElementsRecursive(innerContent, depth+1)
+					elem.Children = p.parseInlineElementsRecursive(innerContent, depth+1, pos+1)
 				} else {
 					// Non-nestable (code, verbatim) - store as raw content
 					elem.Content = innerContent
@@ -642,6 +1068,7 @@ func (p *Parser) parseInlineElementsRecursive(text string, depth int) []ast.Inli
 
 				elements = append(elements, elem)
 				remaining = remaining[end+2:]
+				pos += end + 2
 				continue
 			}
 		}
@@ -653,6 +1080,8 @@ func (p *Parser) parseInlineElementsRecursive(text string, depth int) []ast.Inli
 			elements = append(elements, ast.InlineElement{
 				Type:    ast.InlineText,
 				Content: remaining,
+				Start:   pos,
+				End:     pos + len(remaining),
 			})
 			break
 		} else if nextMarker > 0 {
@@ -660,15 +1089,21 @@ func (p *Parser) parseInlineElementsRecursive(text string, depth int) []ast.Inli
 			elements = append(elements, ast.InlineElement{
 				Type:    ast.InlineText,
 				Content: remaining[:nextMarker],
+				Start:   pos,
+				End:     pos + nextMarker,
 			})
 			remaining = remaining[nextMarker:]
+			pos += nextMarker
 		} else {
 			// Marker at start but didn't match a valid pattern, consume as text
 			elements = append(elements, ast.InlineElement{
 				Type:    ast.InlineText,
 				Content: string(remaining[0]),
+				Start:   pos,
+				End:     pos + 1,
 			})
 			remaining = remaining[1:]
+			pos++
 		}
 	}
 
@@ -678,13 +1113,22 @@ func (p *Parser) parseInlineElementsRecursive(text string, depth int) []ast.Inli
 // findClosingMarker finds the position of the closing marker, respecting nesting
 func (p *Parser) findClosingMarker(text string, closer byte) int {
 	for i := 0; i < len(text); i++ {
-		if text[i] == closer {
+		if text[i] == closer && !escapedMarker(text, i) {
 			return i
 		}
 	}
 	return -1
 }
 
+// zeroWidthSpace is Org's escape character for markup
+const zeroWidthSpace = "\u200b"
+
+// escapedMarker reports whether the marker at text[i] is immediately
+// followed by a zero-width space, which makes it a literal character
+func escapedMarker(text string, i int) bool {
+	return strings.HasPrefix(text[i+1:], zeroWidthSpace)
+}
+
 // findNextMarker finds the position of the next potential inline marker
 func (p *Parser) findNextMarker(text string) int {
 	for i := 0; i < len(text); i++ {
@@ -692,38 +1136,174 @@ func (p *Parser) findNextMarker(text string) int {
 		if ch == '*' || ch == '/' || ch == '~' || ch == '=' || ch == '+' || ch == '_' {
 			return i
 		}
-		if ch == '[' && i+1 < len(text) && text[i+1] == '[' {
+		if ch == '\\' && isEntity(text[i:]) {
+			return i
+		}
+		if ch == '[' && (strings.HasPrefix(text[i+1:], "[") || strings.HasPrefix(text[i+1:], "fn:")) {
+			return i
+		}
+		if (ch == '<' || ch == '[') && i+1 < len(text) && isDigit(text[i+1]) {
 			return i
 		}
 	}
 	return -1
 }
 
+// isEntity reports whether text starts with a known entity such as \alpha
+func isEntity(text string) bool {
+	m := entityRegex.FindStringSubmatch(text)
+	if m == nil {
+		return false
+	}
+	_, known := entity.Lookup(m[1])
+	return known
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
 func (p *Parser) peekTokenIs(t token.TokenType) bool {
 	return p.peekToken.Type == t
 }
 
-// ParseTimestamp parses a timestamp string and returns a Timestamp node
+// ParseTimestamp parses the first timestamp in text, including a range
+// such as <2024-01-01 Mon>--<2024-01-03 Wed>, and returns nil if there is
+// none
 func ParseTimestamp(text string) *ast.Timestamp {
-	matches := timestampRegex.FindStringSubmatch(text)
-	if matches == nil {
-		return nil
+	for i := 0; i < len(text); i++ {
+		if text[i] != '<' && text[i] != '[' {
+			continue
+		}
+		if ts, _ := matchTimestamp(text[i:]); ts != nil {
+			return ts
+		}
 	}
+	return nil
+}
 
+// matchTimestamp parses the timestamp or range at the start of text and
+// returns it with its length, or nil and 0 if text does not start with one
+func matchTimestamp(text string) (*ast.Timestamp, int) {
+	m := timestampRegex.FindStringSubmatchIndex(text)
+	if m == nil {
+		return nil, 0
+	}
+	group := func(i int) string {
+		if m[2*i] < 0 {
+			return ""
+		}
+		return text[m[2*i]:m[2*i+1]]
+	}
 	ts := &ast.Timestamp{
-		Active: strings.HasPrefix(text, "<"),
-		Date:   matches[1],
+		Active:  text[0] == '<',
+		Date:    group(1),
+		Day:     group(2),
+		Time:    group(3),
+		EndTime: group(4),
+	}
+	if group(6) != "" {
+		ts.Repeat = group(5) + group(6)
 	}
+	ts.Warning = group(7)
+	n := m[1]
+
+	// A range continues with -- and a timestamp of the same kind, unless
+	// the timestamp already ends within the day
+	if rest, ok := strings.CutPrefix(text[n:], "--"); ok && rest != "" && rest[0] == text[0] {
+		if end, size := matchTimestamp(rest); ts.EndTime == "" && end != nil && end.EndDate == "" && end.EndTime == "" {
+			ts.EndDate, ts.EndDay, ts.EndTime = end.Date, end.Day, end.Time
+			n += len("--") + size
+		}
+	}
+	return ts, n
+}
 
-	if len(matches) > 2 && matches[2] != "" {
-		ts.Time = matches[2]
+// ParseClock parses a CLOCK line such as
+// "CLOCK: [2024-01-15 Mon 09:00]--[2024-01-15 Mon 10:30] =>  1:30" and
+// returns nil if line is not one. A clock without an end is running.
+func ParseClock(line string) *ast.Clock {
+	m := clockRegex.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return nil
+	}
+	clock := &ast.Clock{Start: ParseTimestamp(m[1])}
+	if clock.Start == nil {
+		return nil
 	}
-	if len(matches) > 4 && matches[4] != "" {
-		ts.Repeat = matches[3] + matches[4]
+	if m[2] == "" {
+		return clock
 	}
-	if len(matches) > 5 && matches[5] != "" {
-		ts.Warning = matches[5]
+	if clock.End = ParseTimestamp(m[2]); clock.End == nil {
+		return nil
 	}
+	if m[3] != "" {
+		hours, _ := strconv.Atoi(m[3])
+		minutes, _ := strconv.Atoi(m[4])
+		clock.Duration = time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute
+	} else {
+		start, err1 := clock.Start.ToTime()
+		end, err2 := clock.End.ToTime()
+		if err1 == nil && err2 == nil {
+			clock.Duration = end.Sub(start)
+		}
+	}
+	return clock
+}
 
-	return ts
+// planning moves a planning line found directly below headline h into its
+// Scheduled, Deadline and Closed fields and reports whether node was one
+func planning(h *ast.Headline, node ast.Node) bool {
+	para, ok := node.(*ast.Paragraph)
+	if !ok || len(h.Children) > 0 || para.Token.Line != h.Token.Line+1 {
+		return false
+	}
+	scheduled, deadline, closed, ok := ParsePlanning(para.Content)
+	if !ok {
+		return false
+	}
+	h.Scheduled, h.Deadline, h.Closed = scheduled, deadline, closed
+	return true
+}
+
+// ParsePlanning parses a planning line such as
+// "SCHEDULED: <2024-02-01 Thu> DEADLINE: <2024-02-10>" and returns its
+// timestamps. ok is false if the line is not made up only of planning
+// keywords each followed by a timestamp, or one of its timestamps cannot
+// be parsed, so that callers keep the line as it is.
+func ParsePlanning(line string) (scheduled, deadline, closed *ast.Timestamp, ok bool) {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "SCHEDULED:") && !strings.HasPrefix(trimmed, "DEADLINE:") &&
+		!strings.HasPrefix(trimmed, "CLOSED:") {
+		return nil, nil, nil, false
+	}
+	// The keyword and timestamp pairs must make up the whole line, so that
+	// no text is lost when the line is taken as planning
+	end := 0
+	matches := planningRegex.FindAllStringSubmatchIndex(trimmed, -1)
+	for _, loc := range matches {
+		if strings.TrimSpace(trimmed[end:loc[0]]) != "" {
+			return nil, nil, nil, false
+		}
+		end = loc[1]
+	}
+	if len(matches) == 0 || strings.TrimSpace(trimmed[end:]) != "" {
+		return nil, nil, nil, false
+	}
+	for _, loc := range matches {
+		keyword, stamp := trimmed[loc[2]:loc[3]], trimmed[loc[4]:loc[5]]
+		ts := ParseTimestamp(stamp)
+		if ts == nil {
+			return nil, nil, nil, false
+		}
+		switch keyword {
+		case "SCHEDULED":
+			scheduled = ts
+		case "DEADLINE":
+			deadline = ts
+		case "CLOSED":
+			closed = ts
+		}
+	}
+	return scheduled, deadline, closed, true
 }
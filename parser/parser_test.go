@@ -6,9 +6,12 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	"github.com/justyntemme/organelle/ast"
 	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/todo"
 )
 
 func TestParseHeadlineHierarchy(t *testing.T) {
@@ -529,6 +532,7 @@ func TestParseTimestamp(t *testing.T) {
 		{"<2024-01-15 Mon 10:00>", true, "2024-01-15", "10:00", "", ""},
 		{"<2024-01-15 +1w>", true, "2024-01-15", "", "+1w", ""},
 		{"<2024-01-15 +1w -3d>", true, "2024-01-15", "", "+1w", "-3d"},
+		{"see [2024] and <2024-01-15 Mon> [2024-01-16]", true, "2024-01-15", "", "", ""},
 	}
 
 	for _, tt := range tests {
@@ -591,6 +595,21 @@ func TestParserWithLogger(t *testing.T) {
 	}
 }
 
+func TestParserDebugLogging(t *testing.T) {
+	var buf strings.Builder
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	New(lexer.New("* Test headline\n", lexer.WithLogger(logger)), WithLogger(logger)).ParseDocument()
+	if !strings.Contains(buf.String(), "parsed headline") || !strings.Contains(buf.String(), "msg=token") {
+		t.Errorf("expected debug messages from lexer and parser, got:\n%s", buf.String())
+	}
+
+	doc := New(lexer.New("* Quiet\n", lexer.WithLogger(nil)), WithLogger(nil)).ParseDocument()
+	if len(doc.Children) != 1 {
+		t.Errorf("expected a nil logger to discard output, got=%d children", len(doc.Children))
+	}
+}
+
 func TestComplexDocument(t *testing.T) {
 	input := `#+TITLE: Project Plan
 #+AUTHOR: Team Lead
@@ -767,3 +786,1021 @@ func TestInputSizeLimit(t *testing.T) {
 		t.Errorf("expected ErrInputTooLarge, got=%v", l.Err())
 	}
 }
+
+func TestParseStartupSettings(t *testing.T) {
+	input := `#+STARTUP: overview hidestars
+#+STARTUP: show3levels nohideblocks customopt
+* Folded
+:PROPERTIES:
+:VISIBILITY: folded
+:END:
+* Plain
+`
+	l := lexer.New(input)
+	p := New(l)
+	doc := p.ParseDocument()
+
+	startup := doc.Startup()
+	if startup.Visibility != ast.VisibilityShowLevels || startup.Levels != 3 {
+		t.Errorf("expected show3levels, got=%s levels=%d", startup.Visibility, startup.Levels)
+	}
+	if !startup.HideStars {
+		t.Error("expected HideStars to be set")
+	}
+	if len(startup.Options) != 5 || startup.Options[4] != "customopt" {
+		t.Errorf("expected all raw options to be kept, got=%v", startup.Options)
+	}
+
+	h1 := doc.Children[2].(*ast.Headline)
+	if h1.Visibility() != ast.VisibilityFolded {
+		t.Errorf("expected folded visibility, got=%s", h1.Visibility())
+	}
+	h2 := doc.Children[3].(*ast.Headline)
+	if h2.Visibility() != ast.VisibilityDefault {
+		t.Errorf("expected default visibility, got=%s", h2.Visibility())
+	}
+}
+
+func TestParseCall(t *testing.T) {
+	input := `#+NAME: double
+#+HEADER: :var n=1
+#+BEGIN_SRC python
+return n * 2
+#+END_SRC
+
+#+CALL: double[:session s](n=4, label="a, b") :results silent
+#+call: missing()
+`
+	l := lexer.New(input)
+	p := New(l)
+	doc := p.ParseDocument()
+
+	if len(p.Errors()) != 0 {
+		t.Errorf("parser has errors: %v", p.Errors())
+	}
+
+	var calls []*ast.Call
+	for _, n := range doc.Children {
+		if c, ok := n.(*ast.Call); ok {
+			calls = append(calls, c)
+		}
+	}
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 calls, got=%d", len(calls))
+	}
+
+	c := calls[0]
+	if c.Name != "double" {
+		t.Errorf("call.Name expected 'double', got=%q", c.Name)
+	}
+	if c.InsideHeader != ":session s" {
+		t.Errorf("call.InsideHeader expected ':session s', got=%q", c.InsideHeader)
+	}
+	if c.EndHeader != ":results silent" {
+		t.Errorf("call.EndHeader expected ':results silent', got=%q", c.EndHeader)
+	}
+	if len(c.Args) != 2 || c.Args[0] != (ast.CallArg{Name: "n", Value: "4"}) || c.Args[1].Value != `"a, b"` {
+		t.Errorf("unexpected call.Args: %+v", c.Args)
+	}
+	if c.Block(doc) == nil || c.Block(doc).Language != "python" {
+		t.Error("expected call to resolve to the named python block")
+	}
+	if calls[1].Block(doc) != nil {
+		t.Error("expected unresolved call to return nil block")
+	}
+	if got := c.String(); got != "#+CALL: double[:session s](n=4, label=\"a, b\") :results silent\n" {
+		t.Errorf("call.String() = %q", got)
+	}
+}
+
+func TestNamedElementsMalformedKeyword(t *testing.T) {
+	for _, input := range []string{
+		"#+: x\n#+NAME: foo\n#+BEGIN_SRC sh\necho\n#+END_SRC\n",
+		"#+NAME: foo\n#+: x\ntext\n",
+	} {
+		doc := New(lexer.New(input)).ParseDocument()
+		named := doc.NamedElements()
+		if strings.HasPrefix(input, "#+: x") {
+			if len(named) != 1 || named[0].Name != "foo" {
+				t.Errorf("%q: expected the block named foo, got=%v", input, named)
+			}
+		} else if len(named) != 0 || doc.NamedElement("foo") != nil {
+			t.Errorf("%q: expected nothing named, got=%v", input, named)
+		}
+	}
+}
+
+func TestParseFragment(t *testing.T) {
+	input := "Body text\r\n*** Deep child\r\n- item\r\n**** Deeper\r\n*** Sibling\r\n"
+
+	nodes, err := ParseFragment(input)
+	if err != nil {
+		t.Fatalf("ParseFragment failed: %v", err)
+	}
+
+	if len(nodes) != 3 {
+		t.Fatalf("expected 3 top-level nodes, got=%d", len(nodes))
+	}
+	if para, ok := nodes[0].(*ast.Paragraph); !ok || para.Content != "Body text" {
+		t.Errorf("expected paragraph 'Body text', got=%#v", nodes[0])
+	}
+	child, ok := nodes[1].(*ast.Headline)
+	if !ok || child.Level != 3 || child.Title != "Deep child" {
+		t.Fatalf("expected level 3 headline, got=%#v", nodes[1])
+	}
+	if len(child.Children) != 2 {
+		t.Errorf("expected list and nested headline under child, got=%d", len(child.Children))
+	}
+	if _, ok := nodes[2].(*ast.Headline); !ok {
+		t.Errorf("expected sibling headline, got=%T", nodes[2])
+	}
+}
+
+func TestInlineElementOffsets(t *testing.T) {
+	input := "* Heading\nSee *bold /it/* and [[https://x.org][the site]].\n"
+	l := lexer.New(input)
+	p := New(l)
+	doc := p.ParseDocument()
+
+	para := doc.Children[0].(*ast.Headline).Children[0].(*ast.Paragraph)
+
+	var bold, link ast.InlineElement
+	for _, e := range para.Inline {
+		switch e.Type {
+		case ast.InlineBold:
+			bold = e
+		case ast.InlineLink:
+			link = e
+		}
+	}
+
+	if got := para.Content[bold.Start:bold.End]; got != "*bold /it/*" {
+		t.Errorf("bold span wrong, got=%q", got)
+	}
+	italic := bold.Children[1]
+	if got := para.Content[italic.Start:italic.End]; got != "/it/" {
+		t.Errorf("nested italic span wrong, got=%q", got)
+	}
+	if got := para.Content[link.Start:link.End]; got != "[[https://x.org][the site]]" {
+		t.Errorf("link span wrong, got=%q", got)
+	}
+	desc := link.Children[0]
+	if got := para.Content[desc.Start:desc.End]; got != "the site" {
+		t.Errorf("link description span wrong, got=%q", got)
+	}
+
+	start, end := para.Span(bold)
+	if got := input[start:end]; got != "*bold /it/*" {
+		t.Errorf("absolute span wrong, got=%q", got)
+	}
+}
+
+func TestInlinePlainFastPath(t *testing.T) {
+	p := New(lexer.New(""))
+	for _, text := range []string{"Just prose, no markup.", "a", "Ends with a bracket ["} {
+		fast := p.parseInlineElements(text)
+		slow := p.parseInlineElementsRecursive(text, 0, 0)
+		if len(fast) != 1 || len(slow) != 1 || fast[0].Type != ast.InlineText ||
+			fast[0].Content != slow[0].Content || fast[0].Start != slow[0].Start || fast[0].End != slow[0].End {
+			t.Errorf("fast path differs for %q: got=%+v want=%+v", text, fast, slow)
+		}
+	}
+	if got := p.parseInlineElements(""); got != nil {
+		t.Errorf("expected no elements for empty text, got=%+v", got)
+	}
+}
+
+func TestFootnoteReferences(t *testing.T) {
+	input := "See this[fn:1] and *that[fn:my-note]*, not [fn:: inline] or [fn]."
+	doc := New(lexer.New(input)).ParseDocument()
+	para := doc.Children[0].(*ast.Paragraph)
+
+	ref := para.Inline[1]
+	if ref.Type != ast.InlineFootnoteRef || ref.Content != "1" {
+		t.Fatalf("expected footnote reference 1, got=%+v", ref)
+	}
+	if got := para.Content[ref.Start:ref.End]; got != "[fn:1]" {
+		t.Errorf("footnote span wrong, got=%q", got)
+	}
+	bold := para.Inline[3]
+	if nested := bold.Children[1]; nested.Type != ast.InlineFootnoteRef || nested.Content != "my-note" {
+		t.Errorf("expected footnote reference inside bold, got=%+v", bold.Children)
+	}
+	for _, e := range para.Inline[4:] {
+		if e.Type != ast.InlineText {
+			t.Errorf("expected the rest to stay text, got=%+v", e)
+		}
+	}
+	if got := bold.PlainText(); got != "that" {
+		t.Errorf("expected footnote reference to have no plain text, got=%q", got)
+	}
+}
+
+func TestFootnoteDefinitions(t *testing.T) {
+	input := `A claim[fn:src].
+* Footnotes
+[fn:src] The *source*,
+over two lines.
+[fn:2] Short.
+
+After the notes.
+  [fn:3] Indented, so not a definition.
+`
+	doc := New(lexer.New(input)).ParseDocument()
+	section := doc.Children[1].(*ast.Headline)
+	if !section.IsFootnoteSection() {
+		t.Errorf("expected the Footnotes headline to be recognized")
+	}
+	if len(section.Children) != 4 {
+		t.Fatalf("expected 2 definitions and 2 paragraphs, got=%d children", len(section.Children))
+	}
+
+	ref := doc.Children[0].(*ast.Paragraph).Inline[1]
+	def, ok := doc.Footnote(ref.Content)
+	if !ok || def.Label != "src" || len(def.Children) != 2 {
+		t.Fatalf("expected reference to resolve to a two-line definition, got=%+v", def)
+	}
+	first := def.Children[0].(*ast.Paragraph)
+	if first.Content != "The *source*," || first.Inline[1].Type != ast.InlineBold {
+		t.Errorf("unexpected first line %q", first.Content)
+	}
+	if start, end := first.Span(first.Inline[1]); input[start:end] != "*source*" {
+		t.Errorf("expected spans to stay absolute, got=%q", input[start:end])
+	}
+	if got := def.String(); got != "[fn:src] The *source*,\nover two lines.\n" {
+		t.Errorf("unexpected definition %q", got)
+	}
+
+	if defs := doc.Footnotes(); len(defs) != 2 || defs[1].Label != "2" {
+		t.Errorf("expected two definitions in order, got=%d", len(defs))
+	}
+	if _, ok := doc.Footnote("3"); ok {
+		t.Errorf("expected an indented line not to define a footnote")
+	}
+}
+
+func TestZeroWidthSpaceEscapesMarkers(t *testing.T) {
+	input := "Literal *\u200bstars*\u200b here, but *bold* works."
+	l := lexer.New(input)
+	p := New(l)
+	doc := p.ParseDocument()
+
+	para := doc.Children[0].(*ast.Paragraph)
+	bolds := 0
+	for _, e := range para.Inline {
+		if e.Type == ast.InlineBold {
+			bolds++
+			if e.PlainText() != "bold" {
+				t.Errorf("expected bold text 'bold', got=%q", e.PlainText())
+			}
+		}
+	}
+	if bolds != 1 {
+		t.Errorf("expected exactly 1 bold element, got=%d", bolds)
+	}
+}
+
+func TestParseEntities(t *testing.T) {
+	input := `Angle \alpha{} and \larr arrow, but not \alphabet or \unknown.`
+	l := lexer.New(input)
+	p := New(l)
+	doc := p.ParseDocument()
+
+	para := doc.Children[0].(*ast.Paragraph)
+	var names []string
+	var plain strings.Builder
+	for _, e := range para.Inline {
+		if e.Type == ast.InlineEntity {
+			names = append(names, e.Content)
+		}
+		plain.WriteString(e.PlainText())
+	}
+
+	if len(names) != 2 || names[0] != "alpha" || names[1] != "larr" {
+		t.Errorf("expected entities [alpha larr], got=%v", names)
+	}
+	if got := plain.String(); got != `Angle α and ← arrow, but not \alphabet or \unknown.` {
+		t.Errorf("unexpected plain text %q", got)
+	}
+
+	// Backslashes that start no entity stay in one run of text
+	doc = New(lexer.New(`Path C:\Users\me\docs then \alpha`)).ParseDocument()
+	inline := doc.Children[0].(*ast.Paragraph).Inline
+	if len(inline) != 2 || inline[0].Type != ast.InlineText || inline[0].Content != `Path C:\Users\me\docs then ` || inline[1].Type != ast.InlineEntity {
+		t.Errorf("expected one text element and an entity, got=%+v", inline)
+	}
+}
+
+func TestParsePlanning(t *testing.T) {
+	scheduled, deadline, closed, ok := ParsePlanning("SCHEDULED: <2024-02-01 Thu 10:00 +1w> DEADLINE: <2024-02-10>")
+	if !ok {
+		t.Fatal("expected planning line to be recognized")
+	}
+	if scheduled == nil || scheduled.Date != "2024-02-01" || scheduled.Time != "10:00" || scheduled.Repeat != "+1w" {
+		t.Errorf("unexpected scheduled: %+v", scheduled)
+	}
+	if deadline == nil || deadline.Date != "2024-02-10" {
+		t.Errorf("unexpected deadline: %+v", deadline)
+	}
+	if closed != nil {
+		t.Errorf("expected no closed timestamp, got %+v", closed)
+	}
+
+	for _, line := range []string{
+		"Just text mentioning SCHEDULED: <2024-02-01>",
+		"SCHEDULED: soon",
+		"SCHEDULED:",
+		"DEADLINE: <2024-01-01 Mon> and some notes",
+		"see DEADLINE: <2024-01-01 Mon>",
+	} {
+		if _, _, _, ok := ParsePlanning(line); ok {
+			t.Errorf("expected %q not to be a planning line", line)
+		}
+	}
+}
+
+func TestHeadlinePlanning(t *testing.T) {
+	input := "* TODO Task\nCLOSED: [2024-02-02 Fri 09:15] SCHEDULED: <2024-02-01 Thu 10:00>\nBody\n* Other\nBody\nDEADLINE: <2024-03-01>\n"
+	doc := New(lexer.New(input)).ParseDocument()
+
+	hl := doc.Children[0].(*ast.Headline)
+	if hl.Scheduled == nil || hl.Scheduled.Day != "Thu" || hl.Scheduled.Time != "10:00" || !hl.Scheduled.Active {
+		t.Errorf("unexpected scheduled: %+v", hl.Scheduled)
+	}
+	if hl.Closed == nil || hl.Closed.Date != "2024-02-02" || hl.Closed.Active {
+		t.Errorf("unexpected closed: %+v", hl.Closed)
+	}
+	if len(hl.Children) != 1 {
+		t.Fatalf("expected planning line not to be kept as a paragraph, got=%d children", len(hl.Children))
+	}
+	if got := hl.Planning(); got != "CLOSED: [2024-02-02 Fri 09:15] SCHEDULED: <2024-02-01 Thu 10:00>" {
+		t.Errorf("unexpected planning line %q", got)
+	}
+
+	other := doc.Children[1].(*ast.Headline)
+	if other.Deadline != nil || len(other.Children) != 2 {
+		t.Errorf("expected planning line below the body to stay a paragraph, got=%+v", other.Deadline)
+	}
+
+	// A timestamp the parser does not understand keeps the line as text
+	doc = New(lexer.New("* Task\nSCHEDULED: <someday>\n")).ParseDocument()
+	hl = doc.Children[0].(*ast.Headline)
+	if hl.Scheduled != nil || len(hl.Children) != 1 {
+		t.Errorf("expected unparsed planning line to stay a paragraph, got=%+v", hl.Scheduled)
+	}
+}
+
+func TestLogbookClocks(t *testing.T) {
+	input := `* Task
+:LOGBOOK:
+CLOCK: [2024-01-15 Mon 09:00]--[2024-01-15 Mon 10:30] =>  1:30
+- Note taken on [2024-01-15 Mon 09:00]
+CLOCK: [2024-01-14 Sun 22:00]--[2024-01-15 Mon 00:15]
+CLOCK: [2024-01-16 Tue 09:00]
+:END:
+:NOTES:
+CLOCK: [2024-01-15 Mon 09:00]--[2024-01-15 Mon 10:30] =>  1:30
+:END:
+`
+	doc := New(lexer.New(input)).ParseDocument()
+	hl := doc.Children[0].(*ast.Headline)
+	logbook := hl.Children[0].(*ast.Drawer)
+
+	if len(logbook.Clocks) != 3 {
+		t.Fatalf("expected 3 clocks, got=%d", len(logbook.Clocks))
+	}
+	first := logbook.Clocks[0]
+	if first.Start.Time != "09:00" || first.End.Time != "10:30" || first.Duration != 90*time.Minute {
+		t.Errorf("unexpected clock: %+v", first)
+	}
+	if first.Token.Line != 3 {
+		t.Errorf("expected clock on line 3, got=%d", first.Token.Line)
+	}
+	if got := first.String(); got != "CLOCK: [2024-01-15 Mon 09:00]--[2024-01-15 Mon 10:30] =>  1:30" {
+		t.Errorf("unexpected clock line %q", got)
+	}
+	if d := logbook.Clocks[1].Duration; d != 2*time.Hour+15*time.Minute {
+		t.Errorf("expected duration from the range without =>, got=%v", d)
+	}
+	if running := logbook.Clocks[2]; !running.Running() || running.Duration != 0 {
+		t.Errorf("expected running clock, got=%+v", running)
+	}
+	if !strings.Contains(logbook.Content, "Note taken on") {
+		t.Errorf("expected content to keep all lines, got=%q", logbook.Content)
+	}
+	if notes := hl.Children[1].(*ast.Drawer); len(notes.Clocks) != 0 {
+		t.Errorf("expected CLOCK lines outside LOGBOOK to stay content")
+	}
+}
+
+func TestExportOption(t *testing.T) {
+	input := "#+OPTIONS: toc:2 num:nil\n#+OPTIONS: toc:nil\n* A\n"
+	doc := New(lexer.New(input)).ParseDocument()
+
+	if v, ok := doc.ExportOption("toc"); !ok || v != "nil" {
+		t.Errorf("expected later toc option to win, got=%q (%v)", v, ok)
+	}
+	if v, ok := doc.ExportOption("num"); !ok || v != "nil" {
+		t.Errorf("expected num:nil, got=%q (%v)", v, ok)
+	}
+	if _, ok := doc.ExportOption("H"); ok {
+		t.Error("expected missing option to be absent")
+	}
+}
+
+func TestDrawerRecovery(t *testing.T) {
+	input := `* First
+:LOGBOOK:
+- State "DONE" from "TODO"
+:ID: stray
+* Second
+:END:
+:NOTES:
+kept
+:END:
+`
+	for _, strict := range []bool{false, true} {
+		var opts []Option
+		if strict {
+			opts = append(opts, WithStrict())
+		}
+		p := New(lexer.New(input), opts...)
+		doc := p.ParseDocument()
+
+		if len(doc.Children) != 2 {
+			t.Fatalf("strict=%v: expected the unclosed drawer not to swallow the next headline, got %d children", strict, len(doc.Children))
+		}
+		first := doc.Children[0].(*ast.Headline)
+		if len(first.Children) != 3 {
+			t.Fatalf("strict=%v: expected 3 children under First, got=%d", strict, len(first.Children))
+		}
+		for i, c := range first.Children {
+			if _, ok := c.(*ast.Drawer); ok {
+				t.Errorf("strict=%v: child %d: expected no drawer", strict, i)
+			}
+		}
+
+		second := doc.Children[1].(*ast.Headline)
+		if len(second.Children) != 2 {
+			t.Fatalf("strict=%v: expected stray :END: and NOTES drawer under Second, got=%d", strict, len(second.Children))
+		}
+		if para, ok := second.Children[0].(*ast.Paragraph); !ok || para.Content != ":END:" {
+			t.Errorf("strict=%v: expected stray :END: kept as text, got %T", strict, second.Children[0])
+		}
+		if d, ok := second.Children[1].(*ast.Drawer); !ok || d.Name != "NOTES" || d.Content != "kept" {
+			t.Errorf("strict=%v: expected NOTES drawer, got %T", strict, second.Children[1])
+		}
+
+		if !strict && len(p.Errors()) != 0 {
+			t.Errorf("expected no errors outside strict mode, got %v", p.Errors())
+		}
+		if strict && len(p.Errors()) != 3 {
+			t.Errorf("expected 3 diagnostics in strict mode, got %v", p.Errors())
+		}
+	}
+}
+
+func TestStrictPropertiesDrawer(t *testing.T) {
+	input := "* A\n:PROPERTIES:\n:ID: 1\nnot a property\n:END:\n"
+	p := New(lexer.New(input), WithStrict())
+	doc := p.ParseDocument()
+	if v, _ := doc.Children[0].(*ast.Headline).Property("ID"); v != "1" {
+		t.Errorf("expected ID property, got=%q", v)
+	}
+	if len(p.Errors()) != 1 || !strings.Contains(p.Errors()[0], "not a property") {
+		t.Errorf("expected a diagnostic for the invalid line, got %v", p.Errors())
+	}
+}
+
+func TestIllegalLineRecovery(t *testing.T) {
+	input := "* Notes\nbad \xff byte\n#+BEGIN_SRC sh\necho \x00\n#+END_SRC\n** Child\n"
+	p := New(lexer.New(input))
+	doc := p.ParseDocument()
+
+	if len(p.Errors()) != 2 {
+		t.Fatalf("expected 2 errors, got=%v", p.Errors())
+	}
+	if !strings.HasPrefix(p.Errors()[0], "line 2:") || !strings.HasPrefix(p.Errors()[1], "line 4:") {
+		t.Errorf("expected errors on lines 2 and 4, got=%v", p.Errors())
+	}
+
+	h := doc.Children[0].(*ast.Headline)
+	if len(h.Children) != 3 {
+		t.Fatalf("expected parsing to continue after the illegal lines, got=%d children", len(h.Children))
+	}
+	if para := h.Children[0].(*ast.Paragraph); para.Content != "bad \uFFFD byte" {
+		t.Errorf("expected invalid byte to be replaced, got=%q", para.Content)
+	}
+	if block := h.Children[1].(*ast.Block); block.Content != "echo \uFFFD" {
+		t.Errorf("expected NUL inside the block to be replaced, got=%q", block.Content)
+	}
+	if child := h.Children[2].(*ast.Headline); child.Title != "Child" {
+		t.Errorf("expected Child headline, got=%q", child.Title)
+	}
+}
+
+// FuzzParseDocument checks that the parser terminates on any input and
+// only produces valid UTF-8 text
+func FuzzParseDocument(f *testing.F) {
+	for _, seed := range []string{
+		"* TODO Title :tag:\nSCHEDULED: <2024-01-01 Mon>\n:PROPERTIES:\n:ID: x\n:END:\n",
+		"#+BEGIN_QUOTE\n*bold* [[link][desc]]\n#+END_QUOTE\n",
+		"- a\n  - b\n    1. c\n| x |\n|---|\n#+CALL: f(a=1)\n",
+		"\x00", "\xff", ":END:\n:X:\n", "#+BEGIN_SRC\n", "* \n**",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		doc := New(lexer.New(input)).ParseDocument()
+		var check func([]ast.Node)
+		check = func(nodes []ast.Node) {
+			for _, n := range nodes {
+				switch node := n.(type) {
+				case *ast.Headline:
+					check(node.Children)
+				case *ast.Paragraph:
+					if !utf8.ValidString(node.Content) || strings.ContainsRune(node.Content, 0) {
+						t.Fatalf("invalid paragraph text %q from %q", node.Content, input)
+					}
+				}
+			}
+		}
+		check(doc.Children)
+	})
+}
+
+func TestLongLineRecovery(t *testing.T) {
+	input := "* A\n" + strings.Repeat("x", 30) + "\n* B\n"
+	p := New(lexer.New(input, lexer.WithMaxLineLength(20)))
+	doc := p.ParseDocument()
+
+	if len(p.Errors()) != 1 || p.Errors()[0] != "line 2: line exceeds maximum allowed length" {
+		t.Errorf("expected one long line error, got=%v", p.Errors())
+	}
+	if len(doc.Children) != 2 {
+		t.Fatalf("expected both headlines, got=%d", len(doc.Children))
+	}
+	a := doc.Children[0].(*ast.Headline)
+	if len(a.Children) != 2 {
+		t.Fatalf("expected the line split into 2 paragraphs, got=%d", len(a.Children))
+	}
+}
+
+func TestTodoMatcher(t *testing.T) {
+	input := "* NEXT Call Bob\n* TODO Not a keyword here\n* WAIT? Odd keyword\n* NEXTSTEP Title\n"
+	m := todo.NewMatcher([]string{"NEXT", "WAIT?"}, []string{"DONE"})
+	doc := New(lexer.New(input), WithTodoMatcher(m)).ParseDocument()
+
+	tests := []struct {
+		keyword, title string
+	}{
+		{"NEXT", "Call Bob"},
+		{"", "TODO Not a keyword here"},
+		{"WAIT?", "Odd keyword"},
+		{"", "NEXTSTEP Title"},
+	}
+	if len(doc.Children) != len(tests) {
+		t.Fatalf("expected %d headlines, got=%d", len(tests), len(doc.Children))
+	}
+	for i, tt := range tests {
+		h := doc.Children[i].(*ast.Headline)
+		if h.Keyword != tt.keyword || h.Title != tt.title {
+			t.Errorf("headline %d: expected (%q, %q), got=(%q, %q)", i, tt.keyword, tt.title, h.Keyword, h.Title)
+		}
+	}
+}
+
+func TestZerothSection(t *testing.T) {
+	input := "#+TITLE: Notes\n#+AUTHOR: Jane\nIntro text\n* First\nBody\n"
+	doc := New(lexer.New(input), WithSections()).ParseDocument()
+
+	if len(doc.Children) != 2 {
+		t.Fatalf("expected section and headline, got=%d", len(doc.Children))
+	}
+	s, ok := doc.Children[0].(*ast.Section)
+	if !ok {
+		t.Fatalf("expected *ast.Section, got=%T", doc.Children[0])
+	}
+	if len(s.Keywords()) != 2 || s.Keyword("author") != "Jane" {
+		t.Errorf("expected two keywords in the section, got=%v", s.Keywords())
+	}
+	if body := s.Body(); len(body) != 1 || body[0].(*ast.Paragraph).Content != "Intro text" {
+		t.Errorf("unexpected section body %v", body)
+	}
+	if s.Token.Line != 1 {
+		t.Errorf("expected section to start on line 1, got=%d", s.Token.Line)
+	}
+	if doc.Keyword("TITLE") != "Notes" {
+		t.Errorf("expected document keywords to be found in the section, got=%q", doc.Keyword("TITLE"))
+	}
+	if doc.ZerothSection() != s {
+		t.Error("expected ZerothSection to return the parsed section")
+	}
+
+	// Without sections the same view is built on demand
+	loose := New(lexer.New(input)).ParseDocument()
+	if zs := loose.ZerothSection(); zs == nil || len(zs.Children) != 3 {
+		t.Errorf("expected a derived zeroth section of 3 nodes, got=%v", zs)
+	}
+	if New(lexer.New("* Only\n"), WithSections()).ParseDocument().ZerothSection() != nil {
+		t.Error("expected no zeroth section before a leading headline")
+	}
+}
+
+func TestHeadlineSections(t *testing.T) {
+	input := "* Parent\n:PROPERTIES:\n:ID: p\n:END:\nBody text\n** Child\nChild text\n** Empty\n"
+	doc := New(lexer.New(input), WithSections()).ParseDocument()
+
+	parent := doc.Children[0].(*ast.Headline)
+	s, ok := parent.Children[0].(*ast.Section)
+	if !ok {
+		t.Fatalf("expected the headline's first child to be a section, got=%T", parent.Children[0])
+	}
+	if len(s.Children) != 2 || len(parent.Children) != 3 {
+		t.Fatalf("expected section of 2 nodes and 2 subheadlines, got section=%d children=%d", len(s.Children), len(parent.Children))
+	}
+	if v, ok := parent.Property("ID"); !ok || v != "p" {
+		t.Errorf("expected property lookup through the section, got=%q", v)
+	}
+	subs := parent.Subheadlines()
+	if len(subs) != 2 || subs[0].Title != "Child" {
+		t.Fatalf("unexpected subheadlines %v", subs)
+	}
+	if _, ok := subs[0].Children[0].(*ast.Section); !ok {
+		t.Errorf("expected nested headlines to get sections")
+	}
+	if len(subs[1].Children) != 0 || subs[1].Section() != nil {
+		t.Errorf("expected no section for a headline without body")
+	}
+
+	// Without sections the body is derived from the loose children
+	loose := New(lexer.New(input)).ParseDocument().Children[0].(*ast.Headline)
+	if body := loose.BodyNodes(); len(body) != 2 {
+		t.Errorf("expected 2 body nodes, got=%d", len(body))
+	}
+	if ls := loose.Section(); ls == nil || ls.Token.Line != 2 {
+		t.Errorf("expected a derived section starting on line 2, got=%v", ls)
+	}
+}
+
+func TestBufferTodoKeywords(t *testing.T) {
+	input := `* TODO Before the keywords
+#+TODO: TODO NEXT WAITING | DONE CANCELLED
+#+SEQ_TODO: REPORT BUG FIXED
+* NEXT Call Bob
+* CANCELLED Party
+* BUG Crash on save
+* FIXED Typo
+* DONE Shipped
+`
+	doc := New(lexer.New(input)).ParseDocument()
+	tests := []struct {
+		keyword string
+		done    bool
+	}{
+		{"TODO", false},
+		{"NEXT", false},
+		{"CANCELLED", true},
+		{"BUG", false},
+		{"FIXED", true},
+		{"DONE", true},
+	}
+	var headlines []*ast.Headline
+	for _, n := range doc.Children {
+		if h, ok := n.(*ast.Headline); ok {
+			headlines = append(headlines, h)
+		}
+	}
+	if len(headlines) != len(tests) {
+		t.Fatalf("expected %d headlines, got=%d", len(tests), len(headlines))
+	}
+	for i, tt := range tests {
+		if h := headlines[i]; h.Keyword != tt.keyword || h.Done != tt.done {
+			t.Errorf("headline %d: expected (%q, %v), got=(%q, %v)", i, tt.keyword, tt.done, h.Keyword, h.Done)
+		}
+	}
+
+	// The document's keywords replace the configured ones
+	m := todo.NewMatcher([]string{"LATER"}, []string{"DONE"})
+	doc = New(lexer.New("#+TODO: NOW | THEN\n* LATER Not a keyword\n* THEN Done\n"), WithTodoMatcher(m)).ParseDocument()
+	if h := doc.Children[1].(*ast.Headline); h.Keyword != "" {
+		t.Errorf("expected LATER to be replaced, got keyword %q", h.Keyword)
+	}
+	if h := doc.Children[2].(*ast.Headline); h.Keyword != "THEN" || !h.Done {
+		t.Errorf("expected THEN to be a done keyword, got (%q, %v)", h.Keyword, h.Done)
+	}
+}
+
+func TestTODOKeywordSpecs(t *testing.T) {
+	input := "#+TODO: TODO(t) WAIT(w@/!) | DONE(d!)\n* WAIT Review\n* DONE Ship\n"
+	p := New(lexer.New(input))
+	doc := p.ParseDocument()
+	if h := doc.Children[1].(*ast.Headline); h.Keyword != "WAIT" || h.Title != "Review" {
+		t.Errorf("expected the annotation to be stripped from WAIT, got (%q, %q)", h.Keyword, h.Title)
+	}
+	if h := doc.Children[2].(*ast.Headline); h.Keyword != "DONE" || !h.Done {
+		t.Errorf("expected DONE to be done, got (%q, %v)", h.Keyword, h.Done)
+	}
+	if k, ok := p.TodoMatcher().Spec("WAIT"); !ok || k.Key != 'w' || k.Enter != todo.LogNote || k.Leave != todo.LogTime {
+		t.Errorf("unexpected WAIT spec %+v", k)
+	}
+
+	kws := todo.ParseSpec("NEXT(n) | FINISHED(f!)")
+	doc = New(lexer.New("* NEXT Plan\n* FINISHED Party\n* TODO Plain\n"), WithTODOKeywords(kws...)).ParseDocument()
+	want := []string{"NEXT", "FINISHED", ""}
+	for i, kw := range want {
+		if h := doc.Children[i].(*ast.Headline); h.Keyword != kw {
+			t.Errorf("headline %d: expected keyword %q, got=%q", i, kw, h.Keyword)
+		}
+	}
+}
+
+func TestAllTags(t *testing.T) {
+	input := `#+FILETAGS: :notes:work:
+#+TAGS: {@office @home(h)} laptop
+* Projects :work:@office:
+** Website :web:
+*** Call designer :@home:
+`
+	doc := New(lexer.New(input)).ParseDocument()
+	projects := doc.Children[2].(*ast.Headline)
+	website := projects.Subheadlines()[0]
+	call := website.Subheadlines()[0]
+
+	if got := strings.Join(doc.AllTags(website, false), " "); got != "notes work @office web" {
+		t.Errorf("unexpected inherited tags %q", got)
+	}
+	if got := strings.Join(doc.AllTags(call, false), " "); got != "notes work @office web @home" {
+		t.Errorf("unexpected inherited tags %q", got)
+	}
+	if got := strings.Join(doc.AllTags(call, true), " "); got != "notes work web @home" {
+		t.Errorf("expected @home to exclude inherited @office, got %q", got)
+	}
+	if got := strings.Join(doc.AllTags(&ast.Headline{Tags: []string{"x"}}, false), " "); got != "notes work x" {
+		t.Errorf("expected a headline outside the document to get file tags, got %q", got)
+	}
+}
+
+func TestFileTags(t *testing.T) {
+	doc := New(lexer.New("#+FILETAGS: :work:project:\n#+filetags: home\n* Task\n")).ParseDocument()
+	if got := strings.Join(doc.FileTags(), " "); got != "work project home" {
+		t.Errorf("unexpected file tags %q", got)
+	}
+	if tags := New(lexer.New("* Task\n")).ParseDocument().FileTags(); tags != nil {
+		t.Errorf("expected no file tags, got %v", tags)
+	}
+}
+
+func TestTimestampRanges(t *testing.T) {
+	ts := ParseTimestamp("<2024-01-01 Mon>--<2024-01-03 Wed>")
+	if ts == nil || ts.Date != "2024-01-01" || ts.EndDate != "2024-01-03" || ts.EndDay != "Wed" {
+		t.Fatalf("unexpected range %+v", ts)
+	}
+	if got := ts.String(); got != "<2024-01-01 Mon>--<2024-01-03 Wed>" {
+		t.Errorf("expected the range to round-trip, got=%q", got)
+	}
+	if ts := ParseTimestamp("[2024-01-01 Mon 10:00]--[2024-01-02 Tue 12:30]"); ts == nil || ts.Active || ts.EndTime != "12:30" {
+		t.Errorf("unexpected inactive range %+v", ts)
+	}
+	if ts := ParseTimestamp("<2024-01-01 Mon>--[2024-01-03 Wed]"); ts == nil || ts.EndDate != "" {
+		t.Errorf("expected mixed brackets not to form a range, got %+v", ts)
+	}
+	if ts := ParseTimestamp("Created [2024-02-01 Thu]"); ts == nil || ts.Active || ts.Date != "2024-02-01" {
+		t.Errorf("expected the timestamp to be found in text, got %+v", ts)
+	}
+
+	input := "* Trip\nSCHEDULED: <2024-05-01 Wed>--<2024-05-03 Fri>\nFlights on <2024-05-01 Wed 08:00>--<2024-05-03 Fri 19:00>, booked [2024-03-01 Fri].\n"
+	doc := New(lexer.New(input)).ParseDocument()
+	h := doc.Children[0].(*ast.Headline)
+	if h.Scheduled == nil || h.Scheduled.EndDate != "2024-05-03" {
+		t.Fatalf("expected a scheduled range, got %+v", h.Scheduled)
+	}
+	if got := h.Planning(); got != "SCHEDULED: <2024-05-01 Wed>--<2024-05-03 Fri>" {
+		t.Errorf("unexpected planning line %q", got)
+	}
+
+	para := h.Children[0].(*ast.Paragraph)
+	var stamps []ast.InlineElement
+	for _, e := range para.Inline {
+		if e.Type == ast.InlineTimestamp {
+			stamps = append(stamps, e)
+		}
+	}
+	if len(stamps) != 2 {
+		t.Fatalf("expected 2 inline timestamps, got=%+v", para.Inline)
+	}
+	if s := stamps[0]; s.Timestamp.EndTime != "19:00" || para.Content[s.Start:s.End] != s.Content || s.PlainText() != "<2024-05-01 Wed 08:00>--<2024-05-03 Fri 19:00>" {
+		t.Errorf("unexpected inline range %+v", s)
+	}
+	if s := stamps[1]; s.Timestamp.Active || s.Content != "[2024-03-01 Fri]" {
+		t.Errorf("unexpected inline timestamp %+v", s)
+	}
+}
+
+func TestTimestampTimeRange(t *testing.T) {
+	ts := ParseTimestamp("<2024-01-15 Mon 10:00-11:30 +1w>")
+	if ts == nil || ts.Time != "10:00" || ts.EndTime != "11:30" || ts.EndDate != "" || ts.Repeat != "+1w" {
+		t.Fatalf("unexpected time range %+v", ts)
+	}
+	if got := ts.String(); got != "<2024-01-15 Mon 10:00-11:30 +1w>" {
+		t.Errorf("expected the time range to round-trip, got=%q", got)
+	}
+	if got := ts.End().Sub(ts.Start()); got != 90*time.Minute {
+		t.Errorf("expected a 90 minute meeting, got %v", got)
+	}
+	if got := ts.End(); !got.Equal(time.Date(2024, 1, 15, 11, 30, 0, 0, time.UTC)) {
+		t.Errorf("unexpected end %v", got)
+	}
+
+	if ts := ParseTimestamp("<2024-01-15 Mon>"); !ts.End().Equal(ts.Start()) || ts.Start().IsZero() {
+		t.Errorf("expected a plain timestamp to end where it starts, got %v-%v", ts.Start(), ts.End())
+	}
+	if ts := ParseTimestamp("<2024-01-15 Mon 22:00>--<2024-01-16 Tue 01:00>"); ts.End().Sub(ts.Start()) != 3*time.Hour {
+		t.Errorf("unexpected range duration %v", ts.End().Sub(ts.Start()))
+	}
+
+	input := "* Standup\nSCHEDULED: <2024-01-15 Mon 09:00-09:15>\nMoved to <2024-01-16 Tue 9:30-9:45>.\n"
+	doc := New(lexer.New(input)).ParseDocument()
+	h := doc.Children[0].(*ast.Headline)
+	if h.Scheduled == nil || h.Scheduled.EndTime != "09:15" {
+		t.Fatalf("expected a scheduled time range, got %+v", h.Scheduled)
+	}
+	if got := h.Planning(); got != "SCHEDULED: <2024-01-15 Mon 09:00-09:15>" {
+		t.Errorf("unexpected planning line %q", got)
+	}
+	para := h.Children[0].(*ast.Paragraph)
+	for _, e := range para.Inline {
+		if e.Type == ast.InlineTimestamp {
+			if e.Content != "<2024-01-16 Tue 9:30-9:45>" || e.Timestamp.End().Hour() != 9 || e.Timestamp.End().Minute() != 45 {
+				t.Errorf("unexpected inline time range %+v", e)
+			}
+			return
+		}
+	}
+	t.Errorf("expected an inline timestamp, got=%+v", para.Inline)
+}
+
+func TestRepeaters(t *testing.T) {
+	for _, tt := range []struct {
+		input string
+		want  ast.Repeater
+	}{
+		{"+1w", ast.Repeater{Kind: ast.RepeatCumulative, Value: 1, Unit: 'w'}},
+		{"++2d", ast.Repeater{Kind: ast.RepeatCatchUp, Value: 2, Unit: 'd'}},
+		{".+3m", ast.Repeater{Kind: ast.RepeatRestart, Value: 3, Unit: 'm'}},
+		{"+12h", ast.Repeater{Kind: ast.RepeatCumulative, Value: 12, Unit: 'h'}},
+	} {
+		got, ok := ast.ParseRepeater(tt.input)
+		if !ok || got != tt.want || got.String() != tt.input {
+			t.Errorf("ParseRepeater(%q): got=%+v ok=%v", tt.input, got, ok)
+		}
+	}
+	for _, bad := range []string{"", "1w", "+w", "+0d", "+1x", "-1d"} {
+		if _, ok := ast.ParseRepeater(bad); ok {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+
+	ts := ParseTimestamp("<2024-01-01 Mon 09:00 ++1w>")
+	r, ok := ts.Repeater()
+	if !ok || r.Kind != ast.RepeatCatchUp {
+		t.Fatalf("unexpected repeater %+v", r)
+	}
+
+	day := func(s string) time.Time {
+		t.Helper()
+		d, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	start, now := day("2024-01-01 09:00"), day("2024-01-20 12:00") // Monday, Saturday
+	for _, tt := range []struct {
+		repeat string
+		want   string
+	}{
+		{"+1w", "2024-01-08 09:00"},
+		{"++1w", "2024-01-22 09:00"},
+		{".+1w", "2024-01-27 09:00"},
+		{"++1d", "2024-01-21 09:00"},
+		{".+2h", "2024-01-20 14:00"},
+		{"++1m", "2024-02-01 09:00"},
+		{"++1y", "2025-01-01 09:00"},
+		{"++3h", "2024-01-20 15:00"}, // 12:00 is now, which is not after it
+	} {
+		r, _ := ast.ParseRepeater(tt.repeat)
+		if got := r.Next(start, now).Format("2006-01-02 15:04"); got != tt.want {
+			t.Errorf("%s: expected %s, got=%s", tt.repeat, tt.want, got)
+		}
+	}
+	if got := r.Next(start, day("2023-12-01 00:00")); !got.Equal(day("2024-01-08 09:00")) {
+		t.Errorf("expected catch-up to move at least one interval, got=%v", got)
+	}
+}
+
+func TestHabits(t *testing.T) {
+	input := `* TODO Shave
+SCHEDULED: <2024-01-15 Mon .+2d/3d>
+:PROPERTIES:
+:STYLE:    habit
+:END:
+* TODO Water plants
+SCHEDULED: <2024-01-15 Mon ++1w>
+:PROPERTIES:
+:STYLE: habit
+:END:
+* TODO Not a habit
+SCHEDULED: <2024-01-15 Mon .+1d>
+* TODO No repeater
+SCHEDULED: <2024-01-15 Mon>
+:PROPERTIES:
+:STYLE: habit
+:END:
+`
+	doc := New(lexer.New(input)).ParseDocument()
+	var hs []*ast.Headline
+	for _, n := range doc.Children {
+		hs = append(hs, n.(*ast.Headline))
+	}
+
+	habit, ok := hs[0].Habit()
+	if !ok {
+		t.Fatalf("expected a habit, scheduled=%+v", hs[0].Scheduled)
+	}
+	want := ast.Repeater{Kind: ast.RepeatRestart, Value: 2, Unit: 'd', MaxValue: 3, MaxUnit: 'd'}
+	if habit.Repeater != want || habit.Headline != hs[0] {
+		t.Errorf("unexpected habit %+v", habit)
+	}
+	if got := hs[0].Planning(); got != "SCHEDULED: <2024-01-15 Mon .+2d/3d>" {
+		t.Errorf("expected the range repeater to round-trip, got=%q", got)
+	}
+	if habit, ok := hs[1].Habit(); !ok || habit.Repeater.Kind != ast.RepeatCatchUp || habit.Repeater.MaxValue != 0 {
+		t.Errorf("unexpected habit %+v", habit)
+	}
+	for _, h := range hs[2:] {
+		if _, ok := h.Habit(); ok {
+			t.Errorf("expected %q not to be a habit", h.Title)
+		}
+	}
+}
+
+func TestEffort(t *testing.T) {
+	for input, want := range map[string]time.Duration{
+		"1:30":     90 * time.Minute,
+		"0:05":     5 * time.Minute,
+		"2h":       2 * time.Hour,
+		"90min":    90 * time.Minute,
+		"1h 30min": 90 * time.Minute,
+		"1d2h":     26 * time.Hour,
+		"0.5h":     30 * time.Minute,
+		"45":       45 * time.Minute,
+	} {
+		if got, ok := ast.ParseEffort(input); !ok || got != want {
+			t.Errorf("ParseEffort(%q): expected %v, got=%v ok=%v", input, want, got, ok)
+		}
+	}
+	for _, bad := range []string{"", "soon", "1:5", "2x", "h", "1:75", "Inf", "NaN", "1e300", "1_0", "+5", "1e3h", "..5", ".h", "99999999999999h", "+1:30", "1.5:30"} {
+		if got, ok := ast.ParseEffort(bad); ok {
+			t.Errorf("expected %q to be rejected, got=%v", bad, got)
+		}
+	}
+
+	input := `* Project
+:PROPERTIES:
+:Effort: 1:00
+:END:
+** Design
+:PROPERTIES:
+:Effort: 2h
+:END:
+** Build
+*** Backend
+:PROPERTIES:
+:EFFORT: 90min
+:END:
+*** Frontend
+:PROPERTIES:
+:Effort: 0:30
+:END:
+** Unestimated
+`
+	doc := New(lexer.New(input)).ParseDocument()
+	project := doc.Children[0].(*ast.Headline)
+	subs := project.Subheadlines()
+	if d, ok := project.Effort(); !ok || d != time.Hour {
+		t.Errorf("expected the project's own effort of 1h, got=%v ok=%v", d, ok)
+	}
+	if d, ok := subs[1].Effort(); ok {
+		t.Errorf("expected Build to have no own effort, got=%v", d)
+	}
+	if d, ok := subs[1].TotalEffort(); !ok || d != 2*time.Hour {
+		t.Errorf("expected Build to sum to 2h, got=%v ok=%v", d, ok)
+	}
+	if d, ok := project.TotalEffort(); !ok || d != 4*time.Hour {
+		t.Errorf("expected the children's 4h to replace the project's own effort, got=%v ok=%v", d, ok)
+	}
+	if _, ok := subs[2].TotalEffort(); ok {
+		t.Errorf("expected no effort for an unestimated headline")
+	}
+}
@@ -0,0 +1,185 @@
+package parser
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/token"
+)
+
+var (
+	headlineLineRegex = regexp.MustCompile(`^\*+ `)
+	listLineRegex     = regexp.MustCompile(`^\s*(?:[-+]|\d+[.)])\s`)
+	drawerLineRegex   = regexp.MustCompile(`^\s*:[A-Za-z0-9_-]+:\s*$`)
+)
+
+// Range is the result of ParseRange
+type Range struct {
+	Start int // Snapped start offset of the parsed window
+	End   int // Snapped end offset of the parsed window
+	Line  int // Line number of Start
+
+	// Ancestors are the headlines enclosing the window, outermost first.
+	// Only their headline lines are parsed, so Children is empty.
+	Ancestors []*ast.Headline
+
+	// Nodes are the elements in the window. Headlines inside it nest as
+	// usual; nodes before the first one belong to the innermost ancestor.
+	Nodes []ast.Node
+}
+
+// rangeLine is a line of input as seen by the boundary scan
+type rangeLine struct {
+	offset   int
+	boundary bool // An element can start on this line
+}
+
+// ParseRange parses only the part of input between the byte offsets start
+// and end, so that an editor can re-render the visible part of a large
+// file. The window is widened to element boundaries: it never begins or
+// ends inside a block, drawer, table or list. Tokens carry offsets and
+// line numbers in input, not in the window.
+//
+// Lines before the window are scanned, but not parsed, to find the
+// enclosing headlines and the #+TODO lines that apply to the window.
+func ParseRange(input string, start, end int, opts ...Option) (*Range, error) {
+	start = max(0, min(start, len(input)))
+	end = max(start, min(end, len(input)))
+
+	lines := scanBoundaries(input, end)
+	first := 0
+	for i, l := range lines {
+		if l.offset > start {
+			break
+		}
+		if l.boundary {
+			first = i
+		}
+	}
+	r := &Range{Start: lines[first].offset, End: len(input), Line: first + 1}
+	for _, l := range lines[first+1:] {
+		if l.boundary && l.offset >= end {
+			r.End = l.offset
+			break
+		}
+	}
+
+	// TODO keyword declarations and headlines before the window, the
+	// headlines nested by level. One parser reads all the headline lines,
+	// so each #+TODO line is applied once.
+	hp := New(lexer.New(""), opts...)
+	for _, l := range lines[:first] {
+		text := input[l.offset:lineEnd(input, l.offset)]
+		if !l.boundary {
+			continue
+		}
+		if key, value, ok := strings.Cut(strings.TrimPrefix(text, "#+"), ":"); ok && strings.HasPrefix(text, "#+") && isTodoKey(key) {
+			hp.addTodoSequence(strings.TrimSpace(value))
+			continue
+		}
+		hl, ok := hp.parseHeadlineLine(text)
+		if !ok {
+			continue
+		}
+		for len(r.Ancestors) > 0 && r.Ancestors[len(r.Ancestors)-1].Level >= hl.Level {
+			r.Ancestors = r.Ancestors[:len(r.Ancestors)-1]
+		}
+		r.Ancestors = append(r.Ancestors, hl)
+	}
+	if hl, ok := hp.parseHeadlineLine(input[r.Start:lineEnd(input, r.Start)]); ok {
+		// The headline opening the window closes ancestors of its level
+		for len(r.Ancestors) > 0 && r.Ancestors[len(r.Ancestors)-1].Level >= hl.Level {
+			r.Ancestors = r.Ancestors[:len(r.Ancestors)-1]
+		}
+	}
+	if hp.bufferTodo {
+		// The window continues with the keywords declared before it
+		opts = append(opts[:len(opts):len(opts)], func(p *Parser) {
+			p.todo, p.bufferTodo = hp.todo, true
+		})
+	}
+
+	p := New(lexer.New(input[r.Start:r.End], lexer.WithOrigin(r.Start, r.Line)), opts...)
+	r.Nodes = p.ParseFragment()
+	if len(p.errors) > 0 {
+		return r, errors.New(strings.Join(p.errors, "; "))
+	}
+	return r, nil
+}
+
+// scanBoundaries splits input into lines up to and including the first
+// boundary at or after end, marking the lines where an element can start
+func scanBoundaries(input string, end int) []rangeLine {
+	var (
+		lines             []rangeLine
+		inBlock, inDrawer bool
+		prevTable         bool
+		prevList          bool
+	)
+	for offset := 0; ; {
+		next := lineEnd(input, offset)
+		text := input[offset:next]
+		trimmed := strings.TrimSpace(text)
+		upper := strings.ToUpper(trimmed)
+		isTable := strings.HasPrefix(trimmed, "|")
+		isList := listLineRegex.MatchString(text)
+		indented := text != "" && (text[0] == ' ' || text[0] == '\t')
+		if inDrawer && headlineLineRegex.MatchString(text) {
+			inDrawer = false // A drawer without :END: is plain text
+		}
+
+		boundary := !inBlock && !inDrawer &&
+			!(prevTable && isTable) &&
+			!(prevList && (isList || indented))
+		lines = append(lines, rangeLine{offset: offset, boundary: boundary})
+		if boundary && offset >= end && len(lines) > 1 {
+			return lines
+		}
+
+		switch {
+		case inBlock:
+			inBlock = !strings.HasPrefix(upper, "#+END_")
+		case inDrawer:
+			inDrawer = upper != ":END:"
+		case strings.HasPrefix(upper, "#+BEGIN_"):
+			inBlock = true
+		case drawerLineRegex.MatchString(text) && upper != ":END:":
+			inDrawer = true
+		}
+		prevTable = isTable
+		prevList = isList || (prevList && indented)
+
+		if next >= len(input) {
+			return lines
+		}
+		offset = next + 1
+	}
+}
+
+// lineEnd returns the offset of the newline ending the line at offset, or
+// len(input) for the last line
+func lineEnd(input string, offset int) int {
+	if i := strings.IndexByte(input[offset:], '\n'); i != -1 {
+		return offset + i
+	}
+	return len(input)
+}
+
+// parseHeadlineLine parses a single headline line without its body, with
+// the TODO keywords the parser has seen so far
+func (p *Parser) parseHeadlineLine(line string) (*ast.Headline, bool) {
+	if !strings.HasPrefix(line, "*") {
+		return nil, false
+	}
+	p.l = lexer.New(line)
+	p.diagnostics = 0
+	p.nextToken()
+	p.nextToken()
+	if p.curToken.Type != token.STARS {
+		return nil, false
+	}
+	return p.parseHeadline(), true
+}
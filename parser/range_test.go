@@ -0,0 +1,96 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/justyntemme/organelle/ast"
+)
+
+const rangeInput = `#+TITLE: Big file
+* Projects
+** Alpha
+Intro paragraph.
+#+BEGIN_SRC go
+func main() {}
+#+END_SRC
+| a | b |
+| 1 | 2 |
+** Beta
+Closing words.
+* Archive
+Old stuff.
+`
+
+func TestParseRangeSnapsToElements(t *testing.T) {
+	// Start inside the source block, end inside the table
+	start := strings.Index(rangeInput, "func main")
+	end := strings.Index(rangeInput, "| 1 |") + 2
+
+	r, err := ParseRange(rangeInput, start, end)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := rangeInput[r.Start:r.End]; !strings.HasPrefix(got, "#+BEGIN_SRC go\n") || !strings.HasSuffix(got, "| 1 | 2 |\n") {
+		t.Errorf("expected window to cover the whole block and table, got %q", got)
+	}
+	if r.Line != 5 {
+		t.Errorf("expected window to start on line 5, got=%d", r.Line)
+	}
+
+	if len(r.Ancestors) != 2 || r.Ancestors[0].Title != "Projects" || r.Ancestors[1].Title != "Alpha" {
+		t.Fatalf("unexpected ancestors %v", r.Ancestors)
+	}
+	if len(r.Nodes) != 2 {
+		t.Fatalf("expected block and table, got=%d nodes", len(r.Nodes))
+	}
+	block, ok := r.Nodes[0].(*ast.Block)
+	if !ok || block.Token.Line != 5 || block.Token.Offset != r.Start {
+		t.Errorf("expected block with absolute position, got %T %+v", r.Nodes[0], r.Nodes[0])
+	}
+}
+
+func TestParseRangeAtHeadline(t *testing.T) {
+	start := strings.Index(rangeInput, "** Beta")
+	r, err := ParseRange(rangeInput, start+3, start+4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(r.Ancestors) != 1 || r.Ancestors[0].Title != "Projects" {
+		t.Errorf("expected only Projects as ancestor, got %v", r.Ancestors)
+	}
+	hl, ok := r.Nodes[0].(*ast.Headline)
+	if !ok || hl.Title != "Beta" || hl.Level != 2 {
+		t.Fatalf("expected Beta headline, got %T", r.Nodes[0])
+	}
+	if rangeInput[r.Start:r.End] != "** Beta\n" {
+		t.Errorf("unexpected window %q", rangeInput[r.Start:r.End])
+	}
+}
+
+func TestParseRangeWholeInput(t *testing.T) {
+	r, err := ParseRange(rangeInput, -5, len(rangeInput)+10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Start != 0 || r.End != len(rangeInput) || len(r.Ancestors) != 0 {
+		t.Errorf("expected the whole input, got %d..%d", r.Start, r.End)
+	}
+}
+
+func TestParseRangeTodoSequences(t *testing.T) {
+	input := "#+TODO: NEXT WAITING | DONE\n* NEXT Plan\n** WAITING Review\nBody text.\n"
+	start := strings.Index(input, "** WAITING")
+
+	r, err := ParseRange(input, start, len(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(r.Ancestors) != 1 || r.Ancestors[0].Keyword != "NEXT" || r.Ancestors[0].Title != "Plan" {
+		t.Fatalf("expected NEXT ancestor, got %v", r.Ancestors)
+	}
+	hl, ok := r.Nodes[0].(*ast.Headline)
+	if !ok || hl.Keyword != "WAITING" || hl.Title != "Review" {
+		t.Errorf("expected WAITING headline in window, got %T %+v", r.Nodes[0], r.Nodes[0])
+	}
+}
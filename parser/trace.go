@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/token"
+)
+
+// TraceEvent records one parse decision: the token an element started at,
+// the rule that parsed it and the node it became
+type TraceEvent struct {
+	Token token.Token
+	Rule  string   // headline, paragraph, list, ...
+	Node  ast.Node // nil if the rule produced nothing
+	Note  string   // Where the node went, such as "child of line 3"
+}
+
+// WithTrace records a TraceEvent for every element parsed, for debugging
+// how a problematic file is read. Retrieve the events with Trace.
+func WithTrace() Option {
+	return func(p *Parser) {
+		p.tracing = true
+	}
+}
+
+// Trace returns the events recorded with WithTrace, in parse order
+func (p *Parser) Trace() []TraceEvent {
+	return p.trace
+}
+
+// note annotates the latest trace event
+func (p *Parser) note(format string, args ...any) {
+	if !p.tracing || len(p.trace) == 0 {
+		return
+	}
+	p.trace[len(p.trace)-1].Note = fmt.Sprintf(format, args...)
+}
+
+// WriteTrace writes input as a numbered source listing with the events
+// that started on each line shown below it:
+//
+//	1 | * TODO Task
+//	  |   headline -> Headline level 1 "Task" (top level)
+func WriteTrace(w io.Writer, input string, events []TraceEvent) error {
+	byLine := map[int][]TraceEvent{}
+	for _, e := range events {
+		byLine[e.Token.Line] = append(byLine[e.Token.Line], e)
+	}
+
+	bw := bufio.NewWriter(w)
+	for i, line := range strings.Split(strings.TrimSuffix(input, "\n"), "\n") {
+		fmt.Fprintf(bw, "%4d | %s\n", i+1, line)
+		for _, e := range byLine[i+1] {
+			fmt.Fprintf(bw, "     |   %s -> %s", e.Rule, describe(e.Node))
+			if e.Note != "" {
+				fmt.Fprintf(bw, " (%s)", e.Note)
+			}
+			bw.WriteString("\n")
+		}
+	}
+	return bw.Flush()
+}
+
+// describe names the type of n with a few identifying details
+func describe(n ast.Node) string {
+	switch n := n.(type) {
+	case nil:
+		return "nothing"
+	case *ast.Headline:
+		return fmt.Sprintf("Headline level %d %q", n.Level, n.Title)
+	case *ast.Keyword:
+		if n == nil {
+			return "nothing"
+		}
+		return "Keyword " + n.Key
+	case *ast.Block:
+		return "Block " + n.Type
+	case *ast.Drawer:
+		return "Drawer " + n.Name
+	case *ast.List:
+		return fmt.Sprintf("List of %d items", len(n.Items))
+	case *ast.Table:
+		return fmt.Sprintf("Table of %d rows", len(n.Rows))
+	case *ast.FootnoteDefinition:
+		return "FootnoteDefinition " + n.Label
+	}
+	return strings.TrimPrefix(fmt.Sprintf("%T", n), "*ast.")
+}
@@ -0,0 +1,49 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/justyntemme/organelle/lexer"
+)
+
+func TestTrace(t *testing.T) {
+	input := "* TODO Task\nSCHEDULED: <2024-01-10 Wed>\nBody\n** Sub\n- a\n- b\n"
+	p := New(lexer.New(input), WithTrace())
+	p.ParseDocument()
+
+	var rules []string
+	for _, e := range p.Trace() {
+		rules = append(rules, e.Rule)
+	}
+	if got := strings.Join(rules, ","); got != "headline,paragraph,paragraph,headline,list" {
+		t.Fatalf("unexpected rules %s", got)
+	}
+	if note := p.Trace()[1].Note; note != "planning line of headline on line 1" {
+		t.Errorf("unexpected planning note %q", note)
+	}
+
+	var out strings.Builder
+	if err := WriteTrace(&out, input, p.Trace()); err != nil {
+		t.Fatal(err)
+	}
+	want := `   1 | * TODO Task
+     |   headline -> Headline level 1 "Task" (top level)
+   2 | SCHEDULED: <2024-01-10 Wed>
+     |   paragraph -> Paragraph (planning line of headline on line 1)
+   3 | Body
+     |   paragraph -> Paragraph
+   4 | ** Sub
+     |   headline -> Headline level 2 "Sub" (child of line 1)
+   5 | - a
+     |   list -> List of 2 items
+   6 | - b
+`
+	if out.String() != want {
+		t.Errorf("unexpected listing:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	if New(lexer.New(input)).Trace() != nil {
+		t.Errorf("expected no trace without WithTrace")
+	}
+}
@@ -0,0 +1,28 @@
+package redact
+
+import "github.com/justyntemme/organelle/ast"
+
+// Archived decides whether a walk over a document visits subtrees tagged
+// :ARCHIVE:. It is the setting behind the IncludeArchived options of the
+// packages that walk headlines, so that they all follow the same rules:
+//
+//   - By default archived subtrees are skipped, as Org leaves them out of
+//     agendas, exports and reports.
+//   - A skipped headline hides its whole subtree, including descendants
+//     that are not tagged :ARCHIVE: themselves.
+//   - IncludeArchived, or #+OPTIONS: arch:t in the document, visits them.
+type Archived bool
+
+// For returns the setting to walk doc with: a, or true if the #+OPTIONS
+// of doc say arch:t
+func (a Archived) For(doc *ast.Document) Archived {
+	if v, ok := doc.ExportOption("arch"); ok && v == "t" {
+		return true
+	}
+	return a
+}
+
+// Skip reports whether the walk leaves out h and its subtree
+func (a Archived) Skip(h *ast.Headline) bool {
+	return !bool(a) && h.IsArchived()
+}
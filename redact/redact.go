@@ -0,0 +1,98 @@
+// Package redact provides AST passes that hide parts of a document before
+// it is handed to a less trusted consumer.
+//
+// Passes never modify their input: headlines on the path to a removed
+// subtree are copied, everything else is shared with the original tree.
+// This makes it safe to redact a cached document once per request.
+package redact
+
+import (
+	"github.com/justyntemme/organelle/ast"
+)
+
+// Rule decides whether a headline (and its whole subtree) must be hidden
+type Rule func(h *ast.Headline) bool
+
+// HideTags hides any subtree whose headline carries one of the given tags
+func HideTags(tags ...string) Rule {
+	return func(h *ast.Headline) bool {
+		for _, tag := range tags {
+			if h.HasTag(tag) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// HideProperty hides any subtree whose headline has the property key set
+// to value. An empty value hides the subtree whenever the key is present.
+func HideProperty(key, value string) Rule {
+	return func(h *ast.Headline) bool {
+		v, ok := h.Property(key)
+		if !ok {
+			return false
+		}
+		return value == "" || v == value
+	}
+}
+
+// HideArchived hides subtrees tagged :ARCHIVE:, matching Org's default
+// of leaving archived trees out of agendas and exports
+func HideArchived() Rule {
+	return func(h *ast.Headline) bool {
+		return h.IsArchived()
+	}
+}
+
+// Any combines rules, hiding a subtree if at least one rule matches
+func Any(rules ...Rule) Rule {
+	return func(h *ast.Headline) bool {
+		for _, r := range rules {
+			if r(h) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Apply returns a copy of doc with every subtree matched by rule removed
+func Apply(doc *ast.Document, rule Rule) *ast.Document {
+	return &ast.Document{Children: filterNodes(doc.Children, rule)}
+}
+
+// filterNodes drops hidden headlines and copies the visible ones whose
+// descendants changed
+func filterNodes(nodes []ast.Node, rule Rule) []ast.Node {
+	out := make([]ast.Node, 0, len(nodes))
+	for _, n := range nodes {
+		hl, ok := n.(*ast.Headline)
+		if !ok {
+			out = append(out, n)
+			continue
+		}
+		if rule(hl) {
+			continue
+		}
+		children := filterNodes(hl.Children, rule)
+		if len(children) != len(hl.Children) || subtreeChanged(hl.Children, children) {
+			cp := *hl
+			cp.Children = children
+			out = append(out, &cp)
+			continue
+		}
+		out = append(out, hl)
+	}
+	return out
+}
+
+// subtreeChanged reports whether filtering replaced any child with a copy
+func subtreeChanged(before, after []ast.Node) bool {
+	for i := range before {
+		if before[i] != after[i] {
+			return true
+		}
+	}
+	return false
+}
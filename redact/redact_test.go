@@ -0,0 +1,136 @@
+package redact
+
+import (
+	"testing"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+func parse(t *testing.T, input string) *ast.Document {
+	t.Helper()
+	p := parser.New(lexer.New(input))
+	doc := p.ParseDocument()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser has errors: %v", p.Errors())
+	}
+	return doc
+}
+
+func TestHideTags(t *testing.T) {
+	input := `* Public
+Visible text
+** Secret :private:
+Hidden text
+** Also public
+* Diary :private:
+`
+	doc := parse(t, input)
+	out := Apply(doc, HideTags("private"))
+
+	if len(out.Children) != 1 {
+		t.Fatalf("expected 1 top-level headline, got=%d", len(out.Children))
+	}
+	h1 := out.Children[0].(*ast.Headline)
+	if len(h1.Children) != 2 {
+		t.Fatalf("expected 2 children under Public, got=%d", len(h1.Children))
+	}
+	if h2 := h1.Children[1].(*ast.Headline); h2.Title != "Also public" {
+		t.Errorf("expected 'Also public', got=%q", h2.Title)
+	}
+
+	// The original document must be untouched
+	orig := doc.Children[0].(*ast.Headline)
+	if len(orig.Children) != 3 {
+		t.Errorf("original document was modified, got %d children", len(orig.Children))
+	}
+	if len(doc.Children) != 2 {
+		t.Errorf("original document was modified, got %d top-level nodes", len(doc.Children))
+	}
+}
+
+func TestHideProperty(t *testing.T) {
+	input := `* Shared
+* Internal
+:PROPERTIES:
+:visibility: internal
+:END:
+* Other
+:PROPERTIES:
+:VISIBILITY: public
+:END:
+`
+	doc := parse(t, input)
+
+	out := Apply(doc, HideProperty("VISIBILITY", "internal"))
+	if len(out.Children) != 2 {
+		t.Fatalf("expected 2 headlines, got=%d", len(out.Children))
+	}
+
+	out = Apply(doc, HideProperty("VISIBILITY", ""))
+	if len(out.Children) != 1 {
+		t.Fatalf("expected 1 headline, got=%d", len(out.Children))
+	}
+}
+
+func TestUnchangedSubtreesAreShared(t *testing.T) {
+	doc := parse(t, "* A\n** B\n* C :secret:\n")
+	out := Apply(doc, Any(HideTags("secret"), HideProperty("HIDDEN", "")))
+
+	if out.Children[0] != doc.Children[0] {
+		t.Error("expected untouched headline to be shared with the original")
+	}
+}
+
+func TestHideArchived(t *testing.T) {
+	input := `* Project
+** TODO Open task
+** Archive :ARCHIVE:
+*** DONE Old task
+`
+	doc := parse(t, input)
+	out := Apply(doc, HideArchived())
+
+	project := out.Children[0].(*ast.Headline)
+	if len(project.Children) != 1 {
+		t.Fatalf("expected archive sibling to be removed, got %d children", len(project.Children))
+	}
+	if !doc.Children[0].(*ast.Headline).Children[1].(*ast.Headline).IsArchived() {
+		t.Error("expected Archive sibling to report IsArchived")
+	}
+}
+
+func TestArchived(t *testing.T) {
+	input := `* Project
+** Archive :ARCHIVE:
+*** Old task
+**** Detail
+`
+	visited := func(doc *ast.Document, a Archived) []string {
+		var out []string
+		var walk func(nodes []ast.Node)
+		walk = func(nodes []ast.Node) {
+			for _, n := range nodes {
+				if h, ok := n.(*ast.Headline); ok && !a.Skip(h) {
+					out = append(out, h.Title)
+					walk(h.Children)
+				}
+			}
+		}
+		walk(doc.Children)
+		return out
+	}
+
+	doc := parse(t, input)
+	if got := visited(doc, Archived(false).For(doc)); len(got) != 1 || got[0] != "Project" {
+		t.Errorf("expected the archived subtree skipped with its descendants, got=%v", got)
+	}
+	if got := visited(doc, Archived(true).For(doc)); len(got) != 4 {
+		t.Errorf("expected every headline with IncludeArchived, got=%v", got)
+	}
+	doc = parse(t, "#+OPTIONS: toc:nil arch:t\n"+input)
+	if got := visited(doc, Archived(false).For(doc)); len(got) != 4 {
+		t.Errorf("expected every headline with arch:t, got=%v", got)
+	}
+}
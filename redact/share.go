@@ -0,0 +1,196 @@
+package redact
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/links"
+	"github.com/justyntemme/organelle/parser"
+)
+
+// ShareOption configures Share
+type ShareOption func(*shareConfig)
+
+type shareConfig struct {
+	excludeTags []string
+	properties  []string // Keys to remove; nil drops every drawer
+}
+
+// WithExcludeTags replaces the default :noexport: tag with the given tags.
+// Tags listed on #+EXCLUDE_TAGS are always honoured.
+func WithExcludeTags(tags ...string) ShareOption {
+	return func(c *shareConfig) {
+		c.excludeTags = tags
+	}
+}
+
+// WithRedactedProperties keeps PROPERTIES drawers but removes the given
+// keys from them. By default every drawer is dropped.
+func WithRedactedProperties(keys ...string) ShareOption {
+	return func(c *shareConfig) {
+		c.properties = append([]string{}, keys...)
+	}
+}
+
+// Share returns a copy of doc that is safe to hand to someone else. It
+// removes subtrees tagged :noexport: and COMMENT subtrees, drops drawers
+// (or only the configured properties), removes comments, comment blocks
+// and keywords that name local files (#+SETUPFILE, #+INCLUDE), and
+// replaces file links in titles, paragraphs, list items and table cells by
+// their description or the bare file name, so local paths do not leak.
+func Share(doc *ast.Document, opts ...ShareOption) *ast.Document {
+	c := &shareConfig{excludeTags: []string{"noexport"}}
+	for _, opt := range opts {
+		opt(c)
+	}
+	tags := append([]string{}, c.excludeTags...)
+	tags = append(tags, strings.Fields(doc.Keyword("EXCLUDE_TAGS"))...)
+	rule := Any(HideTags(tags...), func(h *ast.Headline) bool {
+		return h.Keyword == "COMMENT" || h.Title == "COMMENT" || strings.HasPrefix(h.Title, "COMMENT ")
+	})
+	return &ast.Document{Children: c.share(filterNodes(doc.Children, rule))}
+}
+
+func (c *shareConfig) share(nodes []ast.Node) []ast.Node {
+	out := make([]ast.Node, 0, len(nodes))
+	for _, n := range nodes {
+		switch node := n.(type) {
+		case *ast.Comment:
+			continue
+		case *ast.Keyword:
+			if node == nil { // parseKeyword may yield a typed nil
+				continue
+			}
+			if containsFold(pathKeywords, node.Key) {
+				continue
+			}
+		case *ast.Block:
+			if node.Type == "COMMENT" {
+				continue
+			}
+		case *ast.Drawer:
+			if d := c.drawer(node); d != nil {
+				out = append(out, d)
+			}
+			continue
+		case *ast.Headline:
+			cp := *node
+			cp.Title = stripFileLinks(node.Title)
+			cp.Children = c.share(node.Children)
+			n = &cp
+		case *ast.Section:
+			cp := *node
+			cp.Children = c.share(node.Children)
+			n = &cp
+		case *ast.FootnoteDefinition:
+			cp := *node
+			cp.Children = c.share(node.Children)
+			n = &cp
+		case *ast.Paragraph:
+			n = paragraph(node)
+		case *ast.List:
+			n = list(node, c)
+		case *ast.Table:
+			n = table(node)
+		}
+		out = append(out, n)
+	}
+	return out
+}
+
+// drawer returns the part of d that may be shared, or nil
+func (c *shareConfig) drawer(d *ast.Drawer) *ast.Drawer {
+	if c.properties == nil || d.Name != "PROPERTIES" {
+		return nil
+	}
+	cp := *d
+	cp.Properties = map[string]string{}
+	cp.Keys = nil
+	for _, key := range d.PropertyKeys() {
+		if !containsFold(c.properties, key) {
+			cp.Properties[key] = d.Properties[key]
+			cp.Keys = append(cp.Keys, key)
+		}
+	}
+	return &cp
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathKeywords are keywords whose value is a local file
+var pathKeywords = []string{"SETUPFILE", "INCLUDE"}
+
+var linkRegex = regexp.MustCompile(`\[\[([^\]]*)\](?:\[([^\]]*)\])?\]`)
+
+// stripFileLinks replaces file links, including bare paths such as
+// [[~/notes.org]] and file+sys: links, with their description, or with
+// the file name when there is none
+func stripFileLinks(s string) string {
+	return linkRegex.ReplaceAllStringFunc(s, func(link string) string {
+		m := linkRegex.FindStringSubmatch(link)
+		url := m[1]
+		if scheme, rest, ok := strings.Cut(url, ":"); ok && strings.HasPrefix(scheme, "file+") {
+			url = "file:" + rest
+		}
+		if links.Classify(url) != links.File {
+			return link
+		}
+		if m[2] != "" {
+			return m[2]
+		}
+		return path.Base(links.FilePath(url))
+	})
+}
+
+// paragraph returns p with file: links stripped, re-parsing the inline
+// elements so their offsets match the new content
+func paragraph(p *ast.Paragraph) *ast.Paragraph {
+	content := stripFileLinks(p.Content)
+	if content == p.Content {
+		return p
+	}
+	cp := *p
+	cp.Content = content
+	cp.Inline = []ast.InlineElement{{Type: ast.InlineText, Content: content, End: len(content)}}
+	if nodes, _ := parser.ParseFragment(content); len(nodes) == 1 {
+		if parsed, ok := nodes[0].(*ast.Paragraph); ok {
+			cp.Inline = parsed.Inline
+		}
+	}
+	return &cp
+}
+
+func table(t *ast.Table) *ast.Table {
+	cp := *t
+	cp.Rows = make([]*ast.TableRow, len(t.Rows))
+	for i, row := range t.Rows {
+		r := *row
+		r.Cells = make([]string, len(row.Cells))
+		for j, cell := range row.Cells {
+			r.Cells[j] = stripFileLinks(cell)
+		}
+		cp.Rows[i] = &r
+	}
+	return &cp
+}
+
+func list(l *ast.List, c *shareConfig) *ast.List {
+	cp := *l
+	cp.Items = make([]*ast.ListItem, len(l.Items))
+	for i, item := range l.Items {
+		it := *item
+		it.Content = stripFileLinks(item.Content)
+		it.Children = c.share(item.Children)
+		cp.Items[i] = &it
+	}
+	return &cp
+}
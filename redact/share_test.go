@@ -0,0 +1,123 @@
+package redact
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+const shareInput = `#+EXCLUDE_TAGS: draft
+* Plan
+:PROPERTIES:
+:OWNER: ada
+:COST: 100
+:END:
+# internal note
+See [[file:/home/ada/notes/plan.org][the plan]] and [[file:~/budget.xlsx]].
+- Link to [[file:/tmp/a.txt::5]]
+#+BEGIN_COMMENT
+hidden
+#+END_COMMENT
+** Secret :noexport:
+** Sketch :draft:
+** COMMENT Old ideas
+* Done
+`
+
+func TestShare(t *testing.T) {
+	doc := parse(t, shareInput)
+	out := Share(doc)
+
+	var titles []string
+	var walk func([]ast.Node)
+	walk = func(nodes []ast.Node) {
+		for _, n := range nodes {
+			switch node := n.(type) {
+			case *ast.Headline:
+				titles = append(titles, node.Title)
+				walk(node.Children)
+			case *ast.Drawer, *ast.Comment:
+				t.Errorf("unexpected %T in shared copy", n)
+			case *ast.Block:
+				t.Errorf("expected comment block to be removed, got %q", node.Type)
+			case *ast.Paragraph:
+				if strings.Contains(node.Content, "file:") {
+					t.Errorf("file link left in %q", node.Content)
+				}
+				if node.Content != "See the plan and budget.xlsx." {
+					t.Errorf("unexpected paragraph %q", node.Content)
+				}
+				if len(node.Inline) != 1 || node.Inline[0].Type != ast.InlineText {
+					t.Errorf("expected inline elements to be re-parsed, got %+v", node.Inline)
+				}
+			case *ast.List:
+				if got := node.Items[0].Content; got != "Link to a.txt" {
+					t.Errorf("unexpected list item %q", got)
+				}
+			}
+		}
+	}
+	walk(out.Children)
+	if strings.Join(titles, ",") != "Plan,Done" {
+		t.Errorf("expected Plan,Done, got=%v", titles)
+	}
+
+	// The original document must be untouched
+	if !strings.Contains(doc.Children[1].(*ast.Headline).String(), "file:/home/ada") {
+		t.Error("original document was modified")
+	}
+}
+
+func TestShareRedactedProperties(t *testing.T) {
+	out := Share(parse(t, shareInput), WithRedactedProperties("cost"))
+	h := out.Children[1].(*ast.Headline)
+	if v, ok := h.Property("OWNER"); !ok || v != "ada" {
+		t.Errorf("expected OWNER to be kept, got=%q", v)
+	}
+	if _, ok := h.Property("COST"); ok {
+		t.Error("expected COST to be removed")
+	}
+}
+
+func TestShareLocalPaths(t *testing.T) {
+	input := `#+SETUPFILE: /home/ada/org/setup.org
+#+INCLUDE: "~/org/chapter.org"
+#+TITLE: Plan
+* Read [[file:/home/ada/paper.pdf][the paper]] and [[~/todo.org]]
+Bare [[/home/ada/a.txt]], relative [[./b.txt]], [[file+sys:/opt/c.sh]] and [[https://example.com][web]].
+| Doc                        | Note |
+|----------------------------+------|
+| [[file:/home/ada/d.org][d]] | [[../e.org]] |
+`
+	out := Share(parse(t, input))
+
+	var text strings.Builder
+	for _, n := range out.Children {
+		text.WriteString(n.String())
+	}
+	got := text.String()
+	for _, leak := range []string{"/home/ada", "~/", "./b.txt", "/opt", "../", "SETUPFILE", "INCLUDE"} {
+		if strings.Contains(got, leak) {
+			t.Errorf("shared copy still contains %q:\n%s", leak, got)
+		}
+	}
+	for _, want := range []string{"#+TITLE: Plan", "Read the paper and todo.org", "Bare a.txt, relative b.txt, c.sh and [[https://example.com][web]].", "| d | e.org |"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in shared copy:\n%s", want, got)
+		}
+	}
+}
+
+func TestShareMalformedKeyword(t *testing.T) {
+	// The parser reports the keyword and leaves a nil node in its place
+	out := Share(parser.New(lexer.New("#+: oops\n* A\ntext\n")).ParseDocument())
+	if len(out.Children) != 1 {
+		t.Fatalf("expected only the headline, got=%d children", len(out.Children))
+	}
+	if h, ok := out.Children[0].(*ast.Headline); !ok || h.Title != "A" {
+		t.Errorf("expected headline A, got=%v", out.Children[0])
+	}
+}
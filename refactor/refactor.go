@@ -0,0 +1,228 @@
+// Package refactor renames, adds and removes tags across the Org files of
+// a workspace. Only the lines that change are rewritten, so the rest of
+// every file stays byte for byte as it was, and a dry run reports the
+// edits without writing them.
+package refactor
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/workspace"
+)
+
+// tagSuffixRegex matches the tags at the end of a headline line, as the
+// parser reads them
+var tagSuffixRegex = regexp.MustCompile(`\s+:[a-zA-Z0-9_@#%:]+:\s*$`)
+
+// Edit replaces one line of a file
+type Edit struct {
+	Line int // 1-based
+	Old  string
+	New  string
+}
+
+// Change is the edits an operation makes to one file
+type Change struct {
+	File  string // Relative to the workspace
+	Edits []Edit
+}
+
+// Option is a functional option for configuring an operation
+type Option func(*config)
+
+type config struct {
+	dryRun bool
+}
+
+// DryRun reports the changes without writing any file
+func DryRun() Option {
+	return func(c *config) {
+		c.dryRun = true
+	}
+}
+
+// RenameTag renames the tag from to to on the headlines and in the
+// #+FILETAGS lines of the .org files below dir. A headline that already
+// has to loses from instead. Tags are compared as written, so :Work: and
+// :work: are different tags.
+func RenameTag(dir, from, to string, opts ...Option) ([]Change, error) {
+	return run(dir, opts, func(tags []string, _ *ast.Headline) []string {
+		out := make([]string, 0, len(tags))
+		for _, t := range tags {
+			if t == from {
+				t = to
+			}
+			if !contains(out, t) {
+				out = append(out, t)
+			}
+		}
+		return out
+	})
+}
+
+// AddTag adds tag to the headlines below dir for which match reports
+// true, after their other tags. A nil match adds it to every headline.
+// #+FILETAGS lines are left alone.
+func AddTag(dir, tag string, match func(*ast.Headline) bool, opts ...Option) ([]Change, error) {
+	return run(dir, opts, func(tags []string, h *ast.Headline) []string {
+		if h == nil || contains(tags, tag) || match != nil && !match(h) {
+			return tags
+		}
+		return append(tags[:len(tags):len(tags)], tag)
+	})
+}
+
+// RemoveTag removes tag from the headlines and the #+FILETAGS lines of
+// the .org files below dir
+func RemoveTag(dir, tag string, opts ...Option) ([]Change, error) {
+	return run(dir, opts, func(tags []string, _ *ast.Headline) []string {
+		out := make([]string, 0, len(tags))
+		for _, t := range tags {
+			if t != tag {
+				out = append(out, t)
+			}
+		}
+		return out
+	})
+}
+
+// retag returns the new tags of a headline, or of a #+FILETAGS line when
+// h is nil
+type retag func(tags []string, h *ast.Headline) []string
+
+// run applies fn to the files below dir and writes the changed ones back
+// unless the options ask for a dry run
+func run(dir string, opts []Option, fn retag) ([]Change, error) {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	files, err := workspace.ReadFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	changes := plan(files, fn)
+	if c.dryRun {
+		return changes, nil
+	}
+	for _, ch := range changes {
+		path := filepath.Join(dir, filepath.FromSlash(ch.File))
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, []byte(apply(files[ch.File], ch.Edits)), info.Mode().Perm()); err != nil {
+			return nil, err
+		}
+	}
+	return changes, nil
+}
+
+// plan returns the edits fn makes to files, which map names to Org
+// source, sorted by file name
+func plan(files map[string]string, fn retag) []Change {
+	ws := workspace.New(files)
+	var out []Change
+	for _, f := range ws.Files {
+		lines := strings.Split(files[f.Name], "\n")
+		var edits []Edit
+		edit := func(line int, text string) {
+			old := strings.TrimSuffix(lines[line-1], "\r")
+			if text != old {
+				edits = append(edits, Edit{Line: line, Old: old, New: text})
+			}
+		}
+		for _, kw := range f.Doc.Keywords() {
+			if strings.EqualFold(kw.Key, "FILETAGS") {
+				tags := ast.SplitTags(kw.Value)
+				if next := fn(tags, nil); !equal(tags, next) {
+					edit(kw.Token.Line, fileTagsLine(lines[kw.Token.Line-1], next))
+				}
+			}
+		}
+		walk(f.Doc.Children, func(h *ast.Headline) {
+			if next := fn(h.Tags, h); !equal(h.Tags, next) {
+				edit(h.Token.Line, headlineLine(lines[h.Token.Line-1], next))
+			}
+		})
+		if len(edits) > 0 {
+			sort.Slice(edits, func(i, j int) bool { return edits[i].Line < edits[j].Line })
+			out = append(out, Change{File: f.Name, Edits: edits})
+		}
+	}
+	return out
+}
+
+// headlineLine returns the headline line with its tags replaced by tags,
+// keeping the whitespace before them
+func headlineLine(line string, tags []string) string {
+	line = strings.TrimSuffix(line, "\r")
+	gap := " "
+	if loc := tagSuffixRegex.FindStringIndex(line); loc != nil {
+		suffix := line[loc[0]:]
+		gap = suffix[:len(suffix)-len(strings.TrimLeft(suffix, " \t"))]
+		line = line[:loc[0]]
+	}
+	if len(tags) == 0 {
+		return line
+	}
+	return line + gap + ":" + strings.Join(tags, ":") + ":"
+}
+
+// fileTagsLine returns the #+FILETAGS line with its value set to tags
+func fileTagsLine(line string, tags []string) string {
+	key, _, _ := strings.Cut(strings.TrimSuffix(line, "\r"), ":")
+	if len(tags) == 0 {
+		return key + ":"
+	}
+	return key + ": :" + strings.Join(tags, ":") + ":"
+}
+
+// apply returns src with the edits made, keeping line endings
+func apply(src string, edits []Edit) string {
+	lines := strings.Split(src, "\n")
+	for _, e := range edits {
+		cr := ""
+		if strings.HasSuffix(lines[e.Line-1], "\r") {
+			cr = "\r"
+		}
+		lines[e.Line-1] = e.New + cr
+	}
+	return strings.Join(lines, "\n")
+}
+
+// walk calls fn for every headline in nodes, depth first
+func walk(nodes []ast.Node, fn func(*ast.Headline)) {
+	for _, n := range nodes {
+		if h, ok := n.(*ast.Headline); ok {
+			fn(h)
+			walk(h.Children, fn)
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
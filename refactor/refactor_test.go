@@ -0,0 +1,146 @@
+package refactor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/justyntemme/organelle/ast"
+)
+
+const notes = "#+FILETAGS: :work:home:\n" +
+	"* TODO Plan    :work:\n" +
+	"Body with :work: in it\n" +
+	"** Call :phone:work:\n" +
+	"* Read\n"
+
+func setup(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, src := range map[string]string{
+		"notes.org":   notes,
+		"sub/cr.org":  "* Errand :Work:office:\r\n* Trip :work:\r\n",
+		"plain.txt":   "* Ignored :work:\n",
+		"sub/off.org": "* Nothing to do :home:\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func read(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRenameTag(t *testing.T) {
+	dir := setup(t)
+	changes, err := RenameTag(dir, "work", "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "[{notes.org [{1 #+FILETAGS: :work:home: #+FILETAGS: :job:home:} " +
+		"{2 * TODO Plan    :work: * TODO Plan    :job:} " +
+		"{4 ** Call :phone:work: ** Call :phone:job:}]} " +
+		"{sub/cr.org [{2 * Trip :work: * Trip :job:}]}]"
+	if got := fmt.Sprint(changes); got != want {
+		t.Errorf("expected %s, got=%s", want, got)
+	}
+	wantNotes := "#+FILETAGS: :job:home:\n" +
+		"* TODO Plan    :job:\n" +
+		"Body with :work: in it\n" +
+		"** Call :phone:job:\n" +
+		"* Read\n"
+	if got := read(t, dir, "notes.org"); got != wantNotes {
+		t.Errorf("expected %q, got=%q", wantNotes, got)
+	}
+	if got, want := read(t, dir, "sub/cr.org"), "* Errand :Work:office:\r\n* Trip :job:\r\n"; got != want {
+		t.Errorf("expected %q, got=%q", want, got)
+	}
+	if got := read(t, dir, "plain.txt"); got != "* Ignored :work:\n" {
+		t.Errorf("expected files other than .org to be left alone, got=%q", got)
+	}
+}
+
+func TestRenameTagOntoExisting(t *testing.T) {
+	dir := setup(t)
+	if _, err := RenameTag(dir, "phone", "work"); err != nil {
+		t.Fatal(err)
+	}
+	got := read(t, dir, "notes.org")
+	want := "#+FILETAGS: :work:home:\n" +
+		"* TODO Plan    :work:\n" +
+		"Body with :work: in it\n" +
+		"** Call :work:\n" +
+		"* Read\n"
+	if got != want {
+		t.Errorf("expected %q, got=%q", want, got)
+	}
+}
+
+func TestAddTag(t *testing.T) {
+	dir := setup(t)
+	match := func(h *ast.Headline) bool { return h.Keyword == "TODO" || h.Level == 1 }
+	if _, err := AddTag(dir, "review", match); err != nil {
+		t.Fatal(err)
+	}
+	want := "#+FILETAGS: :work:home:\n" +
+		"* TODO Plan    :work:review:\n" +
+		"Body with :work: in it\n" +
+		"** Call :phone:work:\n" +
+		"* Read :review:\n"
+	if got := read(t, dir, "notes.org"); got != want {
+		t.Errorf("expected %q, got=%q", want, got)
+	}
+	if _, err := AddTag(dir, "review", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := read(t, dir, "sub/off.org"), "* Nothing to do :home:review:\n"; got != want {
+		t.Errorf("expected %q, got=%q", want, got)
+	}
+}
+
+func TestRemoveTag(t *testing.T) {
+	dir := setup(t)
+	if _, err := RemoveTag(dir, "work"); err != nil {
+		t.Fatal(err)
+	}
+	want := "#+FILETAGS: :home:\n" +
+		"* TODO Plan\n" +
+		"Body with :work: in it\n" +
+		"** Call :phone:\n" +
+		"* Read\n"
+	if got := read(t, dir, "notes.org"); got != want {
+		t.Errorf("expected %q, got=%q", want, got)
+	}
+	if _, err := RemoveTag(dir, "home"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := read(t, dir, "notes.org")[:12], "#+FILETAGS:\n"; got != want {
+		t.Errorf("expected %q, got=%q", want, got)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	dir := setup(t)
+	changes, err := RemoveTag(dir, "work", DryRun())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 {
+		t.Errorf("expected changes to 2 files, got=%v", changes)
+	}
+	if got := read(t, dir, "notes.org"); got != notes {
+		t.Errorf("expected the dry run to leave the file alone, got=%q", got)
+	}
+}
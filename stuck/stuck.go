@@ -0,0 +1,162 @@
+// Package stuck finds open TODO items that have gone quiet, like Org's
+// stuck projects view. An item's last activity is the latest of its
+// planning timestamps, its CREATED property and the timestamps in its
+// LOGBOOK drawer: state changes, notes and clocks.
+package stuck
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/parser"
+	"github.com/justyntemme/organelle/redact"
+	"github.com/justyntemme/organelle/todo"
+)
+
+// Item is an open TODO headline without recent activity
+type Item struct {
+	Headline     *ast.Headline
+	LastActivity time.Time // Zero if the item has no activity at all
+}
+
+// Project groups the stuck items below one top-level headline
+type Project struct {
+	Root  *ast.Headline
+	Items []Item
+}
+
+// Finder looks for stuck items
+type Finder struct {
+	days     int
+	now      time.Time
+	matcher  *todo.Matcher
+	archived redact.Archived
+}
+
+// Option is a functional option for configuring the Finder
+type Option func(*Finder)
+
+// WithDays sets how many days without activity make an item stuck
+// (default 14)
+func WithDays(days int) Option {
+	return func(f *Finder) {
+		f.days = days
+	}
+}
+
+// WithNow sets the time ages are measured from (default time.Now)
+func WithNow(now time.Time) Option {
+	return func(f *Finder) {
+		f.now = now
+	}
+}
+
+// WithTodoMatcher sets the keywords that tell open items from done ones.
+// It should match the one the document was parsed with.
+func WithTodoMatcher(m *todo.Matcher) Option {
+	return func(f *Finder) {
+		f.matcher = m
+	}
+}
+
+// IncludeArchived also looks into subtrees tagged :ARCHIVE:; see
+// redact.Archived
+func IncludeArchived() Option {
+	return func(f *Finder) {
+		f.archived = true
+	}
+}
+
+// New creates a Finder
+func New(opts ...Option) *Finder {
+	f := &Finder{days: 14, matcher: todo.Default}
+	for _, opt := range opts {
+		opt(f)
+	}
+	if f.now.IsZero() {
+		f.now = time.Now()
+	}
+	return f
+}
+
+// Find returns the projects of doc that have stuck items, in document
+// order. A top-level TODO headline is its own project root.
+func (f *Finder) Find(doc *ast.Document) []Project {
+	cutoff := f.now.AddDate(0, 0, -f.days)
+	var projects []Project
+	archived := f.archived.For(doc)
+	for _, n := range doc.Children {
+		root, ok := n.(*ast.Headline)
+		if !ok || archived.Skip(root) {
+			continue
+		}
+		var items []Item
+		var walk func(h *ast.Headline)
+		walk = func(h *ast.Headline) {
+			if h.Keyword != "" && !f.matcher.IsDone(h.Keyword) {
+				if last := LastActivity(h); last.Before(cutoff) {
+					items = append(items, Item{Headline: h, LastActivity: last})
+				}
+			}
+			for _, sub := range h.Subheadlines() {
+				if !archived.Skip(sub) {
+					walk(sub)
+				}
+			}
+		}
+		walk(root)
+		if len(items) > 0 {
+			projects = append(projects, Project{Root: root, Items: items})
+		}
+	}
+	return projects
+}
+
+// Find returns the stuck projects of doc with default options
+func Find(doc *ast.Document) []Project {
+	return New().Find(doc)
+}
+
+var timestampRegex = regexp.MustCompile(`[<\[]\d{4}-\d{2}-\d{2}[^>\]\n]*[>\]]`)
+
+// LastActivity returns the latest time recorded on h itself, or the zero
+// time if there is none. Timestamps in the future count as activity, so
+// scheduled items are not stuck.
+func LastActivity(h *ast.Headline) time.Time {
+	var last time.Time
+	see := func(ts *ast.Timestamp) {
+		if ts == nil {
+			return
+		}
+		if t, err := ts.ToTime(); err == nil && t.After(last) {
+			last = t
+		}
+	}
+
+	see(h.Scheduled)
+	see(h.Deadline)
+	see(h.Closed)
+	if created, ok := h.Property("CREATED"); ok {
+		see(parser.ParseTimestamp(created))
+	}
+	for _, c := range h.BodyNodes() {
+		d, ok := c.(*ast.Drawer)
+		if !ok || d.Name != "LOGBOOK" {
+			continue
+		}
+		for _, s := range timestampRegex.FindAllString(d.Content, -1) {
+			see(parser.ParseTimestamp(s))
+		}
+	}
+	return last
+}
+
+// Age returns the number of whole days between the item's last activity
+// and now, or -1 if it has no activity
+func (i Item) Age(now time.Time) int {
+	if i.LastActivity.IsZero() {
+		return -1
+	}
+	return int(now.Sub(i.LastActivity) / (24 * time.Hour))
+}
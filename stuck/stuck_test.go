@@ -0,0 +1,69 @@
+package stuck
+
+import (
+	"testing"
+	"time"
+
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+const input = `* Website
+** TODO Write copy
+:PROPERTIES:
+:CREATED: [2024-01-02 Tue]
+:END:
+** TODO Pick a theme
+:LOGBOOK:
+- State "TODO"       from "WAIT"       [2024-02-27 Tue 10:00]
+:END:
+** TODO Launch
+SCHEDULED: <2024-03-15 Fri>
+** DONE Buy domain
+** TODO Migrate mail
+:LOGBOOK:
+CLOCK: [2024-01-05 Fri 09:00]--[2024-01-05 Fri 10:00] =>  1:00
+:END:
+* TODO Call plumber
+* Garden
+** TODO Prune roses
+:LOGBOOK:
+- Note taken on [2024-02-28 Wed 08:00]
+:END:
+`
+
+var now = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func TestFind(t *testing.T) {
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	projects := New(WithNow(now), WithDays(14)).Find(doc)
+
+	if len(projects) != 2 {
+		t.Fatalf("expected 2 projects, got=%d", len(projects))
+	}
+	website := projects[0]
+	if website.Root.Title != "Website" || len(website.Items) != 2 {
+		t.Fatalf("unexpected project %q with %d items", website.Root.Title, len(website.Items))
+	}
+	if item := website.Items[0]; item.Headline.Title != "Write copy" || item.Age(now) != 59 {
+		t.Errorf("expected CREATED to date the item, got %q aged %d", item.Headline.Title, item.Age(now))
+	}
+	if item := website.Items[1]; item.Headline.Title != "Migrate mail" || item.LastActivity.Day() != 5 {
+		t.Errorf("expected clock to date the item, got %q at %v", item.Headline.Title, item.LastActivity)
+	}
+
+	plumber := projects[1]
+	if plumber.Root.Title != "Call plumber" || plumber.Items[0].Age(now) != -1 {
+		t.Errorf("expected top-level TODO without activity to be its own project, got %q", plumber.Root.Title)
+	}
+}
+
+func TestFindSkipsArchived(t *testing.T) {
+	doc := parser.New(lexer.New("* Old project :ARCHIVE:\n** TODO Forgotten\n")).ParseDocument()
+	if projects := New(WithNow(now)).Find(doc); len(projects) != 0 {
+		t.Errorf("archived project reported: %+v", projects)
+	}
+	if projects := New(WithNow(now), IncludeArchived()).Find(doc); len(projects) != 1 {
+		t.Errorf("IncludeArchived: got %d projects, want 1", len(projects))
+	}
+}
@@ -0,0 +1,163 @@
+// Package toc builds a table of contents from the headline tree and
+// renders it as an Org list, an HTML <nav> or Markdown.
+package toc
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/justyntemme/organelle/anchor"
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/redact"
+)
+
+// ExcludeTag marks headlines left out of the table of contents together
+// with their subtrees, such as the headline holding the TOC itself
+const ExcludeTag = "TOC"
+
+// Entry is one headline in the table of contents
+type Entry struct {
+	Title    string
+	Level    int
+	Anchor   string // Fragment identifier from package anchor, without the leading '#'
+	Headline *ast.Headline
+	Children []*Entry
+}
+
+// Builder collects table of contents entries
+type Builder struct {
+	depth    int // 0 means unlimited
+	depthSet bool
+	archived redact.Archived
+}
+
+// Option is a functional option for configuring the Builder
+type Option func(*Builder)
+
+// WithDepth limits the entries to headlines of at most the given level,
+// overriding #+OPTIONS: toc:N. A depth of zero includes every level.
+func WithDepth(depth int) Option {
+	return func(b *Builder) {
+		b.depth = depth
+		b.depthSet = true
+	}
+}
+
+// IncludeArchived lists subtrees tagged :ARCHIVE:; see redact.Archived
+func IncludeArchived() Option {
+	return func(b *Builder) {
+		b.archived = true
+	}
+}
+
+// New creates a Builder
+func New(opts ...Option) *Builder {
+	b := &Builder{}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Build returns the top-level entries of doc. Without WithDepth the
+// #+OPTIONS toc item applies: toc:nil yields no entries and toc:N limits
+// the depth to N.
+func (b *Builder) Build(doc *ast.Document) []*Entry {
+	depth := b.depth
+	if !b.depthSet {
+		if v, ok := doc.ExportOption("toc"); ok {
+			switch v {
+			case "nil":
+				return nil
+			case "t":
+			default:
+				if n, err := strconv.Atoi(v); err == nil {
+					if n <= 0 {
+						return nil
+					}
+					depth = n
+				}
+			}
+		}
+	}
+	return collect(doc.Children, depth, b.archived.For(doc), anchor.New(doc))
+}
+
+// Build returns the entries of doc with default options
+func Build(doc *ast.Document) []*Entry {
+	return New().Build(doc)
+}
+
+func collect(nodes []ast.Node, depth int, archived redact.Archived, anchors *anchor.Set) []*Entry {
+	var out []*Entry
+	for _, n := range nodes {
+		h, ok := n.(*ast.Headline)
+		if !ok || h.HasTag(ExcludeTag) || (depth > 0 && h.Level > depth) || archived.Skip(h) {
+			continue
+		}
+		out = append(out, &Entry{
+			Title:    h.Title,
+			Level:    h.Level,
+			Anchor:   anchors.For(h),
+			Headline: h,
+			Children: collect(h.Children, depth, archived, anchors),
+		})
+	}
+	return out
+}
+
+// Org renders entries as a nested Org list of internal links
+func Org(entries []*Entry) string {
+	var b strings.Builder
+	writeList(&b, entries, "", func(e *Entry) string {
+		if id, ok := e.Headline.Property("CUSTOM_ID"); ok && id != "" {
+			return "[[#" + id + "][" + e.Title + "]]"
+		}
+		return "[[*" + e.Title + "][" + e.Title + "]]"
+	})
+	return b.String()
+}
+
+// Markdown renders entries as a nested Markdown list of anchor links
+func Markdown(entries []*Entry) string {
+	var b strings.Builder
+	writeList(&b, entries, "", func(e *Entry) string {
+		title := strings.NewReplacer("[", `\[`, "]", `\]`).Replace(e.Title)
+		return "[" + title + "](#" + e.Anchor + ")"
+	})
+	return b.String()
+}
+
+func writeList(b *strings.Builder, entries []*Entry, indent string, link func(*Entry) string) {
+	for _, e := range entries {
+		b.WriteString(indent + "- " + link(e) + "\n")
+		writeList(b, e.Children, indent+"  ", link)
+	}
+}
+
+// HTML renders entries as a <nav> element with nested unordered lists
+func HTML(entries []*Entry) string {
+	if len(entries) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("<nav id=\"table-of-contents\">\n")
+	writeHTML(&b, entries)
+	b.WriteString("</nav>\n")
+	return b.String()
+}
+
+func writeHTML(b *strings.Builder, entries []*Entry) {
+	b.WriteString("<ul>\n")
+	for _, e := range entries {
+		fmt.Fprintf(b, "<li><a href=\"#%s\">%s</a>", html.EscapeString(e.Anchor), html.EscapeString(e.Title))
+		if len(e.Children) > 0 {
+			b.WriteString("\n")
+			writeHTML(b, e.Children)
+		}
+		b.WriteString("</li>\n")
+	}
+	b.WriteString("</ul>\n")
+}
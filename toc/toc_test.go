@@ -0,0 +1,108 @@
+package toc
+
+import (
+	"testing"
+
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+const input = `#+OPTIONS: toc:2
+* Contents :TOC:
+- stale entries
+* Introduction
+** Goals & Scope
+*** Too deep
+* Usage
+:PROPERTIES:
+:CUSTOM_ID: how-to
+:END:
+** Introduction
+`
+
+func TestBuild(t *testing.T) {
+	entries := Build(parser.New(lexer.New(input)).ParseDocument())
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 top-level entries, got=%d", len(entries))
+	}
+	intro := entries[0]
+	if intro.Anchor != "introduction" || len(intro.Children) != 1 {
+		t.Fatalf("unexpected entry %+v", intro)
+	}
+	if intro.Children[0].Anchor != "goals-scope" || len(intro.Children[0].Children) != 0 {
+		t.Errorf("expected level 3 to be cut off, got %+v", intro.Children[0])
+	}
+	usage := entries[1]
+	if usage.Anchor != "how-to" {
+		t.Errorf("expected CUSTOM_ID anchor, got=%q", usage.Anchor)
+	}
+	if usage.Children[0].Anchor != "introduction-1" {
+		t.Errorf("expected duplicate title to get a suffix, got=%q", usage.Children[0].Anchor)
+	}
+}
+
+func TestDepthOverrides(t *testing.T) {
+	doc := parser.New(lexer.New(input)).ParseDocument()
+	if got := New(WithDepth(1)).Build(doc); len(got[0].Children) != 0 {
+		t.Errorf("expected depth 1 to drop children")
+	}
+	if got := New(WithDepth(0)).Build(doc); len(got[0].Children[0].Children) != 1 {
+		t.Errorf("expected unlimited depth to include level 3")
+	}
+
+	off := parser.New(lexer.New("#+OPTIONS: toc:nil\n* A\n")).ParseDocument()
+	if got := Build(off); len(got) != 0 {
+		t.Errorf("expected toc:nil to produce no entries, got=%d", len(got))
+	}
+}
+
+func TestRender(t *testing.T) {
+	entries := Build(parser.New(lexer.New(input)).ParseDocument())
+
+	wantOrg := `- [[*Introduction][Introduction]]
+  - [[*Goals & Scope][Goals & Scope]]
+- [[#how-to][Usage]]
+  - [[*Introduction][Introduction]]
+`
+	if got := Org(entries); got != wantOrg {
+		t.Errorf("unexpected Org TOC:\n%s", got)
+	}
+
+	wantMD := `- [Introduction](#introduction)
+  - [Goals & Scope](#goals-scope)
+- [Usage](#how-to)
+  - [Introduction](#introduction-1)
+`
+	if got := Markdown(entries); got != wantMD {
+		t.Errorf("unexpected Markdown TOC:\n%s", got)
+	}
+
+	wantHTML := `<nav id="table-of-contents">
+<ul>
+<li><a href="#introduction">Introduction</a>
+<ul>
+<li><a href="#goals-scope">Goals &amp; Scope</a></li>
+</ul>
+</li>
+<li><a href="#how-to">Usage</a>
+<ul>
+<li><a href="#introduction-1">Introduction</a></li>
+</ul>
+</li>
+</ul>
+</nav>
+`
+	if got := HTML(entries); got != wantHTML {
+		t.Errorf("unexpected HTML TOC:\n%s", got)
+	}
+}
+
+func TestBuildSkipsArchived(t *testing.T) {
+	doc := parser.New(lexer.New("* A\n* B :ARCHIVE:\n* C\n")).ParseDocument()
+	if got := Org(Build(doc)); got != "- [[*A][A]]\n- [[*C][C]]\n" {
+		t.Errorf("unexpected toc:\n%s", got)
+	}
+	if entries := New(IncludeArchived()).Build(doc); len(entries) != 3 {
+		t.Errorf("IncludeArchived: got %d entries, want 3", len(entries))
+	}
+}
@@ -0,0 +1,110 @@
+package todo
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Log says what is recorded when a headline enters or leaves a state
+type Log int
+
+const (
+	LogNone Log = iota
+	LogTime     // "!": a timestamp
+	LogNote     // "@": a timestamp and a note
+)
+
+// Keyword is a TODO keyword with the settings written in parentheses after
+// it in a #+TODO line: a fast-access key and what to log when entering and
+// leaving the state. WAIT(w@/!) has key w, takes a note on entry and logs
+// the time on exit.
+type Keyword struct {
+	Name  string
+	Done  bool
+	Key   rune // Fast-access key, 0 if none
+	Enter Log
+	Leave Log
+}
+
+// String formats k as in a #+TODO line
+func (k Keyword) String() string {
+	var b strings.Builder
+	if k.Key != 0 {
+		b.WriteRune(k.Key)
+	}
+	b.WriteString(k.Enter.flag())
+	if k.Leave != LogNone {
+		b.WriteString("/" + k.Leave.flag())
+	}
+	if b.Len() == 0 {
+		return k.Name
+	}
+	return k.Name + "(" + b.String() + ")"
+}
+
+func (l Log) flag() string {
+	switch l {
+	case LogTime:
+		return "!"
+	case LogNote:
+		return "@"
+	}
+	return ""
+}
+
+func parseLog(s string) (Log, bool) {
+	switch s {
+	case "":
+		return LogNone, true
+	case "!":
+		return LogTime, true
+	case "@":
+		return LogNote, true
+	}
+	return LogNone, false
+}
+
+// ParseKeyword parses a keyword of a #+TODO line. A word whose parentheses
+// do not hold a valid annotation is a keyword as it stands, as in Emacs.
+func ParseKeyword(word string) Keyword {
+	k := Keyword{Name: word}
+	name, rest, found := strings.Cut(word, "(")
+	if !found || name == "" || !strings.HasSuffix(rest, ")") {
+		return k
+	}
+	ann := strings.TrimSuffix(rest, ")")
+
+	var key rune
+	if r, size := utf8.DecodeRuneInString(ann); ann != "" && !strings.ContainsRune("!@/", r) {
+		key, ann = r, ann[size:]
+	}
+	enter, leave, _ := strings.Cut(ann, "/")
+	e, ok1 := parseLog(enter)
+	l, ok2 := parseLog(leave)
+	if !ok1 || !ok2 {
+		return k
+	}
+	return Keyword{Name: name, Key: key, Enter: e, Leave: l}
+}
+
+// ParseSpec parses the value of a #+TODO or #+SEQ_TODO line. The keywords
+// after "|" are done; without a "|", the last keyword is the only done one.
+func ParseSpec(value string) []Keyword {
+	before, after, found := strings.Cut(value, "|")
+	var kws []Keyword
+	for _, w := range strings.Fields(before) {
+		kws = append(kws, ParseKeyword(w))
+	}
+	if !found {
+		if len(kws) > 0 {
+			kws[len(kws)-1].Done = true
+		}
+		return kws
+	}
+	for _, w := range strings.Fields(after) {
+		k := ParseKeyword(w)
+		k.Done = true
+		kws = append(kws, k)
+	}
+	return kws
+}
@@ -0,0 +1,145 @@
+// Package todo recognizes the TODO keywords that open headline titles.
+//
+// A Matcher holds the active and done keywords of a document. Keywords
+// are compared as literal words, so any non-blank text (including regular
+// expression metacharacters) can be a keyword, and a keyword only matches
+// when the title is the keyword alone or continues after a space. The
+// parser, the writer and org.EscapeHeadlineTitle take a Matcher, and
+// package validate builds one from its schema, so that they agree on which
+// titles begin with a keyword.
+package todo
+
+import "strings"
+
+// Matcher recognizes a set of TODO keywords
+type Matcher struct {
+	todo  []string
+	done  []string
+	specs []Keyword
+}
+
+// Default matches the built-in TODO and DONE keywords
+var Default = NewMatcher([]string{"TODO"}, []string{"DONE"})
+
+// NewMatcher creates a Matcher for the given active and done keywords.
+// Blank keywords are ignored.
+func NewMatcher(todo, done []string) *Matcher {
+	var kws []Keyword
+	for _, name := range words(todo) {
+		kws = append(kws, Keyword{Name: name})
+	}
+	for _, name := range words(done) {
+		kws = append(kws, Keyword{Name: name, Done: true})
+	}
+	return FromKeywords(kws...)
+}
+
+// FromKeywords creates a Matcher for keywords with their settings, such as
+// those returned by ParseSpec. Keywords with blank names are ignored.
+func FromKeywords(kws ...Keyword) *Matcher {
+	m := &Matcher{}
+	for _, k := range kws {
+		if len(words([]string{k.Name})) == 0 {
+			continue
+		}
+		k.Name = strings.TrimSpace(k.Name)
+		if k.Done {
+			m.done = append(m.done, k.Name)
+		} else {
+			m.todo = append(m.todo, k.Name)
+		}
+		m.specs = append(m.specs, k)
+	}
+	return m
+}
+
+func words(keywords []string) []string {
+	var out []string
+	for _, kw := range keywords {
+		if kw = strings.TrimSpace(kw); kw != "" && !strings.ContainsAny(kw, " \t") {
+			out = append(out, kw)
+		}
+	}
+	return out
+}
+
+// ParseSequence splits the value of a #+TODO or #+SEQ_TODO line into the
+// names of its active and done keywords; see ParseSpec
+func ParseSequence(value string) (todo, done []string) {
+	for _, k := range ParseSpec(value) {
+		if k.Done {
+			done = append(done, k.Name)
+		} else {
+			todo = append(todo, k.Name)
+		}
+	}
+	return todo, done
+}
+
+// Extend returns a Matcher with the keywords of m followed by kws; m is not
+// modified
+func (m *Matcher) Extend(kws ...Keyword) *Matcher {
+	return FromKeywords(append(m.Specs(), kws...)...)
+}
+
+// Specs returns the keywords with their settings, in the order given
+func (m *Matcher) Specs() []Keyword {
+	return append([]Keyword(nil), m.specs...)
+}
+
+// Spec returns the settings of the keyword name
+func (m *Matcher) Spec(name string) (Keyword, bool) {
+	for _, k := range m.specs {
+		if k.Name == name {
+			return k, true
+		}
+	}
+	return Keyword{}, false
+}
+
+// Todo returns the active keywords
+func (m *Matcher) Todo() []string {
+	return m.todo
+}
+
+// Done returns the done keywords
+func (m *Matcher) Done() []string {
+	return m.done
+}
+
+// Keywords returns the active keywords followed by the done keywords
+func (m *Matcher) Keywords() []string {
+	return append(append([]string(nil), m.todo...), m.done...)
+}
+
+// IsKeyword reports whether s is one of the keywords
+func (m *Matcher) IsKeyword(s string) bool {
+	return contains(m.todo, s) || contains(m.done, s)
+}
+
+// IsDone reports whether s is one of the done keywords
+func (m *Matcher) IsDone(s string) bool {
+	return contains(m.done, s)
+}
+
+// Match splits a headline title into its leading keyword and the rest of
+// the title. The keyword is empty when the title does not start with one.
+func (m *Matcher) Match(title string) (keyword, rest string) {
+	word, rest, _ := strings.Cut(title, " ")
+	if i := strings.IndexByte(word, '\t'); i >= 0 {
+		word, rest = title[:i], title[i+1:]
+	}
+	if !m.IsKeyword(word) {
+		return "", title
+	}
+	return word, strings.TrimSpace(rest)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
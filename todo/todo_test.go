@@ -0,0 +1,103 @@
+package todo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	m := NewMatcher([]string{"TODO", "C++", "[WAIT]"}, []string{"DONE"})
+	tests := []struct {
+		title, keyword, rest string
+	}{
+		{"TODO Write specs", "TODO", "Write specs"},
+		{"TODO", "TODO", ""},
+		{"TODO\tTabbed", "TODO", "Tabbed"},
+		{"DONE  Spaced out", "DONE", "Spaced out"},
+		{"TODOS are fun", "", "TODOS are fun"},
+		{"todo lowercase", "", "todo lowercase"},
+		{"C++ rewrite", "C++", "rewrite"},
+		{"[WAIT] on review", "[WAIT]", "on review"},
+		{"CXX rewrite", "", "CXX rewrite"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		kw, rest := m.Match(tt.title)
+		if kw != tt.keyword || rest != tt.rest {
+			t.Errorf("Match(%q): expected (%q, %q), got=(%q, %q)", tt.title, tt.keyword, tt.rest, kw, rest)
+		}
+	}
+}
+
+func TestMatcherSets(t *testing.T) {
+	m := NewMatcher([]string{"NEXT", " ", "TWO WORDS"}, []string{"DONE", "CANCELLED"})
+	if got := m.Keywords(); len(got) != 3 || got[0] != "NEXT" || got[2] != "CANCELLED" {
+		t.Errorf("unexpected keywords %v", got)
+	}
+	if !m.IsDone("CANCELLED") || m.IsDone("NEXT") {
+		t.Error("expected only CANCELLED and DONE to be done keywords")
+	}
+	if m.IsKeyword("TODO") {
+		t.Error("expected TODO not to be a keyword of a custom matcher")
+	}
+	if !Default.IsKeyword("TODO") || !Default.IsDone("DONE") {
+		t.Error("expected the default matcher to know TODO and DONE")
+	}
+}
+
+func TestParseSequence(t *testing.T) {
+	tests := []struct {
+		value      string
+		todo, done string
+	}{
+		{"TODO NEXT WAITING | DONE CANCELLED", "TODO NEXT WAITING", "DONE CANCELLED"},
+		{"TODO FEEDBACK VERIFY DONE", "TODO FEEDBACK VERIFY", "DONE"},
+		{"| DONE", "", "DONE"},
+		{"TODO |", "TODO", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		todo, done := ParseSequence(tt.value)
+		if strings.Join(todo, " ") != tt.todo || strings.Join(done, " ") != tt.done {
+			t.Errorf("ParseSequence(%q): expected (%q, %q), got=(%v, %v)", tt.value, tt.todo, tt.done, todo, done)
+		}
+	}
+
+	m := NewMatcher([]string{"TODO"}, []string{"DONE"})
+	if e := m.Extend(Keyword{Name: "BUG"}, Keyword{Name: "FIXED", Done: true}); !e.IsKeyword("TODO") || !e.IsDone("FIXED") || m.IsKeyword("BUG") {
+		t.Error("expected Extend to add keywords to a copy")
+	}
+}
+
+func TestParseSpec(t *testing.T) {
+	kws := ParseSpec("TODO(t) NEXT(n!) WAIT(w@/!) HOLD(@) ODD(x DROP(/!) | DONE(d!) CANCELLED(c@)")
+	want := []Keyword{
+		{Name: "TODO", Key: 't'},
+		{Name: "NEXT", Key: 'n', Enter: LogTime},
+		{Name: "WAIT", Key: 'w', Enter: LogNote, Leave: LogTime},
+		{Name: "HOLD", Enter: LogNote},
+		{Name: "ODD(x"},
+		{Name: "DROP", Leave: LogTime},
+		{Name: "DONE", Done: true, Key: 'd', Enter: LogTime},
+		{Name: "CANCELLED", Done: true, Key: 'c', Enter: LogNote},
+	}
+	if len(kws) != len(want) {
+		t.Fatalf("expected %d keywords, got=%v", len(want), kws)
+	}
+	for i := range want {
+		if kws[i] != want[i] {
+			t.Errorf("keyword %d: expected %+v, got=%+v", i, want[i], kws[i])
+		}
+	}
+	if s := kws[2].String(); s != "WAIT(w@/!)" {
+		t.Errorf("expected WAIT(w@/!), got=%q", s)
+	}
+
+	m := FromKeywords(kws...)
+	if !m.IsKeyword("WAIT") || m.IsKeyword("WAIT(w@/!)") || !m.IsDone("CANCELLED") {
+		t.Error("expected the matcher to use the names without annotations")
+	}
+	if k, ok := m.Spec("NEXT"); !ok || k.Key != 'n' {
+		t.Errorf("expected NEXT with key n, got=%+v", k)
+	}
+}
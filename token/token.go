@@ -7,6 +7,7 @@ type Token struct {
 	Literal string
 	Line    int
 	Column  int // Added for better error reporting
+	Offset  int // Byte offset of the token start in the input
 }
 
 const (
@@ -0,0 +1,179 @@
+// Package validate checks Org files against a schema of conventions, such
+// as required properties, allowed TODO keywords and tags, and a maximum
+// headline depth, for teams that keep structured Org databases.
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/parser"
+	"github.com/justyntemme/organelle/todo"
+	"github.com/justyntemme/organelle/workspace"
+)
+
+// SchemaFile is the name of the schema file Run looks for at the root of
+// a workspace
+const SchemaFile = ".organelle.json"
+
+// Schema declares the conventions of a workspace. Empty fields impose no
+// constraint.
+type Schema struct {
+	// RequiredProperties must be set on every headline at RequiredLevel,
+	// or on every headline when RequiredLevel is 0
+	RequiredProperties []string `json:"required_properties"`
+	RequiredLevel      int      `json:"required_level"`
+	TodoKeywords       []string `json:"todo_keywords"`
+	Tags               []string `json:"tags"`
+	MaxDepth           int      `json:"max_depth"`
+}
+
+// LoadSchema reads a JSON schema file
+func LoadSchema(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Schema{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// matcher recognizes the schema's keywords besides TODO and DONE, so that
+// headlines using them are parsed with a keyword
+func (s *Schema) matcher() *todo.Matcher {
+	keywords := append([]string(nil), todo.Default.Todo()...)
+	return todo.NewMatcher(append(keywords, s.TodoKeywords...), todo.Default.Done())
+}
+
+// Violation is a single schema violation
+type Violation struct {
+	File     string
+	Line     int
+	Headline string
+	Message  string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s", v.File, v.Line, v.Headline, v.Message)
+}
+
+// Run validates every .org file below dir. A nil schema is loaded from
+// SchemaFile in dir. Violations are sorted by file
+// and line.
+func Run(dir string, schema *Schema) ([]Violation, error) {
+	if schema == nil {
+		s, err := LoadSchema(filepath.Join(dir, SchemaFile))
+		if err != nil {
+			return nil, err
+		}
+		schema = s
+	}
+
+	ws, err := workspace.Load(dir, parser.WithTodoMatcher(schema.matcher()))
+	if err != nil {
+		return nil, err
+	}
+	var out []Violation
+	for _, f := range ws.Files {
+		out = append(out, Document(f.Name, f.Doc, schema)...)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].File != out[j].File {
+			return out[i].File < out[j].File
+		}
+		return out[i].Line < out[j].Line
+	})
+	return out, nil
+}
+
+// Document validates a parsed document; name is used as the File of the
+// violations
+func Document(name string, doc *ast.Document, schema *Schema) []Violation {
+	keywords := set(schema.TodoKeywords)
+	tags := set(schema.Tags)
+
+	var out []Violation
+	report := func(h *ast.Headline, format string, args ...any) {
+		out = append(out, Violation{
+			File:     name,
+			Line:     h.Token.Line,
+			Headline: h.Title,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	if tags != nil {
+		for _, kw := range doc.Keywords() {
+			if !strings.EqualFold(kw.Key, "FILETAGS") {
+				continue
+			}
+			for _, tag := range ast.SplitTags(kw.Value) {
+				if !tags[tag] {
+					out = append(out, Violation{
+						File:     name,
+						Line:     kw.Token.Line,
+						Headline: "#+FILETAGS",
+						Message:  fmt.Sprintf("tag %q is not allowed", tag),
+					})
+				}
+			}
+		}
+	}
+
+	var walk func([]ast.Node)
+	walk = func(nodes []ast.Node) {
+		for _, n := range nodes {
+			h, ok := n.(*ast.Headline)
+			if !ok {
+				continue
+			}
+			if schema.MaxDepth > 0 && h.Level > schema.MaxDepth {
+				report(h, "level %d exceeds the maximum depth of %d", h.Level, schema.MaxDepth)
+			}
+			if keywords != nil && h.Keyword != "" && !keywords[h.Keyword] {
+				report(h, "TODO keyword %q is not allowed", h.Keyword)
+			}
+			if tags != nil {
+				for _, tag := range h.Tags {
+					if !tags[tag] {
+						report(h, "tag %q is not allowed", tag)
+					}
+				}
+			}
+			if schema.RequiredLevel == 0 || h.Level == schema.RequiredLevel {
+				var missing []string
+				for _, key := range schema.RequiredProperties {
+					if _, ok := h.Property(key); !ok {
+						missing = append(missing, key)
+					}
+				}
+				if len(missing) > 0 {
+					report(h, "missing required properties: %s", strings.Join(missing, ", "))
+				}
+			}
+			walk(h.Children)
+		}
+	}
+	walk(doc.Children)
+	return out
+}
+
+// set returns nil for an empty list, meaning anything is allowed
+func set(values []string) map[string]bool {
+	if len(values) == 0 {
+		return nil
+	}
+	m := make(map[string]bool, len(values))
+	for _, v := range values {
+		m[v] = true
+	}
+	return m
+}
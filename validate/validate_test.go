@@ -0,0 +1,110 @@
+package validate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+)
+
+const tasks = `* TODO Write spec :work:
+:PROPERTIES:
+:OWNER: ada
+:END:
+* DONE Review :work:misc:
+** Notes
+*** Too deep
+`
+
+func TestDocument(t *testing.T) {
+	schema := &Schema{
+		RequiredProperties: []string{"OWNER"},
+		RequiredLevel:      1,
+		TodoKeywords:       []string{"TODO"},
+		Tags:               []string{"work"},
+		MaxDepth:           2,
+	}
+	doc := parser.New(lexer.New(tasks)).ParseDocument()
+	got := Document("tasks.org", doc, schema)
+
+	expected := []string{
+		`tasks.org:5: Review: TODO keyword "DONE" is not allowed`,
+		`tasks.org:5: Review: tag "misc" is not allowed`,
+		`tasks.org:5: Review: missing required properties: OWNER`,
+		`tasks.org:7: Too deep: level 3 exceeds the maximum depth of 2`,
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d violations, got=%d: %v", len(expected), len(got), got)
+	}
+	for i, v := range got {
+		if v.String() != expected[i] {
+			t.Errorf("violation %d: expected %q, got=%q", i, expected[i], v.String())
+		}
+	}
+}
+
+func TestRunLoadsSchema(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		SchemaFile:         `{"tags": ["work"]}`,
+		"a.org":            "* Task :home:\n",
+		"sub/b.org":        "* Task :work:\n",
+		"sub/notes.txt":    "* Ignored :home:\n",
+		"sub/deeper/c.org": "* Other :play:\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := Run(dir, nil)
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 violations, got=%d: %v", len(got), got)
+	}
+	if got[0].File != "a.org" || !strings.Contains(got[0].Message, `"home"`) {
+		t.Errorf("unexpected first violation %v", got[0])
+	}
+	if got[1].File != filepath.Join("sub", "deeper", "c.org") {
+		t.Errorf("unexpected second violation %v", got[1])
+	}
+}
+
+func TestRunCustomKeywords(t *testing.T) {
+	dir := t.TempDir()
+	input := "* WAITING Review\n* DONE Shipped\n* NEXT Thing\n"
+	if err := os.WriteFile(filepath.Join(dir, "a.org"), []byte(input), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Run(dir, &Schema{TodoKeywords: []string{"TODO", "WAITING"}})
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if len(got) != 1 || got[0].Headline != "Shipped" {
+		t.Errorf("expected only DONE to be reported, got=%v", got)
+	}
+}
+
+func TestRunMissingSchema(t *testing.T) {
+	if _, err := Run(t.TempDir(), nil); err == nil {
+		t.Error("expected an error without a schema file")
+	}
+}
+
+func TestDocumentFileTags(t *testing.T) {
+	doc := parser.New(lexer.New("#+FILETAGS: :work:draft:\n* Task :work:\n")).ParseDocument()
+	got := Document("notes.org", doc, &Schema{Tags: []string{"work"}})
+	if len(got) != 1 || got[0].String() != `notes.org:1: #+FILETAGS: tag "draft" is not allowed` {
+		t.Errorf("expected the file tag to be checked, got=%v", got)
+	}
+}
@@ -0,0 +1,194 @@
+// Package workspace aggregates data across the Org files of a directory,
+// such as the tags, properties and TODO keywords in use, for completion
+// and consistency reports.
+package workspace
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+	"github.com/justyntemme/organelle/todo"
+)
+
+// File is a parsed file of the workspace
+type File struct {
+	Name string // Relative to the workspace for Load
+	Doc  *ast.Document
+	Todo *todo.Matcher // TODO keywords in effect at the end of the file
+}
+
+// Workspace is a set of parsed files, sorted by name
+type Workspace struct {
+	Files []File
+}
+
+// Usage is a name and the number of times it is used
+type Usage struct {
+	Name  string
+	Count int
+}
+
+// New parses files, which maps names to Org source
+func New(files map[string]string, opts ...parser.Option) *Workspace {
+	w := &Workspace{}
+	for name, src := range files {
+		p := parser.New(lexer.New(src), opts...)
+		doc := p.ParseDocument()
+		w.Files = append(w.Files, File{Name: name, Doc: doc, Todo: p.TodoMatcher()})
+	}
+	sort.Slice(w.Files, func(i, j int) bool { return w.Files[i].Name < w.Files[j].Name })
+	return w
+}
+
+// Load parses the .org files below dir
+func Load(dir string, opts ...parser.Option) (*Workspace, error) {
+	files, err := ReadFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	return New(files, opts...), nil
+}
+
+// ReadFiles reads the .org files below dir, keyed by their slash-separated
+// path relative to dir. Tools that rewrite files use it to get at the
+// source; the others use Load.
+func ReadFiles(dir string) (map[string]string, error) {
+	files := map[string]string{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".org" {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			rel = path
+		}
+		files[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// KnownTags returns the tags set on headlines and in #+FILETAGS, most
+// used first. Tags are counted as written, so :Work: and :work: are
+// separate entries; see NearDuplicates.
+func (w *Workspace) KnownTags() []Usage {
+	counts := map[string]int{}
+	for _, f := range w.Files {
+		for _, tag := range f.Doc.FileTags() {
+			counts[tag]++
+		}
+		walk(f.Doc.Children, func(h *ast.Headline) {
+			for _, tag := range h.Tags {
+				counts[tag]++
+			}
+		})
+	}
+	return sorted(counts)
+}
+
+// KnownProperties returns the keys of headline PROPERTIES drawers, most
+// used first
+func (w *Workspace) KnownProperties() []Usage {
+	counts := map[string]int{}
+	for _, f := range w.Files {
+		walk(f.Doc.Children, func(h *ast.Headline) {
+			for _, n := range h.BodyNodes() {
+				if d, ok := n.(*ast.Drawer); ok && d.Name == "PROPERTIES" {
+					for _, k := range d.PropertyKeys() {
+						counts[k]++
+					}
+				}
+			}
+		})
+	}
+	return sorted(counts)
+}
+
+// KnownTodoKeywords returns the TODO keywords declared in any file,
+// including the defaults of files without #+TODO lines, with the number
+// of headlines that carry them, most used first. Declared keywords that
+// no headline uses have a count of 0.
+func (w *Workspace) KnownTodoKeywords() []Usage {
+	counts := map[string]int{}
+	for _, f := range w.Files {
+		if f.Todo != nil {
+			for _, kw := range f.Todo.Keywords() {
+				counts[kw] += 0
+			}
+		}
+		walk(f.Doc.Children, func(h *ast.Headline) {
+			if h.Keyword != "" {
+				counts[h.Keyword]++
+			}
+		})
+	}
+	return sorted(counts)
+}
+
+// NearDuplicates groups the names of usages that differ only in case,
+// such as :Work: and :work:, for consistency reports. Each group has the
+// most used spelling first; names without a near duplicate are left out.
+func NearDuplicates(usages []Usage) [][]Usage {
+	var keys []string
+	groups := map[string][]Usage{}
+	for _, u := range usages {
+		key := strings.ToLower(u.Name)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], u)
+	}
+	var out [][]Usage
+	for _, key := range keys {
+		if g := groups[key]; len(g) > 1 {
+			sortUsages(g)
+			out = append(out, g)
+		}
+	}
+	return out
+}
+
+// walk calls fn for every headline in nodes, depth first
+func walk(nodes []ast.Node, fn func(*ast.Headline)) {
+	for _, n := range nodes {
+		if h, ok := n.(*ast.Headline); ok {
+			fn(h)
+			walk(h.Children, fn)
+		}
+	}
+}
+
+// sorted returns counts as usages, most used first and then by name
+func sorted(counts map[string]int) []Usage {
+	out := make([]Usage, 0, len(counts))
+	for name, n := range counts {
+		out = append(out, Usage{Name: name, Count: n})
+	}
+	sortUsages(out)
+	return out
+}
+
+func sortUsages(u []Usage) {
+	sort.Slice(u, func(i, j int) bool {
+		if u[i].Count != u[j].Count {
+			return u[i].Count > u[j].Count
+		}
+		return u[i].Name < u[j].Name
+	})
+}
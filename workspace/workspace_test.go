@@ -0,0 +1,86 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var files = map[string]string{
+	"a.org": `#+FILETAGS: :notes:
+* TODO Plan :work:
+:PROPERTIES:
+:CLIENT: Acme
+:EFFORT: 1:00
+:END:
+** DONE Call :work:phone:
+* Read :Work:
+`,
+	"b.org": `#+TODO: NEXT WAITING | FINISHED CANCELED
+* NEXT Ship :work:
+:PROPERTIES:
+:CLIENT: Globex
+:END:
+* FINISHED Review
+`,
+}
+
+func TestKnownTags(t *testing.T) {
+	got := New(files).KnownTags()
+	want := []Usage{{"work", 3}, {"Work", 1}, {"notes", 1}, {"phone", 1}}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected %v, got=%v", want, got)
+	}
+}
+
+func TestKnownProperties(t *testing.T) {
+	got := New(files).KnownProperties()
+	want := []Usage{{"CLIENT", 2}, {"EFFORT", 1}}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected %v, got=%v", want, got)
+	}
+}
+
+func TestKnownTodoKeywords(t *testing.T) {
+	got := New(files).KnownTodoKeywords()
+	want := []Usage{
+		{"DONE", 1}, {"FINISHED", 1}, {"NEXT", 1}, {"TODO", 1},
+		{"CANCELED", 0}, {"WAITING", 0},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected %v, got=%v", want, got)
+	}
+}
+
+func TestNearDuplicates(t *testing.T) {
+	got := NearDuplicates(New(files).KnownTags())
+	want := [][]Usage{{{"work", 3}, {"Work", 1}}}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected %v, got=%v", want, got)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, src := range map[string]string{"sub/b.org": files["b.org"], "a.org": files["a.org"], "notes.txt": "* TODO x :skip:\n"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(w.Files) != 2 || w.Files[0].Name != "a.org" || w.Files[1].Name != "sub/b.org" {
+		t.Fatalf("expected a.org and sub/b.org, got=%+v", w.Files)
+	}
+	for _, u := range w.KnownTags() {
+		if u.Name == "skip" {
+			t.Error("expected files other than .org to be ignored")
+		}
+	}
+}
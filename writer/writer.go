@@ -0,0 +1,317 @@
+// Package writer serializes an AST back to canonical Org syntax.
+//
+// Unlike the String methods on AST nodes, which are meant for debugging,
+// the output of this package is guaranteed to re-parse to an equivalent
+// tree: property drawers keep their key order, tables are written with
+// aligned columns and full-width separators, nested list items are
+// indented under their parent, and body text that would re-parse as a
+// planning line is set apart from its headline.
+package writer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/parser"
+	"github.com/justyntemme/organelle/todo"
+)
+
+// Writer serializes documents as Org text
+type Writer struct {
+	alignTables bool
+	todo        *todo.Matcher
+}
+
+// zeroWidthSpace guards text that would otherwise be read as structure,
+// as in package org
+const zeroWidthSpace = "\u200b"
+
+// Option is a functional option for configuring the Writer
+type Option func(*Writer)
+
+// WithAlignTables controls whether table columns are padded to a common
+// width (default true)
+func WithAlignTables(align bool) Option {
+	return func(w *Writer) {
+		w.alignTables = align
+	}
+}
+
+// WithTodoMatcher sets the TODO keywords the output is read back with
+// (default todo.Default). As in the parser, #+TODO lines in the document
+// replace or extend them. A headline without a keyword whose title starts
+// with one has the title guarded by a zero-width space, so that it does
+// not gain the keyword when parsed again.
+func WithTodoMatcher(m *todo.Matcher) Option {
+	return func(w *Writer) {
+		w.todo = m
+	}
+}
+
+// New creates a Writer
+func New(opts ...Option) *Writer {
+	w := &Writer{alignTables: true, todo: todo.Default}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Write serializes doc to out
+func (w *Writer) Write(out io.Writer, doc *ast.Document) error {
+	dw := *w
+	buffer := false
+	for _, k := range doc.Keywords() {
+		switch strings.ToUpper(k.Key) {
+		case "TODO", "SEQ_TODO", "TYP_TODO":
+			kws := todo.ParseSpec(k.Value)
+			if buffer {
+				dw.todo = dw.todo.Extend(kws...)
+			} else {
+				dw.todo, buffer = todo.FromKeywords(kws...), true
+			}
+		}
+	}
+	bw := bufio.NewWriter(out)
+	dw.writeNodes(bw, doc.Children, "")
+	return bw.Flush()
+}
+
+// String serializes a document with default options
+func String(doc *ast.Document) string {
+	var b strings.Builder
+	New().Write(&b, doc)
+	return b.String()
+}
+
+// Node serializes a single node and its descendants with default options
+func Node(n ast.Node) string {
+	var b strings.Builder
+	bw := bufio.NewWriter(&b)
+	New().writeNode(bw, n, "")
+	bw.Flush()
+	return b.String()
+}
+
+func (w *Writer) writeNodes(bw *bufio.Writer, nodes []ast.Node, indent string) {
+	for i, n := range nodes {
+		w.writeNode(bw, n, indent)
+		if i+1 == len(nodes) {
+			continue
+		}
+		next, ok := nodes[i+1].(*ast.Paragraph)
+		if !ok {
+			continue
+		}
+		switch n := n.(type) {
+		case *ast.FootnoteDefinition:
+			// A footnote definition runs on into a directly following line
+			writeLine(bw, "", "")
+		case *ast.Paragraph:
+			// Paragraphs that were apart in the source stay apart
+			if next.Token.Line > n.Token.Line+1 {
+				writeLine(bw, "", "")
+			}
+		}
+	}
+}
+
+// writeNode writes n with every line prefixed by indent. Headlines are
+// never indented; they only occur at the top level of their parent.
+func (w *Writer) writeNode(bw *bufio.Writer, node ast.Node, indent string) {
+	switch n := node.(type) {
+	case *ast.Headline:
+		w.writeHeadline(bw, n)
+	case *ast.Paragraph:
+		writeLine(bw, indent, n.Content)
+	case *ast.Keyword:
+		if n == nil { // parseKeyword may yield a typed nil
+			return
+		}
+		writeLine(bw, indent, strings.TrimRight("#+"+n.Key+": "+n.Value, " "))
+	case *ast.Call:
+		writeLine(bw, indent, strings.TrimSuffix(n.String(), "\n"))
+	case *ast.Comment:
+		if n.Content == "" {
+			writeLine(bw, indent, "#")
+		} else {
+			writeLine(bw, indent, "# "+n.Content)
+		}
+	case *ast.Block:
+		w.writeBlock(bw, n, indent)
+	case *ast.Drawer:
+		w.writeDrawer(bw, n, indent)
+	case *ast.List:
+		w.writeList(bw, n, indent)
+	case *ast.Table:
+		w.writeTable(bw, n, indent)
+	case *ast.HorizontalRule:
+		writeLine(bw, indent, "-----")
+	case *ast.Section:
+		w.writeNodes(bw, n.Children, indent)
+	case *ast.FootnoteDefinition:
+		// Definitions start at the beginning of the line
+		for _, line := range strings.Split(strings.TrimSuffix(n.String(), "\n"), "\n") {
+			writeLine(bw, "", line)
+		}
+	case nil:
+	default:
+		// Unknown node types fall back to their own serialization
+		for _, line := range strings.Split(strings.TrimSuffix(n.String(), "\n"), "\n") {
+			writeLine(bw, indent, line)
+		}
+	}
+}
+
+func (w *Writer) writeHeadline(bw *bufio.Writer, h *ast.Headline) {
+	var b strings.Builder
+	b.WriteString(strings.Repeat("*", max(h.Level, 1)))
+	if h.Keyword != "" {
+		b.WriteString(" " + h.Keyword)
+	}
+	if h.Priority != "" {
+		b.WriteString(" [#" + h.Priority + "]")
+	}
+	if h.Title != "" {
+		title := h.Title
+		if kw, _ := w.todo.Match(title); kw != "" && h.Keyword == "" {
+			title = zeroWidthSpace + title
+		}
+		b.WriteString(" " + title)
+	}
+	if len(h.Tags) > 0 {
+		b.WriteString(" :" + strings.Join(h.Tags, ":") + ":")
+	}
+	writeLine(bw, "", b.String())
+	if planning := h.Planning(); planning != "" {
+		writeLine(bw, "", planning)
+	}
+	if first := firstParagraph(h.Children); first != nil {
+		if _, _, _, ok := parser.ParsePlanning(first.Content); ok {
+			// Keep a paragraph that reads as planning apart from the
+			// headline, or it would re-parse as its planning line
+			writeLine(bw, "", "")
+		}
+	}
+	w.writeNodes(bw, h.Children, "")
+}
+
+// firstParagraph returns the first of nodes, looking into sections, if it
+// is a paragraph
+func firstParagraph(nodes []ast.Node) *ast.Paragraph {
+	if len(nodes) == 0 {
+		return nil
+	}
+	switch n := nodes[0].(type) {
+	case *ast.Paragraph:
+		return n
+	case *ast.Section:
+		return firstParagraph(n.Children)
+	}
+	return nil
+}
+
+func (w *Writer) writeBlock(bw *bufio.Writer, b *ast.Block, indent string) {
+	begin := "#+BEGIN_" + b.Type
+	if b.Language != "" {
+		begin += " " + b.Language
+	}
+	if b.Params != "" {
+		begin += " " + b.Params
+	}
+	writeLine(bw, indent, begin)
+	if b.Content != "" {
+		for _, line := range strings.Split(strings.TrimSuffix(b.Content, "\n"), "\n") {
+			writeLine(bw, indent, line)
+		}
+	}
+	writeLine(bw, indent, "#+END_"+b.Type)
+}
+
+func (w *Writer) writeDrawer(bw *bufio.Writer, d *ast.Drawer, indent string) {
+	writeLine(bw, indent, ":"+d.Name+":")
+	if d.Name == "PROPERTIES" {
+		for _, k := range d.PropertyKeys() {
+			writeLine(bw, indent, strings.TrimRight(":"+k+": "+d.Properties[k], " "))
+		}
+	} else if d.Content != "" {
+		for _, line := range strings.Split(strings.TrimSuffix(d.Content, "\n"), "\n") {
+			writeLine(bw, indent, line)
+		}
+	}
+	writeLine(bw, indent, ":END:")
+}
+
+func (w *Writer) writeList(bw *bufio.Writer, l *ast.List, indent string) {
+	for i, item := range l.Items {
+		bullet := "- "
+		if l.Ordered {
+			bullet = fmt.Sprintf("%d. ", i+1)
+		}
+		checkbox := ""
+		switch item.Checkbox {
+		case ast.CheckboxUnchecked:
+			checkbox = "[ ] "
+		case ast.CheckboxChecked:
+			checkbox = "[X] "
+		case ast.CheckboxPartial:
+			checkbox = "[-] "
+		}
+		writeLine(bw, indent, bullet+checkbox+item.Content)
+		// Children line up with the text after the bullet, as Org indents them
+		w.writeNodes(bw, item.Children, indent+strings.Repeat(" ", len(bullet)))
+	}
+}
+
+func (w *Writer) writeTable(bw *bufio.Writer, t *ast.Table, indent string) {
+	var widths []int
+	for _, row := range t.Rows {
+		for i, c := range row.Cells {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if w.alignTables {
+				widths[i] = max(widths[i], utf8.RuneCountInString(c))
+			}
+		}
+	}
+	if len(widths) == 0 {
+		widths = []int{0}
+	}
+
+	for _, row := range t.Rows {
+		var b strings.Builder
+		b.WriteString("|")
+		for i, width := range widths {
+			if row.Separator {
+				if i > 0 {
+					b.WriteString("+")
+				}
+				b.WriteString(strings.Repeat("-", width+2))
+				continue
+			}
+			cell := ""
+			if i < len(row.Cells) {
+				cell = row.Cells[i]
+			}
+			pad := max(width-utf8.RuneCountInString(cell), 0)
+			b.WriteString(" " + cell + strings.Repeat(" ", pad) + " |")
+		}
+		if row.Separator {
+			b.WriteString("|")
+		}
+		writeLine(bw, indent, b.String())
+	}
+}
+
+func writeLine(bw *bufio.Writer, indent, line string) {
+	if line != "" {
+		bw.WriteString(indent)
+	}
+	bw.WriteString(line)
+	bw.WriteString("\n")
+}
@@ -0,0 +1,204 @@
+package writer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/justyntemme/organelle/ast"
+	"github.com/justyntemme/organelle/export/text"
+	"github.com/justyntemme/organelle/lexer"
+	"github.com/justyntemme/organelle/parser"
+	"github.com/justyntemme/organelle/todo"
+)
+
+func parse(t *testing.T, input string) *ast.Document {
+	t.Helper()
+	p := parser.New(lexer.New(input))
+	doc := p.ParseDocument()
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	return doc
+}
+
+const roundTripInput = `#+TITLE: Round trip
+* TODO [#A] Heading :work:urgent:
+SCHEDULED: <2024-01-01 Mon>
+:PROPERTIES:
+:Zeta: 1
+:Alpha: 2
+:Mid: three words
+:END:
+Some *bold* and /italic/ text.
+- one
+  - nested
+    - deeper
+- [X] two
+| a | bbb |
+|---+-----|
+| long cell | c |
+#+BEGIN_SRC go :tangle main.go
+fmt.Println("hi")
+#+END_SRC
+# a comment
+#+CALL: build(target="all")
+** Child
+:LOGBOOK:
+- Note taken on [2024-01-02 Tue 10:00]
+:END:
+A claim[fn:1].
+* Footnotes
+[fn:1] The source,
+over two lines.
+
+Not part of the note.
+`
+
+func TestRoundTripIsStable(t *testing.T) {
+	first := String(parse(t, roundTripInput))
+	second := String(parse(t, first))
+	if first != second {
+		t.Errorf("expected re-serialization to be stable\nfirst:\n%s\nsecond:\n%s", first, second)
+	}
+}
+
+func TestParagraphsStayApart(t *testing.T) {
+	input := "First paragraph.\n\nSecond paragraph.\nSame paragraph.\n* H\nOne\n\n\nTwo\n"
+	doc := parse(t, input)
+	out := String(doc)
+	if want := "First paragraph.\n\nSecond paragraph.\nSame paragraph.\n* H\nOne\n\nTwo\n"; out != want {
+		t.Errorf("expected %q, got=%q", want, out)
+	}
+
+	render := func(doc *ast.Document) string {
+		var buf bytes.Buffer
+		if err := text.New().Export(&buf, doc); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+	if before, after := render(doc), render(parse(t, out)); before != after {
+		t.Errorf("export changed after writing:\n%s\nwant:\n%s", after, before)
+	}
+}
+
+func TestPlanningLikeParagraphStaysBody(t *testing.T) {
+	for _, input := range []string{
+		"* Task\n\nSCHEDULED: <2024-02-01 Thu>\n",
+		"* Task\nDEADLINE: <2024-02-10 Sat>\n\nSCHEDULED: <2024-02-01 Thu>\n",
+	} {
+		out := String(parse(t, input))
+		if out != input {
+			t.Errorf("expected %q, got=%q", input, out)
+		}
+		h := parse(t, out).Children[0].(*ast.Headline)
+		if h.Scheduled != nil || len(h.Children) != 1 {
+			t.Errorf("expected the SCHEDULED line to stay a paragraph, got=%+v", h)
+		}
+	}
+}
+
+func TestPlanningLineKeepsText(t *testing.T) {
+	for _, input := range []string{
+		"* H\nSCHEDULED: soon\nbody\n",
+		"* H\nDEADLINE: <2024-01-01 Mon> and some notes\nbody\n",
+	} {
+		doc := parse(t, input)
+		if got := String(doc); got != input {
+			t.Errorf("expected %q to round trip, got=%q", input, got)
+		}
+	}
+}
+
+func TestKeywordlessTitleKeepsNoKeyword(t *testing.T) {
+	tests := []struct {
+		doc  *ast.Document
+		opts []Option
+	}{
+		{&ast.Document{Children: []ast.Node{&ast.Headline{Level: 1, Title: "TODO list cleanup"}}}, nil},
+		{&ast.Document{Children: []ast.Node{&ast.Headline{Level: 1, Title: "NEXT steps"}}},
+			[]Option{WithTodoMatcher(todo.NewMatcher([]string{"NEXT"}, nil))}},
+		{&ast.Document{Children: []ast.Node{
+			&ast.Keyword{Key: "TODO", Value: "NEXT | SHIPPED"},
+			&ast.Headline{Level: 1, Title: "NEXT steps"},
+		}}, nil},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		if err := New(tt.opts...).Write(&b, tt.doc); err != nil {
+			t.Fatal(err)
+		}
+		p := parser.New(lexer.New(b.String()), parser.WithTodoMatcher(todo.NewMatcher([]string{"TODO", "NEXT"}, []string{"DONE"})))
+		doc := p.ParseDocument()
+		var hl *ast.Headline
+		for _, n := range doc.Children {
+			if h, ok := n.(*ast.Headline); ok {
+				hl = h
+			}
+		}
+		want := tt.doc.Children[len(tt.doc.Children)-1].(*ast.Headline).Keyword
+		if hl.Keyword != want {
+			t.Errorf("expected keyword %q after writing %q, got=%q", want, b.String(), hl.Keyword)
+		}
+	}
+}
+
+func TestFootnoteDefinitionEnds(t *testing.T) {
+	doc := parse(t, String(parse(t, roundTripInput)))
+	if defs := doc.Footnotes(); len(defs) != 1 || len(defs[0].Children) != 2 {
+		t.Fatalf("expected the definition to keep its two lines, got=%+v", defs)
+	}
+}
+
+func TestSectionsSerializeTransparently(t *testing.T) {
+	doc := parser.New(lexer.New(roundTripInput), parser.WithSections()).ParseDocument()
+	if _, ok := doc.Children[0].(*ast.Section); !ok {
+		t.Fatalf("expected a zeroth section, got=%T", doc.Children[0])
+	}
+	if got, want := String(doc), String(parse(t, roundTripInput)); got != want {
+		t.Errorf("expected sections not to change the output\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPropertyOrderPreserved(t *testing.T) {
+	out := String(parse(t, roundTripInput))
+	want := ":PROPERTIES:\n:Zeta: 1\n:Alpha: 2\n:Mid: three words\n:END:\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("expected properties in source order, got:\n%s", out)
+	}
+}
+
+func TestTableSeparatorShape(t *testing.T) {
+	out := String(parse(t, roundTripInput))
+	want := "| a         | bbb |\n|-----------+-----|\n| long cell | c   |\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("expected aligned table, got:\n%s", out)
+	}
+
+	doc := parse(t, "| a | b | c |\n|---|\n")
+	out = String(doc)
+	if !strings.Contains(out, "|---+---+---|") {
+		t.Errorf("expected separator to span every column, got:\n%s", out)
+	}
+	reparsed := parse(t, out).Children[0].(*ast.Table)
+	if len(reparsed.Rows) != 2 || !reparsed.Rows[1].Separator {
+		t.Errorf("expected separator row to survive a round trip, got %+v", reparsed.Rows)
+	}
+}
+
+func TestNestedListIndentation(t *testing.T) {
+	out := String(parse(t, "- one\n  - nested\n    - deeper\n- two\n"))
+	want := "- one\n  - nested\n    - deeper\n- two\n"
+	if out != want {
+		t.Errorf("expected %q, got=%q", want, out)
+	}
+}
+
+func TestNodeWithoutSourceOrder(t *testing.T) {
+	d := &ast.Drawer{Name: "PROPERTIES", Properties: map[string]string{"b": "2", "a": "1"}}
+	want := ":PROPERTIES:\n:a: 1\n:b: 2\n:END:\n"
+	if got := Node(d); got != want {
+		t.Errorf("expected sorted keys for a drawer built in code, got=%q", got)
+	}
+}